@@ -1,11 +1,15 @@
 package cache
 
 import (
+	"context"
 	"time"
 
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	toolscache "k8s.io/client-go/tools/cache"
 	"k8s.io/kubectl/pkg/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/darkowlzz/operator-toolkit/cache/informer"
 )
@@ -24,14 +28,107 @@ type Options struct {
 	// Namespace restricts the cache's ListWatch to the desired namespace
 	// Default watches all namespaces
 	Namespace string
+
+	// Namespaces restricts the cache's ListWatch to the desired set of
+	// namespaces. A ListWatch is created per namespace and the results are
+	// merged when listing across all namespaces. The cluster scoped objects
+	// are fetched from a cluster-wide ListWatch. Overrides Namespace when
+	// set. Default or empty watches based on Namespace. More namespaces can
+	// be added at runtime with MultiNamespaceCache.AddNamespace.
+	Namespaces []string
+
+	// Mapper is used with Namespaces to find the scope of the objects. The
+	// cluster scoped objects are listed, watched and indexed with the
+	// cluster-wide ListWatch instead of a ListWatch per namespace. Without a
+	// Mapper, all the objects are treated as namespaced, except for Get with
+	// an empty namespace.
+	Mapper apimeta.RESTMapper
+
+	// Indexers are the additional indexers added to every informer created
+	// by the cache, along with the namespace indexer. Use FieldIndexers to
+	// create indexers that can be used by passing field selectors to List.
+	Indexers toolscache.Indexers
+
+	// WatchErrorHandler is called when an informer fails to list or watch
+	// the objects of a GVK, with the number of consecutive failures. It can
+	// be used to surface persistent watch failures for readiness checks and
+	// metrics. Default only logs the errors.
+	WatchErrorHandler informer.WatchErrorHandler
+
+	// ObjectLimits bound the memory used by the cache by limiting the number
+	// of cached objects per GVK and trimming fields of the cached objects.
+	// With Namespaces, the limits apply to each namespace separately.
+	// Default caches all the objects as they are.
+	ObjectLimits informer.ObjectLimits
+
+	// FatalWatchError classifies the list and watch errors that can't be
+	// resolved by retrying, like apierrors.IsForbidden for misconfigured
+	// RBAC. The informer of a GVK is stopped on the first fatal error and
+	// WatchErrorHandler is called with an informer.FatalWatchError, instead
+	// of retrying forever. Default retries on all the errors.
+	FatalWatchError informer.FatalErrorFunc
+
+	// KeyFuncs key the cached objects by a custom key, like an external ID,
+	// for the external objects that aren't identified by their namespace and
+	// name. Get looks up the objects by the key returned by
+	// KeyFuncs.ObjectKeyFunc. With Namespaces, the namespace of the object
+	// key still selects the namespace cache. Default keys the objects by
+	// their namespace and name.
+	KeyFuncs informer.KeyFuncs
+
+	// RelistPeriod is the period after which the informers list all the
+	// objects again and remove the cached objects absent from the latest
+	// list. It's useful for the backends whose watch doesn't send the delete
+	// events, which leaves the deleted objects in the cache. Default never
+	// relists.
+	RelistPeriod time.Duration
+
+	// AllowWatchBookmarks requests the watch bookmark events from the
+	// backend. The informers resume the watches from the resource version of
+	// the latest bookmark after a disconnect, instead of relisting. This
+	// requires a ListWatcherClient that implements WatchOptionsClient and
+	// supports bookmarks. Default doesn't request the bookmarks.
+	AllowWatchBookmarks bool
 }
 
 var defaultResyncTime = 10 * time.Hour
 
+// Cache is a controller-runtime cache that can also index multiple fields of
+// an object at once.
+type Cache interface {
+	cache.Cache
+
+	// IndexFields adds multiple field indexers to the informer of the given
+	// object at once. It must be called before the cache is started.
+	IndexFields(ctx context.Context, obj client.Object, extractors map[string]client.IndexerFunc) error
+
+	// GetInformerWithOptions is like GetInformer, but creates the informer
+	// of the object with the given resync period and extra indexers if it
+	// doesn't exist. An error matching informer.ErrInformerOptionsConflict
+	// is returned if the existing informer conflicts with the options.
+	GetInformerWithOptions(ctx context.Context, obj client.Object, opts informer.InformerOptions) (cache.Informer, error)
+}
+
+// MultiNamespaceCache is a Cache of a set of namespaces that can be extended
+// at runtime. The Cache returned by New with Options.Namespaces implements
+// it.
+type MultiNamespaceCache interface {
+	Cache
+
+	// AddNamespace adds the given namespace to the cache without restarting
+	// the cache, like when onboarding a new tenant. The objects already
+	// watched by the cache are watched in the namespace and become visible
+	// once they're synced.
+	AddNamespace(ns string) error
+}
+
 // New initializes and returns a new Cache.
-func New(createLWFunc informer.CreateListWatcherFunc, opts Options) cache.Cache {
+func New(createLWFunc informer.CreateListWatcherFunc, opts Options) Cache {
 	opts = defaultOpts(opts)
-	im := informer.NewInformersMap(opts.Scheme, *opts.Resync, opts.Namespace, createLWFunc)
+	if len(opts.Namespaces) > 0 {
+		return newMultiNamespaceCache(createLWFunc, opts)
+	}
+	im := informer.NewInformersMap(opts.Scheme, *opts.Resync, opts.Namespace, createLWFunc, opts.informersMapOptions()...)
 	return &informerCache{InformersMap: im}
 }
 
@@ -47,3 +144,17 @@ func defaultOpts(opts Options) Options {
 	}
 	return opts
 }
+
+// informersMapOptions returns the options of the InformersMaps created by
+// the cache.
+func (o Options) informersMapOptions() []informer.InformersMapOption {
+	return []informer.InformersMapOption{
+		informer.WithIndexers(o.Indexers),
+		informer.WithWatchErrorHandler(o.WatchErrorHandler),
+		informer.WithObjectLimits(o.ObjectLimits),
+		informer.WithFatalErrorFunc(o.FatalWatchError),
+		informer.WithKeyFuncs(o.KeyFuncs),
+		informer.WithRelistPeriod(o.RelistPeriod),
+		informer.WithWatchBookmarks(o.AllowWatchBookmarks),
+	}
+}
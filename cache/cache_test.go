@@ -0,0 +1,1009 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/darkowlzz/operator-toolkit/cache/informer"
+)
+
+// fakeListWatcherClient is a ListWatcherClient that lists configmaps and
+// namespaces from a static set of objects and never sends any watch event.
+type fakeListWatcherClient struct {
+	configMaps []corev1.ConfigMap
+	namespaces []corev1.Namespace
+}
+
+func (f *fakeListWatcherClient) List(ctx context.Context, namespace string, obj runtime.Object) (runtime.Object, error) {
+	if list, ok := obj.(*corev1.NamespaceList); ok {
+		list.Items = append(list.Items, f.namespaces...)
+		return list, nil
+	}
+	list := obj.(*corev1.ConfigMapList)
+	for _, cm := range f.configMaps {
+		if namespace == "" || cm.Namespace == namespace {
+			list.Items = append(list.Items, cm)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeListWatcherClient) Watch(ctx context.Context, namespace string, kind string) (watch.Interface, error) {
+	return watch.NewFake(), nil
+}
+
+// failingWatchClient is a ListWatcherClient that lists configmaps from a
+// static set of configmaps and fails to watch.
+type failingWatchClient struct {
+	fakeListWatcherClient
+}
+
+func (f *failingWatchClient) Watch(ctx context.Context, namespace string, kind string) (watch.Interface, error) {
+	return nil, errors.New("watch unavailable")
+}
+
+// forbiddenClient is a ListWatcherClient that fails to list with a
+// permission denied error.
+type forbiddenClient struct {
+	fakeListWatcherClient
+}
+
+func (f *forbiddenClient) List(ctx context.Context, namespace string, obj runtime.Object) (runtime.Object, error) {
+	return nil, apierrors.NewForbidden(corev1.Resource("configmaps"), "", errors.New("rbac denied"))
+}
+
+// watchingClient is a ListWatcherClient that lists configmaps from a static
+// set of configmaps and sends the watch events of a fake watcher.
+type watchingClient struct {
+	fakeListWatcherClient
+	watcher  *watch.FakeWatcher
+	watching chan struct{}
+	once     sync.Once
+}
+
+func newWatchingClient(configMaps ...corev1.ConfigMap) *watchingClient {
+	return &watchingClient{
+		fakeListWatcherClient: fakeListWatcherClient{configMaps: configMaps},
+		watcher:               watch.NewFake(),
+		watching:              make(chan struct{}),
+	}
+}
+
+func (f *watchingClient) Watch(ctx context.Context, namespace string, kind string) (watch.Interface, error) {
+	f.once.Do(func() { close(f.watching) })
+	return f.watcher, nil
+}
+
+func newConfigMap(name, namespace string) corev1.ConfigMap {
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+	}
+}
+
+// startCache starts the given cache and waits for it to start.
+func startCache(t *testing.T, ctx context.Context, c interface {
+	Start(context.Context) error
+	WaitForCacheSync(context.Context) bool
+}) {
+	go func() {
+		assert.Nil(t, c.Start(ctx))
+	}()
+	syncCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	assert.True(t, c.WaitForCacheSync(syncCtx))
+}
+
+func TestMultiNamespaceCache(t *testing.T) {
+	lwc := &fakeListWatcherClient{
+		configMaps: []corev1.ConfigMap{
+			newConfigMap("cm1", "ns-a"),
+			newConfigMap("cm2", "ns-b"),
+			newConfigMap("cm3", "ns-c"),
+		},
+		namespaces: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}},
+		},
+	}
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme:     scheme.Scheme,
+		Namespaces: []string{"ns-a", "ns-b"},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startCache(t, ctx, c)
+
+	// List across all the namespaces.
+	cmList := &corev1.ConfigMapList{}
+	assert.Nil(t, c.List(ctx, cmList))
+	names := []string{}
+	for _, cm := range cmList.Items {
+		names = append(names, cm.Name)
+	}
+	assert.ElementsMatch(t, []string{"cm1", "cm2"}, names)
+
+	// List in a namespace.
+	cmList = &corev1.ConfigMapList{}
+	assert.Nil(t, c.List(ctx, cmList, client.InNamespace("ns-b")))
+	assert.Len(t, cmList.Items, 1)
+	assert.Equal(t, "cm2", cmList.Items[0].Name)
+
+	// Get the cached objects.
+	cm := &corev1.ConfigMap{}
+	assert.Nil(t, c.Get(ctx, client.ObjectKey{Name: "cm1", Namespace: "ns-a"}, cm))
+
+	// Objects in the other namespaces aren't cached.
+	assert.NotNil(t, c.Get(ctx, client.ObjectKey{Name: "cm3", Namespace: "ns-c"}, cm))
+	assert.NotNil(t, c.List(ctx, &corev1.ConfigMapList{}, client.InNamespace("ns-c")))
+
+	// Get the cluster scoped objects from the cluster-wide cache.
+	ns := &corev1.Namespace{}
+	assert.Nil(t, c.Get(ctx, client.ObjectKey{Name: "ns-a"}, ns))
+	assert.Equal(t, "ns-a", ns.Name)
+}
+
+func TestMultiNamespaceCacheAddNamespace(t *testing.T) {
+	lwc := &fakeListWatcherClient{
+		configMaps: []corev1.ConfigMap{
+			newConfigMap("cm1", "ns-a"),
+			newConfigMap("cm2", "ns-b"),
+			newConfigMap("cm3", "ns-c"),
+		},
+	}
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme:     scheme.Scheme,
+		Namespaces: []string{"ns-a"},
+	})
+	mc, ok := c.(MultiNamespaceCache)
+	require.True(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Watch the configmaps with an event handler and a field index before
+	// the namespaces are added.
+	var mu sync.Mutex
+	added := []string{}
+	inf, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+	require.Nil(t, err)
+	inf.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			mu.Lock()
+			defer mu.Unlock()
+			added = append(added, obj.(*corev1.ConfigMap).Name)
+		},
+	})
+	require.Nil(t, c.IndexField(ctx, &corev1.ConfigMap{}, "name", func(obj client.Object) []string {
+		return []string{obj.GetName()}
+	}))
+
+	// A namespace added before the cache is started.
+	require.Nil(t, mc.AddNamespace("ns-b"))
+	startCache(t, ctx, c)
+
+	// A namespace added after the cache is started, while the cache is
+	// being read.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			_ = c.List(ctx, &corev1.ConfigMapList{})
+		}
+	}()
+	require.Nil(t, mc.AddNamespace("ns-c"))
+	<-done
+	// Adding a namespace again is a no-op.
+	require.Nil(t, mc.AddNamespace("ns-c"))
+
+	// The objects in the added namespaces become visible.
+	assert.Eventually(t, func() bool {
+		cmList := &corev1.ConfigMapList{}
+		if err := c.List(ctx, cmList); err != nil {
+			return false
+		}
+		return len(cmList.Items) == 3
+	}, 10*time.Second, 100*time.Millisecond)
+	cm := &corev1.ConfigMap{}
+	assert.Nil(t, c.Get(ctx, client.ObjectKey{Name: "cm3", Namespace: "ns-c"}, cm))
+
+	// The field index works in the added namespace.
+	cmList := &corev1.ConfigMapList{}
+	assert.Nil(t, c.List(ctx, cmList, client.InNamespace("ns-c"), client.MatchingFields{"name": "cm3"}))
+	assert.Len(t, cmList.Items, 1)
+
+	// The event handler receives the objects of the added namespaces.
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(added) == 3
+	}, 10*time.Second, 100*time.Millisecond)
+	mu.Lock()
+	assert.ElementsMatch(t, []string{"cm1", "cm2", "cm3"}, added)
+	mu.Unlock()
+	assert.True(t, inf.HasSynced())
+}
+
+func TestMultiNamespaceCacheClusterScoped(t *testing.T) {
+	lwc := &fakeListWatcherClient{
+		configMaps: []corev1.ConfigMap{
+			newConfigMap("cm1", "ns-a"),
+		},
+		namespaces: []corev1.Namespace{
+			{ObjectMeta: metav1.ObjectMeta{Name: "ns-a"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "ns-b"}},
+		},
+	}
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), apimeta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), apimeta.RESTScopeRoot)
+
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme:     scheme.Scheme,
+		Namespaces: []string{"ns-a", "ns-b"},
+		Mapper:     mapper,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Index the cluster scoped objects before the cache is started.
+	require.Nil(t, c.IndexField(ctx, &corev1.Namespace{}, "name", func(obj client.Object) []string {
+		return []string{obj.GetName()}
+	}))
+	startCache(t, ctx, c)
+
+	// The cluster scoped objects are listed once, from the cluster-wide
+	// cache.
+	nsList := &corev1.NamespaceList{}
+	assert.Nil(t, c.List(ctx, nsList))
+	names := []string{}
+	for _, ns := range nsList.Items {
+		names = append(names, ns.Name)
+	}
+	assert.ElementsMatch(t, []string{"ns-a", "ns-b"}, names)
+
+	// The field index works for the cluster scoped objects.
+	nsList = &corev1.NamespaceList{}
+	assert.Nil(t, c.List(ctx, nsList, client.MatchingFields{"name": "ns-b"}))
+	require.Len(t, nsList.Items, 1)
+	assert.Equal(t, "ns-b", nsList.Items[0].Name)
+
+	// The namespaced objects are still listed per namespace.
+	cmList := &corev1.ConfigMapList{}
+	assert.Nil(t, c.List(ctx, cmList))
+	assert.Len(t, cmList.Items, 1)
+}
+
+func TestIndexFields(t *testing.T) {
+	cm1 := newConfigMap("cm1", "default")
+	cm1.Data = map[string]string{"owner": "alice", "tags": "x,y"}
+	cm2 := newConfigMap("cm2", "default")
+	cm2.Data = map[string]string{"owner": "bob", "tags": "y"}
+	cm3 := newConfigMap("cm3", "other")
+	cm3.Data = map[string]string{"owner": "alice", "tags": "z"}
+
+	lwc := &fakeListWatcherClient{
+		configMaps: []corev1.ConfigMap{cm1, cm2, cm3},
+	}
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	c := New(lw.CreateListWatcherFunc(), Options{Scheme: scheme.Scheme})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Register the indexers before starting the cache.
+	err := c.IndexFields(ctx, &corev1.ConfigMap{}, map[string]client.IndexerFunc{
+		"owner": func(obj client.Object) []string {
+			return []string{obj.(*corev1.ConfigMap).Data["owner"]}
+		},
+		// Multi-value index.
+		"tag": func(obj client.Object) []string {
+			return strings.Split(obj.(*corev1.ConfigMap).Data["tags"], ",")
+		},
+	})
+	assert.Nil(t, err)
+
+	startCache(t, ctx, c)
+
+	listNames := func(opts ...client.ListOption) []string {
+		cmList := &corev1.ConfigMapList{}
+		assert.Nil(t, c.List(ctx, cmList, opts...))
+		names := []string{}
+		for _, cm := range cmList.Items {
+			names = append(names, cm.Name)
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"cm1", "cm3"}, listNames(client.MatchingFields{"owner": "alice"}))
+	assert.ElementsMatch(t, []string{"cm1"}, listNames(client.MatchingFields{"owner": "alice"}, client.InNamespace("default")))
+	assert.ElementsMatch(t, []string{"cm1", "cm2"}, listNames(client.MatchingFields{"tag": "y"}))
+	assert.ElementsMatch(t, []string{"cm1"}, listNames(client.MatchingFields{"tag": "x"}))
+	assert.ElementsMatch(t, []string{"cm1"}, listNames(client.MatchingFields{"owner": "alice", "tag": "y"}))
+	assert.Empty(t, listNames(client.MatchingFields{"owner": "bob", "tag": "z"}))
+}
+
+func TestOptionsIndexers(t *testing.T) {
+	ownerRef := func(uid string) metav1.OwnerReference {
+		return metav1.OwnerReference{APIVersion: "v1", Kind: "Pod", Name: "owner-" + uid, UID: types.UID(uid)}
+	}
+	cm1 := newConfigMap("cm1", "default")
+	cm1.OwnerReferences = []metav1.OwnerReference{ownerRef("uid-1")}
+	cm2 := newConfigMap("cm2", "default")
+	cm2.OwnerReferences = []metav1.OwnerReference{ownerRef("uid-1"), ownerRef("uid-2")}
+	cm3 := newConfigMap("cm3", "other")
+	cm3.OwnerReferences = []metav1.OwnerReference{ownerRef("uid-2")}
+
+	lwc := &fakeListWatcherClient{
+		configMaps: []corev1.ConfigMap{cm1, cm2, cm3},
+	}
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	ownerUIDField := "metadata.ownerReferences.uid"
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme: scheme.Scheme,
+		Indexers: FieldIndexers(map[string]client.IndexerFunc{
+			ownerUIDField: func(obj client.Object) []string {
+				uids := []string{}
+				for _, ref := range obj.GetOwnerReferences() {
+					uids = append(uids, string(ref.UID))
+				}
+				return uids
+			},
+		}),
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	startCache(t, ctx, c)
+
+	listNames := func(opts ...client.ListOption) []string {
+		cmList := &corev1.ConfigMapList{}
+		assert.Nil(t, c.List(ctx, cmList, opts...))
+		names := []string{}
+		for _, cm := range cmList.Items {
+			names = append(names, cm.Name)
+		}
+		return names
+	}
+
+	assert.ElementsMatch(t, []string{"cm1", "cm2"}, listNames(client.MatchingFields{ownerUIDField: "uid-1"}))
+	assert.ElementsMatch(t, []string{"cm2", "cm3"}, listNames(client.MatchingFields{ownerUIDField: "uid-2"}))
+	assert.ElementsMatch(t, []string{"cm2"}, listNames(client.MatchingFields{ownerUIDField: "uid-2"}, client.InNamespace("default")))
+	assert.Empty(t, listNames(client.MatchingFields{ownerUIDField: "uid-3"}))
+
+	// The namespace indexer is still available.
+	assert.ElementsMatch(t, []string{"cm3"}, listNames(client.InNamespace("other")))
+}
+
+func TestWatchErrorHandler(t *testing.T) {
+	lwc := &failingWatchClient{
+		fakeListWatcherClient: fakeListWatcherClient{
+			configMaps: []corev1.ConfigMap{newConfigMap("cm1", "default")},
+		},
+	}
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	var mu sync.Mutex
+	var gotGVK schema.GroupVersionKind
+	var gotErr error
+	gotFailures := []int{}
+
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme: scheme.Scheme,
+		WatchErrorHandler: func(gvk schema.GroupVersionKind, failures int, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			gotGVK = gvk
+			gotErr = err
+			gotFailures = append(gotFailures, failures)
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Get the informer before starting the cache to create it.
+	_, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+	startCache(t, ctx, c)
+
+	// The informer retries with a backoff, wait for repeated failures.
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(gotFailures) >= 2
+	}, 10*time.Second, 100*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, corev1.SchemeGroupVersion.WithKind("ConfigMap"), gotGVK)
+	assert.EqualError(t, gotErr, "watch unavailable")
+	assert.Equal(t, []int{1, 2}, gotFailures[:2])
+}
+
+func TestFatalWatchError(t *testing.T) {
+	lw := ListWatcher{ListWatcherClient: &forbiddenClient{}}
+
+	var mu sync.Mutex
+	gotErrs := []error{}
+
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme:          scheme.Scheme,
+		FatalWatchError: apierrors.IsForbidden,
+		WatchErrorHandler: func(gvk schema.GroupVersionKind, failures int, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			gotErrs = append(gotErrs, err)
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Get the informer before starting the cache to create it. The cache
+	// sync must not hang on the stopped informer.
+	_, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+	startCache(t, ctx, c)
+
+	// Reading from the stopped informer returns the fatal error.
+	err = c.List(ctx, &corev1.ConfigMapList{})
+	var fatalErr *informer.FatalWatchError
+	require.True(t, errors.As(err, &fatalErr))
+	assert.Equal(t, corev1.SchemeGroupVersion.WithKind("ConfigMap"), fatalErr.GVK)
+	assert.True(t, apierrors.IsForbidden(err))
+
+	// The fatal error is reported once and the list isn't retried.
+	time.Sleep(2 * time.Second)
+	mu.Lock()
+	defer mu.Unlock()
+	fatalErrs := 0
+	for _, e := range gotErrs {
+		if errors.As(e, &fatalErr) {
+			fatalErrs++
+		}
+	}
+	assert.Equal(t, 1, fatalErrs)
+}
+
+func TestKeyFuncs(t *testing.T) {
+	// The configmaps are identified by an external ID label.
+	withID := func(cm corev1.ConfigMap, id string) corev1.ConfigMap {
+		cm.Labels = map[string]string{"external-id": id}
+		return cm
+	}
+	lwc := &fakeListWatcherClient{
+		configMaps: []corev1.ConfigMap{
+			withID(newConfigMap("cm1", "ns-a"), "ext-1"),
+			withID(newConfigMap("cm2", "ns-b"), "ext-2"),
+		},
+	}
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme: scheme.Scheme,
+		KeyFuncs: informer.KeyFuncs{
+			KeyFunc: func(obj interface{}) (string, error) {
+				return obj.(*corev1.ConfigMap).Labels["external-id"], nil
+			},
+			ObjectKeyFunc: func(key client.ObjectKey) string {
+				return key.Name
+			},
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+	startCache(t, ctx, c)
+
+	// The objects are retrieved by the external ID.
+	cm := &corev1.ConfigMap{}
+	assert.Nil(t, c.Get(ctx, client.ObjectKey{Name: "ext-2"}, cm))
+	assert.Equal(t, "cm2", cm.Name)
+	assert.Equal(t, "ns-b", cm.Namespace)
+
+	// The namespace and name keys aren't used.
+	err = c.Get(ctx, client.ObjectKey{Name: "cm1", Namespace: "ns-a"}, &corev1.ConfigMap{})
+	assert.True(t, apierrors.IsNotFound(err))
+
+	// Listing by namespace still works.
+	cml := &corev1.ConfigMapList{}
+	assert.Nil(t, c.List(ctx, cml, client.InNamespace("ns-a")))
+	assert.Len(t, cml.Items, 1)
+	assert.Equal(t, "cm1", cml.Items[0].Name)
+}
+
+func TestObjectLimitsTrimFields(t *testing.T) {
+	cm := newConfigMap("cm1", "default")
+	cm.Labels = map[string]string{"app": "web"}
+	cm.Data = map[string]string{"key": "value"}
+	cm.ManagedFields = []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply}}
+	lwc := newWatchingClient(cm)
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme: scheme.Scheme,
+		ObjectLimits: informer.ObjectLimits{
+			TrimFields: []string{"metadata.managedFields", "data"},
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+	startCache(t, ctx, c)
+
+	// Listed objects are trimmed.
+	got := &corev1.ConfigMap{}
+	assert.Nil(t, c.Get(ctx, client.ObjectKey{Name: "cm1", Namespace: "default"}, got))
+	assert.Empty(t, got.ManagedFields)
+	assert.Empty(t, got.Data)
+	assert.Equal(t, map[string]string{"app": "web"}, got.Labels)
+
+	// Watched objects are trimmed.
+	<-lwc.watching
+	cm2 := newConfigMap("cm2", "default")
+	cm2.Data = map[string]string{"key": "value"}
+	cm2.ManagedFields = cm.ManagedFields
+	lwc.watcher.Add(&cm2)
+
+	require.Eventually(t, func() bool {
+		return c.Get(ctx, client.ObjectKey{Name: "cm2", Namespace: "default"}, got) == nil
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.Empty(t, got.ManagedFields)
+	assert.Empty(t, got.Data)
+}
+
+func TestObjectLimitsEvict(t *testing.T) {
+	// The most recently created objects are kept when listing.
+	now := time.Now()
+	cm0 := newConfigMap("cm0", "default")
+	cm0.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+	cm1 := newConfigMap("cm1", "default")
+	cm1.CreationTimestamp = metav1.NewTime(now)
+	cm2 := newConfigMap("cm2", "default")
+	cm2.CreationTimestamp = metav1.NewTime(now)
+	lwc := newWatchingClient(cm1, cm0, cm2)
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme:       scheme.Scheme,
+		ObjectLimits: informer.ObjectLimits{MaxObjects: 2, Evict: true},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	inf, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+
+	// The evicted objects aren't deleted, the handlers aren't notified.
+	var deleted int32
+	inf.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			atomic.AddInt32(&deleted, 1)
+		},
+	})
+	startCache(t, ctx, c)
+
+	listNames := func() []string {
+		cmList := &corev1.ConfigMapList{}
+		assert.Nil(t, c.List(ctx, cmList))
+		names := []string{}
+		for _, cm := range cmList.Items {
+			names = append(names, cm.Name)
+		}
+		sort.Strings(names)
+		return names
+	}
+	assert.Equal(t, []string{"cm1", "cm2"}, listNames())
+
+	// Adding an object past the cap evicts the least recently used object.
+	<-lwc.watching
+	cm3 := newConfigMap("cm3", "default")
+	lwc.watcher.Add(&cm3)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"cm2", "cm3"}, listNames())
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// Updated objects are recently used.
+	cm2.Data = map[string]string{"key": "value"}
+	lwc.watcher.Modify(&cm2)
+	cm4 := newConfigMap("cm4", "default")
+	lwc.watcher.Add(&cm4)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"cm2", "cm4"}, listNames())
+	}, 10*time.Second, 100*time.Millisecond)
+
+	// An evicted object is cached again when updated.
+	cm1.Data = map[string]string{"key": "value"}
+	lwc.watcher.Modify(&cm1)
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"cm1", "cm4"}, listNames())
+	}, 10*time.Second, 100*time.Millisecond)
+
+	assert.Equal(t, int32(0), atomic.LoadInt32(&deleted))
+}
+
+func TestObjectLimitsExceeded(t *testing.T) {
+	lwc := newWatchingClient(newConfigMap("cm2", "default"), newConfigMap("cm1", "default"))
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	var mu sync.Mutex
+	gotErrs := []error{}
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme:       scheme.Scheme,
+		ObjectLimits: informer.ObjectLimits{MaxObjects: 1},
+		WatchErrorHandler: func(gvk schema.GroupVersionKind, failures int, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			gotErrs = append(gotErrs, err)
+		},
+	})
+	errCount := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(gotErrs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+	startCache(t, ctx, c)
+
+	listNames := func() []string {
+		cmList := &corev1.ConfigMapList{}
+		assert.Nil(t, c.List(ctx, cmList))
+		names := []string{}
+		for _, cm := range cmList.Items {
+			names = append(names, cm.Name)
+		}
+		return names
+	}
+
+	// The cache syncs with the objects within the limit, the rest are
+	// reported.
+	assert.Equal(t, []string{"cm1"}, listNames())
+	assert.Equal(t, 1, errCount())
+
+	// The watched objects over the limit are dropped and reported.
+	<-lwc.watching
+	cm3 := newConfigMap("cm3", "default")
+	lwc.watcher.Add(&cm3)
+	assert.Eventually(t, func() bool {
+		return errCount() == 2
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.Equal(t, []string{"cm1"}, listNames())
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, err := range gotErrs {
+		assert.True(t, errors.Is(err, informer.ErrMaxObjectsExceeded))
+	}
+}
+
+func TestGetInformerWithOptions(t *testing.T) {
+	lwc := &fakeListWatcherClient{
+		configMaps: []corev1.ConfigMap{newConfigMap("cm1", "default")},
+	}
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	// The default resync period of the cache is long enough to not resync
+	// during the test.
+	c := New(lw.CreateListWatcherFunc(), Options{Scheme: scheme.Scheme})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nameField := "metadata.name"
+	resync := time.Second
+	opts := informer.InformerOptions{
+		Resync: &resync,
+		Indexers: FieldIndexers(map[string]client.IndexerFunc{
+			nameField: func(obj client.Object) []string {
+				return []string{obj.GetName()}
+			},
+		}),
+	}
+	inf, err := c.GetInformerWithOptions(ctx, &corev1.ConfigMap{}, opts)
+	require.Nil(t, err)
+
+	// The resync of the informer sends the cached objects as updates.
+	var resyncs int32
+	inf.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			atomic.AddInt32(&resyncs, 1)
+		},
+	})
+
+	startCache(t, ctx, c)
+
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&resyncs) > 0
+	}, 5*time.Second, 100*time.Millisecond)
+
+	// The extra indexer is added to the informer.
+	cmList := &corev1.ConfigMapList{}
+	assert.Nil(t, c.List(ctx, cmList, client.MatchingFields{nameField: "cm1"}))
+	assert.Len(t, cmList.Items, 1)
+
+	// The informer can be requested again with the same or no options.
+	_, err = c.GetInformerWithOptions(ctx, &corev1.ConfigMap{}, opts)
+	assert.Nil(t, err)
+	_, err = c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+
+	// Conflicting options are rejected.
+	otherResync := time.Minute
+	_, err = c.GetInformerWithOptions(ctx, &corev1.ConfigMap{}, informer.InformerOptions{Resync: &otherResync})
+	assert.True(t, errors.Is(err, informer.ErrInformerOptionsConflict))
+	_, err = c.GetInformerWithOptions(ctx, &corev1.ConfigMap{}, informer.InformerOptions{
+		Indexers: FieldIndexers(map[string]client.IndexerFunc{
+			"metadata.namespace": func(obj client.Object) []string {
+				return []string{obj.GetNamespace()}
+			},
+		}),
+	})
+	assert.True(t, errors.Is(err, informer.ErrInformerOptionsConflict))
+}
+
+func TestLastSyncResourceVersion(t *testing.T) {
+	cm1 := newConfigMap("cm1", "default")
+	cm1.ResourceVersion = "1"
+	lwc := newWatchingClient(cm1)
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	c := New(lw.CreateListWatcherFunc(), Options{Scheme: scheme.Scheme})
+	im := c.(*informerCache).InformersMap
+	gvk := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+
+	// No informer for the GVK yet.
+	_, found := im.LastSyncResourceVersion(gvk)
+	assert.False(t, found)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+	startCache(t, ctx, c)
+
+	rv, found := im.LastSyncResourceVersion(gvk)
+	assert.True(t, found)
+	assert.Equal(t, "", rv)
+
+	// The resource version advances with the watch events.
+	<-lwc.watching
+	cm2 := newConfigMap("cm2", "default")
+	cm2.ResourceVersion = "5"
+	lwc.watcher.Add(&cm2)
+	assert.Eventually(t, func() bool {
+		rv, _ := im.LastSyncResourceVersion(gvk)
+		return rv == "5"
+	}, 10*time.Second, 100*time.Millisecond)
+
+	cm1.ResourceVersion = "7"
+	lwc.watcher.Modify(&cm1)
+	assert.Eventually(t, func() bool {
+		rv, _ := im.LastSyncResourceVersion(gvk)
+		return rv == "7"
+	}, 10*time.Second, 100*time.Millisecond)
+}
+
+// shrinkingClient is a ListWatcherClient that lists configmaps from a set of
+// configmaps that can be changed and never sends any watch event.
+type shrinkingClient struct {
+	fakeListWatcherClient
+	mu sync.Mutex
+}
+
+func (f *shrinkingClient) List(ctx context.Context, namespace string, obj runtime.Object) (runtime.Object, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fakeListWatcherClient.List(ctx, namespace, obj)
+}
+
+func (f *shrinkingClient) setConfigMaps(configMaps ...corev1.ConfigMap) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.configMaps = configMaps
+}
+
+func TestRelistPeriod(t *testing.T) {
+	lwc := &shrinkingClient{
+		fakeListWatcherClient: fakeListWatcherClient{
+			configMaps: []corev1.ConfigMap{
+				newConfigMap("cm1", "default"),
+				newConfigMap("cm2", "default"),
+			},
+		},
+	}
+	lw := ListWatcher{ListWatcherClient: lwc}
+
+	c := New(lw.CreateListWatcherFunc(), Options{
+		Scheme:       scheme.Scheme,
+		RelistPeriod: 100 * time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inf, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+	deleted := make(chan string, 1)
+	inf.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if d, ok := obj.(toolscache.DeletedFinalStateUnknown); ok {
+				deleted <- d.Key
+			}
+		},
+	})
+	startCache(t, ctx, c)
+
+	cmList := &corev1.ConfigMapList{}
+	assert.Nil(t, c.List(ctx, cmList))
+	assert.Len(t, cmList.Items, 2)
+
+	// Remove cm2 from the backend without a delete event. It's evicted from
+	// the cache after a relist.
+	lwc.setConfigMaps(newConfigMap("cm1", "default"))
+	assert.Eventually(t, func() bool {
+		cmList := &corev1.ConfigMapList{}
+		assert.Nil(t, c.List(ctx, cmList))
+		return len(cmList.Items) == 1 && cmList.Items[0].Name == "cm1"
+	}, 10*time.Second, 100*time.Millisecond)
+
+	select {
+	case key := <-deleted:
+		assert.Equal(t, "default/cm2", key)
+	case <-time.After(10 * time.Second):
+		t.Error("timed out waiting for the delete event")
+	}
+}
+
+// bookmarkClient is a WatchOptionsClient that lists configmaps from a static
+// set of configmaps and sends the watch events of a fake watcher. The options
+// of the watches are sent to the opts channel.
+type bookmarkClient struct {
+	fakeListWatcherClient
+	lists   int32
+	opts    chan metav1.ListOptions
+	mu      sync.Mutex
+	watcher *watch.FakeWatcher
+}
+
+func (f *bookmarkClient) List(ctx context.Context, namespace string, obj runtime.Object) (runtime.Object, error) {
+	atomic.AddInt32(&f.lists, 1)
+	list, err := f.fakeListWatcherClient.List(ctx, namespace, obj)
+	if err != nil {
+		return nil, err
+	}
+	list.(*corev1.ConfigMapList).ResourceVersion = "1"
+	return list, nil
+}
+
+func (f *bookmarkClient) WatchWithOptions(ctx context.Context, namespace string, kind string, opts metav1.ListOptions) (watch.Interface, error) {
+	f.mu.Lock()
+	f.watcher = watch.NewFake()
+	w := f.watcher
+	f.mu.Unlock()
+	f.opts <- opts
+	return w, nil
+}
+
+func (f *bookmarkClient) getWatcher() *watch.FakeWatcher {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.watcher
+}
+
+func TestWatchBookmarks(t *testing.T) {
+	testcases := []struct {
+		name      string
+		bookmarks bool
+	}{
+		{
+			name:      "bookmarks allowed",
+			bookmarks: true,
+		},
+		{
+			name: "bookmarks not requested",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			lwc := &bookmarkClient{
+				fakeListWatcherClient: fakeListWatcherClient{
+					configMaps: []corev1.ConfigMap{newConfigMap("cm1", "default")},
+				},
+				opts: make(chan metav1.ListOptions, 10),
+			}
+			lw := ListWatcher{ListWatcherClient: lwc}
+
+			c := New(lw.CreateListWatcherFunc(), Options{
+				Scheme:              scheme.Scheme,
+				AllowWatchBookmarks: tc.bookmarks,
+			})
+			im := c.(*informerCache).InformersMap
+			gvk := corev1.SchemeGroupVersion.WithKind("ConfigMap")
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			_, err := c.GetInformer(ctx, &corev1.ConfigMap{})
+			assert.Nil(t, err)
+			startCache(t, ctx, c)
+
+			nextOpts := func() metav1.ListOptions {
+				select {
+				case opts := <-lwc.opts:
+					return opts
+				case <-time.After(10 * time.Second):
+					t.Fatal("timed out waiting for a watch")
+				}
+				return metav1.ListOptions{}
+			}
+
+			// The watch starts from the listed resource version.
+			opts := nextOpts()
+			assert.Equal(t, tc.bookmarks, opts.AllowWatchBookmarks)
+			assert.Equal(t, "1", opts.ResourceVersion)
+
+			// The stored resource version advances with the bookmarks.
+			for _, rv := range []string{"10", "20"} {
+				rv := rv
+				bookmark := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{ResourceVersion: rv}}
+				lwc.getWatcher().Action(watch.Bookmark, bookmark)
+				assert.Eventually(t, func() bool {
+					got, _ := im.LastSyncResourceVersion(gvk)
+					return got == rv
+				}, 10*time.Second, 100*time.Millisecond)
+			}
+
+			// After a disconnect, the watch resumes from the latest bookmark
+			// without a relist.
+			lwc.getWatcher().Stop()
+			opts = nextOpts()
+			assert.Equal(t, "20", opts.ResourceVersion)
+			assert.Equal(t, tc.bookmarks, opts.AllowWatchBookmarks)
+			assert.Equal(t, int32(1), atomic.LoadInt32(&lwc.lists))
+
+			// The bookmarks aren't cached as objects.
+			cmList := &corev1.ConfigMapList{}
+			assert.Nil(t, c.List(ctx, cmList))
+			assert.Len(t, cmList.Items, 1)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package cache
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NewCachedClient returns a client that reads from the given cache and writes
+// through the given uncached client, like the default client of a
+// controller-runtime manager. The controllers that use an external cache can
+// use it as a drop-in client.Client. The objects are mapped to their kinds
+// with the given scheme, which defaults to the scheme of the uncached client.
+// Unstructured objects aren't read from the cache.
+func NewCachedClient(c cache.Cache, uncachedClient client.Client, scheme *runtime.Scheme) (client.Client, error) {
+	if scheme != nil {
+		uncachedClient = &schemeClient{Client: uncachedClient, scheme: scheme}
+	}
+	return client.NewDelegatingClient(client.NewDelegatingClientInput{
+		CacheReader: c,
+		Client:      uncachedClient,
+	})
+}
+
+// schemeClient is a client with a different scheme.
+type schemeClient struct {
+	client.Client
+	scheme *runtime.Scheme
+}
+
+// Scheme returns the scheme of the client.
+func (c *schemeClient) Scheme() *runtime.Scheme {
+	return c.scheme
+}
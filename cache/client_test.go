@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubectl/pkg/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewCachedClient(t *testing.T) {
+	// The cache and the API have different objects to tell where the reads
+	// and the writes go.
+	lw := ListWatcher{ListWatcherClient: &fakeListWatcherClient{
+		configMaps: []corev1.ConfigMap{newConfigMap("cached", "default")},
+	}}
+	c := New(lw.CreateListWatcherFunc(), Options{Scheme: scheme.Scheme})
+	apiCM := newConfigMap("api", "default")
+	apiClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&apiCM).Build()
+
+	cli, err := NewCachedClient(c, apiClient, scheme.Scheme)
+	assert.Nil(t, err)
+	assert.Equal(t, scheme.Scheme, cli.Scheme())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err = c.GetInformer(ctx, &corev1.ConfigMap{})
+	assert.Nil(t, err)
+	startCache(t, ctx, c)
+
+	// Reads hit the cache.
+	assert.Nil(t, cli.Get(ctx, types.NamespacedName{Name: "cached", Namespace: "default"}, &corev1.ConfigMap{}))
+	err = cli.Get(ctx, types.NamespacedName{Name: "api", Namespace: "default"}, &corev1.ConfigMap{})
+	assert.True(t, apierrors.IsNotFound(err), "expected not found, got %v", err)
+	cmList := &corev1.ConfigMapList{}
+	assert.Nil(t, cli.List(ctx, cmList))
+	if assert.Len(t, cmList.Items, 1) {
+		assert.Equal(t, "cached", cmList.Items[0].Name)
+	}
+
+	// Writes hit the API client.
+	newCM := newConfigMap("new", "default")
+	assert.Nil(t, cli.Create(ctx, &newCM))
+	assert.Nil(t, apiClient.Get(ctx, types.NamespacedName{Name: "new", Namespace: "default"}, &corev1.ConfigMap{}))
+	assert.Nil(t, cli.Delete(ctx, &apiCM))
+	err = apiClient.Get(ctx, types.NamespacedName{Name: "api", Namespace: "default"}, &corev1.ConfigMap{})
+	assert.True(t, apierrors.IsNotFound(err), "expected not found, got %v", err)
+}
@@ -0,0 +1,36 @@
+package informer
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WithWatchBookmarks sets if the informers request the watch bookmark events
+// from the backend, with the AllowWatchBookmarks watch option. A bookmark
+// event carries the latest resource version of the watched objects, without
+// an object change. The informer stores the resource version of the bookmark
+// events, like the resource version of the other events, and resumes the
+// watch from it after a disconnect, avoiding a relist of all the objects.
+// This requires a backend that supports bookmarks and resuming a watch from
+// a resource version. The bookmark events sent by the backend are handled
+// even when not requested. Default doesn't request the bookmarks.
+func WithWatchBookmarks(enable bool) InformersMapOption {
+	return func(m *InformersMap) {
+		m.watchBookmarks = enable
+	}
+}
+
+// setWatchBookmarks wraps the given ListWatch to set the AllowWatchBookmarks
+// option of the watches.
+func setWatchBookmarks(lw *cache.ListWatch, enable bool) *cache.ListWatch {
+	watchFunc := lw.WatchFunc
+	return &cache.ListWatch{
+		ListFunc:        lw.ListFunc,
+		DisableChunking: lw.DisableChunking,
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			opts.AllowWatchBookmarks = enable
+			return watchFunc(opts)
+		},
+	}
+}
@@ -30,13 +30,22 @@ type CacheReader struct {
 
 	// scopeName is the scope of the resource (namespaced or cluster-scoped).
 	scopeName apimeta.RESTScopeName
+
+	// objectKeyFunc returns the custom key of an object key. The objects are
+	// looked up in the custom key index when set.
+	objectKeyFunc func(client.ObjectKey) string
 }
 
 // Get checks the indexer for the object and writes a copy of it if found.
 func (c *CacheReader) Get(_ context.Context, key client.ObjectKey, out client.Object) error {
-	if c.scopeName == apimeta.RESTScopeNameRoot {
-		key.Namespace = ""
+	if c.objectKeyFunc != nil {
+		obj, exists, err := getByKey(c.indexer, c.objectKeyFunc(key))
+		if err != nil {
+			return err
+		}
+		return c.copyInto(key, obj, exists, out)
 	}
+
 	storeKey := objectKeyToStoreKey(key)
 
 	// Lookup the object from the indexer cache
@@ -45,6 +54,23 @@ func (c *CacheReader) Get(_ context.Context, key client.ObjectKey, out client.Ob
 		return err
 	}
 
+	// A cluster-wide cache contains both namespaced and cluster-scoped
+	// objects. Since the scope of the object isn't known, lookup a
+	// cluster-scoped object without the namespace of the key.
+	if !exists && c.scopeName == apimeta.RESTScopeNameRoot && key.Namespace != "" {
+		key.Namespace = ""
+		obj, exists, err = c.indexer.GetByKey(objectKeyToStoreKey(key))
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.copyInto(key, obj, exists, out)
+}
+
+// copyInto writes a copy of the given cached object of the given key into
+// out. A not found error is returned if the object doesn't exist.
+func (c *CacheReader) copyInto(key client.ObjectKey, obj interface{}, exists bool, out client.Object) error {
 	// Not found, return an error
 	if !exists {
 		// Resource gets transformed into Kind in the error anyway, so this is fine
@@ -86,16 +112,14 @@ func (c *CacheReader) List(_ context.Context, out client.ObjectList, opts ...cli
 	listOpts.ApplyOptions(opts)
 
 	if listOpts.FieldSelector != nil {
-		// TODO(directxman12): support more complicated field selectors by
-		// combining multiple indices, GetIndexers, etc
-		field, val, requiresExact := requiresExactMatch(listOpts.FieldSelector)
+		reqs, requiresExact := requiresExactMatch(listOpts.FieldSelector)
 		if !requiresExact {
 			return fmt.Errorf("non-exact field matches are not supported by the cache")
 		}
 		// list all objects by the field selector.  If this is namespaced and we have one, ask for the
 		// namespaced index key.  Otherwise, ask for the non-namespaced variant by using the fake "all namespaces"
 		// namespace.
-		objs, err = c.indexer.ByIndex(FieldIndexName(field), KeyToNamespacedKey(listOpts.Namespace, val))
+		objs, err = c.byFieldIndexes(listOpts.Namespace, reqs)
 	} else if listOpts.Namespace != "" {
 		objs, err = c.indexer.ByIndex(cache.NamespaceIndex, listOpts.Namespace)
 	} else {
@@ -144,17 +168,59 @@ func objectKeyToStoreKey(k client.ObjectKey) string {
 	return k.Namespace + "/" + k.Name
 }
 
-// requiresExactMatch checks if the given field selector is of the form `k=v` or `k==v`.
-func requiresExactMatch(sel fields.Selector) (field, val string, required bool) {
-	reqs := sel.Requirements()
-	if len(reqs) != 1 {
-		return "", "", false
+// byFieldIndexes returns the objects that match all the given field
+// requirements, using the field indexes. The objects matching the first
+// requirement are filtered by the objects matching the rest of the
+// requirements.
+func (c *CacheReader) byFieldIndexes(namespace string, reqs fields.Requirements) ([]interface{}, error) {
+	objs, err := c.indexer.ByIndex(FieldIndexName(reqs[0].Field), KeyToNamespacedKey(namespace, reqs[0].Value))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, req := range reqs[1:] {
+		matches, err := c.indexer.ByIndex(FieldIndexName(req.Field), KeyToNamespacedKey(namespace, req.Value))
+		if err != nil {
+			return nil, err
+		}
+		matchKeys := make(map[string]struct{}, len(matches))
+		for _, m := range matches {
+			key, err := cache.MetaNamespaceKeyFunc(m)
+			if err != nil {
+				return nil, err
+			}
+			matchKeys[key] = struct{}{}
+		}
+
+		filtered := []interface{}{}
+		for _, obj := range objs {
+			key, err := cache.MetaNamespaceKeyFunc(obj)
+			if err != nil {
+				return nil, err
+			}
+			if _, found := matchKeys[key]; found {
+				filtered = append(filtered, obj)
+			}
+		}
+		objs = filtered
+	}
+
+	return objs, nil
+}
+
+// requiresExactMatch checks if all the requirements of the given field
+// selector are of the form `k=v` or `k==v`.
+func requiresExactMatch(sel fields.Selector) (reqs fields.Requirements, required bool) {
+	reqs = sel.Requirements()
+	if len(reqs) == 0 {
+		return nil, false
 	}
-	req := reqs[0]
-	if req.Operator != selection.Equals && req.Operator != selection.DoubleEquals {
-		return "", "", false
+	for _, req := range reqs {
+		if req.Operator != selection.Equals && req.Operator != selection.DoubleEquals {
+			return nil, false
+		}
 	}
-	return req.Field, req.Value, true
+	return reqs, true
 }
 
 // FieldIndexName constructs the name of the index over the given field,
@@ -0,0 +1,110 @@
+package informer
+
+import (
+	"fmt"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// FatalErrorFunc classifies the list and watch errors of the informers. It
+// returns true for the errors that can't be resolved by retrying, for
+// example, permission denied errors due to misconfigured RBAC.
+type FatalErrorFunc func(err error) bool
+
+// FatalWatchError is reported to the watch error handler when the informer
+// of a GVK is stopped due to a fatal list or watch error.
+type FatalWatchError struct {
+	GVK schema.GroupVersionKind
+	Err error
+}
+
+func (e *FatalWatchError) Error() string {
+	return fmt.Sprintf("informer for %s stopped on fatal error: %v", e.GVK, e.Err)
+}
+
+func (e *FatalWatchError) Unwrap() error {
+	return e.Err
+}
+
+// informerStopper stops an informer once on a fatal error.
+type informerStopper struct {
+	stop chan struct{}
+	once sync.Once
+	err  error
+}
+
+func newInformerStopper() *informerStopper {
+	return &informerStopper{stop: make(chan struct{})}
+}
+
+// stopWith stops the informer with the given error. Only the first error is
+// kept.
+func (s *informerStopper) stopWith(err error) bool {
+	stopped := false
+	s.once.Do(func() {
+		s.err = err
+		close(s.stop)
+		stopped = true
+	})
+	return stopped
+}
+
+// stopped returns the fatal error and true if the informer is stopped.
+func (s *informerStopper) stopped() (error, bool) {
+	select {
+	case <-s.stop:
+		return s.err, true
+	default:
+		return nil, false
+	}
+}
+
+// run runs the informer until the given stop channel is closed or the
+// informer is stopped on a fatal error.
+func (s *informerStopper) run(informer cache.SharedIndexInformer, stop <-chan struct{}) {
+	merged := make(chan struct{})
+	go func() {
+		defer close(merged)
+		select {
+		case <-stop:
+		case <-s.stop:
+		}
+	}()
+	informer.Run(merged)
+}
+
+// stopOnFatalErrors wraps the given ListWatch to stop the informer of the
+// given GVK when a list or watch fails with an error classified as fatal by
+// the InformersMap's fatalErrorFunc. The watchErrorHandler, if any, is
+// notified with a FatalWatchError once the informer is stopped.
+func (m *InformersMap) stopOnFatalErrors(gvk schema.GroupVersionKind, lw *cache.ListWatch, s *informerStopper) *cache.ListWatch {
+	check := func(err error) {
+		if err == nil || !m.fatalErrorFunc(err) {
+			return
+		}
+		fatalErr := &FatalWatchError{GVK: gvk, Err: err}
+		if s.stopWith(fatalErr) && m.watchErrorHandler != nil {
+			m.watchErrorHandler(gvk, 0, fatalErr)
+		}
+	}
+
+	listFunc, watchFunc := lw.ListFunc, lw.WatchFunc
+	return &cache.ListWatch{
+		DisableChunking: lw.DisableChunking,
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			obj, err := listFunc(opts)
+			check(err)
+			return obj, err
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			w, err := watchFunc(opts)
+			check(err)
+			return w, err
+		},
+	}
+}
@@ -2,18 +2,30 @@ package informer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/tools/cache"
 )
 
+// WatchErrorHandler is called when the informer of a GVK fails to list or
+// watch the objects. failures is the number of consecutive failures since the
+// last successful watch, which can be used to detect persistent failures, for
+// example, for readiness checks and metrics. The informer retries with a
+// backoff after every failure. It's also called with ErrMaxObjectsExceeded and
+// zero failures when objects aren't cached due to the ObjectLimits.
+type WatchErrorHandler func(gvk schema.GroupVersionKind, failures int, err error)
+
 type CreateListWatcherFunc func(gvk schema.GroupVersionKind, namespace string, scheme *runtime.Scheme) (*cache.ListWatch, error)
 
 // MapEntry contains the cached data for an Informer.
@@ -23,6 +35,38 @@ type MapEntry struct {
 
 	// CacheReader wraps Informer and implements the CacheReader interface for a single type
 	Reader CacheReader
+
+	// resync is the base resync period the informer was created with.
+	resync time.Duration
+
+	// stopper stops the informer on a fatal list or watch error.
+	stopper *informerStopper
+}
+
+// hasSynced returns true if the informer has synced or is stopped on a fatal
+// error and will never sync.
+func (e *MapEntry) hasSynced() bool {
+	if _, stopped := e.stopper.stopped(); stopped {
+		return true
+	}
+	return e.Informer.HasSynced()
+}
+
+// ErrInformerOptionsConflict is returned when the informer of a GVK is
+// requested with options that conflict with the options it was created with.
+var ErrInformerOptionsConflict = errors.New("informer options conflict with the existing informer")
+
+// InformerOptions override the settings of the InformersMap for the informer
+// of a single GVK. They're applied only when the informer is created. A later
+// request for the same informer must not conflict with them.
+type InformerOptions struct {
+	// Resync is the base resync period of the informer. Defaults to the
+	// resync period of the InformersMap.
+	Resync *time.Duration
+
+	// Indexers are the extra indexers added to the informer, along with the
+	// indexers of the InformersMap.
+	Indexers cache.Indexers
 }
 
 // InformersMap create and caches Informers for (runtime.Object, schema.GroupVersionKind) pairs.
@@ -59,12 +103,73 @@ type InformersMap struct {
 	// namespace is the namespace that all ListWatches are restricted to
 	// default or empty string means all namespaces
 	namespace string
+
+	// indexers are the additional indexers added to every new informer,
+	// along with the namespace indexer.
+	indexers cache.Indexers
+
+	// watchErrorHandler is called when an informer fails to list or watch.
+	watchErrorHandler WatchErrorHandler
+
+	// limits are the limits on the objects cached by every informer.
+	limits ObjectLimits
+
+	// fatalErrorFunc classifies the list and watch errors that stop the
+	// informers.
+	fatalErrorFunc FatalErrorFunc
+
+	// keyFuncs are the functions used to key the cached objects.
+	keyFuncs KeyFuncs
+
+	// relistPeriod is the period after which the informers list all the
+	// objects again. Zero never relists.
+	relistPeriod time.Duration
+
+	// watchBookmarks enables requesting the watch bookmark events.
+	watchBookmarks bool
+}
+
+// InformersMapOption is used to configure an InformersMap.
+type InformersMapOption func(*InformersMap)
+
+// WithIndexers sets the indexers added to every informer created by the
+// InformersMap, along with the namespace indexer.
+func WithIndexers(indexers cache.Indexers) InformersMapOption {
+	return func(m *InformersMap) {
+		m.indexers = indexers
+	}
+}
+
+// WithWatchErrorHandler sets the handler notified of the list and watch
+// failures of the informers.
+func WithWatchErrorHandler(handler WatchErrorHandler) InformersMapOption {
+	return func(m *InformersMap) {
+		m.watchErrorHandler = handler
+	}
+}
+
+// WithObjectLimits sets the limits on the objects cached by every informer.
+func WithObjectLimits(limits ObjectLimits) InformersMapOption {
+	return func(m *InformersMap) {
+		m.limits = limits
+	}
+}
+
+// WithFatalErrorFunc sets the function that classifies the list and watch
+// errors of the informers. An informer is stopped on the first error
+// classified as fatal, instead of retrying forever, and the watch error
+// handler is notified with a FatalWatchError. Getting the objects of a
+// stopped informer returns the FatalWatchError.
+func WithFatalErrorFunc(f FatalErrorFunc) InformersMapOption {
+	return func(m *InformersMap) {
+		m.fatalErrorFunc = f
+	}
 }
 
 // NewInformersMap creates a new InformersMap that can create informers for
 // objects.
-func NewInformersMap(scheme *runtime.Scheme, resync time.Duration, namespace string, createLW CreateListWatcherFunc) *InformersMap {
-	return &InformersMap{
+func NewInformersMap(scheme *runtime.Scheme, resync time.Duration, namespace string, createLW CreateListWatcherFunc, opts ...InformersMapOption) *InformersMap {
+	m := &InformersMap{
 		Scheme:            scheme,
 		resync:            resync,
 		namespace:         namespace,
@@ -72,6 +177,10 @@ func NewInformersMap(scheme *runtime.Scheme, resync time.Duration, namespace str
 		informersByGVK:    make(map[schema.GroupVersionKind]*MapEntry),
 		startWait:         make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
 // Start calls Run on each of the informers and sets started to true.  Blocks
@@ -85,7 +194,7 @@ func (m *InformersMap) Start(ctx context.Context) error {
 		m.stop = ctx.Done()
 
 		for _, informer := range m.informersByGVK {
-			go informer.Informer.Run(ctx.Done())
+			go informer.stopper.run(informer.Informer, ctx.Done())
 		}
 
 		// Set started to true so we immediately start any informers added later.
@@ -115,12 +224,27 @@ func (m *InformersMap) HasSyncedFuncs() []cache.InformerSynced {
 
 	syncedFuncs := make([]cache.InformerSynced, 0, len(m.informersByGVK))
 	for _, informer := range m.informersByGVK {
-		syncedFuncs = append(syncedFuncs, informer.Informer.HasSynced)
+		syncedFuncs = append(syncedFuncs, informer.hasSynced)
 	}
 
 	return syncedFuncs
 }
 
+// LastSyncResourceVersion returns the resource version the informer of the
+// given GVK last synced with, which advances as the watch events are
+// processed. It can be used to checkpoint the sync progress. It returns false
+// if there's no informer for the GVK.
+func (m *InformersMap) LastSyncResourceVersion(gvk schema.GroupVersionKind) (string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	i, ok := m.informersByGVK[gvk]
+	if !ok {
+		return "", false
+	}
+	return i.Informer.LastSyncResourceVersion(), true
+}
+
 func (m *InformersMap) waitForStarted(ctx context.Context) bool {
 	select {
 	case <-m.startWait:
@@ -133,6 +257,14 @@ func (m *InformersMap) waitForStarted(ctx context.Context) bool {
 // Get will create a new Informer and add it to the map of informers if none
 // exists.  Returns the Informer from the map.
 func (m *InformersMap) Get(ctx context.Context, gvk schema.GroupVersionKind, obj runtime.Object) (bool, *MapEntry, error) {
+	return m.GetWithOptions(ctx, gvk, obj, InformerOptions{})
+}
+
+// GetWithOptions is like Get, but creates the Informer with the given
+// options if none exists. If the Informer exists, an error matching
+// ErrInformerOptionsConflict is returned when it wasn't created with the
+// given resync period or doesn't have the given indexers.
+func (m *InformersMap) GetWithOptions(ctx context.Context, gvk schema.GroupVersionKind, obj runtime.Object, opts InformerOptions) (bool, *MapEntry, error) {
 	// Return the informer if it is found.
 	i, started, ok := func() (*MapEntry, bool, bool) {
 		m.mu.RLock()
@@ -141,23 +273,46 @@ func (m *InformersMap) Get(ctx context.Context, gvk schema.GroupVersionKind, obj
 		return i, m.started, ok
 	}()
 
-	if !ok {
+	if ok {
+		if err := checkInformerOptions(gvk, i, opts); err != nil {
+			return started, nil, err
+		}
+	} else {
 		var err error
-		if i, started, err = m.addInformerToMap(gvk, obj); err != nil {
+		if i, started, err = m.addInformerToMap(gvk, obj, opts); err != nil {
 			return started, i, err
 		}
 	}
 
-	if started && !i.Informer.HasSynced() {
-		if !cache.WaitForCacheSync(ctx.Done(), i.Informer.HasSynced) {
+	if started && !i.hasSynced() {
+		if !cache.WaitForCacheSync(ctx.Done(), i.hasSynced) {
 			return started, nil, apierrors.NewTimeoutError(fmt.Sprintf("failed waiting for %T Informer to sync", obj), 0)
 		}
 	}
 
+	if err, stopped := i.stopper.stopped(); stopped {
+		return started, nil, err
+	}
+
 	return started, i, nil
 }
 
-func (m *InformersMap) addInformerToMap(gvk schema.GroupVersionKind, obj runtime.Object) (*MapEntry, bool, error) {
+// checkInformerOptions returns an error if the informer of the given entry
+// wasn't created with the given options.
+func checkInformerOptions(gvk schema.GroupVersionKind, i *MapEntry, opts InformerOptions) error {
+	if opts.Resync != nil && *opts.Resync != i.resync {
+		return fmt.Errorf("%w: %s informer has resync period %v, requested %v", ErrInformerOptionsConflict, gvk, i.resync, *opts.Resync)
+	}
+	existing := i.Informer.GetIndexer().GetIndexers()
+	for name := range opts.Indexers {
+		if _, ok := existing[name]; !ok {
+			return fmt.Errorf("%w: %s informer doesn't have indexer %q", ErrInformerOptionsConflict, gvk, name)
+		}
+	}
+	return nil
+}
+
+func (m *InformersMap) addInformerToMap(gvk schema.GroupVersionKind, obj runtime.Object, opts InformerOptions) (*MapEntry, bool, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -165,7 +320,12 @@ func (m *InformersMap) addInformerToMap(gvk schema.GroupVersionKind, obj runtime
 	// This is for the case where 2 routines tried to get the informer when it wasn't in the map
 	// so neither returned early, but the first one created it.
 	if i, ok := m.informersByGVK[gvk]; ok {
-		return i, m.started, nil
+		return i, m.started, checkInformerOptions(gvk, i, opts)
+	}
+
+	resync := m.resync
+	if opts.Resync != nil {
+		resync = *opts.Resync
 	}
 
 	// Create a NewSharedIndexInformer and add it to the map.
@@ -174,9 +334,37 @@ func (m *InformersMap) addInformerToMap(gvk schema.GroupVersionKind, obj runtime
 	if err != nil {
 		return nil, false, err
 	}
-	ni := cache.NewSharedIndexInformer(lw, obj, resyncPeriod(m.resync)(), cache.Indexers{
-		cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
-	})
+	lw = setWatchBookmarks(lw, m.watchBookmarks)
+	if m.relistPeriod > 0 {
+		lw = relistPeriodically(lw, m.relistPeriod)
+	}
+	var limiter *objectLimiter
+	if m.limits.enabled() {
+		limiter = newObjectLimiter(gvk, m.limits)
+		if m.watchErrorHandler != nil {
+			limiter.report = func(err error) {
+				m.watchErrorHandler(gvk, 0, err)
+			}
+		}
+		lw = limiter.wrap(lw)
+	}
+	stopper := newInformerStopper()
+	if m.fatalErrorFunc != nil {
+		lw = m.stopOnFatalErrors(gvk, lw, stopper)
+	}
+	var watchErrorHandler cache.WatchErrorHandler
+	if m.watchErrorHandler != nil {
+		lw, watchErrorHandler = m.trackWatchErrors(gvk, lw)
+	}
+	ni := cache.NewSharedIndexInformer(lw, obj, resyncPeriod(resync)(), m.informerIndexers(opts.Indexers))
+	if limiter != nil {
+		limiter.setStore(ni.GetIndexer())
+	}
+	if watchErrorHandler != nil {
+		if err := ni.SetWatchErrorHandler(watchErrorHandler); err != nil {
+			return nil, false, err
+		}
+	}
 
 	// RESTScope based on the cache namespace.
 	var scope apimeta.RESTScopeName
@@ -188,16 +376,73 @@ func (m *InformersMap) addInformerToMap(gvk schema.GroupVersionKind, obj runtime
 
 	i := &MapEntry{
 		Informer: ni,
-		Reader:   CacheReader{indexer: ni.GetIndexer(), groupVersionKind: gvk, scopeName: scope},
+		Reader: CacheReader{
+			indexer:          ni.GetIndexer(),
+			groupVersionKind: gvk,
+			scopeName:        scope,
+			objectKeyFunc:    m.keyFuncs.ObjectKeyFunc,
+		},
+		resync:  resync,
+		stopper: stopper,
 	}
 	m.informersByGVK[gvk] = i
 
 	if m.started {
-		go i.Informer.Run(m.stop)
+		go i.stopper.run(i.Informer, m.stop)
 	}
 	return i, m.started, nil
 }
 
+// trackWatchErrors wraps the given ListWatch to track the consecutive list
+// and watch failures of the informer of the given GVK. It returns the wrapped
+// ListWatch and an informer watch error handler that notifies the
+// InformersMap's watchErrorHandler with the number of consecutive failures.
+// The failure count is reset when a watch is established successfully.
+func (m *InformersMap) trackWatchErrors(gvk schema.GroupVersionKind, lw *cache.ListWatch) (*cache.ListWatch, cache.WatchErrorHandler) {
+	var failures int32
+
+	watchFunc := lw.WatchFunc
+	tracked := &cache.ListWatch{
+		ListFunc:        lw.ListFunc,
+		DisableChunking: lw.DisableChunking,
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			w, err := watchFunc(opts)
+			if err == nil {
+				atomic.StoreInt32(&failures, 0)
+			}
+			return w, err
+		},
+	}
+
+	handler := func(r *cache.Reflector, err error) {
+		cache.DefaultWatchErrorHandler(r, err)
+		m.watchErrorHandler(gvk, int(atomic.AddInt32(&failures, 1)), err)
+	}
+
+	return tracked, handler
+}
+
+// informerIndexers returns the indexers of a new informer with the given
+// extra indexers. The namespace indexer and the custom key indexer are always
+// included and can't be overridden.
+func (m *InformersMap) informerIndexers(extra cache.Indexers) cache.Indexers {
+	indexers := cache.Indexers{}
+	for name, indexFunc := range m.indexers {
+		indexers[name] = indexFunc
+	}
+	for name, indexFunc := range extra {
+		indexers[name] = indexFunc
+	}
+	indexers[cache.NamespaceIndex] = cache.MetaNamespaceIndexFunc
+	if m.keyFuncs.NamespaceIndexFunc != nil {
+		indexers[cache.NamespaceIndex] = m.keyFuncs.NamespaceIndexFunc
+	}
+	if m.keyFuncs.KeyFunc != nil {
+		indexers[keyIndex] = keyIndexFunc(m.keyFuncs.KeyFunc)
+	}
+	return indexers
+}
+
 // resyncPeriod returns a function which generates a duration each time it is
 // invoked; this is so that multiple controllers don't get into lock-step and all
 // hammer the apiserver with list requests simultaneously.
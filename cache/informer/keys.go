@@ -0,0 +1,69 @@
+package informer
+
+import (
+	"fmt"
+
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// keyIndex is the name of the index of the objects by the custom key.
+const keyIndex = "operator-toolkit:key"
+
+// KeyFuncs are the functions used to key the cached objects, for the
+// external objects that aren't identified by their namespace and name. The
+// objects are stored by namespace and name in the informers and are indexed
+// by the custom key.
+type KeyFuncs struct {
+	// KeyFunc returns the key of an object, like an external ID. The keys
+	// must be unique for the objects of a GVK. Defaults to the namespace and
+	// name of the object.
+	KeyFunc cache.KeyFunc
+
+	// ObjectKeyFunc returns the key of the object with the given
+	// client.ObjectKey, used by the cache Get. It must return the key
+	// returned by KeyFunc for the object. Required with KeyFunc.
+	ObjectKeyFunc func(client.ObjectKey) string
+
+	// NamespaceIndexFunc returns the namespaces of an object, used by the
+	// cache List in a namespace. Defaults to the namespace of the object.
+	NamespaceIndexFunc cache.IndexFunc
+}
+
+// WithKeyFuncs sets the functions used to key the objects cached by every
+// informer created by the InformersMap.
+func WithKeyFuncs(keyFuncs KeyFuncs) InformersMapOption {
+	return func(m *InformersMap) {
+		m.keyFuncs = keyFuncs
+	}
+}
+
+// keyIndexFunc returns an index function that indexes an object by the given
+// key function.
+func keyIndexFunc(keyFunc cache.KeyFunc) cache.IndexFunc {
+	return func(obj interface{}) ([]string, error) {
+		key, err := keyFunc(obj)
+		if err != nil {
+			return nil, err
+		}
+		return []string{key}, nil
+	}
+}
+
+// getByKey returns the object with the given key from the given indexer,
+// using the custom key index. An error is returned if multiple objects have
+// the key.
+func getByKey(indexer cache.Indexer, key string) (interface{}, bool, error) {
+	objs, err := indexer.ByIndex(keyIndex, key)
+	if err != nil {
+		return nil, false, err
+	}
+	switch len(objs) {
+	case 0:
+		return nil, false, nil
+	case 1:
+		return objs[0], true, nil
+	default:
+		return nil, false, fmt.Errorf("found %d objects with key %q", len(objs), key)
+	}
+}
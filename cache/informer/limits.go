@@ -0,0 +1,356 @@
+package informer
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// ErrMaxObjectsExceeded is reported when the number of objects of a GVK
+// exceeds the maximum number of cached objects and eviction is disabled.
+var ErrMaxObjectsExceeded = errors.New("max cached objects exceeded")
+
+// ObjectLimits are the guardrails on the objects cached by the informers, to
+// bound the memory used by the cache.
+type ObjectLimits struct {
+	// MaxObjects is the maximum number of cached objects per GVK. Zero means
+	// no limit.
+	MaxObjects int
+
+	// Evict evicts the least recently added or updated objects when the
+	// number of objects exceeds MaxObjects. The evicted objects are removed
+	// from the cache without notifying the event handlers, since they still
+	// exist. When listing, the most recently created objects are kept. If
+	// false, the objects over the limit aren't cached, the earliest created
+	// objects are kept when listing, and ErrMaxObjectsExceeded is reported to
+	// the watch error handler.
+	Evict bool
+
+	// TrimFields are the dot separated paths of the fields removed from the
+	// objects before they are cached, for example "metadata.managedFields".
+	TrimFields []string
+}
+
+// enabled returns true if any limit is set.
+func (l ObjectLimits) enabled() bool {
+	return l.MaxObjects > 0 || len(l.TrimFields) > 0
+}
+
+// objectLimiter enforces the ObjectLimits on the objects of a ListWatch. It
+// tracks the keys of the cached objects in the order they were last added or
+// updated.
+type objectLimiter struct {
+	limits     ObjectLimits
+	trimPaths  [][]string
+	gvk        schema.GroupVersionKind
+	mu         sync.Mutex
+	order      *list.List
+	elements   map[string]*list.Element
+	store      cache.Store
+	storeReady chan struct{}
+	// report is called with the errors of the objects that aren't cached.
+	report func(error)
+}
+
+func newObjectLimiter(gvk schema.GroupVersionKind, limits ObjectLimits) *objectLimiter {
+	trimPaths := make([][]string, 0, len(limits.TrimFields))
+	for _, field := range limits.TrimFields {
+		trimPaths = append(trimPaths, strings.Split(field, "."))
+	}
+	return &objectLimiter{
+		limits:     limits,
+		trimPaths:  trimPaths,
+		gvk:        gvk,
+		order:      list.New(),
+		elements:   map[string]*list.Element{},
+		storeReady: make(chan struct{}),
+		report:     utilruntime.HandleError,
+	}
+}
+
+// setStore sets the informer store, used to remove the evicted objects.
+func (l *objectLimiter) setStore(store cache.Store) {
+	l.store = store
+	close(l.storeReady)
+}
+
+// wrap returns a ListWatch that enforces the limits on the given ListWatch.
+func (l *objectLimiter) wrap(lw *cache.ListWatch) *cache.ListWatch {
+	listFunc := lw.ListFunc
+	watchFunc := lw.WatchFunc
+	return &cache.ListWatch{
+		DisableChunking: lw.DisableChunking,
+		ListFunc: func(opts metav1.ListOptions) (runtime.Object, error) {
+			obj, err := listFunc(opts)
+			if err != nil {
+				return obj, err
+			}
+			return obj, l.limitList(obj)
+		},
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			w, err := watchFunc(opts)
+			if err != nil {
+				return w, err
+			}
+			return newLimitedWatch(w, l), nil
+		},
+	}
+}
+
+// limitList trims the objects of a list and limits the number of items. The
+// tracked keys are reset with the listed objects.
+func (l *objectLimiter) limitList(listObj runtime.Object) error {
+	items, err := apimeta.ExtractList(listObj)
+	if err != nil {
+		return err
+	}
+
+	// Order the items by creation to keep the objects deterministically and
+	// track them from the least recently added.
+	sortByCreation(items)
+	if l.limits.MaxObjects > 0 && len(items) > l.limits.MaxObjects {
+		if l.limits.Evict {
+			items = items[len(items)-l.limits.MaxObjects:]
+		} else {
+			l.report(fmt.Errorf("%s: %d objects, limit %d: %w", l.gvk, len(items), l.limits.MaxObjects, ErrMaxObjectsExceeded))
+			items = items[:l.limits.MaxObjects]
+		}
+	}
+
+	for _, item := range items {
+		if err := l.trim(item); err != nil {
+			return err
+		}
+	}
+	if err := apimeta.SetList(listObj, items); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.order.Init()
+	l.elements = map[string]*list.Element{}
+	for _, item := range items {
+		if key, err := cache.MetaNamespaceKeyFunc(item); err == nil {
+			l.elements[key] = l.order.PushBack(key)
+		}
+	}
+	return nil
+}
+
+// sortByCreation sorts the objects by their creation timestamp, and by their
+// keys for the objects created at the same time.
+func sortByCreation(objs []runtime.Object) {
+	type sortKey struct {
+		created time.Time
+		key     string
+	}
+	keys := make(map[runtime.Object]sortKey, len(objs))
+	for _, obj := range objs {
+		k := sortKey{}
+		if accessor, err := apimeta.Accessor(obj); err == nil {
+			k.created = accessor.GetCreationTimestamp().Time
+		}
+		k.key, _ = cache.MetaNamespaceKeyFunc(obj)
+		keys[obj] = k
+	}
+	sort.SliceStable(objs, func(i, j int) bool {
+		a, b := keys[objs[i]], keys[objs[j]]
+		if !a.created.Equal(b.created) {
+			return a.created.Before(b.created)
+		}
+		return a.key < b.key
+	})
+}
+
+// trim removes the trim fields from an object.
+func (l *objectLimiter) trim(obj runtime.Object) error {
+	if len(l.trimPaths) == 0 {
+		return nil
+	}
+
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		for _, path := range l.trimPaths {
+			unstructured.RemoveNestedField(u.Object, path...)
+		}
+		return nil
+	}
+
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return fmt.Errorf("failed to convert %T to Unstructured: %v", obj, err)
+	}
+	for _, path := range l.trimPaths {
+		unstructured.RemoveNestedField(content, path...)
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(content, obj); err != nil {
+		return fmt.Errorf("failed to convert Unstructured to %T: %v", obj, err)
+	}
+	return nil
+}
+
+// observe records an added or updated object. It returns the keys of the
+// objects to evict, or ErrMaxObjectsExceeded if the limit is exceeded and
+// eviction is disabled.
+func (l *objectLimiter) observe(obj runtime.Object) ([]string, error) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e, ok := l.elements[key]; ok {
+		l.order.MoveToBack(e)
+		return nil, nil
+	}
+
+	if l.limits.MaxObjects > 0 && l.order.Len() >= l.limits.MaxObjects && !l.limits.Evict {
+		return nil, fmt.Errorf("%s: limit %d: %w", l.gvk, l.limits.MaxObjects, ErrMaxObjectsExceeded)
+	}
+	l.elements[key] = l.order.PushBack(key)
+
+	evicted := []string{}
+	for l.limits.MaxObjects > 0 && l.order.Len() > l.limits.MaxObjects {
+		front := l.order.Front()
+		evictKey := l.order.Remove(front).(string)
+		delete(l.elements, evictKey)
+		evicted = append(evicted, evictKey)
+	}
+	return evicted, nil
+}
+
+// forget removes a deleted object from the tracked objects.
+func (l *objectLimiter) forget(obj runtime.Object) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if e, ok := l.elements[key]; ok {
+		l.order.Remove(e)
+		delete(l.elements, key)
+	}
+}
+
+// limitedWatch is a watch that enforces the limits of an objectLimiter on the
+// events of a watch. The objects over the limit are dropped or evicted before
+// they reach the informer.
+type limitedWatch struct {
+	watch.Interface
+	limiter *objectLimiter
+	result  chan watch.Event
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newLimitedWatch(w watch.Interface, limiter *objectLimiter) *limitedWatch {
+	lw := &limitedWatch{
+		Interface: w,
+		limiter:   limiter,
+		result:    make(chan watch.Event),
+		done:      make(chan struct{}),
+	}
+	go lw.run()
+	return lw
+}
+
+// ResultChan implements watch.Interface.
+func (w *limitedWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements watch.Interface.
+func (w *limitedWatch) Stop() {
+	w.once.Do(func() {
+		close(w.done)
+		w.Interface.Stop()
+	})
+}
+
+// send sends an event to the result channel. It returns false if the watch
+// is stopped.
+func (w *limitedWatch) send(e watch.Event) bool {
+	select {
+	case w.result <- e:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+func (w *limitedWatch) run() {
+	defer close(w.result)
+
+	for e := range w.Interface.ResultChan() {
+		switch e.Type {
+		case watch.Added, watch.Modified:
+			if err := w.limiter.trim(e.Object); err != nil {
+				w.limiter.report(fmt.Errorf("%s: %w", w.limiter.gvk, err))
+				continue
+			}
+			evicted, err := w.limiter.observe(e.Object)
+			if err != nil {
+				// Drop the object over the limit.
+				w.limiter.report(err)
+				continue
+			}
+			if !w.send(e) {
+				return
+			}
+			if !w.evict(evicted) {
+				return
+			}
+		case watch.Deleted:
+			w.limiter.forget(e.Object)
+			if !w.send(e) {
+				return
+			}
+		default:
+			if !w.send(e) {
+				return
+			}
+		}
+	}
+}
+
+// evict removes the objects of the given keys from the informer store. The
+// event handlers aren't notified since the objects aren't deleted. An evicted
+// object is cached again when it's updated. It returns false if the watch is
+// stopped.
+func (w *limitedWatch) evict(keys []string) bool {
+	if len(keys) == 0 {
+		return true
+	}
+	select {
+	case <-w.limiter.storeReady:
+	case <-w.done:
+		return false
+	}
+	for _, key := range keys {
+		obj, exists, err := w.limiter.store.GetByKey(key)
+		if err != nil || !exists {
+			continue
+		}
+		if err := w.limiter.store.Delete(obj); err != nil {
+			w.limiter.report(fmt.Errorf("%s: failed to evict %q: %w", w.limiter.gvk, key, err))
+		}
+	}
+	return true
+}
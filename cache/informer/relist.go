@@ -0,0 +1,99 @@
+package informer
+
+import (
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// WithRelistPeriod makes the informers list all the objects again after
+// every period, with a 10 percent jitter. The informer store is replaced with
+// the listed objects, which removes the objects that are absent from the
+// latest list, even if their delete events were never sent by the watch. The
+// event handlers are notified of the removed objects with
+// cache.DeletedFinalStateUnknown. Zero, the default, never relists unless the
+// watch ends.
+func WithRelistPeriod(period time.Duration) InformersMapOption {
+	return func(m *InformersMap) {
+		m.relistPeriod = period
+	}
+}
+
+// relistPeriodically wraps the given ListWatch to end every watch after
+// about the given period. The reflector of the informer lists all the
+// objects again when a watch ends, before watching again.
+func relistPeriodically(lw *cache.ListWatch, period time.Duration) *cache.ListWatch {
+	watchFunc := lw.WatchFunc
+	return &cache.ListWatch{
+		ListFunc:        lw.ListFunc,
+		DisableChunking: lw.DisableChunking,
+		WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+			w, err := watchFunc(opts)
+			if err != nil {
+				return nil, err
+			}
+			return newExpiringWatch(w, resyncPeriod(period)()), nil
+		},
+	}
+}
+
+// expiringWatch is a watch that ends after a period.
+type expiringWatch struct {
+	watch.Interface
+	result chan watch.Event
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newExpiringWatch(w watch.Interface, period time.Duration) *expiringWatch {
+	ew := &expiringWatch{
+		Interface: w,
+		result:    make(chan watch.Event),
+		done:      make(chan struct{}),
+	}
+	go ew.run(period)
+	return ew
+}
+
+// ResultChan implements watch.Interface.
+func (w *expiringWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+// Stop implements watch.Interface.
+func (w *expiringWatch) Stop() {
+	w.once.Do(func() {
+		close(w.done)
+		w.Interface.Stop()
+	})
+}
+
+func (w *expiringWatch) run(period time.Duration) {
+	defer close(w.result)
+
+	timer := time.NewTimer(period)
+	defer timer.Stop()
+
+	for {
+		select {
+		case e, ok := <-w.Interface.ResultChan():
+			if !ok {
+				return
+			}
+			select {
+			case w.result <- e:
+			case <-w.done:
+				return
+			}
+		case <-timer.C:
+			// End the watch to relist.
+			w.Stop()
+			return
+		case <-w.done:
+			return
+		}
+	}
+}
@@ -29,6 +29,8 @@ type informerCache struct {
 	*informer.InformersMap
 }
 
+var _ Cache = &informerCache{}
+
 // Get implements Reader
 func (ic *informerCache) Get(ctx context.Context, key client.ObjectKey, out client.Object) error {
 	gvk, err := apiutil.GVKForObject(out, ic.Scheme)
@@ -137,6 +139,21 @@ func (ic *informerCache) GetInformer(ctx context.Context, obj client.Object) (cr
 	return i.Informer, err
 }
 
+// GetInformerWithOptions returns the informer for the obj, creating it with
+// the given options if it doesn't exist.
+func (ic *informerCache) GetInformerWithOptions(ctx context.Context, obj client.Object, opts informer.InformerOptions) (crCache.Informer, error) {
+	gvk, err := apiutil.GVKForObject(obj, ic.Scheme)
+	if err != nil {
+		return nil, err
+	}
+
+	_, i, err := ic.InformersMap.GetWithOptions(ctx, gvk, obj, opts)
+	if err != nil {
+		return nil, err
+	}
+	return i.Informer, err
+}
+
 // NeedLeaderElection implements the LeaderElectionRunnable interface
 // to indicate that this can be started without requiring the leader lock.
 func (ic *informerCache) NeedLeaderElection() bool {
@@ -156,8 +173,45 @@ func (ic *informerCache) IndexField(ctx context.Context, obj client.Object, fiel
 	return indexByField(informer, field, extractValue)
 }
 
+// IndexFields adds multiple field indexers to the informer of the given
+// object at once, using the extraction functions to get the value(s) of the
+// fields. Since the indexers can't be added to an informer that has already
+// started, this should be used to register all the indexers of an object
+// before the cache is started. The extraction functions may return multiple
+// values, or a composite value computed from multiple fields of the object.
+// Each of the values can be used to find the object. Like IndexField, the
+// values are prefixed with the namespace of the object, if present. The field
+// indexes can be used by passing field selectors to List. A field selector
+// with multiple fields returns the objects that match all the fields.
+func (ic *informerCache) IndexFields(ctx context.Context, obj client.Object, extractors map[string]client.IndexerFunc) error {
+	informer, err := ic.GetInformer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return informer.AddIndexers(FieldIndexers(extractors))
+}
+
+// FieldIndexers returns field indexers for the given field extraction
+// functions. The returned indexers can be passed to the cache with
+// Options.Indexers to index the objects of every informer in the cache. Like
+// IndexFields, the field indexes can be used by passing field selectors to
+// List.
+func FieldIndexers(extractors map[string]client.IndexerFunc) cache.Indexers {
+	indexers := cache.Indexers{}
+	for field, extractor := range extractors {
+		indexers[informer.FieldIndexName(field)] = fieldIndexFunc(extractor)
+	}
+	return indexers
+}
+
 func indexByField(indexer crCache.Informer, field string, extractor client.IndexerFunc) error {
-	indexFunc := func(objRaw interface{}) ([]string, error) {
+	return indexer.AddIndexers(cache.Indexers{informer.FieldIndexName(field): fieldIndexFunc(extractor)})
+}
+
+// fieldIndexFunc returns an index func that indexes the objects by the values
+// returned by the extractor, prefixed with the namespace of the objects.
+func fieldIndexFunc(extractor client.IndexerFunc) cache.IndexFunc {
+	return func(objRaw interface{}) ([]string, error) {
 		// TODO(directxman12): check if this is the correct type?
 		obj, isObj := objRaw.(client.Object)
 		if !isObj {
@@ -169,7 +223,7 @@ func indexByField(indexer crCache.Informer, field string, extractor client.Index
 		}
 		ns := meta.GetNamespace()
 
-		rawVals := extractor(obj)
+		rawVals := uniqueValues(extractor(obj))
 		var vals []string
 		if ns == "" {
 			// if we're not doubling the keys for the namespaced case, just re-use what was returned to us
@@ -191,6 +245,19 @@ func indexByField(indexer crCache.Informer, field string, extractor client.Index
 
 		return vals, nil
 	}
+}
 
-	return indexer.AddIndexers(cache.Indexers{informer.FieldIndexName(field): indexFunc})
+// uniqueValues returns the given values without any duplicates, preserving
+// the order.
+func uniqueValues(vals []string) []string {
+	seen := make(map[string]struct{}, len(vals))
+	result := make([]string, 0, len(vals))
+	for _, v := range vals {
+		if _, found := seen[v]; found {
+			continue
+		}
+		seen[v] = struct{}{}
+		result = append(result, v)
+	}
+	return result
 }
@@ -18,6 +18,15 @@ type ListWatcherClient interface {
 	Watch(ctx context.Context, namespace string, kind string) (watch.Interface, error)
 }
 
+// WatchOptionsClient is a ListWatcherClient that watches with the watch
+// options, like the resource version to resume the watch from and
+// AllowWatchBookmarks. The watch events must start after the given resource
+// version, if any.
+type WatchOptionsClient interface {
+	ListWatcherClient
+	WatchWithOptions(ctx context.Context, namespace string, kind string, opts metav1.ListOptions) (watch.Interface, error)
+}
+
 // ListWatcher embeds a ListWatcherClient and uses the client to provider a
 // cache.ListWatch.
 type ListWatcher struct {
@@ -42,6 +51,9 @@ func (r ListWatcher) CreateListWatcherFunc() informer.CreateListWatcherFunc {
 				return r.List(ctx, namespace, res)
 			},
 			WatchFunc: func(opts metav1.ListOptions) (watch.Interface, error) {
+				if woc, ok := r.ListWatcherClient.(WatchOptionsClient); ok {
+					return woc.WatchWithOptions(ctx, namespace, gvk.Kind, opts)
+				}
 				return r.Watch(ctx, namespace, gvk.Kind)
 			},
 		}, nil
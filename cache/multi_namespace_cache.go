@@ -0,0 +1,465 @@
+// NOTE: This is mostly based on
+// https://github.com/kubernetes-sigs/controller-runtime/blob/v0.8.3/pkg/cache/multi_namespace_cache.go,
+// modified to use the informerCache with a ListWatcher func for any API
+// server.
+
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	toolscache "k8s.io/client-go/tools/cache"
+	crCache "sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/darkowlzz/operator-toolkit/cache/informer"
+)
+
+var log = logf.Log.WithName("object-cache")
+
+// newMultiNamespaceCache creates a cache with an informerCache for each of
+// the given namespaces and a cluster-wide informerCache for the cluster
+// scoped objects.
+func newMultiNamespaceCache(createLWFunc informer.CreateListWatcherFunc, opts Options) *multiNamespaceCache {
+	caches := map[string]Cache{}
+	for _, ns := range opts.Namespaces {
+		caches[ns] = newNamespaceCache(createLWFunc, opts, ns)
+	}
+	return &multiNamespaceCache{
+		namespaceToCache: caches,
+		clusterCache:     newNamespaceCache(createLWFunc, opts, corev1.NamespaceAll),
+		Scheme:           opts.Scheme,
+		createLWFunc:     createLWFunc,
+		opts:             opts,
+		informers:        map[schema.GroupVersionKind]*multiNamespaceInformer{},
+	}
+}
+
+// newNamespaceCache creates an informerCache for the given namespace.
+func newNamespaceCache(createLWFunc informer.CreateListWatcherFunc, opts Options, namespace string) *informerCache {
+	im := informer.NewInformersMap(opts.Scheme, *opts.Resync, namespace, createLWFunc, opts.informersMapOptions()...)
+	return &informerCache{InformersMap: im}
+}
+
+// multiNamespaceCache knows how to handle multiple namespaced caches. Use
+// this to scope the cache to a set of namespaces instead of watching every
+// namespace.
+type multiNamespaceCache struct {
+	// mu guards the namespaced caches, the informers and the start context
+	// against the namespaces added at runtime.
+	mu               sync.RWMutex
+	namespaceToCache map[string]Cache
+	// clusterCache is a cluster-wide cache used to get the cluster scoped
+	// objects. Its informers are created only for the objects fetched from
+	// it, the namespaced objects aren't watched across all the namespaces.
+	clusterCache Cache
+	Scheme       *runtime.Scheme
+
+	// createLWFunc and opts are used to create the caches of the namespaces
+	// added with AddNamespace.
+	createLWFunc informer.CreateListWatcherFunc
+	opts         Options
+
+	// ctx is the context the cache is started with. It's used to start the
+	// caches of the namespaces added after the cache is started.
+	ctx context.Context
+
+	// informers are the informers returned by the cache, by GVK. The
+	// informers of the added namespaces are added to them.
+	informers map[schema.GroupVersionKind]*multiNamespaceInformer
+}
+
+var _ MultiNamespaceCache = &multiNamespaceCache{}
+
+// GetInformer returns an informer that wraps the informers of the obj in all
+// the namespaces.
+func (c *multiNamespaceCache) GetInformer(ctx context.Context, obj client.Object) (crCache.Informer, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return c.getInformer(ctx, gvk, func(ctx context.Context, cache Cache) (crCache.Informer, error) {
+		return cache.GetInformer(ctx, obj)
+	})
+}
+
+// GetInformerWithOptions returns an informer that wraps the informers of the
+// obj in all the namespaces, creating them with the given options if they
+// don't exist.
+func (c *multiNamespaceCache) GetInformerWithOptions(ctx context.Context, obj client.Object, opts informer.InformerOptions) (crCache.Informer, error) {
+	gvk, err := apiutil.GVKForObject(obj, c.Scheme)
+	if err != nil {
+		return nil, err
+	}
+	return c.getInformer(ctx, gvk, func(ctx context.Context, cache Cache) (crCache.Informer, error) {
+		return cache.GetInformerWithOptions(ctx, obj, opts)
+	})
+}
+
+// GetInformerForKind returns an informer that wraps the informers of the
+// GroupVersionKind in all the namespaces.
+func (c *multiNamespaceCache) GetInformerForKind(ctx context.Context, gvk schema.GroupVersionKind) (crCache.Informer, error) {
+	return c.getInformer(ctx, gvk, func(ctx context.Context, cache Cache) (crCache.Informer, error) {
+		return cache.GetInformerForKind(ctx, gvk)
+	})
+}
+
+// getInformer returns the informer of the given GVK that wraps the informers
+// in all the namespaces, got from the namespaced caches with the given get
+// function. The get function is also used to get the informers of the
+// namespaces added later.
+func (c *multiNamespaceCache) getInformer(ctx context.Context, gvk schema.GroupVersionKind, get getInformerFunc) (crCache.Informer, error) {
+	// The cluster scoped objects are watched by the cluster-wide cache only.
+	clusterScoped, err := c.isClusterScoped(gvk)
+	if err != nil {
+		return nil, err
+	}
+	if clusterScoped {
+		return get(ctx, c.clusterCache)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mi, ok := c.informers[gvk]
+	if !ok {
+		mi = &multiNamespaceInformer{namespaceToInformer: map[string]crCache.Informer{}}
+	}
+	for ns, cache := range c.namespaceToCache {
+		informer, err := get(ctx, cache)
+		if err != nil {
+			return nil, err
+		}
+		mi.set(ns, informer)
+	}
+	mi.get = get
+	c.informers[gvk] = mi
+	return mi, nil
+}
+
+// isClusterScoped returns true if the objects of the given GVK are cluster
+// scoped, based on the RESTMapper of the cache. Without a RESTMapper, the
+// objects are treated as namespaced.
+func (c *multiNamespaceCache) isClusterScoped(gvk schema.GroupVersionKind) (bool, error) {
+	if c.opts.Mapper == nil {
+		return false, nil
+	}
+	mapping, err := c.opts.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, err
+	}
+	return mapping.Scope.Name() == apimeta.RESTScopeNameRoot, nil
+}
+
+// AddNamespace adds the given namespace to the cache at runtime, without
+// restarting the cache. The informers of the objects already watched by the
+// cache are created in the namespace, with the event handlers and the
+// indexers added to them. If the cache is started, the informers are started
+// and the objects in the namespace become visible once they're synced. It's
+// a no-op if the namespace is already cached.
+func (c *multiNamespaceCache) AddNamespace(ns string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.namespaceToCache[ns]; ok {
+		return nil
+	}
+
+	// The informers are created before the namespaced cache is started, this
+	// doesn't wait for them to sync.
+	cache := newNamespaceCache(c.createLWFunc, c.opts, ns)
+	for gvk, mi := range c.informers {
+		informer, err := mi.get(context.Background(), cache)
+		if err != nil {
+			return fmt.Errorf("failed to get the informer of %v in namespace %q: %w", gvk, ns, err)
+		}
+		if err := mi.add(ns, informer); err != nil {
+			return fmt.Errorf("failed to add the informer of %v in namespace %q: %w", gvk, ns, err)
+		}
+	}
+	c.namespaceToCache[ns] = cache
+
+	if c.ctx != nil {
+		go startNamespaceCache(c.ctx, ns, cache)
+	}
+	return nil
+}
+
+// caches returns a copy of the namespaced caches.
+func (c *multiNamespaceCache) caches() map[string]Cache {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	caches := make(map[string]Cache, len(c.namespaceToCache))
+	for ns, cache := range c.namespaceToCache {
+		caches[ns] = cache
+	}
+	return caches
+}
+
+// namespaceCache returns the cache of the given namespace.
+func (c *multiNamespaceCache) namespaceCache(ns string) (Cache, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cache, ok := c.namespaceToCache[ns]
+	return cache, ok
+}
+
+// startNamespaceCache starts the given namespaced cache. Blocks on the
+// context.
+func startNamespaceCache(ctx context.Context, ns string, cache Cache) {
+	if err := cache.Start(ctx); err != nil {
+		log.Error(err, "multinamespace cache failed to start namespaced informer", "namespace", ns)
+	}
+}
+
+// Start starts all the namespaced caches and the cluster-wide cache. Blocks
+// on the context.
+func (c *multiNamespaceCache) Start(ctx context.Context) error {
+	c.mu.Lock()
+	c.ctx = ctx
+	for ns, cache := range c.namespaceToCache {
+		go startNamespaceCache(ctx, ns, cache)
+	}
+	c.mu.Unlock()
+
+	go func() {
+		if err := c.clusterCache.Start(ctx); err != nil {
+			log.Error(err, "multinamespace cache failed to start cluster-wide informer")
+		}
+	}()
+	<-ctx.Done()
+	return nil
+}
+
+// WaitForCacheSync waits until all the namespaced caches and the cluster-wide
+// cache are synced.
+func (c *multiNamespaceCache) WaitForCacheSync(ctx context.Context) bool {
+	synced := true
+	for _, cache := range c.caches() {
+		if s := cache.WaitForCacheSync(ctx); !s {
+			synced = s
+		}
+	}
+	if s := c.clusterCache.WaitForCacheSync(ctx); !s {
+		synced = s
+	}
+	return synced
+}
+
+// IndexField adds the indexer to all the namespaced caches, or to the
+// cluster-wide cache for the cluster scoped objects. The indexer is also
+// added to the namespaces added later.
+func (c *multiNamespaceCache) IndexField(ctx context.Context, obj client.Object, field string, extractValue client.IndexerFunc) error {
+	informer, err := c.GetInformer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return indexByField(informer, field, extractValue)
+}
+
+// IndexFields adds the field indexers to all the namespaced caches, or to the
+// cluster-wide cache for the cluster scoped objects. The indexers are also
+// added to the namespaces added later.
+func (c *multiNamespaceCache) IndexFields(ctx context.Context, obj client.Object, extractors map[string]client.IndexerFunc) error {
+	informer, err := c.GetInformer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return informer.AddIndexers(FieldIndexers(extractors))
+}
+
+// Get gets the object from the cache of the namespace of the object. The
+// cluster scoped objects, with an empty namespace, are fetched from the
+// cluster-wide cache.
+func (c *multiNamespaceCache) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	if key.Namespace == corev1.NamespaceAll {
+		return c.clusterCache.Get(ctx, key, obj)
+	}
+	cache, ok := c.namespaceCache(key.Namespace)
+	if !ok {
+		return fmt.Errorf("unable to get: %v because of unknown namespace for the cache", key)
+	}
+	return cache.Get(ctx, key, obj)
+}
+
+// List gets all the objects in all the namespaces of the cache if asked for
+// all namespaces, else from the cache of the asked namespace. The cluster
+// scoped objects are listed from the cluster-wide cache.
+func (c *multiNamespaceCache) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	gvk, err := apiutil.GVKForObject(list, c.Scheme)
+	if err != nil {
+		return err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	clusterScoped, err := c.isClusterScoped(gvk)
+	if err != nil {
+		return err
+	}
+	if clusterScoped {
+		return c.clusterCache.List(ctx, list, opts...)
+	}
+
+	listOpts := client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+	if listOpts.Namespace != corev1.NamespaceAll {
+		cache, ok := c.namespaceCache(listOpts.Namespace)
+		if !ok {
+			return fmt.Errorf("unable to list: %v because of unknown namespace for the cache", listOpts.Namespace)
+		}
+		return cache.List(ctx, list, opts...)
+	}
+
+	listAccessor, err := apimeta.ListAccessor(list)
+	if err != nil {
+		return err
+	}
+
+	allItems, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	var resourceVersion string
+	for _, cache := range c.caches() {
+		listObj := list.DeepCopyObject().(client.ObjectList)
+		if err := cache.List(ctx, listObj, opts...); err != nil {
+			return err
+		}
+		items, err := apimeta.ExtractList(listObj)
+		if err != nil {
+			return err
+		}
+		accessor, err := apimeta.ListAccessor(listObj)
+		if err != nil {
+			return fmt.Errorf("object: %T must be a list type", list)
+		}
+		allItems = append(allItems, items...)
+		// The last list call should have the most correct resource version.
+		resourceVersion = accessor.GetResourceVersion()
+	}
+	listAccessor.SetResourceVersion(resourceVersion)
+
+	return apimeta.SetList(list, allItems)
+}
+
+// NeedLeaderElection implements the LeaderElectionRunnable interface
+// to indicate that this can be started without requiring the leader lock.
+func (c *multiNamespaceCache) NeedLeaderElection() bool {
+	return false
+}
+
+// getInformerFunc gets an informer from a namespaced cache.
+type getInformerFunc func(context.Context, Cache) (crCache.Informer, error)
+
+// eventHandler is an event handler added to an informer.
+type eventHandler struct {
+	handler      toolscache.ResourceEventHandler
+	resyncPeriod *time.Duration
+}
+
+// multiNamespaceInformer knows how to handle interacting with the underlying
+// informer across multiple namespaces.
+type multiNamespaceInformer struct {
+	mu                  sync.RWMutex
+	namespaceToInformer map[string]crCache.Informer
+
+	// get gets the informer from the cache of an added namespace.
+	get getInformerFunc
+	// handlers and indexers are added to the informers of the added
+	// namespaces.
+	handlers []eventHandler
+	indexers []toolscache.Indexers
+}
+
+var _ crCache.Informer = &multiNamespaceInformer{}
+
+// set sets the informer of the given namespace.
+func (i *multiNamespaceInformer) set(ns string, informer crCache.Informer) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.namespaceToInformer[ns] = informer
+}
+
+// add adds the informer of a new namespace with the event handlers and the
+// indexers of the other namespaced informers.
+func (i *multiNamespaceInformer) add(ns string, informer crCache.Informer) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, indexers := range i.indexers {
+		if err := informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	for _, h := range i.handlers {
+		if h.resyncPeriod != nil {
+			informer.AddEventHandlerWithResyncPeriod(h.handler, *h.resyncPeriod)
+		} else {
+			informer.AddEventHandler(h.handler)
+		}
+	}
+	i.namespaceToInformer[ns] = informer
+	return nil
+}
+
+// AddEventHandler adds the handler to each namespaced informer.
+func (i *multiNamespaceInformer) AddEventHandler(handler toolscache.ResourceEventHandler) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.handlers = append(i.handlers, eventHandler{handler: handler})
+	for _, informer := range i.namespaceToInformer {
+		informer.AddEventHandler(handler)
+	}
+}
+
+// AddEventHandlerWithResyncPeriod adds the handler with a resync period to
+// each namespaced informer.
+func (i *multiNamespaceInformer) AddEventHandlerWithResyncPeriod(handler toolscache.ResourceEventHandler, resyncPeriod time.Duration) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.handlers = append(i.handlers, eventHandler{handler: handler, resyncPeriod: &resyncPeriod})
+	for _, informer := range i.namespaceToInformer {
+		informer.AddEventHandlerWithResyncPeriod(handler, resyncPeriod)
+	}
+}
+
+// AddIndexers adds the indexer for each namespaced informer.
+func (i *multiNamespaceInformer) AddIndexers(indexers toolscache.Indexers) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	for _, informer := range i.namespaceToInformer {
+		if err := informer.AddIndexers(indexers); err != nil {
+			return err
+		}
+	}
+	i.indexers = append(i.indexers, indexers)
+	return nil
+}
+
+// HasSynced checks if each namespaced informer has synced.
+func (i *multiNamespaceInformer) HasSynced() bool {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+
+	for _, informer := range i.namespaceToInformer {
+		if ok := informer.HasSynced(); !ok {
+			return ok
+		}
+	}
+	return true
+}
@@ -2,9 +2,18 @@ package composite
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strings"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 )
 
@@ -22,6 +31,30 @@ type Client struct {
 type Options struct {
 	// RawListing is used to perform raw listing operations, uncached.
 	RawListing bool
+
+	// MetadataReader is used to read metadata-only objects,
+	// PartialObjectMetadata, from a metadata cache. When not set, the cached
+	// client is used to read the metadata-only objects.
+	MetadataReader client.Reader
+}
+
+// uncachedKey is the context key of the uncached get marker.
+type uncachedKey struct{}
+
+// Uncached returns a copy of the context that makes the composite client Get
+// skip the cache and read the object from the API server. This can be used
+// for read-after-write consistency, when the cache may be stale.
+//
+// The client Get of this version of controller-runtime doesn't accept
+// options, so the marker is carried by the context instead of a GetOption.
+func Uncached(ctx context.Context) context.Context {
+	return context.WithValue(ctx, uncachedKey{}, true)
+}
+
+// isUncached returns true if the context is marked for uncached get.
+func isUncached(ctx context.Context) bool {
+	uncached, _ := ctx.Value(uncachedKey{}).(bool)
+	return uncached
 }
 
 // NewClient creates and returns a composite Client.
@@ -47,8 +80,26 @@ func NewClientFromManager(mgr manager.Manager, opts Options) (client.Client, err
 
 // Get first fetches the object using the cached client. If the object is not
 // found in the cached client, it retries using the uncached client.
+// Metadata-only objects, PartialObjectMetadata, are fetched from the metadata
+// reader when configured, falling back to a metadata-only API call using the
+// uncached client. When the context is marked with Uncached, the object is
+// fetched using the uncached client only.
 func (c *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
-	if cErr := c.Client.Get(ctx, key, obj); cErr != nil {
+	reader := client.Reader(c.Client)
+	if pom, ok := obj.(*metav1.PartialObjectMetadata); ok {
+		if pom.GroupVersionKind().Empty() {
+			return fmt.Errorf("GroupVersionKind must be set for metadata-only get of %q", key)
+		}
+		if c.MetadataReader != nil {
+			reader = c.MetadataReader
+		}
+	}
+
+	if isUncached(ctx) {
+		return c.uncached.Get(ctx, key, obj)
+	}
+
+	if cErr := reader.Get(ctx, key, obj); cErr != nil {
 		// If not found in the cache, try with the uncached client.
 		if apierrors.IsNotFound(cErr) {
 			return c.uncached.Get(ctx, key, obj)
@@ -60,9 +111,136 @@ func (c *Client) Get(ctx context.Context, key client.ObjectKey, obj client.Objec
 
 // List lists the objects based on the client configuration. If RawListing is
 // true, it uses the uncached client to list, else it uses the cached client.
+// Metadata-only lists, PartialObjectMetadataList, use the metadata reader
+// when configured and RawListing is false. If the cache isn't started or
+// synced yet, it falls back to list using the uncached client.
 func (c *Client) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
 	if c.RawListing {
 		return c.uncached.List(ctx, list, opts...)
 	}
-	return c.Client.List(ctx, list, opts...)
+	reader := client.Reader(c.Client)
+	if _, ok := list.(*metav1.PartialObjectMetadataList); ok && c.MetadataReader != nil {
+		reader = c.MetadataReader
+	}
+	if cErr := reader.List(ctx, list, opts...); cErr != nil {
+		// If the cache can't serve the list yet, try with the uncached
+		// client.
+		if isCacheNotReady(cErr) {
+			return c.uncached.List(ctx, list, opts...)
+		}
+		return cErr
+	}
+	return nil
+}
+
+// isCacheNotReady returns true if the given cache error is due to the cache
+// not being started or synced. A cache read never calls the API server, a
+// timeout is returned by the cache only when waiting for the informer to
+// sync.
+func isCacheNotReady(err error) bool {
+	var notStarted *cache.ErrCacheNotStarted
+	return errors.As(err, &notStarted) || apierrors.IsTimeout(err)
+}
+
+// GetMany fetches the objects with the given keys, of the kind of the given
+// list, with a single List instead of a Get per object, reducing the round
+// trips when many objects of the same kind are needed. The list is scoped to
+// the namespace of the keys when they're all in the same namespace. The
+// objects missing in the listed objects are fetched with Get, which falls
+// back to the uncached client. The found objects are set as the items of the
+// list, in the order of the keys. The objects that don't exist are left out.
+func (c *Client) GetMany(ctx context.Context, keys []client.ObjectKey, list client.ObjectList) error {
+	if len(keys) == 0 {
+		return apimeta.SetList(list, []runtime.Object{})
+	}
+
+	var opts []client.ListOption
+	if ns, ok := commonNamespace(keys); ok {
+		opts = append(opts, client.InNamespace(ns))
+	}
+	if err := c.List(ctx, list, opts...); err != nil {
+		return err
+	}
+
+	// Index the listed objects by their keys.
+	items, err := apimeta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	listed := make(map[client.ObjectKey]runtime.Object, len(items))
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok {
+			return fmt.Errorf("list item %T is not a client.Object", item)
+		}
+		listed[client.ObjectKeyFromObject(obj)] = item
+	}
+
+	result := make([]runtime.Object, 0, len(keys))
+	for _, key := range keys {
+		if item, ok := listed[key]; ok {
+			result = append(result, item)
+			continue
+		}
+
+		// Fetch the objects that weren't listed.
+		obj, err := c.newListItem(list)
+		if err != nil {
+			return err
+		}
+		if err := c.Get(ctx, key, obj); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+		result = append(result, obj)
+	}
+	return apimeta.SetList(list, result)
+}
+
+// commonNamespace returns the namespace of the given keys and true if all
+// the keys are in the same namespace.
+func commonNamespace(keys []client.ObjectKey) (string, bool) {
+	for _, key := range keys[1:] {
+		if key.Namespace != keys[0].Namespace {
+			return "", false
+		}
+	}
+	return keys[0].Namespace, true
+}
+
+// newListItem returns a new object of the kind of the items of the given
+// list.
+func (c *Client) newListItem(list client.ObjectList) (client.Object, error) {
+	// Metadata-only lists carry the GVK of the objects they list.
+	if pomList, ok := list.(*metav1.PartialObjectMetadataList); ok {
+		gvk := pomList.GroupVersionKind()
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+		pom := &metav1.PartialObjectMetadata{}
+		pom.SetGroupVersionKind(gvk)
+		return pom, nil
+	}
+
+	gvk, err := apiutil.GVKForObject(list, c.Scheme())
+	if err != nil {
+		return nil, err
+	}
+	gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+
+	if _, ok := list.(*unstructured.UnstructuredList); ok {
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(gvk)
+		return u, nil
+	}
+
+	obj, err := c.Scheme().New(gvk)
+	if err != nil {
+		return nil, err
+	}
+	cObj, ok := obj.(client.Object)
+	if !ok {
+		return nil, fmt.Errorf("%T is not a client.Object", obj)
+	}
+	return cObj, nil
 }
@@ -12,6 +12,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
 
 var _ = Describe("Composite client", func() {
@@ -74,6 +75,91 @@ var _ = Describe("Composite client", func() {
 		Expect(cache.Called).To(Equal(2))
 	})
 
+	It("should fetch metadata-only object with composite client", func() {
+		cCli := NewClient(dCli, k8sClient, Options{})
+
+		// Create a resource.
+		nsx := corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "some-ns-for-metadata-get",
+				Labels: map[string]string{"foo": "bar"},
+			},
+		}
+		Expect(k8sClient.Create(context.Background(), &nsx)).To(Succeed())
+
+		defer func() {
+			Expect(k8sClient.Delete(context.Background(), &nsx)).To(Succeed())
+		}()
+
+		key := client.ObjectKeyFromObject(&nsx)
+
+		By("Expecting metadata-only get without GVK to fail")
+		Expect(cCli.Get(context.Background(), key, &metav1.PartialObjectMetadata{})).NotTo(Succeed())
+
+		By("Expecting to get the metadata using the uncached client on cache miss")
+		pom := &metav1.PartialObjectMetadata{}
+		pom.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+		Expect(cCli.Get(context.Background(), key, pom)).To(Succeed())
+		Expect(cache.Called).To(Equal(1))
+		Expect(pom.GetName()).To(Equal(nsx.Name))
+		Expect(pom.GetLabels()).To(HaveKeyWithValue("foo", "bar"))
+	})
+
+	It("should fetch metadata-only object from the metadata reader", func() {
+		metaReader := fakeMetadataReader{}
+		cCli := NewClient(dCli, k8sClient, Options{MetadataReader: &metaReader})
+
+		pom := &metav1.PartialObjectMetadata{}
+		pom.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+		Expect(cCli.Get(context.Background(), client.ObjectKey{Name: "foo"}, pom)).To(Succeed())
+		Expect(metaReader.Called).To(Equal(1))
+		Expect(cache.Called).To(Equal(0))
+		Expect(pom.GetName()).To(Equal("foo"))
+
+		By("Expecting metadata-only list to use the metadata reader")
+		pomList := &metav1.PartialObjectMetadataList{}
+		pomList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NamespaceList"))
+		Expect(cCli.List(context.Background(), pomList)).To(Succeed())
+		Expect(metaReader.Called).To(Equal(2))
+		Expect(cache.Called).To(Equal(0))
+	})
+
+	It("should fetch from the uncached client with an uncached context", func() {
+		metaReader := fakeMetadataReader{}
+		cCli := NewClient(dCli, k8sClient, Options{MetadataReader: &metaReader})
+
+		// Create a resource.
+		nsx := corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "some-ns-for-uncached-get"},
+		}
+		Expect(k8sClient.Create(context.Background(), &nsx)).To(Succeed())
+
+		defer func() {
+			Expect(k8sClient.Delete(context.Background(), &nsx)).To(Succeed())
+		}()
+
+		key := client.ObjectKeyFromObject(&nsx)
+		ctx := Uncached(context.Background())
+
+		By("Expecting to get the object without using the cache")
+		Expect(cCli.Get(ctx, key, &corev1.Namespace{})).To(Succeed())
+		Expect(cache.Called).To(Equal(0))
+
+		By("Expecting to get the metadata without using the metadata cache")
+		pom := &metav1.PartialObjectMetadata{}
+		pom.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+		Expect(cCli.Get(ctx, key, pom)).To(Succeed())
+		Expect(metaReader.Called).To(Equal(0))
+		Expect(pom.GetUID()).To(Equal(nsx.GetUID()))
+
+		By("Expecting the metadata cache to be used without an uncached context")
+		pom = &metav1.PartialObjectMetadata{}
+		pom.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("Namespace"))
+		Expect(cCli.Get(context.Background(), key, pom)).To(Succeed())
+		Expect(metaReader.Called).To(Equal(1))
+		Expect(pom.GetUID()).To(BeEmpty())
+	})
+
 	It("list from the cached client", func() {
 		cCli := NewClient(dCli, k8sClient, Options{RawListing: false})
 
@@ -91,6 +177,46 @@ var _ = Describe("Composite client", func() {
 		Expect(cache.Called).To(Equal(0))
 		Expect(len(nsl.Items) > 0).To(BeTrue())
 	})
+
+	It("should get many objects with a single list from the cached client", func() {
+		newConfigMap := func(name string) *corev1.ConfigMap {
+			return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+		}
+
+		// The cache has a and b, the API server has a, b and c.
+		cached := &countingReader{
+			Reader: fake.NewClientBuilder().WithObjects(newConfigMap("a"), newConfigMap("b")).Build(),
+		}
+		uncached := fake.NewClientBuilder().WithObjects(newConfigMap("a"), newConfigMap("b"), newConfigMap("c")).Build()
+		cachedCli, err := client.NewDelegatingClient(client.NewDelegatingClientInput{
+			CacheReader: cached,
+			Client:      uncached,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		cCli := NewClient(cachedCli, uncached, Options{})
+
+		keys := []client.ObjectKey{
+			{Name: "c", Namespace: "default"},
+			{Name: "a", Namespace: "default"},
+			{Name: "d", Namespace: "default"},
+			{Name: "b", Namespace: "default"},
+		}
+		cml := &corev1.ConfigMapList{}
+		Expect(cCli.GetMany(context.Background(), keys, cml)).To(Succeed())
+
+		By("Expecting a single list to serve the cached objects")
+		Expect(cached.Lists).To(Equal(1))
+
+		By("Expecting a get for each cache miss")
+		Expect(cached.Gets).To(Equal(2))
+
+		By("Expecting the existing objects in the order of the keys")
+		names := []string{}
+		for _, cm := range cml.Items {
+			names = append(names, cm.Name)
+		}
+		Expect(names).To(Equal([]string{"c", "a", "b"}))
+	})
 })
 
 // fakeReader is used with a delegating client as a fake cache.
@@ -110,3 +236,42 @@ func (f *fakeReader) List(ctx context.Context, list client.ObjectList, opts ...c
 	f.Called = f.Called + 1
 	return nil
 }
+
+// countingReader is a Reader that counts the get and list calls.
+type countingReader struct {
+	client.Reader
+	Gets  int
+	Lists int
+}
+
+// Get implements the Reader interface Get method.
+func (c *countingReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	c.Gets = c.Gets + 1
+	return c.Reader.Get(ctx, key, obj)
+}
+
+// List implements the Reader interface List method.
+func (c *countingReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.Lists = c.Lists + 1
+	return c.Reader.List(ctx, list, opts...)
+}
+
+// fakeMetadataReader is used as a fake metadata cache. It returns metadata
+// with the requested name for any get request.
+type fakeMetadataReader struct {
+	Called int
+}
+
+// Get implements the Reader interface Get method.
+func (f *fakeMetadataReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	f.Called = f.Called + 1
+	obj.SetName(key.Name)
+	obj.SetNamespace(key.Namespace)
+	return nil
+}
+
+// List implements the Reader interface List method.
+func (f *fakeMetadataReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	f.Called = f.Called + 1
+	return nil
+}
@@ -6,5 +6,11 @@
 // For List operations, the client can be configured to use the cache or
 // directly list from the k8s api server. Unlike Get, List does not return
 // error when objects are not found. It returns an empty list. The decision to
-// retry without cache can't be made for List operations.
+// retry without cache can't be made for List operations. But when the cache
+// isn't started or synced yet, the client lists from the k8s api server.
+// Metadata-only objects, PartialObjectMetadata, can be read from a separate
+// metadata cache. When not found in the cache, they're fetched using a
+// metadata-only API call.
+// For read-after-write consistency, a Get with a context marked with Uncached
+// skips the cache and reads the object from the k8s api server.
 package composite
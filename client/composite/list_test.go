@@ -0,0 +1,86 @@
+package composite
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// listClient is a client that counts the list calls and fails them with the
+// given error, if any.
+type listClient struct {
+	client.Client
+	err   error
+	Lists int
+}
+
+// List implements the Reader interface List method.
+func (c *listClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.Lists = c.Lists + 1
+	if c.err != nil {
+		return c.err
+	}
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestListCacheNotReady(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+
+	testcases := []struct {
+		name         string
+		cacheErr     error
+		wantErr      bool
+		wantUncached bool
+	}{
+		{
+			name:         "cache not started",
+			cacheErr:     &cache.ErrCacheNotStarted{},
+			wantUncached: true,
+		},
+		{
+			name:         "cache not synced",
+			cacheErr:     apierrors.NewTimeoutError("failed waiting for *v1.ConfigMap Informer to sync", 0),
+			wantUncached: true,
+		},
+		{
+			name:     "other cache error",
+			cacheErr: errors.New("cache failure"),
+			wantErr:  true,
+		},
+		{
+			name: "cache list succeeds",
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cached := &listClient{Client: fake.NewClientBuilder().Build(), err: tc.cacheErr}
+			uncached := &listClient{Client: fake.NewClientBuilder().WithObjects(cm).Build()}
+			cCli := NewClient(cached, uncached, Options{})
+
+			cml := &corev1.ConfigMapList{}
+			err := cCli.List(context.TODO(), cml)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t, actual: %v", tc.wantErr, err)
+			}
+
+			// The cache is always tried first.
+			assert.Equal(t, 1, cached.Lists)
+			if tc.wantUncached {
+				assert.Equal(t, 1, uncached.Lists)
+				assert.Len(t, cml.Items, 1)
+			} else {
+				assert.Equal(t, 0, uncached.Lists)
+			}
+		})
+	}
+}
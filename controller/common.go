@@ -7,9 +7,42 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
+// ReconcileFinishLogLevel is the default verbosity level of the reconcile
+// finish logs.
+const ReconcileFinishLogLevel = 4
+
 // LogReconcileFinish is used to log the reconcile function execution
 // information. The start time is the start time of the reconcile function, it
 // is used to calculate the execution time of the function.
 func LogReconcileFinish(log logr.Logger, msg string, start time.Time, result *ctrl.Result, e *error) {
-	log.V(4).Info(msg, "execution-time", time.Since(start).String(), "result", *result, "error", e)
+	log.V(ReconcileFinishLogLevel).Info(msg, "execution-time", time.Since(start).String(), "result", *result, "error", e)
+}
+
+// ReconcileSummary is the summary of a reconciliation.
+type ReconcileSummary struct {
+	// Start is the start time of the reconciliation.
+	Start time.Time
+	// Result is the result of the reconciliation.
+	Result ctrl.Result
+	// Err is the error of the reconciliation.
+	Err error
+	// ObjectsChanged is the number of the object writes to the API server.
+	ObjectsChanged int
+	// ConditionsUpdated are the types of the updated status conditions.
+	ConditionsUpdated []string
+}
+
+// LogReconcileSummary is like LogReconcileFinish, but logs a summary of the
+// reconciliation at the given verbosity level. Along with the execution time,
+// result and error, the summary includes the number of changed objects and
+// the updated status conditions, which helps diagnose busy reconcile loops.
+func LogReconcileSummary(log logr.Logger, level int, msg string, s ReconcileSummary) {
+	log.V(level).Info(msg,
+		"execution-time", time.Since(s.Start).String(),
+		"result", s.Result,
+		"requeue", s.Result.Requeue || s.Result.RequeueAfter > 0,
+		"error", s.Err,
+		"objects-changed", s.ObjectsChanged,
+		"conditions-updated", s.ConditionsUpdated,
+	)
 }
@@ -1,15 +1,19 @@
 package v1
 
 import (
+	"time"
+
 	"github.com/go-logr/logr"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/darkowlzz/operator-toolkit/constant"
+	tkctrl "github.com/darkowlzz/operator-toolkit/controller"
 	"github.com/darkowlzz/operator-toolkit/telemetry"
 )
 
@@ -33,6 +37,46 @@ const (
 	// from the parent object is removed and the parent object is allowed to be
 	// deleted.
 	FinalizerCleanup
+	// LastSeenCleanup allows running custom cleanup logic without using a
+	// finalizer, along with owner reference based garbage collection. It's
+	// the same as OwnerReferenceCleanup with WithNotFoundCleanup. The
+	// reconciler keeps an in-memory copy of the last seen version of the
+	// reconciled objects. When an object is found to be gone, the custom
+	// cleanup code is executed with the last seen copy of the object. Unlike
+	// FinalizerCleanup, this doesn't block the deletion of the object, but the
+	// cleanup is best effort. The last seen copy may be stale and the cleanup
+	// won't run for objects that were deleted while the controller wasn't
+	// running, or before they were reconciled at least once by the current
+	// controller process. Use FinalizerCleanup when the cleanup must be
+	// guaranteed.
+	LastSeenCleanup
+)
+
+// ForceReconcileAnnotation is the annotation on a reconciled object to force
+// its reconciliation when the observed generation skip is enabled with
+// WithObservedGenerationSkip.
+const ForceReconcileAnnotation = "operator-toolkit/force-reconcile"
+
+// StatusUpdateStrategy is the strategy used to write the status of the
+// reconciled object at the end of a reconciliation.
+type StatusUpdateStrategy int
+
+const (
+	// StatusUpdate updates the whole object status. The update conflicts if
+	// the object was modified after it was fetched.
+	StatusUpdate StatusUpdateStrategy = iota
+	// StatusMergePatch patches the object status with a JSON merge patch
+	// computed from the object fetched at the start of the reconciliation.
+	// Only the changed status fields are sent, reducing the conflicts with
+	// the other writers of the object.
+	StatusMergePatch
+	// StatusMergePatchWithRetry patches the object status like
+	// StatusMergePatch, but the patch conflicts if the object was modified
+	// after it was fetched, like when it was fetched from a stale cache. On
+	// conflict, the status changes are applied on the latest object and the
+	// patch is retried, up to DefaultStatusPatchRetries times. See
+	// PatchStatusWithRetry.
+	StatusMergePatchWithRetry
 )
 
 // CompositeReconciler defines a composite reconciler.
@@ -40,12 +84,22 @@ type CompositeReconciler struct {
 	name            string
 	initCondition   metav1.Condition
 	finalizerName   string
+	earlyFinalizer  bool
+	skipObserved    bool
+	phaseCondition  bool
 	cleanupStrategy CleanupStrategy
+	notFoundCleanup bool
+	statusStrategy  StatusUpdateStrategy
+	statusSnapshot  bool
 	ctrlr           Controller
 	prototype       client.Object
 	client          client.Client
 	scheme          *runtime.Scheme
 	inst            *telemetry.Instrumentation
+	lastSeen        *lastSeenCache
+	recorder        record.EventRecorder
+	timeout         time.Duration
+	summaryLevel    int
 }
 
 // CompositeReconcilerOption is used to configure CompositeReconciler.
@@ -88,6 +142,54 @@ func WithFinalizer(finalizer string) CompositeReconcilerOption {
 	}
 }
 
+// WithEarlyFinalizer configures the CompositeReconciler to add the finalizer
+// right after fetching the object, before defaulting, validation and
+// initialization, as the first write to the object. By default, the finalizer
+// is added after initialization, just before Operate. An early finalizer
+// ensures that no external resource is created before the object is
+// protected by the finalizer. Applies only to FinalizerCleanup.
+func WithEarlyFinalizer(early bool) CompositeReconcilerOption {
+	return func(c *CompositeReconciler) {
+		c.earlyFinalizer = early
+	}
+}
+
+// WithObservedGenerationSkip configures the CompositeReconciler to skip the
+// Operate step for objects whose status observedGeneration matches their
+// generation and that aren't being deleted, unless the object has the
+// ForceReconcileAnnotation. The status is still updated with UpdateStatus to
+// correct any status drift. The observedGeneration is set in the status once
+// Operate succeeds without a requeue. The status of the object must have an
+// observedGeneration field. This avoids redundant expensive Operate calls
+// when the object spec hasn't changed.
+func WithObservedGenerationSkip(skip bool) CompositeReconcilerOption {
+	return func(c *CompositeReconciler) {
+		c.skipObserved = skip
+	}
+}
+
+// WithSummaryLogLevel sets the verbosity level of the summary logged at the
+// end of every reconciliation. The summary includes the execution time, the
+// result, the error, the number of the object writes and the updated status
+// conditions. Defaults to controller.ReconcileFinishLogLevel.
+func WithSummaryLogLevel(level int) CompositeReconcilerOption {
+	return func(c *CompositeReconciler) {
+		c.summaryLevel = level
+	}
+}
+
+// WithPhaseCondition enables setting the Reconciling status condition that
+// reflects the reconcile phase of the object: Initializing, Operating or
+// CleaningUp. The condition is true while the phase is in progress and false
+// once the object is reconciled. The condition is set with the unstructured
+// status helpers and works with any object with metav1.Condition status
+// conditions.
+func WithPhaseCondition(enable bool) CompositeReconcilerOption {
+	return func(c *CompositeReconciler) {
+		c.phaseCondition = enable
+	}
+}
+
 // WithCleanupStrategy sets the CleanupStrategy of the CompositeReconciler.
 func WithCleanupStrategy(cleanupStrat CleanupStrategy) CompositeReconcilerOption {
 	return func(c *CompositeReconciler) {
@@ -95,6 +197,40 @@ func WithCleanupStrategy(cleanupStrat CleanupStrategy) CompositeReconcilerOption
 	}
 }
 
+// WithNotFoundCleanup configures the CompositeReconciler to run the custom
+// cleanup logic with the last seen copy of an object when the object is found
+// to be gone, independent of the CleanupStrategy. This helps run the cleanup
+// for objects that disappear without a finalizer, for example, when
+// finalizers can't be used. With FinalizerCleanup, the objects that are
+// cleaned up by the finalizer aren't cleaned up again. See LastSeenCleanup
+// for the limitations of the last seen copy based cleanup.
+func WithNotFoundCleanup(enable bool) CompositeReconcilerOption {
+	return func(c *CompositeReconciler) {
+		c.notFoundCleanup = enable
+	}
+}
+
+// WithStatusUpdateStrategy sets the StatusUpdateStrategy used to write the
+// object status at the end of a reconciliation. Defaults to StatusUpdate.
+func WithStatusUpdateStrategy(strategy StatusUpdateStrategy) CompositeReconcilerOption {
+	return func(c *CompositeReconciler) {
+		c.statusStrategy = strategy
+	}
+}
+
+// WithStatusSnapshot configures the CompositeReconciler to save only the
+// metadata and the status of the object before operating on it, instead of a
+// deep copy of the whole object. The saved copy is used to detect and patch
+// the status changes. This reduces the allocations for large objects. The
+// rest of the saved copy is shared with the object being reconciled, so any
+// change to the spec in Operate isn't seen as a diff, which is fine since
+// only the status is written.
+func WithStatusSnapshot(enable bool) CompositeReconcilerOption {
+	return func(c *CompositeReconciler) {
+		c.statusSnapshot = enable
+	}
+}
+
 // WithScheme sets the runtime Scheme of the CompositeReconciler.
 func WithScheme(scheme *runtime.Scheme) CompositeReconcilerOption {
 	return func(c *CompositeReconciler) {
@@ -102,6 +238,25 @@ func WithScheme(scheme *runtime.Scheme) CompositeReconcilerOption {
 	}
 }
 
+// WithEventRecorder sets the EventRecorder of the CompositeReconciler. When
+// set, k8s events are recorded on the reconciled object for the lifecycle
+// transitions of the object.
+func WithEventRecorder(recorder record.EventRecorder) CompositeReconcilerOption {
+	return func(c *CompositeReconciler) {
+		c.recorder = recorder
+	}
+}
+
+// WithReconcileTimeout sets a timeout for a whole reconciliation. The context
+// passed to the Controller is cancelled once the timeout expires and the
+// reconciliation is requeued. The Controller operations must honor the context
+// for the timeout to take effect. By default, there's no timeout.
+func WithReconcileTimeout(timeout time.Duration) CompositeReconcilerOption {
+	return func(c *CompositeReconciler) {
+		c.timeout = timeout
+	}
+}
+
 // WithInstrumentation configures the instrumentation  of the
 // CompositeReconciler.
 func WithInstrumentation(tp trace.TracerProvider, mp metric.MeterProvider, log logr.Logger) CompositeReconcilerOption {
@@ -133,6 +288,7 @@ func (c *CompositeReconciler) Init(mgr ctrl.Manager, ctrlr Controller, prototype
 	// Add defaults.
 	c.initCondition = DefaultInitCondition
 	c.cleanupStrategy = OwnerReferenceCleanup
+	c.summaryLevel = tkctrl.ReconcileFinishLogLevel
 
 	// Run the options to override the defaults.
 	for _, opt := range opts {
@@ -150,6 +306,14 @@ func (c *CompositeReconciler) Init(mgr ctrl.Manager, ctrlr Controller, prototype
 		WithInstrumentation(nil, nil, ctrl.Log)(c)
 	}
 
+	// Cache the last seen objects for cleanup without finalizer.
+	if c.cleanupStrategy == LastSeenCleanup {
+		c.notFoundCleanup = true
+	}
+	if c.notFoundCleanup {
+		c.lastSeen = newLastSeenCache()
+	}
+
 	return nil
 }
 
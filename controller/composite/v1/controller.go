@@ -52,3 +52,13 @@ type Controller interface {
 	// custom cleanup requirement, the cleanup logic can be defined here.
 	Cleanup(context.Context, client.Object) (result ctrl.Result, err error)
 }
+
+// WarningValidator is an optional interface that can be implemented by a
+// Controller to report non-fatal validation issues, like the use of
+// deprecated fields. When implemented, ValidateWithWarnings is called instead
+// of Validate. The returned warnings are recorded as Warning events on the
+// object and the reconciliation continues. A returned error fails the
+// validation like Validate.
+type WarningValidator interface {
+	ValidateWithWarnings(context.Context, client.Object) ([]string, error)
+}
@@ -3,18 +3,26 @@ package v1
 import (
 	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/darkowlzz/operator-toolkit/controller/composite/v1/mocks"
+	"github.com/darkowlzz/operator-toolkit/object"
+	operatorv1 "github.com/darkowlzz/operator-toolkit/operator/v1"
+	"github.com/darkowlzz/operator-toolkit/operator/v1/operand"
+	operandmocks "github.com/darkowlzz/operator-toolkit/operator/v1/operand/mocks"
 	tdv1alpha1 "github.com/darkowlzz/operator-toolkit/testdata/api/v1alpha1"
 )
 
@@ -61,6 +69,7 @@ func TestReconcile(t *testing.T) {
 		expectations func(*mocks.MockController)
 		wantResult   ctrl.Result
 		wantErr      bool
+		wantEvents   []string
 	}{
 		{
 			name: "instance not found",
@@ -92,6 +101,7 @@ func TestReconcile(t *testing.T) {
 			},
 			wantResult: ctrl.Result{},
 			wantErr:    true,
+			wantEvents: []string{"Warning ValidationFailed"},
 		},
 		{
 			name:         "init failure",
@@ -131,6 +141,7 @@ func TestReconcile(t *testing.T) {
 				m.EXPECT().Initialize(gomock.Any(), gomock.Any(), gomock.Any())
 			},
 			wantResult: ctrl.Result{Requeue: true},
+			wantEvents: []string{"Normal Initialized"},
 		},
 		{
 			name:         "fetch status failure",
@@ -277,6 +288,7 @@ func TestReconcile(t *testing.T) {
 			},
 			wantResult: ctrl.Result{Requeue: true},
 			wantErr:    true,
+			wantEvents: []string{"Normal CleanupStarted"},
 		},
 		{
 			name:         "finalizer cleanup success",
@@ -298,6 +310,7 @@ func TestReconcile(t *testing.T) {
 				m.EXPECT().Cleanup(gomock.Any(), gomock.Any())
 			},
 			wantResult: ctrl.Result{},
+			wantEvents: []string{"Normal CleanupStarted", "Normal CleanupCompleted"},
 		},
 	}
 
@@ -319,6 +332,10 @@ func TestReconcile(t *testing.T) {
 			// Create a reconciler with the mock controller, scheme and client.
 			c := tc.reconciler(m, scheme, cli)
 
+			// Add a fake recorder to the reconciler to collect the events.
+			rec := record.NewFakeRecorder(10)
+			WithEventRecorder(rec)(c)
+
 			request := ctrl.Request{NamespacedName: gameNamespacedName}
 			ctx := context.Background()
 
@@ -329,6 +346,1345 @@ func TestReconcile(t *testing.T) {
 			if res != tc.wantResult {
 				t.Errorf("unexpected reconcile result:\n(WNT) %v\n(GOT) %v", tc.wantResult, res)
 			}
+
+			// Check the recorded events. The fake recorder events are of
+			// the format "<type> <reason> <message>".
+			close(rec.Events)
+			gotEvents := []string{}
+			for e := range rec.Events {
+				gotEvents = append(gotEvents, e)
+			}
+			assert.Equal(t, len(tc.wantEvents), len(gotEvents), "recorded events: %v", gotEvents)
+			for i := range tc.wantEvents {
+				if i < len(gotEvents) {
+					assert.True(t, strings.HasPrefix(gotEvents[i], tc.wantEvents[i]+" "), "unexpected event %q, want %q", gotEvents[i], tc.wantEvents[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileLastSeenCleanup(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	// Create an initialized instance of the target object.
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-game",
+			Namespace: "test-ns",
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	// isGameObj matches the cleanup argument with the last seen game object.
+	isGameObj := gomock.AssignableToTypeOf(&tdv1alpha1.Game{})
+
+	testcases := []struct {
+		name string
+		// seen tells if the object should be reconciled before it's deleted.
+		seen         bool
+		expectations func(*mocks.MockController)
+		wantResults  []ctrl.Result
+		wantErrs     []bool
+	}{
+		{
+			name:         "object never seen",
+			seen:         false,
+			expectations: func(m *mocks.MockController) {},
+			wantResults:  []ctrl.Result{{}},
+			wantErrs:     []bool{false},
+		},
+		{
+			name: "cleanup on disappearance",
+			seen: true,
+			expectations: func(m *mocks.MockController) {
+				m.EXPECT().Cleanup(gomock.Any(), isGameObj).
+					DoAndReturn(func(ctx context.Context, obj client.Object) (ctrl.Result, error) {
+						assert.Equal(t, "test-game", obj.GetName())
+						return ctrl.Result{}, nil
+					})
+			},
+			// Second reconcile after successful cleanup should be a no-op.
+			wantResults: []ctrl.Result{{}, {}},
+			wantErrs:    []bool{false, false},
+		},
+		{
+			name: "cleanup failure is retried",
+			seen: true,
+			expectations: func(m *mocks.MockController) {
+				gomock.InOrder(
+					m.EXPECT().Cleanup(gomock.Any(), isGameObj).Return(ctrl.Result{}, errors.New("failed to cleanup")),
+					m.EXPECT().Cleanup(gomock.Any(), isGameObj).Return(ctrl.Result{}, nil),
+				)
+			},
+			wantResults: []ctrl.Result{{}, {}, {}},
+			wantErrs:    []bool{true, false, false},
+		},
+		{
+			name: "terminal cleanup failure is not retried",
+			seen: true,
+			expectations: func(m *mocks.MockController) {
+				m.EXPECT().Cleanup(gomock.Any(), isGameObj).Return(ctrl.Result{}, terminalError{errors.New("permission denied")})
+			},
+			// The last seen object is forgotten, no cleanup on the second
+			// reconcile.
+			wantResults: []ctrl.Result{{}, {}},
+			wantErrs:    []bool{false, false},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(gameObj.DeepCopy()).
+				Build()
+
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := mocks.NewMockController(mctrl)
+
+			cr := &CompositeReconciler{}
+			assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+				WithScheme(scheme),
+				WithClient(cli),
+				WithCleanupStrategy(LastSeenCleanup),
+			))
+
+			request := ctrl.Request{NamespacedName: gameNamespacedName}
+			ctx := context.Background()
+
+			if tc.seen {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+				m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Operate(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, nil)
+				m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+				_, err := cr.Reconcile(ctx, request)
+				assert.Nil(t, err)
+			}
+
+			// Delete the object and reconcile.
+			assert.Nil(t, cli.Delete(ctx, gameObj.DeepCopy()))
+			tc.expectations(m)
+
+			for i := range tc.wantResults {
+				res, err := cr.Reconcile(ctx, request)
+				if (err != nil) != tc.wantErrs[i] {
+					t.Errorf("reconcile %d: expected error %t, actual: %v", i, tc.wantErrs[i], err)
+				}
+				assert.Equal(t, tc.wantResults[i], res, "reconcile %d result", i)
+			}
+		})
+	}
+}
+
+func TestReconcileNotFoundCleanup(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	// Create an initialized instance of the target object.
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-game",
+			Namespace:  "test-ns",
+			Finalizers: []string{"test-finalizer"},
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	testcases := []struct {
+		name            string
+		cleanupStrategy CleanupStrategy
+		notFoundCleanup bool
+		// markDeleted tells if the object should be marked for deletion and
+		// reconciled before it's gone.
+		markDeleted bool
+		// wantCleanups is the number of times the cleanup is expected to run.
+		wantCleanups int
+	}{
+		{
+			name:            "cleanup on disappearance",
+			cleanupStrategy: OwnerReferenceCleanup,
+			notFoundCleanup: true,
+			wantCleanups:    1,
+		},
+		{
+			name:            "not found cleanup disabled",
+			cleanupStrategy: OwnerReferenceCleanup,
+			notFoundCleanup: false,
+			wantCleanups:    0,
+		},
+		{
+			name:            "cleanup on disappearance without finalizer cleanup",
+			cleanupStrategy: FinalizerCleanup,
+			notFoundCleanup: true,
+			wantCleanups:    1,
+		},
+		{
+			name:            "no cleanup on disappearance after finalizer cleanup",
+			cleanupStrategy: FinalizerCleanup,
+			notFoundCleanup: true,
+			markDeleted:     true,
+			wantCleanups:    1,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(gameObj.DeepCopy()).
+				Build()
+
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := mocks.NewMockController(mctrl)
+
+			cr := &CompositeReconciler{}
+			assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+				WithScheme(scheme),
+				WithClient(cli),
+				WithFinalizer("test-finalizer"),
+				WithCleanupStrategy(tc.cleanupStrategy),
+				WithNotFoundCleanup(tc.notFoundCleanup),
+			))
+
+			request := ctrl.Request{NamespacedName: gameNamespacedName}
+			ctx := context.Background()
+
+			m.EXPECT().Default(gomock.Any(), gomock.Any()).AnyTimes()
+			m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+			m.EXPECT().Operate(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, nil).AnyTimes()
+			m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).AnyTimes()
+			m.EXPECT().Cleanup(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, nil).Times(tc.wantCleanups)
+
+			// Reconcile the object to be seen.
+			_, err := cr.Reconcile(ctx, request)
+			assert.Nil(t, err)
+
+			if tc.markDeleted {
+				// Mark the object for deletion and reconcile to run the
+				// finalizer based cleanup.
+				game := &tdv1alpha1.Game{}
+				assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+				now := metav1.Now()
+				game.SetDeletionTimestamp(&now)
+				assert.Nil(t, cli.Update(ctx, game))
+
+				_, err := cr.Reconcile(ctx, request)
+				assert.Nil(t, err)
+			}
+
+			// Delete the object and reconcile.
+			assert.Nil(t, cli.Delete(ctx, gameObj.DeepCopy()))
+			res, err := cr.Reconcile(ctx, request)
+			assert.Nil(t, err)
+			assert.Equal(t, ctrl.Result{}, res)
+		})
+	}
+}
+
+func TestReconcileTimeout(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	// Create an initialized instance of the target object.
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-game",
+			Namespace: "test-ns",
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	testcases := []struct {
+		name         string
+		operate      func(ctx context.Context, obj client.Object) (ctrl.Result, error)
+		wantResult   ctrl.Result
+		wantDeadline bool
+	}{
+		{
+			name: "operate within timeout",
+			operate: func(ctx context.Context, obj client.Object) (ctrl.Result, error) {
+				return ctrl.Result{}, nil
+			},
+			wantResult: ctrl.Result{},
+		},
+		{
+			name: "operate exceeds timeout",
+			operate: func(ctx context.Context, obj client.Object) (ctrl.Result, error) {
+				// Block until the reconcile context is cancelled.
+				<-ctx.Done()
+				return ctrl.Result{}, ctx.Err()
+			},
+			wantResult:   ctrl.Result{Requeue: true},
+			wantDeadline: true,
+		},
+		{
+			name: "operate ignores context error",
+			operate: func(ctx context.Context, obj client.Object) (ctrl.Result, error) {
+				<-ctx.Done()
+				return ctrl.Result{}, nil
+			},
+			wantResult:   ctrl.Result{Requeue: true},
+			wantDeadline: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(gameObj.DeepCopy()).
+				Build()
+
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := mocks.NewMockController(mctrl)
+			m.EXPECT().Default(gomock.Any(), gomock.Any())
+			m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+			// The status is updated and written even when the reconciliation
+			// times out.
+			m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, obj client.Object) error {
+					if ctx.Err() != nil {
+						return ctx.Err()
+					}
+					obj.(*tdv1alpha1.Game).Status.ObservedGeneration = 5
+					return nil
+				})
+			m.EXPECT().Operate(gomock.Any(), gomock.Any()).DoAndReturn(tc.operate)
+
+			cr := &CompositeReconciler{}
+			assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+				WithScheme(scheme),
+				WithClient(cli),
+				WithReconcileTimeout(50*time.Millisecond),
+			))
+
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "test-game", Namespace: "test-ns"}}
+			res, err := cr.Reconcile(context.Background(), request)
+			assert.Equal(t, tc.wantResult, res)
+			if tc.wantDeadline {
+				assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+				// Only the timeout error is returned.
+				assert.NotContains(t, err.Error(), "status")
+			} else {
+				assert.Nil(t, err)
+			}
+
+			game := &tdv1alpha1.Game{}
+			assert.Nil(t, cli.Get(context.Background(), request.NamespacedName, game))
+			assert.Equal(t, int64(5), game.Status.ObservedGeneration)
+		})
+	}
+}
+
+func TestReconcileEarlyFinalizer(t *testing.T) {
+	testFinalizerName := "foofinalizer"
+
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	// Create an initialized instance of the target object.
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-game",
+			Namespace: "test-ns",
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRuntimeObjects(gameObj).
+		Build()
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+	m := mocks.NewMockController(mctrl)
+
+	cr := &CompositeReconciler{}
+	assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+		WithScheme(scheme),
+		WithClient(cli),
+		WithCleanupStrategy(FinalizerCleanup),
+		WithFinalizer(testFinalizerName),
+		WithEarlyFinalizer(true),
+	))
+
+	request := ctrl.Request{NamespacedName: gameNamespacedName}
+	ctx := context.Background()
+
+	// The first reconcile only adds the finalizer, no controller calls.
+	res, err := cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{Requeue: true}, res)
+
+	game := &tdv1alpha1.Game{}
+	assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+	assert.Equal(t, []string{testFinalizerName}, game.GetFinalizers())
+
+	// The finalizer exists before Operate is invoked.
+	m.EXPECT().Default(gomock.Any(), gomock.Any())
+	m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+	m.EXPECT().Operate(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, obj client.Object) (ctrl.Result, error) {
+			assert.Equal(t, []string{testFinalizerName}, obj.GetFinalizers())
+			return ctrl.Result{}, nil
+		})
+	m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+
+	res, err = cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+}
+
+func TestReconcileObservedGenerationSkip(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	// Create an initialized instance of the target object.
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-game",
+			Namespace:  "test-ns",
+			Generation: 1,
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRuntimeObjects(gameObj).
+		Build()
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+	m := mocks.NewMockController(mctrl)
+
+	cr := &CompositeReconciler{}
+	assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+		WithScheme(scheme),
+		WithClient(cli),
+		WithObservedGenerationSkip(true),
+	))
+
+	request := ctrl.Request{NamespacedName: gameNamespacedName}
+	ctx := context.Background()
+
+	// expectReconcile sets the expectations of a full reconciliation with
+	// the given Operate error.
+	expectReconcile := func(operateErr error) {
+		m.EXPECT().Default(gomock.Any(), gomock.Any())
+		m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+		m.EXPECT().Operate(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, operateErr)
+		m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+	}
+	// expectSkip sets the expectations of a reconciliation that skips
+	// Operate. The status is still updated.
+	expectSkip := func() {
+		m.EXPECT().Default(gomock.Any(), gomock.Any())
+		m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+		m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+	}
+	// updateGame updates the game object in the API.
+	updateGame := func(update func(*tdv1alpha1.Game)) {
+		game := &tdv1alpha1.Game{}
+		assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+		update(game)
+		assert.Nil(t, cli.Update(ctx, game))
+	}
+	observedGeneration := func() int64 {
+		game := &tdv1alpha1.Game{}
+		assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+		return game.Status.ObservedGeneration
+	}
+
+	// A failed Operate doesn't record the observed generation.
+	expectReconcile(errors.New("operate failure"))
+	_, err := cr.Reconcile(ctx, request)
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), observedGeneration())
+
+	// A successful Operate records the observed generation.
+	expectReconcile(nil)
+	_, err = cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), observedGeneration())
+
+	// A second reconcile with unchanged generation skips Operate.
+	expectSkip()
+	res, err := cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	// Forced reconcile.
+	updateGame(func(g *tdv1alpha1.Game) {
+		g.SetAnnotations(map[string]string{ForceReconcileAnnotation: "true"})
+	})
+	expectReconcile(nil)
+	_, err = cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+
+	// Changed generation.
+	updateGame(func(g *tdv1alpha1.Game) {
+		g.SetAnnotations(nil)
+		g.SetGeneration(2)
+	})
+	expectReconcile(nil)
+	_, err = cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), observedGeneration())
+
+	expectSkip()
+	res, err = cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	// A status edited out of band is corrected by UpdateStatus even when
+	// Operate is skipped.
+	staleCondition := metav1.Condition{
+		Type:               "Stale",
+		Status:             metav1.ConditionTrue,
+		Reason:             "OutOfBand",
+		LastTransitionTime: metav1.Now(),
+	}
+	game := &tdv1alpha1.Game{}
+	assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+	game.Status.Conditions = append(game.Status.Conditions, staleCondition)
+	assert.Nil(t, cli.Status().Update(ctx, game))
+
+	m.EXPECT().Default(gomock.Any(), gomock.Any())
+	m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+	m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, obj client.Object) error {
+			g := obj.(*tdv1alpha1.Game)
+			g.Status.Conditions = g.Status.Conditions[:1]
+			return nil
+		},
+	)
+	_, err = cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+
+	assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+	assert.Len(t, game.Status.Conditions, 1)
+	assert.Equal(t, int64(2), game.Status.ObservedGeneration)
+}
+
+func TestReconcileRetryBudget(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	// Create an initialized instance of the target object.
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-game",
+			Namespace:  "test-ns",
+			Generation: 1,
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	cli := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithRuntimeObjects(gameObj).
+		Build()
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	// An operand that never gets ready.
+	op := operandmocks.NewMockOperand(mctrl)
+	op.EXPECT().Name().Return("opA").AnyTimes()
+	op.EXPECT().Requires().Return([]string{})
+	op.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+	op.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	op.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+
+	co, err := operatorv1.NewCompositeOperator(
+		operatorv1.WithEventRecorder(record.NewFakeRecorder(10)),
+		operatorv1.WithOperands(op),
+		operatorv1.WithRetryBudget(1),
+	)
+	assert.Nil(t, err)
+
+	m := mocks.NewMockController(mctrl)
+	m.EXPECT().Default(gomock.Any(), gomock.Any()).AnyTimes()
+	m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	m.EXPECT().Operate(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, obj client.Object) (ctrl.Result, error) {
+			return co.Ensure(ctx, obj, metav1.OwnerReference{})
+		}).AnyTimes()
+	m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).AnyTimes()
+
+	recorder := record.NewFakeRecorder(10)
+	cr := &CompositeReconciler{}
+	assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+		WithScheme(scheme),
+		WithClient(cli),
+		WithEventRecorder(recorder),
+		WithObservedGenerationSkip(true),
+	))
+
+	request := ctrl.Request{NamespacedName: gameNamespacedName}
+	ctx := context.Background()
+
+	// Not ready within the budget, requeued.
+	res, err := cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+	assert.True(t, res.Requeue)
+
+	// Budget exhausted, not requeued and the failure is recorded.
+	res, err = cr.Reconcile(ctx, request)
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+	assert.Contains(t, <-recorder.Events, EventReasonOperateFailed)
+
+	// The generation isn't observed, the next reconcile still operates.
+	game := &tdv1alpha1.Game{}
+	assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+	assert.Equal(t, int64(0), game.Status.ObservedGeneration)
+}
+
+func TestReconcileTerminalCleanupFailure(t *testing.T) {
+	testFinalizerName := "foofinalizer"
+
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	// Create an initialized instance of the target object being deleted.
+	timenow := metav1.Now()
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-game",
+			Namespace:         "test-ns",
+			Finalizers:        []string{testFinalizerName},
+			DeletionTimestamp: &timenow,
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	testcases := []struct {
+		name       string
+		cleanupErr error
+		wantResult ctrl.Result
+		wantErr    bool
+		// wantCondition tells if the CleanupFailed condition is set.
+		wantCondition bool
+	}{
+		{
+			name:       "cleanup error is retried",
+			cleanupErr: errors.New("cleanup failure"),
+			wantErr:    true,
+		},
+		{
+			name:          "terminal cleanup error is not retried",
+			cleanupErr:    terminalError{errors.New("permission denied")},
+			wantCondition: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(gameObj.DeepCopy()).
+				Build()
+
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := mocks.NewMockController(mctrl)
+			m.EXPECT().Default(gomock.Any(), gomock.Any())
+			m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+			m.EXPECT().Cleanup(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, tc.cleanupErr)
+			m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+
+			recorder := record.NewFakeRecorder(10)
+			cr := &CompositeReconciler{}
+			assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+				WithScheme(scheme),
+				WithClient(cli),
+				WithEventRecorder(recorder),
+				WithCleanupStrategy(FinalizerCleanup),
+				WithFinalizer(testFinalizerName),
+			))
+
+			request := ctrl.Request{NamespacedName: gameNamespacedName}
+			ctx := context.Background()
+			res, err := cr.Reconcile(ctx, request)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t, actual: %v", tc.wantErr, err)
+			}
+			assert.Equal(t, tc.wantResult, res)
+
+			// The finalizer is kept.
+			game := &tdv1alpha1.Game{}
+			assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+			assert.Contains(t, game.GetFinalizers(), testFinalizerName)
+
+			cond, err := object.FindStatusCondition(game, CleanupFailedConditionType)
+			assert.Nil(t, err)
+			assert.Contains(t, <-recorder.Events, EventReasonCleanupStarted)
+			if !tc.wantCondition {
+				assert.Nil(t, cond)
+				assert.Empty(t, recorder.Events)
+				return
+			}
+			if assert.NotNil(t, cond) {
+				assert.Equal(t, metav1.ConditionTrue, cond.Status)
+				assert.Equal(t, tc.cleanupErr.Error(), cond.Message)
+			}
+			assert.Contains(t, <-recorder.Events, EventReasonCleanupFailed)
+		})
+	}
+}
+
+// patchRecordingClient is a client that records the status patches.
+type patchRecordingClient struct {
+	client.Client
+	statusPatches []string
+}
+
+func (c *patchRecordingClient) Status() client.StatusWriter {
+	return &patchRecordingStatusWriter{StatusWriter: c.Client.Status(), c: c}
+}
+
+type patchRecordingStatusWriter struct {
+	client.StatusWriter
+	c *patchRecordingClient
+}
+
+func (w *patchRecordingStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	data, err := patch.Data(obj)
+	if err != nil {
+		return err
+	}
+	w.c.statusPatches = append(w.c.statusPatches, string(data))
+	return w.StatusWriter.Patch(ctx, obj, patch, opts...)
+}
+
+func TestReconcileStatusMergePatch(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	// Create an initialized instance of the target object.
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-game",
+			Namespace: "test-ns",
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	cli := &patchRecordingClient{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme).
+			WithRuntimeObjects(gameObj).
+			Build(),
+	}
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+	m := mocks.NewMockController(mctrl)
+
+	cr := &CompositeReconciler{}
+	assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+		WithScheme(scheme),
+		WithClient(cli),
+		WithStatusUpdateStrategy(StatusMergePatch),
+	))
+
+	m.EXPECT().Default(gomock.Any(), gomock.Any())
+	m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+	m.EXPECT().Operate(gomock.Any(), gomock.Any())
+	m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, obj client.Object) error {
+			obj.(*tdv1alpha1.Game).Status.ObservedGeneration = 5
+			return nil
+		})
+
+	ctx := context.Background()
+	_, err := cr.Reconcile(ctx, ctrl.Request{NamespacedName: gameNamespacedName})
+	assert.Nil(t, err)
+
+	// Only the changed status field is sent in the patch.
+	assert.Equal(t, []string{`{"status":{"observedGeneration":5}}`}, cli.statusPatches)
+
+	game := &tdv1alpha1.Game{}
+	assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+	assert.Equal(t, int64(5), game.Status.ObservedGeneration)
+	assert.Len(t, game.Status.Conditions, 1)
+}
+
+func TestReconcileStatusSnapshot(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	// Create an initialized instance of the target object.
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-game",
+			Namespace: "test-ns",
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	cases := []struct {
+		name         string
+		updateStatus func(obj client.Object)
+		wantPatches  []string
+	}{
+		{
+			name:         "no status change",
+			updateStatus: func(obj client.Object) {},
+			wantPatches:  nil,
+		},
+		{
+			name: "status change",
+			updateStatus: func(obj client.Object) {
+				obj.(*tdv1alpha1.Game).Status.ObservedGeneration = 5
+			},
+			wantPatches: []string{`{"status":{"observedGeneration":5}}`},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := &patchRecordingClient{
+				Client: fake.NewClientBuilder().
+					WithScheme(scheme).
+					WithRuntimeObjects(gameObj.DeepCopy()).
+					Build(),
+			}
+
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := mocks.NewMockController(mctrl)
+
+			cr := &CompositeReconciler{}
+			assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+				WithScheme(scheme),
+				WithClient(cli),
+				WithStatusUpdateStrategy(StatusMergePatch),
+				WithStatusSnapshot(true),
+			))
+
+			m.EXPECT().Default(gomock.Any(), gomock.Any())
+			m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+			m.EXPECT().Operate(gomock.Any(), gomock.Any())
+			m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any()).
+				DoAndReturn(func(ctx context.Context, obj client.Object) error {
+					tc.updateStatus(obj)
+					return nil
+				})
+
+			_, err := cr.Reconcile(context.Background(), ctrl.Request{NamespacedName: gameNamespacedName})
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantPatches, cli.statusPatches)
+		})
+	}
+}
+
+// warningController is a Controller that reports validation warnings.
+type warningController struct {
+	*mocks.MockController
+	warnings []string
+	err      error
+}
+
+func (w *warningController) ValidateWithWarnings(ctx context.Context, obj client.Object) ([]string, error) {
+	return w.warnings, w.err
+}
+
+func TestReconcileValidationWarnings(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	cases := []struct {
+		name       string
+		warnings   []string
+		err        error
+		expectFunc func(*mocks.MockController)
+		wantErr    bool
+		wantEvents []string
+	}{
+		{
+			name:     "warnings with reconcile",
+			warnings: []string{"spec.foo is deprecated", "spec.bar is deprecated"},
+			expectFunc: func(m *mocks.MockController) {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+				m.EXPECT().Operate(gomock.Any(), gomock.Any())
+				m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+			},
+			wantEvents: []string{
+				"Warning ValidationWarning spec.foo is deprecated",
+				"Warning ValidationWarning spec.bar is deprecated",
+			},
+		},
+		{
+			name:     "warnings with validation failure",
+			warnings: []string{"spec.foo is deprecated"},
+			err:      errors.New("validation failure"),
+			expectFunc: func(m *mocks.MockController) {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+			},
+			wantErr: true,
+			wantEvents: []string{
+				"Warning ValidationWarning spec.foo is deprecated",
+				"Warning ValidationFailed validation failure",
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// Create an initialized instance of the target object.
+			gameObj := &tdv1alpha1.Game{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-game",
+					Namespace: "test-ns",
+				},
+				Status: tdv1alpha1.GameStatus{
+					Conditions: []metav1.Condition{
+						DefaultInitCondition,
+					},
+				},
+			}
+
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(gameObj).
+				Build()
+
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := mocks.NewMockController(mctrl)
+			tc.expectFunc(m)
+
+			// Validate is not called when ValidateWithWarnings is
+			// implemented.
+			wc := &warningController{MockController: m, warnings: tc.warnings, err: tc.err}
+
+			recorder := record.NewFakeRecorder(10)
+			cr := &CompositeReconciler{}
+			assert.Nil(t, cr.Init(nil, wc, &tdv1alpha1.Game{},
+				WithScheme(scheme),
+				WithClient(cli),
+				WithEventRecorder(recorder),
+			))
+
+			_, err := cr.Reconcile(context.Background(), ctrl.Request{NamespacedName: gameNamespacedName})
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+
+			close(recorder.Events)
+			events := []string{}
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			assert.Equal(t, tc.wantEvents, events)
+		})
+	}
+}
+
+// terminalError is an error that isn't resolved by retrying.
+type terminalError struct{ error }
+
+func (e terminalError) Terminal() bool { return true }
+
+func TestReconcilePhaseCondition(t *testing.T) {
+	testFinalizerName := "foofinalizer"
+
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-game",
+			Namespace: "test-ns",
+		},
+	}
+
+	initializedGameObj := gameObj.DeepCopy()
+	initializedGameObj.Status = tdv1alpha1.GameStatus{
+		Conditions: []metav1.Condition{
+			DefaultInitCondition,
+		},
+	}
+	initializedGameObj.SetFinalizers([]string{testFinalizerName})
+
+	gameObjDeleteTimestamp := initializedGameObj.DeepCopy()
+	timenow := metav1.Now()
+	gameObjDeleteTimestamp.SetDeletionTimestamp(&timenow)
+
+	testcases := []struct {
+		name         string
+		existingObj  *tdv1alpha1.Game
+		expectations func(*mocks.MockController)
+		wantStatus   metav1.ConditionStatus
+		wantReason   string
+	}{
+		{
+			name:        "initializing",
+			existingObj: gameObj,
+			expectations: func(m *mocks.MockController) {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+				m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Initialize(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: PhaseInitializing,
+		},
+		{
+			name:        "operating with requeue",
+			existingObj: initializedGameObj,
+			expectations: func(m *mocks.MockController) {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+				m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Operate(gomock.Any(), gomock.Any()).Return(ctrl.Result{Requeue: true}, nil)
+				m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+			},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: PhaseOperating,
+		},
+		{
+			name:        "operating with error",
+			existingObj: initializedGameObj,
+			expectations: func(m *mocks.MockController) {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+				m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Operate(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, errors.New("operate failure"))
+				m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+			},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: PhaseOperating,
+		},
+		{
+			name:        "reconciled",
+			existingObj: initializedGameObj,
+			expectations: func(m *mocks.MockController) {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+				m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Operate(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, nil)
+				m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+			},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: PhaseReconciled,
+		},
+		{
+			name:        "terminal error",
+			existingObj: initializedGameObj,
+			expectations: func(m *mocks.MockController) {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+				m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Operate(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, terminalError{errors.New("invalid spec")})
+				m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+			},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: PhaseFailed,
+		},
+		{
+			name:        "cleaning up",
+			existingObj: gameObjDeleteTimestamp,
+			expectations: func(m *mocks.MockController) {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+				m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Cleanup(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, errors.New("cleanup failure"))
+				m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+			},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: PhaseCleaningUp,
+		},
+		{
+			name:        "cleanup terminal error",
+			existingObj: gameObjDeleteTimestamp,
+			expectations: func(m *mocks.MockController) {
+				m.EXPECT().Default(gomock.Any(), gomock.Any())
+				m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Cleanup(gomock.Any(), gomock.Any()).Return(ctrl.Result{}, terminalError{errors.New("permission denied")})
+				m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+			},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: PhaseFailed,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(tc.existingObj.DeepCopy()).
+				Build()
+
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := mocks.NewMockController(mctrl)
+			tc.expectations(m)
+
+			cr := &CompositeReconciler{}
+			assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+				WithScheme(scheme),
+				WithClient(cli),
+				WithInitCondition(DefaultInitCondition),
+				WithCleanupStrategy(FinalizerCleanup),
+				WithFinalizer(testFinalizerName),
+				WithPhaseCondition(true),
+			))
+
+			request := ctrl.Request{NamespacedName: gameNamespacedName}
+			ctx := context.Background()
+			_, _ = cr.Reconcile(ctx, request)
+
+			game := &tdv1alpha1.Game{}
+			assert.Nil(t, cli.Get(ctx, gameNamespacedName, game))
+			cond, err := object.FindStatusCondition(game, ReconcilingConditionType)
+			assert.Nil(t, err)
+			if assert.NotNil(t, cond) {
+				assert.Equal(t, tc.wantStatus, cond.Status)
+				assert.Equal(t, tc.wantReason, cond.Reason)
+			}
+		})
+	}
+}
+
+// logRecord is an info log recorded by recordingLogger.
+type logRecord struct {
+	level         int
+	msg           string
+	keysAndValues map[string]interface{}
+}
+
+// recordingLogger is a logr.Logger that records the info logs.
+type recordingLogger struct {
+	level   int
+	records *[]logRecord
+}
+
+func (l recordingLogger) Enabled() bool { return true }
+
+func (l recordingLogger) Info(msg string, keysAndValues ...interface{}) {
+	kv := map[string]interface{}{}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		kv[keysAndValues[i].(string)] = keysAndValues[i+1]
+	}
+	*l.records = append(*l.records, logRecord{level: l.level, msg: msg, keysAndValues: kv})
+}
+
+func (l recordingLogger) Error(err error, msg string, keysAndValues ...interface{}) {}
+
+func (l recordingLogger) V(level int) logr.Logger {
+	return recordingLogger{level: l.level + level, records: l.records}
+}
+
+func (l recordingLogger) WithValues(keysAndValues ...interface{}) logr.Logger { return l }
+
+func (l recordingLogger) WithName(name string) logr.Logger { return l }
+
+func TestReconcileSummary(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	gameNamespacedName := types.NamespacedName{
+		Name:      "test-game",
+		Namespace: "test-ns",
+	}
+	initializedGameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-game",
+			Namespace: "test-ns",
+		},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				DefaultInitCondition,
+			},
+		},
+	}
+
+	testcases := []struct {
+		name           string
+		operateResult  ctrl.Result
+		operateErr     error
+		wantRequeue    bool
+		wantErr        bool
+		wantChanged    int
+		wantConditions []string
+	}{
+		{
+			name:           "reconciled",
+			wantChanged:    1,
+			wantConditions: []string{ReconcilingConditionType},
+		},
+		{
+			name:           "requeued",
+			operateResult:  ctrl.Result{RequeueAfter: time.Minute},
+			wantRequeue:    true,
+			wantChanged:    1,
+			wantConditions: []string{ReconcilingConditionType},
+		},
+		{
+			name:           "failed",
+			operateErr:     errors.New("operate failure"),
+			wantErr:        true,
+			wantChanged:    1,
+			wantConditions: []string{ReconcilingConditionType},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(initializedGameObj.DeepCopy()).
+				Build()
+
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := mocks.NewMockController(mctrl)
+			m.EXPECT().Default(gomock.Any(), gomock.Any())
+			m.EXPECT().Validate(gomock.Any(), gomock.Any()).Return(nil)
+			m.EXPECT().Operate(gomock.Any(), gomock.Any()).Return(tc.operateResult, tc.operateErr)
+			m.EXPECT().UpdateStatus(gomock.Any(), gomock.Any())
+
+			records := []logRecord{}
+			cr := &CompositeReconciler{}
+			assert.Nil(t, cr.Init(nil, m, &tdv1alpha1.Game{},
+				WithScheme(scheme),
+				WithClient(cli),
+				WithPhaseCondition(true),
+				WithSummaryLogLevel(2),
+				WithInstrumentation(nil, nil, recordingLogger{records: &records}),
+			))
+
+			request := ctrl.Request{NamespacedName: gameNamespacedName}
+			_, _ = cr.Reconcile(context.Background(), request)
+
+			var summary *logRecord
+			for i := range records {
+				if records[i].msg == "reconciliation finished" {
+					summary = &records[i]
+				}
+			}
+			if !assert.NotNil(t, summary) {
+				return
+			}
+			assert.Equal(t, 2, summary.level)
+			assert.Equal(t, tc.wantRequeue, summary.keysAndValues["requeue"])
+			assert.Equal(t, tc.wantErr, summary.keysAndValues["error"] != nil)
+			assert.Equal(t, tc.wantChanged, summary.keysAndValues["objects-changed"])
+			assert.Equal(t, tc.wantConditions, summary.keysAndValues["conditions-updated"])
+			assert.Contains(t, summary.keysAndValues, "execution-time")
+			assert.Contains(t, summary.keysAndValues, "result")
 		})
 	}
 }
@@ -0,0 +1,47 @@
+package v1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+
+	eventv1 "github.com/darkowlzz/operator-toolkit/event/v1"
+)
+
+// Reasons of the events recorded by the CompositeReconciler.
+const (
+	// EventReasonInitialized is used when an object is initialized.
+	EventReasonInitialized = "Initialized"
+	// EventReasonValidationFailed is used when an object fails validation.
+	EventReasonValidationFailed = "ValidationFailed"
+	// EventReasonValidationWarning is used for the validation warnings of an
+	// object.
+	EventReasonValidationWarning = "ValidationWarning"
+	// EventReasonCleanupStarted is used when the cleanup of an object starts.
+	EventReasonCleanupStarted = "CleanupStarted"
+	// EventReasonCleanupCompleted is used when the cleanup of an object
+	// completes successfully.
+	EventReasonCleanupCompleted = "CleanupCompleted"
+	// EventReasonOperateFailed is used when the operation on an object fails
+	// with a terminal error.
+	EventReasonOperateFailed = "OperateFailed"
+	// EventReasonCleanupFailed is used when the cleanup of an object fails
+	// with a terminal error.
+	EventReasonCleanupFailed = "CleanupFailed"
+)
+
+// normalEvent records a normal event on the given object, if an event recorder
+// is configured.
+func (c *CompositeReconciler) normalEvent(obj runtime.Object, reason, message string) {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder.Event(obj, eventv1.K8sEventTypeNormal, reason, message)
+}
+
+// warningEvent records a warning event on the given object, if an event
+// recorder is configured.
+func (c *CompositeReconciler) warningEvent(obj runtime.Object, reason, message string) {
+	if c.recorder == nil {
+		return
+	}
+	c.recorder.Event(obj, eventv1.K8sEventTypeWarning, reason, message)
+}
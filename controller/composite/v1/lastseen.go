@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// lastSeenCache stores the last seen copy of the reconciled objects, keyed by
+// their namespaced name. It's safe for concurrent use by multiple reconcile
+// workers.
+type lastSeenCache struct {
+	mu      sync.RWMutex
+	objects map[types.NamespacedName]client.Object
+}
+
+func newLastSeenCache() *lastSeenCache {
+	return &lastSeenCache{
+		objects: map[types.NamespacedName]client.Object{},
+	}
+}
+
+// set stores the given object as the last seen copy of the object with the
+// given key.
+func (l *lastSeenCache) set(key types.NamespacedName, obj client.Object) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.objects[key] = obj
+}
+
+// get returns the last seen copy of the object with the given key and true if
+// found, else false.
+func (l *lastSeenCache) get(key types.NamespacedName) (client.Object, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	obj, found := l.objects[key]
+	return obj, found
+}
+
+// delete forgets the object with the given key.
+func (l *lastSeenCache) delete(key types.NamespacedName) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.objects, key)
+}
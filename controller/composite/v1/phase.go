@@ -0,0 +1,79 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/darkowlzz/operator-toolkit/object"
+)
+
+// ReconcilingConditionType is the type of the status condition that reflects
+// the reconcile phase of an object, set with WithPhaseCondition.
+const ReconcilingConditionType = "Reconciling"
+
+// CleanupFailedConditionType is the type of the status condition set when the
+// cleanup of an object fails with a terminal error. The finalizer of the
+// object is kept and the cleanup isn't retried until the object changes.
+const CleanupFailedConditionType = "CleanupFailed"
+
+// Phases of the reconciliation, used as the reasons of the Reconciling
+// condition.
+const (
+	// PhaseInitializing is the phase of an object being initialized.
+	PhaseInitializing = "Initializing"
+	// PhaseOperating is the phase of an object being operated on.
+	PhaseOperating = "Operating"
+	// PhaseCleaningUp is the phase of an object being cleaned up.
+	PhaseCleaningUp = "CleaningUp"
+	// PhaseReconciled is the phase of an object that's reconciled.
+	PhaseReconciled = "Reconciled"
+	// PhaseFailed is the phase of an object that failed with a terminal
+	// error.
+	PhaseFailed = "Failed"
+)
+
+// phaseMessages are the condition messages of the in progress phases.
+var phaseMessages = map[string]string{
+	PhaseInitializing: "Initializing the object",
+	PhaseOperating:    "Operating on the object",
+	PhaseCleaningUp:   "Cleaning up the object",
+}
+
+// setPhaseCondition sets the Reconciling condition of the given object for
+// the given phase and the outcome of the phase. The condition is true while
+// the phase is in progress, when it's requeued or failed with an error, and
+// false once the object is reconciled or failed with a terminal error.
+func setPhaseCondition(obj client.Object, phase string, result ctrl.Result, err error, terminalErr error) error {
+	cond := metav1.Condition{
+		Type:    ReconcilingConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  phase,
+		Message: phaseMessages[phase],
+	}
+	switch {
+	case terminalErr != nil:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = PhaseFailed
+		cond.Message = terminalErr.Error()
+	case err != nil:
+		cond.Message = err.Error()
+	case result.Requeue || result.RequeueAfter > 0:
+	default:
+		cond.Status = metav1.ConditionFalse
+		cond.Reason = PhaseReconciled
+		cond.Message = "Reconciliation completed"
+	}
+	return object.SetStatusCondition(obj, cond)
+}
+
+// setCleanupFailedCondition sets the CleanupFailed condition of the given
+// object for the given terminal cleanup error.
+func setCleanupFailedCondition(obj client.Object, err error) error {
+	return object.SetStatusCondition(obj, metav1.Condition{
+		Type:    CleanupFailedConditionType,
+		Status:  metav1.ConditionTrue,
+		Reason:  PhaseFailed,
+		Message: err.Error(),
+	})
+}
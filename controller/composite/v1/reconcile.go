@@ -2,49 +2,134 @@ package v1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
-	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 
 	tkctrl "github.com/darkowlzz/operator-toolkit/controller"
+	tkerror "github.com/darkowlzz/operator-toolkit/error"
 	"github.com/darkowlzz/operator-toolkit/object"
+	"github.com/darkowlzz/operator-toolkit/telemetry"
 )
 
 // Reconcile implements the composite controller reconciliation.
 func (c *CompositeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
+	// Correlate the logs and the traces of the reconciliation with an ID.
+	ctx, _ = telemetry.WithCorrelationID(ctx)
 	ctx, span, _, log := c.inst.Start(ctx, "Reconcile")
 	defer span.End()
 
-	start := time.Now()
-	defer tkctrl.LogReconcileFinish(log, "reconciliation finished", start, &result, &reterr)
+	// summary is the summary of the reconciliation, logged at the end.
+	summary := tkctrl.ReconcileSummary{Start: time.Now()}
+	defer func() {
+		summary.Result, summary.Err = result, reterr
+		tkctrl.LogReconcileSummary(log, c.summaryLevel, "reconciliation finished", summary)
+	}()
+
+	// statusCtx is the context of the deferred status update. It isn't bound
+	// to the reconcile timeout, the status is written even when the
+	// reconciliation times out.
+	statusCtx := ctx
+
+	// Limit the whole reconciliation with a timeout, if configured. On
+	// timeout, requeue with the deadline error.
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+
+		defer func() {
+			if ctx.Err() != context.DeadlineExceeded {
+				return
+			}
+			span.AddEvent("Reconcile timed out")
+			result = ctrl.Result{Requeue: true}
+			if !errors.Is(reterr, context.DeadlineExceeded) {
+				reterr = tkerror.NewAggregate([]error{reterr, fmt.Errorf("reconcile timed out after %v: %w", c.timeout, ctx.Err())})
+			}
+			span.RecordError(reterr)
+		}()
+	}
 
 	controller := c.ctrlr
 
 	// Get an instance of the target object.
 	instance := c.prototype.DeepCopyObject().(client.Object)
 	if getErr := c.client.Get(ctx, req.NamespacedName, instance); getErr != nil {
+		// If the not found cleanup is enabled, run the cleanup for the object
+		// that's gone.
+		if apierrors.IsNotFound(getErr) && c.notFoundCleanup {
+			span.AddEvent("Handle last seen cleanup")
+			result, reterr = c.lastSeenCleanupHandler(ctx, req.NamespacedName)
+			return
+		}
 		reterr = client.IgnoreNotFound(getErr)
 		return
 	}
 
+	// Record the instance to be used for cleanup once it's gone. With the
+	// finalizer based cleanup, the objects being deleted aren't recorded, the
+	// finalizer takes care of their cleanup.
+	if c.notFoundCleanup &&
+		(c.cleanupStrategy != FinalizerCleanup || instance.GetDeletionTimestamp().IsZero()) {
+		c.lastSeen.set(req.NamespacedName, instance.DeepCopyObject().(client.Object))
+	}
+
+	// Add the finalizer before anything else, if configured. The update
+	// results in another reconciliation with the finalizer in place.
+	if c.cleanupStrategy == FinalizerCleanup && c.earlyFinalizer &&
+		instance.GetDeletionTimestamp().IsZero() &&
+		!controllerutil.ContainsFinalizer(instance, c.finalizerName) {
+		span.AddEvent("Finalizer not found, updating object to add early finalizer")
+		controllerutil.AddFinalizer(instance, c.finalizerName)
+		if updateErr := c.client.Update(ctx, instance); updateErr != nil {
+			log.Error(updateErr, "failed to add finalizer")
+			reterr = updateErr
+			return
+		}
+		summary.ObjectsChanged++
+		result = ctrl.Result{Requeue: true}
+		return
+	}
+
+	// Skip the Operate step if the current generation has been observed. The
+	// rest of the reconciliation still runs to keep the status up to date.
+	skipOperate := false
+	if c.skipObserved {
+		observed, obsErr := c.generationObserved(instance)
+		if obsErr != nil {
+			reterr = obsErr
+			return
+		}
+		skipOperate = observed
+	}
+
 	// Add defaults to the primary object instance.
 	span.AddEvent("Populate defaults")
 	controller.Default(ctx, instance)
 
 	// Validate the instance spec.
 	span.AddEvent("Validate")
-	if valErr := controller.Validate(ctx, instance); valErr != nil {
+	if valErr := c.validate(ctx, instance); valErr != nil {
 		reterr = valErr
 		log.Error(valErr, "object validation failed")
+		c.warningEvent(instance, EventReasonValidationFailed, valErr.Error())
 		return
 	}
 
 	// Save the instance before operating on it in memory.
-	oldInstance := instance.DeepCopyObject()
+	var oldInstance client.Object
+	if c.statusSnapshot {
+		oldInstance = statusSnapshot(instance)
+	} else {
+		oldInstance = instance.DeepCopyObject().(client.Object)
+	}
 
 	init, initErr := object.IsInitialized(c.scheme, instance)
 	if initErr != nil {
@@ -66,10 +151,20 @@ func (c *CompositeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 			reterr = initErr
 			return
 		}
+		if c.phaseCondition {
+			if condErr := setPhaseCondition(instance, PhaseInitializing, ctrl.Result{Requeue: true}, nil, nil); condErr != nil {
+				reterr = condErr
+				return
+			}
+		}
 
 		// Update the object status in the API.
 		if updateErr := c.client.Status().Update(ctx, instance); updateErr != nil {
 			log.Error(updateErr, "failed to update initialized object")
+		} else {
+			summary.ObjectsChanged++
+			summary.ConditionsUpdated, _ = object.ChangedConditions(oldInstance, instance)
+			c.normalEvent(instance, EventReasonInitialized, "Object initialized")
 		}
 		span.AddEvent("Updated object status")
 		result = ctrl.Result{Requeue: true}
@@ -87,6 +182,13 @@ func (c *CompositeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	// reconciliation will take place and it's okay to skip status update.
 	skipStatusUpdate := false
 
+	// terminalErr is the terminal error of the operation, if any. It isn't
+	// returned to avoid requeuing the object.
+	var terminalErr error
+
+	// phase is the reconcile phase of the object.
+	phase := PhaseOperating
+
 	// Attempt to patch the status after each reconciliation.
 	defer func() {
 		if skipStatusUpdate {
@@ -99,27 +201,53 @@ func (c *CompositeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		// NOTE: The actual target object gets updated in the API server at the end
 		// of the control loop with the deferred PatchStatus.
 		span.AddEvent("Get status updates")
-		if updateErr := controller.UpdateStatus(ctx, instance); updateErr != nil {
+		if updateErr := controller.UpdateStatus(statusCtx, instance); updateErr != nil {
 			span.RecordError(updateErr)
 			result = ctrl.Result{Requeue: true}
-			reterr = kerrors.NewAggregate([]error{reterr, fmt.Errorf("error while updating status: %v", updateErr)})
+			reterr = tkerror.NewAggregate([]error{reterr, fmt.Errorf("error while updating status: %w", updateErr)})
 			return
 		}
 
+		// Reflect the reconcile phase in the status.
+		if c.phaseCondition {
+			if condErr := setPhaseCondition(instance, phase, result, reterr, terminalErr); condErr != nil {
+				reterr = tkerror.NewAggregate([]error{reterr, fmt.Errorf("error while setting phase condition: %v", condErr)})
+			}
+		}
+
+		// Surface a terminal cleanup error that keeps the finalizer.
+		if terminalErr != nil && phase == PhaseCleaningUp {
+			if condErr := setCleanupFailedCondition(instance, terminalErr); condErr != nil {
+				reterr = tkerror.NewAggregate([]error{reterr, fmt.Errorf("error while setting cleanup failed condition: %v", condErr)})
+			}
+		}
+
+		// Record the observed generation when Operate completed with no
+		// error or requeue.
+		if c.skipObserved && reterr == nil && terminalErr == nil && result == (ctrl.Result{}) &&
+			instance.GetDeletionTimestamp().IsZero() {
+			if genErr := object.SetObservedGeneration(instance, instance.GetGeneration()); genErr != nil {
+				reterr = tkerror.NewAggregate([]error{reterr, fmt.Errorf("error while setting observed generation: %v", genErr)})
+			}
+		}
+
 		span.AddEvent("Checking for status change")
 
 		// Compare the old instance status with the updated instance status
 		// and patch the status if there's a diff.
 		changed, statusChngErr := object.StatusChanged(c.scheme, oldInstance, instance)
 		if statusChngErr != nil {
-			reterr = kerrors.NewAggregate([]error{reterr, fmt.Errorf("error while checking for changed status: %v", statusChngErr)})
+			reterr = tkerror.NewAggregate([]error{reterr, fmt.Errorf("error while checking for changed status: %v", statusChngErr)})
 		}
 
 		if changed {
 			span.AddEvent("Found status change, updating object")
 			// ?: Should patch status only if reterr is nil?
-			if statusErr := c.client.Status().Update(ctx, instance); statusErr != nil {
-				reterr = kerrors.NewAggregate([]error{reterr, fmt.Errorf("error while patching status: %v", statusErr)})
+			if statusErr := c.writeStatus(statusCtx, oldInstance, instance); statusErr != nil {
+				reterr = tkerror.NewAggregate([]error{reterr, fmt.Errorf("error while patching status: %w", statusErr)})
+			} else {
+				summary.ObjectsChanged++
+				summary.ConditionsUpdated, _ = object.ChangedConditions(oldInstance, instance)
 			}
 		} else {
 			span.AddEvent("No status change found")
@@ -130,8 +258,20 @@ func (c *CompositeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 	if c.cleanupStrategy == FinalizerCleanup {
 		span.AddEvent("Handle finalizers")
 		delEnabled, updated, cResult, cErr := c.cleanupHandler(ctx, instance)
+		if delEnabled {
+			phase = PhaseCleaningUp
+		}
+		// Retrying doesn't resolve a terminal cleanup error. Record it and
+		// don't requeue, the cleanup is retried when the object changes.
+		if cErr != nil && tkerror.IsTerminal(cErr) {
+			span.RecordError(cErr)
+			c.warningEvent(instance, EventReasonCleanupFailed, cErr.Error())
+			terminalErr = cErr
+			cResult, cErr = ctrl.Result{}, nil
+		}
 		if updated {
 			log.Info("Finalizers updated")
+			summary.ObjectsChanged++
 			// Object updated, skip deferred status update and let the
 			// subsequent reconciliation handle the status udpate.
 			skipStatusUpdate = true
@@ -145,16 +285,46 @@ func (c *CompositeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		}
 	}
 
+	if skipOperate {
+		span.AddEvent("Generation already observed, skipping Operate")
+		return
+	}
+
 	// Run the operation.
 	span.AddEvent("Run Operate")
 	result, reterr = controller.Operate(ctx, instance)
 	if reterr != nil {
 		log.Error(reterr, "failed to finish Operation")
+
+		// Retrying doesn't resolve a terminal error. Record it and don't
+		// requeue, the object is reconciled again when it changes.
+		if tkerror.IsTerminal(reterr) {
+			span.RecordError(reterr)
+			c.warningEvent(instance, EventReasonOperateFailed, reterr.Error())
+			terminalErr = reterr
+			result, reterr = ctrl.Result{}, nil
+		}
 	}
 
 	return
 }
 
+// generationObserved returns true if the current generation of an object has
+// been observed and the object isn't forced to be reconciled or being deleted.
+func (c *CompositeReconciler) generationObserved(obj client.Object) (bool, error) {
+	if !obj.GetDeletionTimestamp().IsZero() {
+		return false, nil
+	}
+	if _, forced := obj.GetAnnotations()[ForceReconcileAnnotation]; forced {
+		return false, nil
+	}
+	gen, found, err := object.GetObservedGeneration(obj)
+	if err != nil {
+		return false, err
+	}
+	return found && gen == obj.GetGeneration(), nil
+}
+
 // cleanupHandler checks if the target object is marked for deletion. If not,
 // it ensures that a finalizer is added to the target object. If an object is
 // marked for deletion, it runs the custom cleanup functions and returns the
@@ -187,12 +357,18 @@ func (c *CompositeReconciler) cleanupHandler(ctx context.Context, obj client.Obj
 		// Perform cleanup if finalizer is found.
 		if contains(obj.GetFinalizers(), c.finalizerName) {
 			span.AddEvent("Finalizer found, run cleanup")
+			c.normalEvent(obj, EventReasonCleanupStarted, "Cleanup started")
 			result, reterr = c.ctrlr.Cleanup(ctx, obj)
 			if reterr != nil {
 				log.Error(reterr, "failed to cleanup")
 			} else {
-				// Cleanup successful, remove the finalizer.
+				// Cleanup successful, remove the finalizer. Forget the last
+				// seen copy to not run the cleanup again once it's gone.
 				span.AddEvent("Cleanup completed, remove finalizer")
+				c.normalEvent(obj, EventReasonCleanupCompleted, "Cleanup completed")
+				if c.lastSeen != nil {
+					c.lastSeen.delete(client.ObjectKeyFromObject(obj))
+				}
 				controllerutil.RemoveFinalizer(obj, c.finalizerName)
 				if updateErr := c.client.Update(ctx, obj); updateErr != nil {
 					log.Error(updateErr, "failed to remove finalizer")
@@ -207,6 +383,46 @@ func (c *CompositeReconciler) cleanupHandler(ctx context.Context, obj client.Obj
 	return
 }
 
+// lastSeenCleanupHandler runs the custom cleanup functions with the last seen
+// copy of an object that no longer exists. The last seen copy is forgotten
+// once the cleanup completes without error or requeue, else it's kept for the
+// subsequent reconciliations to retry the cleanup. It's a no-op if the object
+// was never seen.
+func (c *CompositeReconciler) lastSeenCleanupHandler(ctx context.Context, key types.NamespacedName) (result ctrl.Result, reterr error) {
+	ctx, span, _, log := c.inst.Start(ctx, "lastSeenCleanupHandler")
+	defer span.End()
+
+	obj, found := c.lastSeen.get(key)
+	if !found {
+		span.AddEvent("Last seen object not found, no-op")
+		return
+	}
+
+	span.AddEvent("Last seen object found, run cleanup")
+	c.normalEvent(obj, EventReasonCleanupStarted, "Cleanup started")
+	result, reterr = c.ctrlr.Cleanup(ctx, obj)
+	if reterr != nil {
+		log.Error(reterr, "failed to cleanup")
+
+		// The object is gone and retrying doesn't resolve a terminal error.
+		// Record it and forget the last seen object.
+		if tkerror.IsTerminal(reterr) {
+			span.RecordError(reterr)
+			c.warningEvent(obj, EventReasonCleanupFailed, reterr.Error())
+			c.lastSeen.delete(key)
+			result, reterr = ctrl.Result{}, nil
+		}
+		return
+	}
+
+	if result == (ctrl.Result{}) {
+		span.AddEvent("Cleanup completed, forget last seen object")
+		c.normalEvent(obj, EventReasonCleanupCompleted, "Cleanup completed")
+		c.lastSeen.delete(key)
+	}
+	return
+}
+
 func contains(slice []string, s string) bool {
 	for _, element := range slice {
 		if element == s {
@@ -215,3 +431,29 @@ func contains(slice []string, s string) bool {
 	}
 	return false
 }
+
+// writeStatus writes the status of the object based on the status update
+// strategy. The old object is the base of the status patch.
+func (c *CompositeReconciler) writeStatus(ctx context.Context, oldObj, obj client.Object) error {
+	switch c.statusStrategy {
+	case StatusMergePatch:
+		return c.client.Status().Patch(ctx, obj, client.MergeFrom(oldObj))
+	case StatusMergePatchWithRetry:
+		return PatchStatusWithRetry(ctx, c.client, oldObj, obj, DefaultStatusPatchRetries)
+	}
+	return c.client.Status().Update(ctx, obj)
+}
+
+// validate validates the given object with the controller. If the controller
+// implements WarningValidator, the validation warnings are recorded as events.
+func (c *CompositeReconciler) validate(ctx context.Context, obj client.Object) error {
+	wv, ok := c.ctrlr.(WarningValidator)
+	if !ok {
+		return c.ctrlr.Validate(ctx, obj)
+	}
+	warnings, err := wv.ValidateWithWarnings(ctx, obj)
+	for _, warning := range warnings {
+		c.warningEvent(obj, EventReasonValidationWarning, warning)
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// statusSnapshot returns a copy of the given object with a deep copy of only
+// the metadata and the status. The rest of the object, like the spec, is
+// shared with the given object. The snapshot is used as the old object to
+// detect and patch the status changes, avoiding a deep copy of large objects.
+// If the metadata or the status of a typed object can't be copied separately,
+// a deep copy of the whole object is returned.
+func statusSnapshot(obj client.Object) client.Object {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content := make(map[string]interface{}, len(u.Object))
+		for k, v := range u.Object {
+			if k == "metadata" || k == "status" {
+				v = runtime.DeepCopyJSONValue(v)
+			}
+			content[k] = v
+		}
+		return &unstructured.Unstructured{Object: content}
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return obj.DeepCopyObject().(client.Object)
+	}
+
+	// Shallow copy the object and replace the metadata and the status with
+	// their deep copies.
+	snapshot := reflect.New(v.Elem().Type())
+	snapshot.Elem().Set(v.Elem())
+	if !deepCopyField(snapshot.Elem(), "ObjectMeta") || !deepCopyField(snapshot.Elem(), "Status") {
+		return obj.DeepCopyObject().(client.Object)
+	}
+	return snapshot.Interface().(client.Object)
+}
+
+// deepCopyField replaces the named struct field of the given struct value
+// with a deep copy of it, using the generated DeepCopy method of the field
+// type. It returns false if the field doesn't exist or can't be deep copied.
+func deepCopyField(v reflect.Value, name string) bool {
+	field := v.FieldByName(name)
+	if !field.IsValid() || field.Kind() != reflect.Struct || !field.CanSet() {
+		return false
+	}
+	deepCopy := field.Addr().MethodByName("DeepCopy")
+	if !deepCopy.IsValid() || deepCopy.Type().NumIn() != 0 || deepCopy.Type().NumOut() != 1 ||
+		deepCopy.Type().Out(0) != field.Addr().Type() {
+		return false
+	}
+	out := deepCopy.Call(nil)[0]
+	if out.IsNil() {
+		return false
+	}
+	field.Set(out.Elem())
+	return true
+}
@@ -0,0 +1,128 @@
+package v1
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/darkowlzz/operator-toolkit/object"
+)
+
+// newLargePod returns a pod with the given number of containers.
+func newLargePod(containers int) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "default",
+			Labels:    map[string]string{"app": "foo"},
+		},
+		Status: corev1.PodStatus{
+			Phase:      corev1.PodPending,
+			Conditions: []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue}},
+		},
+	}
+	for i := 0; i < containers; i++ {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{
+			Name:    fmt.Sprintf("container-%d", i),
+			Image:   "busybox",
+			Command: []string{"sh", "-c", "sleep 3600"},
+			Env:     []corev1.EnvVar{{Name: "FOO", Value: "bar"}},
+		})
+	}
+	return pod
+}
+
+func TestStatusSnapshot(t *testing.T) {
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newLargePod(2))
+	assert.Nil(t, err)
+
+	cases := []struct {
+		name         string
+		obj          client.Object
+		updateStatus func(client.Object)
+		wantChanged  bool
+	}{
+		{
+			name:         "typed object, no status change",
+			obj:          newLargePod(2),
+			updateStatus: func(obj client.Object) {},
+			wantChanged:  false,
+		},
+		{
+			name: "typed object, status change",
+			obj:  newLargePod(2),
+			updateStatus: func(obj client.Object) {
+				pod := obj.(*corev1.Pod)
+				pod.Status.Phase = corev1.PodRunning
+				pod.Status.Conditions[0].Status = corev1.ConditionFalse
+			},
+			wantChanged: true,
+		},
+		{
+			name: "unstructured object, no status change",
+			obj:  &unstructured.Unstructured{Object: runtime.DeepCopyJSON(u)},
+			updateStatus: func(obj client.Object) {
+				u := obj.(*unstructured.Unstructured)
+				assert.Nil(t, unstructured.SetNestedField(u.Object, "busybox:latest", "spec", "image"))
+			},
+			wantChanged: false,
+		},
+		{
+			name: "unstructured object, status change",
+			obj:  &unstructured.Unstructured{Object: runtime.DeepCopyJSON(u)},
+			updateStatus: func(obj client.Object) {
+				u := obj.(*unstructured.Unstructured)
+				assert.Nil(t, unstructured.SetNestedField(u.Object, "Running", "status", "phase"))
+			},
+			wantChanged: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			snapshot := statusSnapshot(tc.obj)
+			tc.updateStatus(tc.obj)
+
+			changed, err := object.StatusChanged(scheme.Scheme, snapshot, tc.obj)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantChanged, changed)
+		})
+	}
+}
+
+func TestStatusSnapshotMetadata(t *testing.T) {
+	pod := newLargePod(1)
+	snapshot := statusSnapshot(pod).(*corev1.Pod)
+
+	// The metadata is copied, the spec is shared.
+	pod.Labels["app"] = "bar"
+	pod.Spec.Containers[0].Image = "nginx"
+	assert.Equal(t, "foo", snapshot.Labels["app"])
+	assert.Equal(t, "nginx", snapshot.Spec.Containers[0].Image)
+}
+
+func BenchmarkStatusSnapshot(b *testing.B) {
+	pod := newLargePod(100)
+
+	b.Run("DeepCopyObject", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = pod.DeepCopyObject()
+		}
+	})
+
+	b.Run("statusSnapshot", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = statusSnapshot(pod)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultStatusPatchRetries is the number of retries of the status patch on
+// conflict with StatusMergePatchWithRetry.
+const DefaultStatusPatchRetries = 3
+
+// PatchStatusWithRetry patches the status of obj with the status diff from
+// oldObj to obj. The patch conflicts if the object was modified after oldObj
+// was fetched, like when oldObj is a stale cached object. On conflict, the
+// latest object is fetched, the status diff is applied on it and the patch is
+// retried, up to the given number of retries. obj is updated with the
+// patched object.
+func PatchStatusWithRetry(ctx context.Context, c client.Client, oldObj, obj client.Object, retries int) error {
+	diff, err := statusDiff(oldObj, obj)
+	if err != nil {
+		return err
+	}
+
+	base := oldObj
+	for i := 0; ; i++ {
+		err := c.Status().Patch(ctx, obj, client.MergeFromWithOptions(base, client.MergeFromWithOptimisticLock{}))
+		if err == nil || !apierrors.IsConflict(err) || i >= retries {
+			return err
+		}
+
+		// Re-apply the status diff on the latest object.
+		latest := newObject(obj)
+		if err := c.Get(ctx, client.ObjectKeyFromObject(obj), latest); err != nil {
+			return err
+		}
+		if err := applyMergePatch(latest, obj, diff); err != nil {
+			return err
+		}
+		base = latest
+	}
+}
+
+// statusDiff returns the JSON merge patch of the status from oldObj to obj.
+func statusDiff(oldObj, obj client.Object) (map[string]interface{}, error) {
+	data, err := client.MergeFrom(oldObj).Data(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute the status diff: %w", err)
+	}
+	patch := map[string]interface{}{}
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return nil, fmt.Errorf("failed to compute the status diff: %w", err)
+	}
+	status, ok := patch["status"]
+	if !ok {
+		return map[string]interface{}{}, nil
+	}
+	return map[string]interface{}{"status": status}, nil
+}
+
+// applyMergePatch sets obj to a copy of base with the given JSON merge patch
+// applied.
+func applyMergePatch(base, obj client.Object, patch map[string]interface{}) error {
+	// Convert a copy since the content of an unstructured object isn't
+	// copied.
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(base.DeepCopyObject())
+	if err != nil {
+		return err
+	}
+	mergePatch(u, patch)
+
+	if uobj, ok := obj.(*unstructured.Unstructured); ok {
+		uobj.SetUnstructuredContent(u)
+		return nil
+	}
+
+	// Convert into a new object to not keep the fields removed by the patch.
+	patched := newObject(obj)
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, patched); err != nil {
+		return err
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(patched).Elem())
+	return nil
+}
+
+// newObject returns a new empty object of the type of the given object.
+func newObject(obj client.Object) client.Object {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		nu := &unstructured.Unstructured{}
+		nu.SetGroupVersionKind(u.GroupVersionKind())
+		return nu
+	}
+	return reflect.New(reflect.TypeOf(obj).Elem()).Interface().(client.Object)
+}
+
+// mergePatch applies the JSON merge patch (RFC 7386) on the target.
+func mergePatch(target, patch map[string]interface{}) {
+	for k, v := range patch {
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+		pm, ok := v.(map[string]interface{})
+		if !ok {
+			target[k] = v
+			continue
+		}
+		tm, ok := target[k].(map[string]interface{})
+		if !ok {
+			tm = map[string]interface{}{}
+			target[k] = tm
+		}
+		mergePatch(tm, pm)
+	}
+}
@@ -0,0 +1,124 @@
+package v1
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	tdv1alpha1 "github.com/darkowlzz/operator-toolkit/testdata/api/v1alpha1"
+)
+
+func TestPatchStatusWithRetry(t *testing.T) {
+	otherCondition := metav1.Condition{
+		Type:               "Other",
+		Status:             metav1.ConditionTrue,
+		Reason:             "Other",
+		LastTransitionTime: metav1.Now(),
+	}
+
+	testcases := []struct {
+		name        string
+		retries     int
+		unstructure bool
+		conflict    bool
+		wantErr     bool
+	}{
+		{
+			name:    "no conflict",
+			retries: 0,
+		},
+		{
+			name:     "conflict, no retries",
+			retries:  0,
+			conflict: true,
+			wantErr:  true,
+		},
+		{
+			name:     "conflict, succeeds on retry",
+			retries:  1,
+			conflict: true,
+		},
+		{
+			name:        "unstructured, conflict, succeeds on retry",
+			retries:     1,
+			unstructure: true,
+			conflict:    true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+			gameObj := &tdv1alpha1.Game{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-game",
+					Namespace: "test-ns",
+				},
+				Status: tdv1alpha1.GameStatus{
+					Conditions: []metav1.Condition{DefaultInitCondition},
+				},
+			}
+			key := client.ObjectKeyFromObject(gameObj)
+			cli := fake.NewClientBuilder().WithScheme(scheme).WithRuntimeObjects(gameObj).Build()
+			ctx := context.Background()
+
+			// Fetch the object to be patched.
+			var oldObj client.Object = &tdv1alpha1.Game{}
+			if tc.unstructure {
+				u := &unstructured.Unstructured{}
+				u.SetGroupVersionKind(tdv1alpha1.GroupVersion.WithKind("Game"))
+				oldObj = u
+			}
+			assert.Nil(t, cli.Get(ctx, key, oldObj))
+
+			// Modify the object after it was fetched, like another writer.
+			if tc.conflict {
+				other := &tdv1alpha1.Game{}
+				assert.Nil(t, cli.Get(ctx, key, other))
+				other.Spec.Foo = "bar"
+				other.Status.Conditions = append(other.Status.Conditions, otherCondition)
+				assert.Nil(t, cli.Update(ctx, other))
+			}
+
+			// Change the status.
+			obj := oldObj.DeepCopyObject().(client.Object)
+			if tc.unstructure {
+				assert.Nil(t, unstructured.SetNestedField(obj.(*unstructured.Unstructured).Object, int64(5), "status", "observedGeneration"))
+			} else {
+				obj.(*tdv1alpha1.Game).Status.ObservedGeneration = 5
+			}
+
+			err := PatchStatusWithRetry(ctx, cli, oldObj, obj, tc.retries)
+			if tc.wantErr {
+				assert.True(t, apierrors.IsConflict(err), "expected conflict, got %v", err)
+				return
+			}
+			assert.Nil(t, err)
+
+			// The status change is applied without losing the changes of
+			// the other writer.
+			game := &tdv1alpha1.Game{}
+			assert.Nil(t, cli.Get(ctx, key, game))
+			assert.Equal(t, int64(5), game.Status.ObservedGeneration)
+			if tc.conflict {
+				assert.Equal(t, "bar", game.Spec.Foo)
+				assert.Len(t, game.Status.Conditions, 2)
+			} else {
+				assert.Len(t, game.Status.Conditions, 1)
+			}
+
+			// The given object is updated with the patched object.
+			assert.Equal(t, game.GetResourceVersion(), obj.GetResourceVersion())
+		})
+	}
+}
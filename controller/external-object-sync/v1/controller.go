@@ -6,6 +6,7 @@ import (
 	"context"
 
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	syncv1 "github.com/darkowlzz/operator-toolkit/controller/sync/v1"
 )
@@ -24,3 +25,14 @@ type Controller interface {
 	// namespace value can be empty.
 	List(context.Context) ([]types.NamespacedName, error)
 }
+
+// DriftDetector is an optional interface that can be implemented by a
+// Controller to enable drift detection. Drift detection finds the objects in
+// the external system that have been changed out-of-band and no longer match
+// their k8s counterparts.
+type DriftDetector interface {
+	// Drifted receives a k8s object, fetches the associated object from the
+	// external system and compares them. It returns true if the external
+	// object has drifted from the k8s object.
+	Drifted(context.Context, client.Object) (bool, error)
+}
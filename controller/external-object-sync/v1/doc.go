@@ -2,5 +2,7 @@
 // It's based on the sync controller and adds a garbage collector sync function
 // for the purpose of syncing objects between a kubernetes cluster and an
 // external system. The garbage collector deletes orphan objects in the
-// external system.
+// external system. An optional drift detector sync function reports, and
+// optionally reconciles, the external objects that have been changed
+// out-of-band.
 package v1
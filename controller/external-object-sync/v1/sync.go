@@ -2,12 +2,15 @@ package v1
 
 import (
 	"context"
+	"errors"
 	"time"
 
-	syncv1 "github.com/darkowlzz/operator-toolkit/controller/sync/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	syncv1 "github.com/darkowlzz/operator-toolkit/controller/sync/v1"
 	"github.com/darkowlzz/operator-toolkit/object"
 )
 
@@ -27,6 +30,10 @@ type Reconciler struct {
 	Ctrlr                         Controller
 	garbageCollectionPeriod       time.Duration
 	startupGarbageCollectionDelay time.Duration
+	driftDetectionPeriod          time.Duration
+	startupDriftDetectionDelay    time.Duration
+	driftReporter                 func([]types.NamespacedName)
+	reconcileDrift                bool
 }
 
 // SetGarbageCollectionPeriod sets the garbage collection period.
@@ -42,14 +49,52 @@ func (s *Reconciler) SetStartupGarbageCollectionDelay(period time.Duration) {
 	s.startupGarbageCollectionDelay = period
 }
 
+// SetDriftDetectionPeriod sets the drift detection period. Drift detection is
+// disabled when the period is zero. The controller must implement the
+// DriftDetector interface for drift detection.
+func (s *Reconciler) SetDriftDetectionPeriod(period time.Duration) {
+	s.driftDetectionPeriod = period
+}
+
+// SetStartupDriftDetectionDelay sets a delay for the initial drift detection
+// at startup.
+func (s *Reconciler) SetStartupDriftDetectionDelay(period time.Duration) {
+	s.startupDriftDetectionDelay = period
+}
+
+// SetDriftReporter sets a function that's called with the drifted objects
+// after every drift detection.
+func (s *Reconciler) SetDriftReporter(reporter func([]types.NamespacedName)) {
+	s.driftReporter = reporter
+}
+
+// SetReconcileDrift sets if the drifted objects should be reconciled by
+// ensuring them in the external system again.
+func (s *Reconciler) SetReconcileDrift(reconcile bool) {
+	s.reconcileDrift = reconcile
+}
+
 // Init initializes the reconciler.
 func (s *Reconciler) Init(mgr ctrl.Manager, ctrlr Controller, prototype client.Object, prototypeList client.ObjectList, opts ...syncv1.ReconcilerOption) error {
+	sfs := []syncv1.SyncFunc{}
+
 	// Add a garbage collector sync func if garbage collection period is not
 	// zero.
 	if s.garbageCollectionPeriod > zeroDuration {
 		sf := syncv1.NewSyncFunc(s.collectGarbage, s.garbageCollectionPeriod, s.startupGarbageCollectionDelay)
-		sfs := []syncv1.SyncFunc{sf}
+		sfs = append(sfs, sf)
+	}
 
+	// Add a drift detector sync func if drift detection period is not zero.
+	if s.driftDetectionPeriod > zeroDuration {
+		if _, ok := ctrlr.(DriftDetector); !ok {
+			return errors.New("controller must implement DriftDetector for drift detection")
+		}
+		sf := syncv1.NewSyncFunc(s.detectDrift, s.driftDetectionPeriod, s.startupDriftDetectionDelay)
+		sfs = append(sfs, sf)
+	}
+
+	if len(sfs) > 0 {
 		opts = append(opts, syncv1.WithSyncFuncs(sfs))
 	}
 
@@ -111,3 +156,78 @@ func (s *Reconciler) collectGarbage() {
 		}
 	}
 }
+
+// detectDrift lists all the prototype objects in k8s and checks if the
+// associated objects in the external system have drifted. The objects being
+// deleted are skipped. The drifted objects
+// are reported to the drift reporter and ensured in the external system again
+// if drift reconciliation is enabled.
+func (s *Reconciler) detectDrift() {
+	ctx, span, _, log := s.Inst.Start(context.Background(), "detectDrift")
+	defer span.End()
+	log = log.WithValues("drift-detector", s.Name)
+
+	detector, ok := s.Ctrlr.(DriftDetector)
+	if !ok {
+		log.Info("controller doesn't implement DriftDetector, skipping drift detection")
+		return
+	}
+
+	// List all the k8s objects.
+	instances := s.PrototypeList.DeepCopyObject().(client.ObjectList)
+	if listErr := s.Client.List(ctx, instances); listErr != nil {
+		log.Error(listErr, "failed to list")
+		return
+	}
+	items, err := apimeta.ExtractList(instances)
+	if err != nil {
+		log.Error(err, "failed to extract objects from list")
+		return
+	}
+
+	// Compare every object with its external counterpart.
+	drifted := []client.Object{}
+	for _, item := range items {
+		obj, ok := item.(client.Object)
+		if !ok {
+			log.Info("unexpected list item type", "item", item)
+			continue
+		}
+		// Skip the objects being deleted, their external objects are
+		// going away.
+		if !obj.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+		isDrifted, err := detector.Drifted(ctx, obj)
+		if err != nil {
+			log.Error(err, "failed to detect drift", "instance", client.ObjectKeyFromObject(obj))
+			continue
+		}
+		if isDrifted {
+			drifted = append(drifted, obj)
+		}
+	}
+
+	driftedKeys := []types.NamespacedName{}
+	for _, obj := range drifted {
+		driftedKeys = append(driftedKeys, client.ObjectKeyFromObject(obj))
+	}
+
+	if len(driftedKeys) > 0 {
+		log.Info("drift detected in external objects", "objects", driftedKeys)
+	}
+
+	if s.driftReporter != nil {
+		s.driftReporter(driftedKeys)
+	}
+
+	if !s.reconcileDrift {
+		return
+	}
+
+	for _, obj := range drifted {
+		if err := s.Ctrlr.Ensure(ctx, obj); err != nil {
+			log.Error(err, "failed to reconcile drifted external object", "instance", client.ObjectKeyFromObject(obj))
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -9,6 +10,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/darkowlzz/operator-toolkit/controller/external-object-sync/v1/mocks"
@@ -76,3 +78,133 @@ func TestCollectGarbage(t *testing.T) {
 		f.Call()
 	}
 }
+
+// driftController is a Controller with a DriftDetector implementation backed
+// by an in-memory external system.
+type driftController struct {
+	*mocks.MockController
+	// external maps the external object names to their version field.
+	external map[string]string
+}
+
+// Drifted implements the DriftDetector interface. An external object has
+// drifted if its version differs from the k8s object's version annotation.
+func (d *driftController) Drifted(ctx context.Context, obj client.Object) (bool, error) {
+	return d.external[obj.GetName()] != obj.GetAnnotations()["version"], nil
+}
+
+func TestDetectDrift(t *testing.T) {
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	// Create instances of the target object.
+	gameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-game",
+			Namespace:   "test-ns",
+			Annotations: map[string]string{"version": "1"},
+		},
+	}
+
+	gameObj2 := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-game2",
+			Namespace:   "test-ns2",
+			Annotations: map[string]string{"version": "1"},
+		},
+	}
+
+	// A drifted object being deleted is skipped.
+	deletionTime := metav1.Now()
+	deletedGameObj := &tdv1alpha1.Game{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "test-game3",
+			Namespace:         "test-ns",
+			Annotations:       map[string]string{"version": "1"},
+			DeletionTimestamp: &deletionTime,
+			Finalizers:        []string{"test-finalizer"},
+		},
+	}
+
+	existingObjs := []runtime.Object{gameObj, gameObj2, deletedGameObj}
+
+	testcases := []struct {
+		name      string
+		reconcile bool
+	}{
+		{name: "report only"},
+		{name: "report and reconcile", reconcile: true},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := mocks.NewMockController(mctrl)
+
+			// The external version of the second object has changed
+			// out-of-band.
+			dc := &driftController{
+				MockController: m,
+				external: map[string]string{
+					gameObj.GetName():        "1",
+					gameObj2.GetName():       "2",
+					deletedGameObj.GetName(): "2",
+				},
+			}
+
+			// Drifted object is ensured again only when reconcile is enabled.
+			if tc.reconcile {
+				m.EXPECT().Ensure(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(ctx context.Context, obj client.Object) error {
+						assert.Equal(t, gameObj2.GetName(), obj.GetName())
+						return nil
+					})
+			}
+
+			cli := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRuntimeObjects(existingObjs...).
+				Build()
+
+			var report []types.NamespacedName
+
+			sr := Reconciler{}
+			sr.SetDriftDetectionPeriod(5 * time.Minute)
+			// Set the delay to avoid running the drift detection
+			// automatically during the test.
+			sr.SetStartupDriftDetectionDelay(1 * time.Minute)
+			sr.SetDriftReporter(func(drifted []types.NamespacedName) {
+				report = drifted
+			})
+			sr.SetReconcileDrift(tc.reconcile)
+			err := sr.Init(nil, dc, &tdv1alpha1.Game{}, &tdv1alpha1.GameList{},
+				syncv1.WithScheme(scheme),
+				syncv1.WithClient(cli),
+			)
+			assert.Nil(t, err)
+			defer sr.StopSync()
+
+			// Run drift detection sync functions.
+			for _, f := range sr.SyncFuncs {
+				f.Call()
+			}
+
+			wantReport := []types.NamespacedName{
+				{Name: gameObj2.GetName(), Namespace: gameObj2.GetNamespace()},
+			}
+			assert.Equal(t, wantReport, report)
+		})
+	}
+}
+
+func TestDriftDetectionRequiresDetector(t *testing.T) {
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	sr := Reconciler{}
+	sr.SetDriftDetectionPeriod(5 * time.Minute)
+	err := sr.Init(nil, mocks.NewMockController(mctrl), &tdv1alpha1.Game{}, &tdv1alpha1.GameList{})
+	assert.NotNil(t, err)
+}
@@ -1,6 +1,9 @@
 package cache
 
-import "sigs.k8s.io/controller-runtime/pkg/client"
+import (
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
 
 // Cache is an event cache. It is used by the event handler to decide if an
 // object should be reconciled or ignored.
@@ -11,3 +14,16 @@ type Cache interface {
 	// object.
 	CacheMiss(client.Object) bool
 }
+
+// ListCache is a Cache that knows all the cached objects. It is used by the
+// event handler to detect the objects that are no longer in the external
+// system and must be removed from the cache.
+type ListCache interface {
+	Cache
+
+	// Keys returns the keys of all the objects in the cache.
+	Keys() []types.NamespacedName
+
+	// Delete removes the object with the given key from the cache.
+	Delete(types.NamespacedName)
+}
@@ -0,0 +1,83 @@
+package cache
+
+import (
+	"reflect"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ChangedFunc checks if an object has changed compared to its cached
+// version.
+type ChangedFunc func(cached, obj client.Object) bool
+
+// MemoryCache is an in-memory ListCache. It stores a copy of every object it
+// sees and reports a cache miss for the new and the changed objects.
+type MemoryCache struct {
+	mu      sync.Mutex
+	objects map[types.NamespacedName]client.Object
+	changed ChangedFunc
+}
+
+var _ ListCache = &MemoryCache{}
+
+// MemoryCacheOption is used to configure MemoryCache.
+type MemoryCacheOption func(*MemoryCache)
+
+// WithChangedFunc sets the function used to check if an object has changed
+// compared to its cached version. Defaults to a deep comparison of the
+// objects.
+func WithChangedFunc(f ChangedFunc) MemoryCacheOption {
+	return func(c *MemoryCache) {
+		c.changed = f
+	}
+}
+
+// NewMemoryCache creates and returns a MemoryCache.
+func NewMemoryCache(opts ...MemoryCacheOption) *MemoryCache {
+	c := &MemoryCache{
+		objects: map[types.NamespacedName]client.Object{},
+		changed: func(cached, obj client.Object) bool {
+			return !reflect.DeepEqual(cached, obj)
+		},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// CacheMiss implements the Cache interface. The new and the changed objects
+// are stored in the cache.
+func (c *MemoryCache) CacheMiss(obj client.Object) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := client.ObjectKeyFromObject(obj)
+	if cached, found := c.objects[key]; found && !c.changed(cached, obj) {
+		return false
+	}
+	c.objects[key] = obj.DeepCopyObject().(client.Object)
+	return true
+}
+
+// Keys implements the ListCache interface.
+func (c *MemoryCache) Keys() []types.NamespacedName {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := make([]types.NamespacedName, 0, len(c.objects))
+	for k := range c.objects {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// Delete implements the ListCache interface.
+func (c *MemoryCache) Delete(key types.NamespacedName) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.objects, key)
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestMemoryCache(t *testing.T) {
+	newConfigMap := func(name, value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Data:       map[string]string{"k": value},
+		}
+	}
+
+	c := NewMemoryCache()
+	assert.True(t, c.CacheMiss(newConfigMap("a", "1")))
+	assert.False(t, c.CacheMiss(newConfigMap("a", "1")))
+	assert.True(t, c.CacheMiss(newConfigMap("a", "2")))
+	assert.True(t, c.CacheMiss(newConfigMap("b", "1")))
+
+	keyA := types.NamespacedName{Name: "a", Namespace: "default"}
+	keyB := types.NamespacedName{Name: "b", Namespace: "default"}
+	assert.ElementsMatch(t, []types.NamespacedName{keyA, keyB}, c.Keys())
+
+	c.Delete(keyA)
+	assert.Equal(t, []types.NamespacedName{keyB}, c.Keys())
+	assert.True(t, c.CacheMiss(newConfigMap("a", "2")))
+}
+
+func TestMemoryCacheChangedFunc(t *testing.T) {
+	// Consider only the resource version.
+	c := NewMemoryCache(WithChangedFunc(func(cached, obj client.Object) bool {
+		return cached.GetResourceVersion() != obj.GetResourceVersion()
+	}))
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default", ResourceVersion: "1"},
+	}
+	assert.True(t, c.CacheMiss(cm))
+
+	cm.Data = map[string]string{"k": "v"}
+	assert.False(t, c.CacheMiss(cm))
+
+	cm.ResourceVersion = "2"
+	assert.True(t, c.CacheMiss(cm))
+}
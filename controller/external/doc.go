@@ -10,5 +10,7 @@
 // object model, but the namespace and name can be set to anything that can be
 // used to save unique objects key in the cache. The cache can store extra
 // information about the external object. It can be queried by the reconciler
-// to get full information about the desired state.
+// to get full information about the desired state. NewGenericEvent and
+// DecodePayload can be used to consistently store an external record in a
+// generic event object and read it back.
 package external
@@ -0,0 +1,70 @@
+package external
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// PayloadAnnotation is the annotation key used to store the JSON encoded
+// external record in a generic event object.
+const PayloadAnnotation = "operator-toolkit/external-payload"
+
+// ErrPayloadNotFound is returned when an object has no external record
+// payload.
+var ErrPayloadNotFound = errors.New("external payload not found")
+
+// NewGenericEvent creates a generic event for an external record. The key is
+// used as the namespace and name of the event object, which is also the key
+// of the reconcile request. The record is JSON encoded and stored in the
+// event object annotations. Use DecodePayload to decode the record from the
+// event object.
+func NewGenericEvent(key types.NamespacedName, record interface{}) (event.GenericEvent, error) {
+	obj := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      key.Name,
+			Namespace: key.Namespace,
+		},
+	}
+	if err := EncodePayload(obj, record); err != nil {
+		return event.GenericEvent{}, err
+	}
+	return event.GenericEvent{Object: obj}, nil
+}
+
+// EncodePayload JSON encodes the given external record and stores it in the
+// annotations of the given object, overwriting any existing payload.
+func EncodePayload(obj client.Object, record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode external payload: %w", err)
+	}
+
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[PayloadAnnotation] = string(data)
+	obj.SetAnnotations(annotations)
+
+	return nil
+}
+
+// DecodePayload decodes the external record stored in the annotations of the
+// given object into record. ErrPayloadNotFound is returned if the object has
+// no payload.
+func DecodePayload(obj client.Object, record interface{}) error {
+	data, found := obj.GetAnnotations()[PayloadAnnotation]
+	if !found {
+		return ErrPayloadNotFound
+	}
+	if err := json.Unmarshal([]byte(data), record); err != nil {
+		return fmt.Errorf("failed to decode external payload: %w", err)
+	}
+	return nil
+}
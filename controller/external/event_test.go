@@ -0,0 +1,62 @@
+package external
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type fakeRecord struct {
+	ID     string            `json:"id"`
+	Size   int               `json:"size"`
+	Labels map[string]string `json:"labels"`
+}
+
+func TestGenericEventPayload(t *testing.T) {
+	key := types.NamespacedName{Name: "foo", Namespace: "bar"}
+	record := fakeRecord{
+		ID:     "abc-123",
+		Size:   5,
+		Labels: map[string]string{"x": "y"},
+	}
+
+	evt, err := NewGenericEvent(key, record)
+	assert.Nil(t, err)
+	assert.Equal(t, key.Name, evt.Object.GetName())
+	assert.Equal(t, key.Namespace, evt.Object.GetNamespace())
+
+	got := fakeRecord{}
+	assert.Nil(t, DecodePayload(evt.Object, &got))
+	assert.Equal(t, record, got)
+}
+
+func TestEncodePayloadKeepsAnnotations(t *testing.T) {
+	obj := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "foo",
+			Annotations: map[string]string{"some": "annotation"},
+		},
+	}
+
+	assert.Nil(t, EncodePayload(obj, fakeRecord{ID: "1"}))
+	assert.Nil(t, EncodePayload(obj, fakeRecord{ID: "2"}))
+	assert.Equal(t, "annotation", obj.GetAnnotations()["some"])
+
+	got := fakeRecord{}
+	assert.Nil(t, DecodePayload(obj, &got))
+	assert.Equal(t, "2", got.ID)
+}
+
+func TestDecodePayloadErrors(t *testing.T) {
+	obj := &metav1.PartialObjectMetadata{}
+	err := DecodePayload(obj, &fakeRecord{})
+	assert.True(t, errors.Is(err, ErrPayloadNotFound))
+
+	obj.SetAnnotations(map[string]string{PayloadAnnotation: "{invalid"})
+	err = DecodePayload(obj, &fakeRecord{})
+	assert.NotNil(t, err)
+	assert.False(t, errors.Is(err, ErrPayloadNotFound))
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/darkowlzz/operator-toolkit/controller/external/cache"
+)
+
+// ListEndAnnotation is the annotation used to mark the event object that
+// marks the end of a list of external objects.
+const ListEndAnnotation = "operator-toolkit/list-end"
+
+var _ handler.EventHandler = &EnqueueRequestFromCacheWithDeletion{}
+
+// EnqueueRequestFromCacheWithDeletion enqueues events based on a cache,
+// similar to EnqueueRequestFromCache, and also detects the objects deleted in
+// the external system. The event source is expected to send an event for
+// every object in the external system, followed by a list end event created
+// with NewListEndEvent. At the end of a list, the objects in the cache that
+// weren't part of the list are considered deleted. They are removed from the
+// cache and enqueued as deletion requests. The reconciler can use IsDeleted()
+// to know if a request is a deletion request, and Forget() once the deletion
+// is handled. To bound the memory used by the deletions that are never
+// forgotten, a deletion is also forgotten when the object reappears and at
+// the end of the list following the one it was detected in.
+type EnqueueRequestFromCacheWithDeletion struct {
+	handler.Funcs
+
+	cache cache.ListCache
+
+	mu sync.Mutex
+	// seen is the set of keys of the objects seen in the current list.
+	seen map[types.NamespacedName]struct{}
+	// deleted is the set of keys of the objects detected as deleted, with
+	// the number of the list they were detected in.
+	deleted map[types.NamespacedName]int
+	// lists is the number of the current list.
+	lists int
+}
+
+// NewEnqueueRequestFromCacheWithDeletion takes a list cache, creates an
+// EnqueueRequestFromCacheWithDeletion and adds a generic event handler that
+// adds the event object in the queue on cache miss and the deleted objects at
+// the end of every list.
+func NewEnqueueRequestFromCacheWithDeletion(c cache.ListCache) *EnqueueRequestFromCacheWithDeletion {
+	hdler := &EnqueueRequestFromCacheWithDeletion{
+		cache:   c,
+		seen:    map[types.NamespacedName]struct{}{},
+		deleted: map[types.NamespacedName]int{},
+	}
+	hdler.GenericFunc = func(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+		if evt.Object == nil {
+			log.Error(nil, "GenericEvent received with no metadata", "event", evt)
+			return
+		}
+
+		if isListEnd(evt.Object) {
+			hdler.handleListEnd(q)
+			return
+		}
+
+		key := types.NamespacedName{
+			Name:      evt.Object.GetName(),
+			Namespace: evt.Object.GetNamespace(),
+		}
+
+		hdler.mu.Lock()
+		hdler.seen[key] = struct{}{}
+		// The object is back in the external system.
+		delete(hdler.deleted, key)
+		hdler.mu.Unlock()
+
+		// Enqueue only if it's a cache miss.
+		if c.CacheMiss(evt.Object) {
+			q.Add(reconcile.Request{NamespacedName: key})
+		}
+	}
+	return hdler
+}
+
+// handleListEnd removes the objects that weren't seen in the list from the
+// cache and enqueues them as deletion requests. The deletions detected in the
+// previous lists are forgotten.
+func (h *EnqueueRequestFromCacheWithDeletion) handleListEnd(q workqueue.RateLimitingInterface) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for key, list := range h.deleted {
+		if list < h.lists {
+			delete(h.deleted, key)
+		}
+	}
+
+	for _, key := range h.cache.Keys() {
+		if _, found := h.seen[key]; found {
+			continue
+		}
+		h.cache.Delete(key)
+		h.deleted[key] = h.lists
+		q.Add(reconcile.Request{NamespacedName: key})
+	}
+
+	// Start a new list.
+	h.seen = map[types.NamespacedName]struct{}{}
+	h.lists++
+}
+
+// IsDeleted returns true if the object with the given key was detected as
+// deleted in the external system.
+func (h *EnqueueRequestFromCacheWithDeletion) IsDeleted(key types.NamespacedName) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, found := h.deleted[key]
+	return found
+}
+
+// Forget removes the deletion flag of the object with the given key. It
+// should be called by the reconciler once the deletion is handled.
+func (h *EnqueueRequestFromCacheWithDeletion) Forget(key types.NamespacedName) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.deleted, key)
+}
+
+// NewListEndEvent returns a generic event that marks the end of a list of
+// external objects.
+func NewListEndEvent() event.GenericEvent {
+	return event.GenericEvent{
+		Object: &metav1.PartialObjectMetadata{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{ListEndAnnotation: "true"},
+			},
+		},
+	}
+}
+
+// isListEnd checks if the given object marks the end of a list.
+func isListEnd(obj client.Object) bool {
+	_, found := obj.GetAnnotations()[ListEndAnnotation]
+	return found
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// fakeListCache is a ListCache that stores the object keys.
+type fakeListCache struct {
+	store map[types.NamespacedName]struct{}
+}
+
+func (c *fakeListCache) CacheMiss(obj client.Object) bool {
+	key := types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}
+	if _, found := c.store[key]; found {
+		return false
+	}
+	c.store[key] = struct{}{}
+	return true
+}
+
+func (c *fakeListCache) Keys() []types.NamespacedName {
+	keys := []types.NamespacedName{}
+	for k := range c.store {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (c *fakeListCache) Delete(key types.NamespacedName) {
+	delete(c.store, key)
+}
+
+func newEvent(name string) event.GenericEvent {
+	return event.GenericEvent{
+		Object: &metav1.PartialObjectMetadata{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		},
+	}
+}
+
+// drainQueue returns all the requests in the queue.
+func drainQueue(q workqueue.RateLimitingInterface) []reconcile.Request {
+	reqs := []reconcile.Request{}
+	for q.Len() > 0 {
+		item, _ := q.Get()
+		reqs = append(reqs, item.(reconcile.Request))
+		q.Done(item)
+	}
+	return reqs
+}
+
+func TestEnqueueRequestFromCacheWithDeletion(t *testing.T) {
+	c := &fakeListCache{store: map[types.NamespacedName]struct{}{}}
+	h := NewEnqueueRequestFromCacheWithDeletion(c)
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	keyA := types.NamespacedName{Name: "a", Namespace: "default"}
+	keyB := types.NamespacedName{Name: "b", Namespace: "default"}
+
+	// First list with two new objects.
+	h.Generic(newEvent("a"), q)
+	h.Generic(newEvent("b"), q)
+	h.Generic(NewListEndEvent(), q)
+	assert.ElementsMatch(t, []reconcile.Request{{NamespacedName: keyA}, {NamespacedName: keyB}}, drainQueue(q))
+	assert.False(t, h.IsDeleted(keyA))
+	assert.False(t, h.IsDeleted(keyB))
+
+	// Second list without object b.
+	h.Generic(newEvent("a"), q)
+	h.Generic(NewListEndEvent(), q)
+	assert.Equal(t, []reconcile.Request{{NamespacedName: keyB}}, drainQueue(q))
+	assert.False(t, h.IsDeleted(keyA))
+	assert.True(t, h.IsDeleted(keyB))
+	assert.ElementsMatch(t, []types.NamespacedName{keyA}, c.Keys())
+
+	// Forget the deletion once it's handled.
+	h.Forget(keyB)
+	assert.False(t, h.IsDeleted(keyB))
+
+	// Deleted object isn't enqueued again in the next list.
+	h.Generic(newEvent("a"), q)
+	h.Generic(NewListEndEvent(), q)
+	assert.Empty(t, drainQueue(q))
+	assert.False(t, h.IsDeleted(keyB))
+}
+
+func TestEnqueueRequestFromCacheWithDeletionExpiry(t *testing.T) {
+	c := &fakeListCache{store: map[types.NamespacedName]struct{}{}}
+	h := NewEnqueueRequestFromCacheWithDeletion(c)
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	keyA := types.NamespacedName{Name: "a", Namespace: "default"}
+
+	h.Generic(newEvent("a"), q)
+	h.Generic(NewListEndEvent(), q)
+	h.Generic(NewListEndEvent(), q)
+	assert.True(t, h.IsDeleted(keyA))
+
+	// A deletion that's never forgotten is dropped at the end of the next
+	// list.
+	h.Generic(NewListEndEvent(), q)
+	assert.False(t, h.IsDeleted(keyA))
+	assert.Empty(t, h.deleted)
+}
+
+func TestEnqueueRequestFromCacheWithDeletionReappear(t *testing.T) {
+	c := &fakeListCache{store: map[types.NamespacedName]struct{}{}}
+	h := NewEnqueueRequestFromCacheWithDeletion(c)
+	q := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer q.ShutDown()
+
+	keyA := types.NamespacedName{Name: "a", Namespace: "default"}
+
+	h.Generic(newEvent("a"), q)
+	h.Generic(NewListEndEvent(), q)
+	h.Generic(NewListEndEvent(), q)
+	drainQueue(q)
+	assert.True(t, h.IsDeleted(keyA))
+
+	// The object comes back before the deletion is handled.
+	h.Generic(newEvent("a"), q)
+	assert.Equal(t, []reconcile.Request{{NamespacedName: keyA}}, drainQueue(q))
+	assert.False(t, h.IsDeleted(keyA))
+}
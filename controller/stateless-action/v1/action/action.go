@@ -2,7 +2,16 @@ package action
 
 //go:generate mockgen -destination=mocks/mock_manager.go -package=mocks github.com/darkowlzz/operator-toolkit/controller/stateless-action/v1/action Manager
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrAbort can be returned by Check, wrapped or as is, to signal that the
+// action is no longer applicable and must not be retried, for example, when
+// the target of the action has been deleted. The action then ends without an
+// error.
+var ErrAbort = errors.New("action aborted")
 
 // Manager manages the actions to be executed on objects.
 type Manager interface {
@@ -14,7 +23,8 @@ type Manager interface {
 	// GetObjects returns all the objects on which action should be run.
 	GetObjects(context.Context) ([]interface{}, error)
 
-	// Check checks if the action is needed anymore.
+	// Check checks if the action is needed anymore. An error matching
+	// ErrAbort ends the action early without an error.
 	Check(context.Context, interface{}) (bool, error)
 
 	// Run runs the action on the given object.
@@ -23,3 +33,17 @@ type Manager interface {
 	// Defer is executed at the end of run to execute once run ends.
 	Defer(context.Context, interface{}) error
 }
+
+// StreamingManager is a Manager that streams the objects on which action
+// should be run, instead of returning them all at once. This avoids holding
+// all the objects in memory when acting on a large number of objects. The
+// actions are launched as the objects are received.
+type StreamingManager interface {
+	Manager
+
+	// GetObjectsChan returns a channel of the objects on which action should
+	// be run. The channel must be closed once all the objects are sent, or
+	// when the given context is done. GetObjects isn't called when this is
+	// implemented.
+	GetObjectsChan(context.Context) (<-chan interface{}, error)
+}
@@ -3,13 +3,19 @@ package v1
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 
 	"github.com/darkowlzz/operator-toolkit/controller/stateless-action/v1/action"
 	actionmocks "github.com/darkowlzz/operator-toolkit/controller/stateless-action/v1/action/mocks"
@@ -171,7 +177,7 @@ func TestRunAction(t *testing.T) {
 				inst:          telemetry.NewInstrumentation(instrumentationName),
 			}
 
-			actionErr := r.RunAction(m, objA)
+			actionErr := r.RunAction(context.Background(), m, objA)
 			if tc.wantErr {
 				assert.NotNil(t, actionErr)
 			} else {
@@ -180,3 +186,431 @@ func TestRunAction(t *testing.T) {
 		})
 	}
 }
+
+// streamingManager is an action.StreamingManager that streams the objects
+// from the getObjects function.
+type streamingManager struct {
+	*actionmocks.MockManager
+	getObjects func(context.Context) (<-chan interface{}, error)
+}
+
+func (m *streamingManager) GetObjectsChan(ctx context.Context) (<-chan interface{}, error) {
+	return m.getObjects(ctx)
+}
+
+func TestRunActionManagerStreaming(t *testing.T) {
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+	mc := mocks.NewMockController(mctrl)
+	mam := actionmocks.NewMockManager(mctrl)
+
+	// Stream the objects one by one. The next object is sent only after the
+	// action of the previous object runs, which requires the actions to be
+	// launched as the objects are received.
+	objects := []string{"a", "b", "c"}
+	ran := make(chan interface{})
+	sm := &streamingManager{
+		MockManager: mam,
+		getObjects: func(ctx context.Context) (<-chan interface{}, error) {
+			ch := make(chan interface{})
+			go func() {
+				defer close(ch)
+				for _, obj := range objects {
+					ch <- obj
+					select {
+					case o := <-ran:
+						assert.Equal(t, obj, o)
+					case <-time.After(5 * time.Second):
+						t.Errorf("action not launched for streamed object %q", obj)
+						return
+					}
+				}
+			}()
+			return ch, nil
+		},
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(len(objects))
+	mc.EXPECT().BuildActionManager(gomock.Any()).Return(sm, nil)
+	for _, obj := range objects {
+		mam.EXPECT().GetName(obj).Return(testActionManagerName, nil)
+		mam.EXPECT().Run(gomock.Any(), obj).DoAndReturn(func(ctx context.Context, o interface{}) error {
+			ran <- o
+			return nil
+		})
+		mam.EXPECT().Check(gomock.Any(), obj).Return(false, nil)
+		mam.EXPECT().Defer(gomock.Any(), obj).DoAndReturn(func(ctx context.Context, o interface{}) error {
+			wg.Done()
+			return nil
+		})
+	}
+	// The slice API isn't used by a streaming manager.
+	mam.EXPECT().GetObjects(gomock.Any()).Times(0)
+
+	r := &Reconciler{}
+	r.Init(nil, mc, WithActionTimeout(5*time.Second))
+
+	assert.Nil(t, r.RunActionManager(context.Background(), "x"))
+	wg.Wait()
+
+	// Failure to get the stream is returned.
+	mc.EXPECT().BuildActionManager(gomock.Any()).Return(&streamingManager{
+		MockManager: mam,
+		getObjects: func(ctx context.Context) (<-chan interface{}, error) {
+			return nil, fmt.Errorf("some error")
+		},
+	}, nil)
+	assert.NotNil(t, r.RunActionManager(context.Background(), "x"))
+}
+
+// testContextKey is a context key used in the tests.
+type testContextKey struct{}
+
+func TestRunActionContext(t *testing.T) {
+	objA := "a"
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+	m := actionmocks.NewMockManager(mctrl)
+
+	r := &Reconciler{
+		actionTimeout: 5 * time.Second,
+		inst:          telemetry.NewInstrumentation(instrumentationName),
+	}
+
+	// The value set on the reconcile context is visible in the action.
+	ctx := context.WithValue(context.Background(), testContextKey{}, "reconcile-value")
+	m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+	m.EXPECT().Run(gomock.Any(), objA).DoAndReturn(func(ctx context.Context, o interface{}) error {
+		assert.Equal(t, "reconcile-value", ctx.Value(testContextKey{}))
+		return nil
+	})
+	m.EXPECT().Defer(gomock.Any(), objA)
+	m.EXPECT().Check(gomock.Any(), objA).Return(false, nil)
+	assert.Nil(t, r.RunAction(ctx, m, objA))
+
+	// Cancelling the parent context terminates the action before the next
+	// check.
+	r.actionRetryPeriod = time.Minute
+	var sinkErr error
+	r.resultSink = func(name string, err error) { sinkErr = err }
+	ctx, cancel := context.WithCancel(context.Background())
+	m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+	m.EXPECT().Run(gomock.Any(), objA).DoAndReturn(func(ctx context.Context, o interface{}) error {
+		cancel()
+		return nil
+	})
+	m.EXPECT().Defer(gomock.Any(), objA)
+	assert.Nil(t, r.RunAction(ctx, m, objA))
+	assert.ErrorIs(t, sinkErr, context.Canceled)
+}
+
+func TestGetObject(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cm", Namespace: "test-ns"},
+	}
+	reader := fake.NewClientBuilder().WithObjects(cm).Build()
+
+	testcases := []struct {
+		name    string
+		opts    []ReconcilerOption
+		key     types.NamespacedName
+		wantErr bool
+	}{
+		{
+			name: "read from reader",
+			opts: []ReconcilerOption{WithReader(reader)},
+			key:  client.ObjectKeyFromObject(cm),
+		},
+		{
+			name:    "not found in reader",
+			opts:    []ReconcilerOption{WithReader(reader)},
+			key:     types.NamespacedName{Name: "foo", Namespace: "test-ns"},
+			wantErr: true,
+		},
+		{
+			name:    "no reader",
+			key:     client.ObjectKeyFromObject(cm),
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+
+			// Init without a manager to ensure that the object is read from
+			// the provided reader only.
+			r := &Reconciler{}
+			r.Init(nil, mocks.NewMockController(mctrl), tc.opts...)
+
+			obj, err := r.GetObject(context.Background(), tc.key, &corev1.ConfigMap{})
+			if tc.wantErr {
+				assert.NotNil(t, err)
+				assert.Nil(t, obj)
+				return
+			}
+			assert.Nil(t, err)
+
+			got, ok := obj.(*corev1.ConfigMap)
+			assert.True(t, ok)
+			assert.Equal(t, cm.Name, got.Name)
+			assert.Equal(t, cm.Namespace, got.Namespace)
+		})
+	}
+}
+
+func TestRunActionResultSink(t *testing.T) {
+	objA := "a"
+
+	testErr := fmt.Errorf("some error")
+
+	testcases := []struct {
+		name         string
+		timeout      time.Duration
+		expectations func(m *actionmocks.MockManager)
+		wantName     string
+		wantErr      bool
+	}{
+		{
+			name: "success",
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+				m.EXPECT().Run(gomock.Any(), objA)
+				m.EXPECT().Defer(gomock.Any(), objA)
+				m.EXPECT().Check(gomock.Any(), objA).Return(false, nil)
+			},
+			wantName: testActionManagerName,
+		},
+		{
+			name: "get name failure",
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return("", testErr)
+			},
+			wantErr: true,
+		},
+		{
+			name: "defer failure",
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+				m.EXPECT().Run(gomock.Any(), objA)
+				m.EXPECT().Defer(gomock.Any(), objA).Return(testErr)
+				m.EXPECT().Check(gomock.Any(), objA).Return(false, nil)
+			},
+			wantName: testActionManagerName,
+			wantErr:  true,
+		},
+		{
+			name: "check aborts",
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+				m.EXPECT().Run(gomock.Any(), objA).Return(testErr).Times(2)
+				m.EXPECT().Defer(gomock.Any(), objA)
+				// The action is retried once and then aborted. The action
+				// ends without an error, before the timeout.
+				gomock.InOrder(
+					m.EXPECT().Check(gomock.Any(), objA).Return(true, nil),
+					m.EXPECT().Check(gomock.Any(), objA).Return(false, fmt.Errorf("object deleted: %w", action.ErrAbort)),
+				)
+			},
+			wantName: testActionManagerName,
+		},
+		{
+			name:    "timeout",
+			timeout: 50 * time.Millisecond,
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+				m.EXPECT().Run(gomock.Any(), objA).Return(testErr).AnyTimes()
+				m.EXPECT().Defer(gomock.Any(), objA)
+				// Check always requires the action, until timeout.
+				m.EXPECT().Check(gomock.Any(), objA).Return(true, nil).AnyTimes()
+			},
+			wantName: testActionManagerName,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := actionmocks.NewMockManager(mctrl)
+			tc.expectations(m)
+
+			type result struct {
+				name string
+				err  error
+			}
+			results := []result{}
+
+			r := &Reconciler{
+				actionTimeout:     5 * time.Second,
+				actionRetryPeriod: 10 * time.Millisecond,
+				inst:              telemetry.NewInstrumentation(instrumentationName),
+			}
+			if tc.timeout > 0 {
+				r.actionTimeout = tc.timeout
+			}
+			WithResultSink(func(name string, err error) {
+				results = append(results, result{name: name, err: err})
+			})(r)
+
+			_ = r.RunAction(context.Background(), m, objA)
+
+			// The sink must be called exactly once.
+			assert.Len(t, results, 1)
+			assert.Equal(t, tc.wantName, results[0].name)
+			if tc.wantErr {
+				assert.NotNil(t, results[0].err)
+			} else {
+				assert.Nil(t, results[0].err)
+			}
+		})
+	}
+}
+
+func TestRunActionEvents(t *testing.T) {
+	objA := "a"
+	target := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cm", Namespace: "test-ns"},
+	}
+
+	testErr := fmt.Errorf("some error")
+
+	testcases := []struct {
+		name         string
+		timeout      time.Duration
+		target       interface{}
+		expectations func(m *actionmocks.MockManager)
+		wantEvents   []string
+	}{
+		{
+			name:   "success",
+			target: target,
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+				m.EXPECT().Run(gomock.Any(), objA)
+				m.EXPECT().Defer(gomock.Any(), objA)
+				m.EXPECT().Check(gomock.Any(), objA).Return(false, nil)
+			},
+			wantEvents: []string{"Normal ActionSucceeded Action testAM completed"},
+		},
+		{
+			name:    "timeout",
+			timeout: 50 * time.Millisecond,
+			target:  target,
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+				m.EXPECT().Run(gomock.Any(), objA).Return(testErr).AnyTimes()
+				m.EXPECT().Defer(gomock.Any(), objA)
+				m.EXPECT().Check(gomock.Any(), objA).Return(true, nil).AnyTimes()
+			},
+			wantEvents: []string{"Warning ActionFailed Action testAM failed: action terminated before completion: context deadline exceeded"},
+		},
+		{
+			name:   "defer failure",
+			target: target,
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+				m.EXPECT().Run(gomock.Any(), objA)
+				m.EXPECT().Defer(gomock.Any(), objA).Return(testErr)
+				m.EXPECT().Check(gomock.Any(), objA).Return(false, nil)
+			},
+			wantEvents: []string{"Warning ActionFailed Action testAM failed: failed to run deferred action: some error"},
+		},
+		{
+			name:   "check aborts",
+			target: target,
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+				m.EXPECT().Run(gomock.Any(), objA)
+				m.EXPECT().Defer(gomock.Any(), objA)
+				m.EXPECT().Check(gomock.Any(), objA).Return(false, action.ErrAbort)
+			},
+		},
+		{
+			name: "target not a runtime object",
+			expectations: func(m *actionmocks.MockManager) {
+				m.EXPECT().GetName(gomock.Any()).Return(testActionManagerName, nil)
+				m.EXPECT().Run(gomock.Any(), objA)
+				m.EXPECT().Defer(gomock.Any(), objA)
+				m.EXPECT().Check(gomock.Any(), objA).Return(false, nil)
+			},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			m := actionmocks.NewMockManager(mctrl)
+			tc.expectations(m)
+
+			recorder := record.NewFakeRecorder(10)
+			r := &Reconciler{
+				actionTimeout:     5 * time.Second,
+				actionRetryPeriod: 10 * time.Millisecond,
+				inst:              telemetry.NewInstrumentation(instrumentationName),
+			}
+			if tc.timeout > 0 {
+				r.actionTimeout = tc.timeout
+			}
+			WithEventRecorder(recorder)(r)
+
+			ctx := context.Background()
+			if tc.target != nil {
+				ctx = contextWithTarget(ctx, tc.target)
+			}
+			_ = r.RunAction(ctx, m, objA)
+
+			close(recorder.Events)
+			events := []string{}
+			for e := range recorder.Events {
+				events = append(events, e)
+			}
+			assert.ElementsMatch(t, tc.wantEvents, events)
+		})
+	}
+}
+
+func TestRunActionManagerEvents(t *testing.T) {
+	objA := "a"
+	target := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cm", Namespace: "test-ns"},
+	}
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+	mc := mocks.NewMockController(mctrl)
+	mam := actionmocks.NewMockManager(mctrl)
+
+	mc.EXPECT().BuildActionManager(target).Return(mam, nil)
+	mam.EXPECT().GetObjects(gomock.Any()).Return([]interface{}{objA}, nil)
+	mam.EXPECT().GetName(objA).Return(testActionManagerName, nil)
+	mam.EXPECT().Run(gomock.Any(), objA)
+	mam.EXPECT().Check(gomock.Any(), objA).Return(false, nil)
+	mam.EXPECT().Defer(gomock.Any(), objA)
+
+	recorder := record.NewFakeRecorder(10)
+	r := &Reconciler{}
+	r.Init(nil, mc,
+		WithActionTimeout(5*time.Second),
+		WithActionRetryPeriod(10*time.Millisecond),
+		WithEventRecorder(recorder),
+	)
+
+	// The event of the action on the object is recorded on the target
+	// object of the action manager.
+	assert.Nil(t, r.RunActionManager(context.Background(), target))
+	select {
+	case e := <-recorder.Events:
+		assert.Equal(t, "Normal ActionSucceeded Action testAM completed", e)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for the action event")
+	}
+}
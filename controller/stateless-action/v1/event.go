@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+
+	eventv1 "github.com/darkowlzz/operator-toolkit/event/v1"
+)
+
+// Reasons of the events recorded by the Reconciler.
+const (
+	// EventReasonActionSucceeded is used when an action completes
+	// successfully.
+	EventReasonActionSucceeded = "ActionSucceeded"
+	// EventReasonActionFailed is used when an action fails and isn't retried
+	// anymore, like when it times out.
+	EventReasonActionFailed = "ActionFailed"
+)
+
+// targetContextKey is the context key of the target object of the actions.
+type targetContextKey struct{}
+
+// contextWithTarget returns a copy of the given context with the given target
+// object of the actions.
+func contextWithTarget(ctx context.Context, obj interface{}) context.Context {
+	return context.WithValue(ctx, targetContextKey{}, obj)
+}
+
+// eventObject returns the object to record the events of an action on. It's
+// the target object of the reconciliation in the given context, else the
+// object the action runs on. False is returned if it isn't a runtime object.
+func eventObject(ctx context.Context, o interface{}) (runtime.Object, bool) {
+	if target := ctx.Value(targetContextKey{}); target != nil {
+		o = target
+	}
+	obj, ok := o.(runtime.Object)
+	return obj, ok
+}
+
+// actionEvent records the outcome of the named action as an event, if an event
+// recorder is configured. A nil error records a normal event, else a warning
+// event.
+func (r *Reconciler) actionEvent(ctx context.Context, o interface{}, name string, err error) {
+	if r.recorder == nil {
+		return
+	}
+	obj, ok := eventObject(ctx, o)
+	if !ok {
+		return
+	}
+	if err != nil {
+		r.recorder.Event(obj, eventv1.K8sEventTypeWarning, EventReasonActionFailed, fmt.Sprintf("Action %s failed: %v", name, err))
+		return
+	}
+	r.recorder.Event(obj, eventv1.K8sEventTypeNormal, EventReasonActionSucceeded, fmt.Sprintf("Action %s completed", name))
+}
@@ -11,6 +11,7 @@ import (
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -28,10 +29,13 @@ type Reconciler struct {
 	name   string
 	ctrlr  Controller
 	client client.Client
+	reader client.Reader
 	scheme *runtime.Scheme
 
 	actionRetryPeriod time.Duration
 	actionTimeout     time.Duration
+	resultSink        func(name string, err error)
+	recorder          record.EventRecorder
 	inst              *telemetry.Instrumentation
 }
 
@@ -58,6 +62,27 @@ func WithActionTimeout(duration time.Duration) ReconcilerOption {
 	}
 }
 
+// WithResultSink sets a function that's called with the action name and the
+// action result at the end of every action run. The error is nil when the
+// action completes successfully. This can be used to aggregate the action
+// outcomes for metrics or status reporting.
+func WithResultSink(sink func(name string, err error)) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.resultSink = sink
+	}
+}
+
+// WithEventRecorder sets the event recorder used to record the outcome of the
+// actions as events on the target object. A normal event is recorded when an
+// action completes successfully and a warning event when it fails, like on
+// timeout. No event is recorded for an aborted action or when the target
+// object isn't a runtime object.
+func WithEventRecorder(recorder record.EventRecorder) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.recorder = recorder
+	}
+}
+
 // WithScheme sets the runtime Scheme of the Reconciler.
 func WithScheme(scheme *runtime.Scheme) ReconcilerOption {
 	return func(r *Reconciler) {
@@ -65,6 +90,15 @@ func WithScheme(scheme *runtime.Scheme) ReconcilerOption {
 	}
 }
 
+// WithReader sets the reader used by GetObject to fetch the target objects.
+// This can be used to serve the reads from a cache or a lister to reduce the
+// API server load on high event volume. Defaults to the manager's client.
+func WithReader(reader client.Reader) ReconcilerOption {
+	return func(r *Reconciler) {
+		r.reader = reader
+	}
+}
+
 // WithInstrumentation configures the instrumentation  of the Reconciler.
 func WithInstrumentation(tp trace.TracerProvider, mp metric.MeterProvider, log logr.Logger) ReconcilerOption {
 	return func(r *Reconciler) {
@@ -96,9 +130,29 @@ func (r *Reconciler) Init(mgr ctrl.Manager, ctrlr Controller, opts ...Reconciler
 	if r.inst == nil {
 		WithInstrumentation(nil, nil, ctrl.Log)(r)
 	}
+
+	// Use the client as the default reader.
+	if r.reader == nil && r.client != nil {
+		r.reader = r.client
+	}
+}
+
+// GetObject is a helper for implementing the Controller GetObject. It fetches
+// the object with the given key into obj using the configured reader and
+// returns it.
+func (r *Reconciler) GetObject(ctx context.Context, key client.ObjectKey, obj client.Object) (interface{}, error) {
+	if r.reader == nil {
+		return nil, errors.New("reconciler has no reader, set one with WithReader")
+	}
+	if err := r.reader.Get(ctx, key, obj); err != nil {
+		return nil, err
+	}
+	return obj, nil
 }
 
 func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
+	// Correlate the logs and the traces of the reconciliation with an ID.
+	ctx, _ = telemetry.WithCorrelationID(ctx)
 	ctx, span, _, log := r.inst.Start(ctx, r.name+": Reconcile")
 	defer span.End()
 
@@ -156,6 +210,9 @@ func (r *Reconciler) RunActionManager(ctx context.Context, o interface{}) error
 	ctx, span, _, log := r.inst.Start(ctx, r.name+": run action manager")
 	defer span.End()
 
+	// Record the action events on the target object.
+	ctx = contextWithTarget(ctx, o)
+
 	span.AddEvent("Build action manager")
 	actmgr, err := r.ctrlr.BuildActionManager(o)
 	if err != nil {
@@ -163,6 +220,31 @@ func (r *Reconciler) RunActionManager(ctx context.Context, o interface{}) error
 		return errors.Wrapf(err, "failed to build action manager")
 	}
 
+	// Stream the objects to run action on if supported.
+	if sm, ok := actmgr.(action.StreamingManager); ok {
+		objects, err := sm.GetObjectsChan(ctx)
+		if err != nil {
+			span.RecordError(err)
+			return errors.Wrapf(err, "failed to get objects from action manager")
+		}
+
+		count := 0
+		for {
+			select {
+			case obj, ok := <-objects:
+				if !ok {
+					span.AddEvent(fmt.Sprintf("Running actions for %d objects", count))
+					return nil
+				}
+				count++
+				r.launchAction(ctx, actmgr, obj, log)
+			case <-ctx.Done():
+				span.AddEvent(fmt.Sprintf("Running actions for %d objects, context done", count))
+				return errors.Wrapf(ctx.Err(), "failed to get all the objects from action manager")
+			}
+		}
+	}
+
 	// Get the objects to run action on.
 	objects, err := actmgr.GetObjects(ctx)
 	if err != nil {
@@ -172,21 +254,59 @@ func (r *Reconciler) RunActionManager(ctx context.Context, o interface{}) error
 
 	span.AddEvent(fmt.Sprintf("Running actions for %d objects", len(objects)))
 
-	// Run the action in a goroutine.
 	for _, obj := range objects {
-		go func(o interface{}) {
-			if runErr := r.RunAction(actmgr, o); runErr != nil {
-				log.Error(runErr, "failed to run action")
-			}
-		}(obj)
+		r.launchAction(ctx, actmgr, obj, log)
 	}
 
 	return nil
 }
 
+// launchAction runs the action on the given object in a goroutine.
+func (r *Reconciler) launchAction(ctx context.Context, actmgr action.Manager, o interface{}, log logr.Logger) {
+	go func() {
+		if runErr := r.RunAction(ctx, actmgr, o); runErr != nil {
+			log.Error(runErr, "failed to run action")
+		}
+	}()
+}
+
 // RunAction checks if an action needs to be run before running it. It also
-// runs a deferred function at the end.
-func (r *Reconciler) RunAction(actmgr action.Manager, o interface{}) (retErr error) {
+// runs a deferred function at the end and records the outcome of the action
+// as an event, if an event recorder is configured. The action runs with a context derived
+// from the given context, keeping its values and trace, and is cancelled when
+// the given context is done or the action times out.
+func (r *Reconciler) RunAction(ctx context.Context, actmgr action.Manager, o interface{}) (retErr error) {
+	var name string
+	// actionErr is the action failure that's not returned, like action
+	// timeout, but reported to the result sink.
+	var actionErr error
+	// aborted is true when the action ended because it's no longer
+	// applicable.
+	var aborted bool
+
+	// Report the result to the result sink at the very end, after the
+	// deferred action function.
+	if r.resultSink != nil {
+		defer func() {
+			if retErr != nil {
+				r.resultSink(name, retErr)
+				return
+			}
+			r.resultSink(name, actionErr)
+		}()
+	}
+
+	// Record the outcome of the action after the deferred action function.
+	defer func() {
+		if retErr != nil {
+			r.actionEvent(ctx, o, name, retErr)
+			return
+		}
+		if !aborted {
+			r.actionEvent(ctx, o, name, actionErr)
+		}
+	}()
+
 	name, err := actmgr.GetName(o)
 	if err != nil {
 		retErr = errors.Wrapf(err, "failed to get action manager name")
@@ -195,7 +315,7 @@ func (r *Reconciler) RunAction(actmgr action.Manager, o interface{}) (retErr err
 
 	// Create a context with timeout to be able to cancel the action if it
 	// can't be completed within the given time.
-	ctx, cancel := context.WithTimeout(context.Background(), r.actionTimeout)
+	ctx, cancel := context.WithTimeout(ctx, r.actionTimeout)
 	defer cancel()
 
 	ctx, span, _, log := r.inst.Start(ctx, r.name+": run action")
@@ -231,6 +351,13 @@ func (r *Reconciler) RunAction(actmgr action.Manager, o interface{}) (retErr err
 		select {
 		case <-time.After(r.actionRetryPeriod):
 			checkResult, checkErr := actmgr.Check(ctx, o)
+			if errors.Is(checkErr, action.ErrAbort) {
+				// The action is no longer applicable, end the action.
+				span.AddEvent("Check aborted the action")
+				log.Info("action aborted", "reason", checkErr)
+				aborted = true
+				return
+			}
 			if checkErr != nil {
 				log.Error(checkErr, "failed to perform action check, retrying")
 				continue
@@ -247,6 +374,7 @@ func (r *Reconciler) RunAction(actmgr action.Manager, o interface{}) (retErr err
 			}
 		case <-ctx.Done():
 			log.Info("context cancelled, terminating action")
+			actionErr = errors.Wrapf(ctx.Err(), "action terminated before completion")
 			return
 		}
 	}
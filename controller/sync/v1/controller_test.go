@@ -3,7 +3,9 @@ package v1
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
@@ -143,3 +145,46 @@ func TestReconcile(t *testing.T) {
 		})
 	}
 }
+
+func TestStartStopSync(t *testing.T) {
+	var count int32
+	sf := NewSyncFunc(func() {
+		atomic.AddInt32(&count, 1)
+	}, 5*time.Millisecond, time.Millisecond)
+
+	sr := &Reconciler{}
+	_ = sr.Init(nil, nil, nil, nil, WithSyncFuncs([]SyncFunc{sf}))
+
+	// Wait for the sync func to run.
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&count) > 0
+	}, time.Second, time.Millisecond)
+
+	// Starting an already running sync fails.
+	assert.NotNil(t, sr.StartSync(context.Background()))
+
+	// Stop the sync and ensure the sync func ceases to run.
+	sr.StopSync()
+	stoppedCount := atomic.LoadInt32(&count)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, stoppedCount, atomic.LoadInt32(&count))
+
+	// Restart the sync and ensure the sync func resumes.
+	assert.Nil(t, sr.StartSync(context.Background()))
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&count) > stoppedCount
+	}, time.Second, time.Millisecond)
+
+	sr.StopSync()
+
+	// Cancelling the context passed to StartSync stops the sync and allows
+	// starting it again.
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.Nil(t, sr.StartSync(ctx))
+	cancel()
+	assert.Eventually(t, func() bool {
+		return sr.StartSync(context.Background()) == nil
+	}, time.Second, time.Millisecond)
+
+	sr.StopSync()
+}
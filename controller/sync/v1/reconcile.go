@@ -10,9 +10,12 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	tkctrl "github.com/darkowlzz/operator-toolkit/controller"
+	"github.com/darkowlzz/operator-toolkit/telemetry"
 )
 
 func (s *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, reterr error) {
+	// Correlate the logs and the traces of the reconciliation with an ID.
+	ctx, _ = telemetry.WithCorrelationID(ctx)
 	ctx, span, _, log := s.Inst.Start(ctx, "Reconcile")
 	defer span.End()
 
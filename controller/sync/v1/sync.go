@@ -1,6 +1,10 @@
 package v1
 
 import (
+	"context"
+	"errors"
+	"sync"
+
 	"github.com/go-logr/logr"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
@@ -25,6 +29,13 @@ type Reconciler struct {
 	Scheme        *runtime.Scheme
 	SyncFuncs     []SyncFunc
 	Inst          *telemetry.Instrumentation
+
+	// syncMu guards the sync lifecycle.
+	syncMu sync.Mutex
+	// syncCancel stops the running sync functions.
+	syncCancel context.CancelFunc
+	// syncDone is closed once the running sync functions return.
+	syncDone chan struct{}
 }
 
 // ReconcilerOption is used to configure Reconciler.
@@ -112,9 +123,72 @@ func (s *Reconciler) Init(mgr ctrl.Manager, ctrlr Controller, prototype client.O
 	return nil
 }
 
-// RunSyncFuncs runs all the SyncFuncs in go routines.
+// RunSyncFuncs runs all the SyncFuncs in go routines. It's a no-op if the
+// SyncFuncs are already running.
 func (s *Reconciler) RunSyncFuncs() {
+	_ = s.StartSync(context.Background())
+}
+
+// StartSync runs all the SyncFuncs in go routines until the given context is
+// cancelled or StopSync is called. An error is returned if the SyncFuncs are
+// already running. To run the sync with a new configuration, stop the sync,
+// update the SyncFuncs and start the sync again.
+func (s *Reconciler) StartSync(ctx context.Context) error {
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	if s.syncCancel != nil {
+		return errors.New("sync already running")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	s.syncCancel = cancel
+	s.syncDone = done
+
+	var wg sync.WaitGroup
 	for _, sf := range s.SyncFuncs {
-		go sf.Run()
+		wg.Add(1)
+		go func(sf SyncFunc) {
+			defer wg.Done()
+			sf.RunWithContext(ctx)
+		}(sf)
 	}
+
+	// Mark the sync as stopped once the context is cancelled and all the
+	// sync functions return, also when the parent context is cancelled
+	// without calling StopSync.
+	go func() {
+		<-ctx.Done()
+		wg.Wait()
+
+		s.syncMu.Lock()
+		if s.syncDone == done {
+			s.syncCancel = nil
+			s.syncDone = nil
+		}
+		s.syncMu.Unlock()
+		close(done)
+	}()
+
+	return nil
+}
+
+// StopSync stops all the running SyncFuncs and waits for them to return. It's
+// a no-op if the SyncFuncs are not running.
+func (s *Reconciler) StopSync() {
+	s.syncMu.Lock()
+	cancel, done := s.syncCancel, s.syncDone
+	s.syncCancel = nil
+	s.syncDone = nil
+	s.syncMu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	// Wait without holding the lock, the sync functions may be blocked on
+	// it.
+	cancel()
+	<-done
 }
@@ -1,6 +1,7 @@
 package v1
 
 import (
+	"context"
 	"time"
 )
 
@@ -37,8 +38,19 @@ func NewSyncFunc(f func(), p time.Duration, d time.Duration) SyncFunc {
 
 // Run runs the SyncFunc function at the SyncFunc period.
 func (sf SyncFunc) Run() {
+	sf.RunWithContext(context.Background())
+}
+
+// RunWithContext runs the SyncFunc function at the SyncFunc period until the
+// given context is cancelled. An in-progress call to the function is not
+// interrupted.
+func (sf SyncFunc) RunWithContext(ctx context.Context) {
 	// Wait before starting the sync func.
-	time.Sleep(sf.startupSyncDelay)
+	select {
+	case <-time.After(sf.startupSyncDelay):
+	case <-ctx.Done():
+		return
+	}
 
 	// Run the sync function before starting a ticker based run.
 	sf.Call()
@@ -49,8 +61,12 @@ func (sf SyncFunc) Run() {
 	defer ticker.Stop()
 
 	for {
-		<-ticker.C
-		sf.Call()
+		select {
+		case <-ticker.C:
+			sf.Call()
+		case <-ctx.Done():
+			return
+		}
 	}
 }
 
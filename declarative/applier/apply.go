@@ -11,8 +11,13 @@ import (
 	"k8s.io/kubectl/pkg/cmd/apply"
 	cmdDelete "k8s.io/kubectl/pkg/cmd/delete"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// DefaultFieldManager is the field manager of the server-side applies without
+// a client.FieldOwner option.
+const DefaultFieldManager = "operator-toolkit"
+
 // NOTE: This file is mostly based on the kubebuilder-declarative-pattern repo,
 // with slight modifications.
 // Refer: https://github.com/kubernetes-sigs/kubebuilder-declarative-pattern/blob/f77bb4933dfbae404f03e34b01c84e268cc4b966/pkg/patterns/declarative/pkg/applier/direct.go
@@ -45,6 +50,29 @@ func (d *DirectApplier) Apply(ctx context.Context,
 	validate bool,
 	extraArgs ...string,
 ) error {
+	return d.apply(namespace, manifest, nil)
+}
+
+// ApplyWithOptions server-side applies the given manifest with the given
+// patch options. client.FieldOwner sets the field manager of the apply,
+// defaulting to DefaultFieldManager, and client.ForceOwnership forces the
+// apply on conflicts with the other field managers, taking the ownership of
+// the conflicting fields.
+// NOTE: This method is not present in upstream.
+func (d *DirectApplier) ApplyWithOptions(ctx context.Context,
+	namespace string,
+	manifest string,
+	validate bool,
+	opts ...client.PatchOption,
+) error {
+	patchOpts := &client.PatchOptions{}
+	patchOpts.ApplyOptions(opts)
+	return d.apply(namespace, manifest, patchOpts)
+}
+
+// apply applies the given manifest. The apply is server-side if patch options
+// are given.
+func (d *DirectApplier) apply(namespace string, manifest string, patchOpts *client.PatchOptions) error {
 	// NOTE: This is modified from the upstream to allow configuring IOStreams.
 	// ioStreams := genericclioptions.IOStreams{
 	//     In:     os.Stdin,
@@ -72,6 +100,14 @@ func (d *DirectApplier) Apply(ctx context.Context,
 	applyOpts.DeleteOptions = &cmdDelete.DeleteOptions{
 		IOStreams: d.ioStreams,
 	}
+	if patchOpts != nil {
+		applyOpts.ServerSideApply = true
+		applyOpts.FieldManager = patchOpts.FieldManager
+		if applyOpts.FieldManager == "" {
+			applyOpts.FieldManager = DefaultFieldManager
+		}
+		applyOpts.ForceConflicts = patchOpts.Force != nil && *patchOpts.Force
+	}
 
 	return applyOpts.Run()
 }
@@ -0,0 +1,145 @@
+package declarative
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/darkowlzz/operator-toolkit/declarative/kubectl"
+	tkerror "github.com/darkowlzz/operator-toolkit/error"
+)
+
+// ApplyAction is the change made to an object by an apply.
+type ApplyAction string
+
+const (
+	// ObjectCreated is the action of an object that didn't exist.
+	ObjectCreated ApplyAction = "Created"
+	// ObjectUpdated is the action of an existing object that was modified.
+	ObjectUpdated ApplyAction = "Updated"
+	// ObjectUnchanged is the action of an existing object that wasn't
+	// modified, the apply was a no-op.
+	ObjectUnchanged ApplyAction = "Unchanged"
+	// ObjectFailed is the action of an object that failed to be applied.
+	ObjectFailed ApplyAction = "Failed"
+)
+
+// ApplyResult is the outcome of applying a built object.
+type ApplyResult struct {
+	// GroupVersionKind is the GVK of the object.
+	GroupVersionKind schema.GroupVersionKind
+	// Key is the namespaced name of the object.
+	Key types.NamespacedName
+	// Action is the change made to the object.
+	Action ApplyAction
+	// Err is the error of a failed apply.
+	Err error
+}
+
+// ApplyWithResults applies the built objects one by one, like Apply, and
+// returns the outcome of each object, to report what changed, like the
+// install progress of a package. The live objects are fetched before and
+// after the apply. An existing object is unchanged if its resource version
+// doesn't change, the API server doesn't update an object on a no-op apply.
+// All the objects are applied even if some of them fail. The returned error
+// aggregates the failures. ApplyWithResults requires a client to be set with
+// WithClient.
+func (b *Builder) ApplyWithResults(ctx context.Context) ([]ApplyResult, error) {
+	if b.client == nil {
+		return nil, errors.New("apply with results requires a client, set one with WithClient")
+	}
+
+	var applier kubectl.OptionsApplier
+	var groups []*applyGroup
+	if b.serverSideApply {
+		var ok bool
+		if applier, ok = b.kubectl.(kubectl.OptionsApplier); !ok {
+			return nil, errors.Errorf("kubectl client %T doesn't support server-side apply", b.kubectl)
+		}
+		var err error
+		if groups, err = groupByFieldManager(b.manifest, b.fieldManager, b.forceConflicts); err != nil {
+			return nil, err
+		}
+	} else {
+		objs, err := decodeObjects(b.manifest)
+		if err != nil {
+			return nil, err
+		}
+		groups = []*applyGroup{{objs: objs}}
+	}
+
+	results := []ApplyResult{}
+	errs := []error{}
+	for _, g := range groups {
+		for _, obj := range g.objs {
+			r := ApplyResult{
+				GroupVersionKind: obj.GetObjectKind().GroupVersionKind(),
+				Key:              client.ObjectKeyFromObject(obj),
+			}
+			r.Action, r.Err = b.applyObject(ctx, applier, g, obj)
+			if r.Err != nil {
+				r.Action = ObjectFailed
+				errs = append(errs, r.Err)
+			}
+			results = append(results, r)
+		}
+	}
+	return results, tkerror.NewAggregate(errs)
+}
+
+// applyObject applies the given object of an apply group and returns the
+// change made to it. The object is server-side applied if an applier is
+// given.
+func (b *Builder) applyObject(ctx context.Context, applier kubectl.OptionsApplier, g *applyGroup, obj client.Object) (ApplyAction, error) {
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	key := client.ObjectKeyFromObject(obj)
+
+	before, found, err := b.liveResourceVersion(ctx, obj)
+	if err != nil {
+		return ObjectFailed, errors.Wrapf(err, "failed to get %s %s", kind, key)
+	}
+
+	m, err := encodeObjects([]client.Object{obj})
+	if err != nil {
+		return ObjectFailed, err
+	}
+	if applier != nil {
+		err = applier.ApplyWithOptions(ctx, "", m, true, g.patchOptions()...)
+	} else {
+		err = b.kubectl.Apply(ctx, "", m, true)
+	}
+	if err != nil {
+		return ObjectFailed, errors.Wrapf(err, "failed to apply %s %s", kind, key)
+	}
+
+	if !found {
+		return ObjectCreated, nil
+	}
+	after, _, err := b.liveResourceVersion(ctx, obj)
+	if err != nil {
+		return ObjectFailed, errors.Wrapf(err, "failed to get %s %s", kind, key)
+	}
+	if after != before {
+		return ObjectUpdated, nil
+	}
+	return ObjectUnchanged, nil
+}
+
+// liveResourceVersion returns the resource version of the live version of the
+// given object and true if it's found.
+func (b *Builder) liveResourceVersion(ctx context.Context, obj client.Object) (string, bool, error) {
+	live := &unstructured.Unstructured{}
+	live.SetGroupVersionKind(obj.GetObjectKind().GroupVersionKind())
+	if err := b.client.Get(ctx, client.ObjectKeyFromObject(obj), live); err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return live.GetResourceVersion(), true, nil
+}
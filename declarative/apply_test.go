@@ -0,0 +1,133 @@
+package declarative
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// clientKubectl is a KubectlClient that applies the manifests with a client.
+// The existing objects are updated only if their content changes, like the
+// API server does on apply.
+type clientKubectl struct {
+	basicKubectl
+	client client.Client
+	// failing are the names of the objects that fail to apply.
+	failing map[string]bool
+}
+
+func (k *clientKubectl) Apply(ctx context.Context, namespace string, manifest string, validate bool, extraArgs ...string) error {
+	objs, err := decodeObjects(manifest)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if k.failing[obj.GetName()] {
+			return fmt.Errorf("apply of %q rejected", obj.GetName())
+		}
+		desired := obj.(*unstructured.Unstructured)
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(desired.GroupVersionKind())
+		if err := k.client.Get(ctx, client.ObjectKeyFromObject(desired), live); err != nil {
+			if !apierrors.IsNotFound(err) {
+				return err
+			}
+			if err := k.client.Create(ctx, desired); err != nil {
+				return err
+			}
+			continue
+		}
+		liveObj := live.DeepCopy().Object
+		removeServerFields(liveObj)
+		if reflect.DeepEqual(liveObj, desired.Object) {
+			continue
+		}
+		desired.SetResourceVersion(live.GetResourceVersion())
+		if err := k.client.Update(ctx, desired); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestApplyWithResults(t *testing.T) {
+	webKey := types.NamespacedName{Name: "web", Namespace: "default"}
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	k := &clientKubectl{client: cli}
+
+	newBuilder := func(deployment string) *Builder {
+		fs := newWebFileSystem(t)
+		assert.Nil(t, fs.WriteFile("web/deployment.yaml", []byte(deployment)))
+		b, err := NewBuilder("web", fs, WithKubectlClient(k), WithClient(cli))
+		assert.Nil(t, err)
+		return b
+	}
+
+	cases := []struct {
+		name       string
+		deployment string
+		failing    map[string]bool
+		wantAction ApplyAction
+		wantErr    bool
+	}{
+		{
+			name:       "create",
+			deployment: webDeployment,
+			wantAction: ObjectCreated,
+		},
+		{
+			name:       "no-op update",
+			deployment: webDeployment,
+			wantAction: ObjectUnchanged,
+		},
+		{
+			name:       "update",
+			deployment: strings.Replace(webDeployment, "replicas: 1", "replicas: 3", 1),
+			wantAction: ObjectUpdated,
+		},
+		{
+			name:       "failure",
+			deployment: strings.Replace(webDeployment, "replicas: 1", "replicas: 5", 1),
+			failing:    map[string]bool{"web": true},
+			wantAction: ObjectFailed,
+			wantErr:    true,
+		},
+	}
+
+	// The cases run in order against the same cluster.
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			k.failing = tc.failing
+			results, err := newBuilder(tc.deployment).ApplyWithResults(context.TODO())
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+			if assert.Len(t, results, 1) {
+				assert.Equal(t, "Deployment", results[0].GroupVersionKind.Kind)
+				assert.Equal(t, webKey, results[0].Key)
+				assert.Equal(t, tc.wantAction, results[0].Action)
+				assert.Equal(t, tc.wantErr, results[0].Err != nil)
+			}
+		})
+	}
+}
+
+func TestApplyWithResultsWithoutClient(t *testing.T) {
+	b, err := NewBuilder("web", newWebFileSystem(t), WithKubectlClient(basicKubectl{}))
+	assert.Nil(t, err)
+	_, err = b.ApplyWithResults(context.TODO())
+	assert.Error(t, err)
+}
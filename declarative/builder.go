@@ -1,10 +1,18 @@
 package declarative
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"io"
 	"os"
+	"path/filepath"
 	"strings"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/api/konfig"
 
@@ -33,6 +41,28 @@ type Builder struct {
 	commonTransforms []transform.TransformFunc
 	// kMutateFuncs are kustomization mutation functions.
 	kMutateFuncs []kustomize.MutateFunc
+	// buildCache is the cache of the kustomize build results.
+	buildCache *BuildCache
+	// client is the kubernetes client used for fetching the live objects.
+	client client.Client
+	// rawManifestDir is the directory of plain manifests to build without
+	// kustomize.
+	rawManifestDir string
+	// ownerRefs are the owner references set on all the manifests in a
+	// package.
+	ownerRefs []metav1.OwnerReference
+	// serverSideApply enables applying the manifests with server-side apply.
+	serverSideApply bool
+	// fieldManager is the default field manager of the server-side applies.
+	fieldManager string
+	// forceConflicts is the default conflict resolution of the server-side
+	// applies.
+	forceConflicts bool
+	// templateData is the data the manifest templates are rendered with.
+	templateData map[string]interface{}
+	// overlay is the path of the kustomize overlay to build, relative to the
+	// package.
+	overlay string
 	// manifest is the resource manifest built by the builder.
 	manifest string
 }
@@ -69,6 +99,87 @@ func WithKustomizeMutationFunc(kMutateFuncs []kustomize.MutateFunc) BuilderOptio
 	}
 }
 
+// WithClient sets the kubernetes client used by the builder to read the live
+// objects from the cluster.
+func WithClient(c client.Client) BuilderOption {
+	return func(b *Builder) {
+		b.client = c
+	}
+}
+
+// WithBuildCache sets a cache for the kustomize build results. The cache is
+// keyed on a hash of the transformed package content and can be shared by
+// multiple builders.
+func WithBuildCache(c *BuildCache) BuilderOption {
+	return func(b *Builder) {
+		b.buildCache = c
+	}
+}
+
+// WithRawManifestDir sets a directory of plain YAML manifests in the
+// filesystem to be built instead of kustomizing the package. All the *.yaml
+// and *.yml files at the top level of the directory are built, in lexical
+// order, without requiring a kustomization. Multi-document manifests are split
+// into individual documents, named with the document index suffixed to the
+// file name, before the transforms are applied. The transforms of a
+// multi-document manifest are applied to all of its documents. The
+// kustomization mutation functions and the build cache are not used.
+func WithRawManifestDir(path string) BuilderOption {
+	return func(b *Builder) {
+		b.rawManifestDir = path
+	}
+}
+
+// WithOwnerReferences sets the owner references of all the manifests in a
+// package. The owner references are set along with the common transforms.
+func WithOwnerReferences(ownerRefs ...metav1.OwnerReference) BuilderOption {
+	return func(b *Builder) {
+		b.ownerRefs = ownerRefs
+	}
+}
+
+// WithServerSideApply enables applying the manifests with server-side apply,
+// with the given field manager. With forceConflicts, the apply takes the
+// ownership of the fields managed by the other field managers instead of
+// failing, like when adopting the objects installed by another tool. The
+// field manager and conflict resolution can be set per manifest with
+// transform.SetFieldManagerFunc, the objects with different field managers
+// are applied separately. This requires a kubectl client that implements
+// kubectl.OptionsApplier.
+func WithServerSideApply(fieldManager string, forceConflicts bool) BuilderOption {
+	return func(b *Builder) {
+		b.serverSideApply = true
+		b.fieldManager = fieldManager
+		b.forceConflicts = forceConflicts
+	}
+}
+
+// WithTemplateData enables rendering the manifest templates of the package
+// with the given data before any transforms are applied. All the
+// *.yaml.tmpl files in the package, and in the raw manifest directory, are
+// rendered with text/template and replaced with the rendered *.yaml
+// manifests, which can then be referred to by the transforms and the
+// kustomization. The other files are never templated. Referring to a key
+// missing in the data fails the build.
+func WithTemplateData(data map[string]interface{}) BuilderOption {
+	return func(b *Builder) {
+		b.templateData = data
+	}
+}
+
+// WithOverlay sets the path of a kustomize overlay in the package to build,
+// relative to the package, like "overlays/prod". This allows building
+// environment specific manifests from the same package. The overlay must
+// contain a kustomization. The transforms are applied to the whole package,
+// as without an overlay, but the kustomization mutation functions are applied
+// to the kustomization of the overlay. Overlays can't be used with
+// WithRawManifestDir.
+func WithOverlay(path string) BuilderOption {
+	return func(b *Builder) {
+		b.overlay = path
+	}
+}
+
 // NewBuilder builds a package, given a filesystem and build options and
 // returns a builder which can be used to apply or delete the built resource
 // manifests.
@@ -90,6 +201,43 @@ func NewBuilder(packageName string, fs filesys.FileSystem, opts ...BuilderOption
 		opt(builder)
 	}
 
+	// Validate the overlay before building.
+	if builder.overlay != "" {
+		if builder.rawManifestDir != "" {
+			return nil, errors.New("overlay can't be used with a raw manifest directory")
+		}
+		if _, err := kustomize.LoadKustomizationFromPackage(builder.fs, builder.buildPath()); err != nil {
+			return nil, errors.Wrapf(err, "invalid overlay %q in package %q", builder.overlay, builder.packageName)
+		}
+	}
+
+	// Render the manifest templates before transforming them.
+	if builder.templateData != nil {
+		dirs := []string{builder.packageName}
+		if builder.rawManifestDir != "" {
+			dirs = append(dirs, builder.rawManifestDir)
+		}
+		for _, dir := range dirs {
+			if !builder.fs.Exists(dir) {
+				continue
+			}
+			if err := renderTemplates(builder.fs, dir, builder.templateData); err != nil {
+				return nil, errors.Wrapf(err, "failed to render templates in %q", dir)
+			}
+		}
+	}
+
+	// Split the raw manifests to transform them individually.
+	var rawFiles []string
+	if builder.rawManifestDir != "" {
+		files, splits, err := splitRawManifests(builder.fs, builder.rawManifestDir)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read raw manifests in %q", builder.rawManifestDir)
+		}
+		rawFiles = files
+		builder.manifestTransform = splitManifestTransform(builder.manifestTransform, splits)
+	}
+
 	// Apply manifest transforms.
 	if builder.manifestTransform != nil && len(builder.manifestTransform) > 0 {
 		if err := transform.Transform(builder.fs, builder.manifestTransform); err != nil {
@@ -97,45 +245,153 @@ func NewBuilder(packageName string, fs filesys.FileSystem, opts ...BuilderOption
 		}
 	}
 
+	// Set the owner references with the common transforms.
+	commonTransforms := append([]transform.TransformFunc{}, builder.commonTransforms...)
+	if len(builder.ownerRefs) > 0 {
+		commonTransforms = append(commonTransforms, transform.SetOwnerReference(builder.ownerRefs))
+	}
+
 	// Apply common transforms.
-	if len(builder.commonTransforms) > 0 {
+	if len(commonTransforms) > 0 {
 		// Load all the non-kustomization files and transform them all with the
 		// common transforms.
-		mt, err := ManifestTransformForPackage(builder.fs, builder.packageName)
+		mt, err := builder.packageManifestTransform(rawFiles)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to get ManifestTransform for package %q", builder.packageName)
 		}
-		if err := transform.Transform(builder.fs, mt, builder.commonTransforms...); err != nil {
+		if err := transform.Transform(builder.fs, mt, commonTransforms...); err != nil {
 			return nil, errors.Wrapf(err, "failed to transform package %q", builder.packageName)
 		}
 	}
 
+	// Build the raw manifests without kustomization.
+	if builder.rawManifestDir != "" {
+		m, err := buildRawManifests(builder.fs, rawFiles)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to build raw manifests in %q", builder.rawManifestDir)
+		}
+		builder.manifest = string(m)
+		return builder, nil
+	}
+
 	// Run mutation and kustomization to obtain the final manifest.
-	m, err := kustomize.MutateAndKustomize(builder.fs, builder.kMutateFuncs, builder.packageName)
+	if builder.buildCache != nil {
+		m, err := builder.cachedBuild()
+		if err != nil {
+			return nil, err
+		}
+		builder.manifest = string(m)
+		return builder, nil
+	}
+
+	m, err := kustomize.MutateAndKustomize(builder.fs, builder.kMutateFuncs, builder.buildPath())
 	if err != nil {
-		return nil, errors.Wrapf(err, "failed to mutate and kustomization package %q", builder.packageName)
+		return nil, errors.Wrapf(err, "failed to mutate and kustomization package %q", builder.buildPath())
 	}
 	builder.manifest = string(m)
 
 	return builder, nil
 }
 
+// buildPath returns the path of the kustomization to build, the package or
+// the overlay in the package.
+func (b *Builder) buildPath() string {
+	if b.overlay == "" {
+		return b.packageName
+	}
+	return filepath.Join(b.packageName, b.overlay)
+}
+
+// packageManifestTransform returns a ManifestTransform of all the manifests
+// to be built. For raw manifest builds, it contains the given raw manifest
+// files only.
+func (b *Builder) packageManifestTransform(rawFiles []string) (transform.ManifestTransform, error) {
+	if b.rawManifestDir == "" {
+		return ManifestTransformForPackage(b.fs, b.packageName)
+	}
+	mt := transform.ManifestTransform{}
+	for _, f := range rawFiles {
+		mt[f] = []transform.TransformFunc{}
+	}
+	return mt, nil
+}
+
+// cachedBuild mutates the kustomization and runs kustomize on the package,
+// using the build cache. Since all the transformations and mutations are
+// applied to the filesystem before the build, a hash of the filesystem
+// content covers all the build inputs.
+func (b *Builder) cachedBuild() ([]byte, error) {
+	if err := kustomize.MutatePackage(b.fs, b.kMutateFuncs, b.buildPath()); err != nil {
+		return nil, errors.Wrapf(err, "failed to mutate package %q", b.buildPath())
+	}
+
+	hash, err := loader.Hash(b.fs)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to hash package %q", b.packageName)
+	}
+	key := b.buildPath() + "/" + hash
+
+	if m, found := b.buildCache.get(key); found {
+		return m, nil
+	}
+
+	m, err := kustomize.Kustomize(b.fs, b.buildPath())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to kustomize package %q", b.buildPath())
+	}
+	b.buildCache.set(key, m)
+
+	return m, nil
+}
+
 // Apply applies the built manifest.
 func (b *Builder) Apply(ctx context.Context) error {
 	// Skip when the manifest is empty.
 	if b.manifest == "" {
 		return nil
 	}
-	return b.kubectl.Apply(ctx, "", b.manifest, true)
+	if !b.serverSideApply {
+		return b.kubectl.Apply(ctx, "", b.manifest, true)
+	}
+
+	applier, ok := b.kubectl.(kubectl.OptionsApplier)
+	if !ok {
+		return errors.Errorf("kubectl client %T doesn't support server-side apply", b.kubectl)
+	}
+	groups, err := groupByFieldManager(b.manifest, b.fieldManager, b.forceConflicts)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		m, err := encodeObjects(g.objs)
+		if err != nil {
+			return err
+		}
+		if err := applier.ApplyWithOptions(ctx, "", m, true, g.patchOptions()...); err != nil {
+			return errors.Wrapf(err, "failed to apply with field manager %q", g.fieldManager)
+		}
+	}
+	return nil
 }
 
-// Delete deletes the built manifest.
-func (b *Builder) Delete(ctx context.Context) error {
+// Delete deletes the built manifest. Delete options, like
+// client.PropagationPolicy and client.GracePeriodSeconds, can be used to
+// control the deletion of the dependents of the objects. The options require
+// a kubectl client that implements kubectl.OptionsDeleter. By default, the
+// objects are deleted in the foreground with a 1 second grace period.
+func (b *Builder) Delete(ctx context.Context, opts ...client.DeleteOption) error {
 	// Skip when the manifest is empty.
 	if b.manifest == "" {
 		return nil
 	}
-	return b.kubectl.Delete(ctx, "", b.manifest, true)
+	if len(opts) == 0 {
+		return b.kubectl.Delete(ctx, "", b.manifest, true)
+	}
+	deleter, ok := b.kubectl.(kubectl.OptionsDeleter)
+	if !ok {
+		return errors.Errorf("kubectl client %T doesn't support delete options", b.kubectl)
+	}
+	return deleter.DeleteWithOptions(ctx, "", b.manifest, true, opts...)
 }
 
 // Manifest returns the built manifest.
@@ -143,6 +399,54 @@ func (b *Builder) Manifest() string {
 	return b.manifest
 }
 
+// Render returns the built manifest, with all the transformations and
+// kustomization applied, without applying it to the cluster.
+func (b *Builder) Render() ([]byte, error) {
+	return []byte(b.manifest), nil
+}
+
+// RenderObjects returns the built manifest as a list of unstructured objects,
+// without applying them to the cluster. The objects are returned in the order
+// they appear in the built manifest.
+func (b *Builder) RenderObjects() ([]client.Object, error) {
+	return decodeObjects(b.manifest)
+}
+
+// decodeObjects decodes a multi-document YAML manifest into a list of
+// unstructured objects. Empty documents are skipped.
+func decodeObjects(manifest string) ([]client.Object, error) {
+	objs := []client.Object{}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(manifest)))
+	for {
+		doc, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, errors.Wrap(err, "failed to read manifest")
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+
+		// Convert to JSON and decode using the unstructured JSON scheme to
+		// preserve the integer types.
+		j, err := utilyaml.ToJSON(doc)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to convert manifest to JSON")
+		}
+		if string(j) == "null" {
+			continue
+		}
+		u := &unstructured.Unstructured{}
+		if err := u.UnmarshalJSON(j); err != nil {
+			return nil, errors.Wrap(err, "failed to decode manifest")
+		}
+		objs = append(objs, u)
+	}
+	return objs, nil
+}
+
 // ManifestTransformForPackage returns a ManifestTransform of all the manifests
 // in a package.
 func ManifestTransformForPackage(fs filesys.FileSystem, packageName string) (transform.ManifestTransform, error) {
@@ -1,9 +1,15 @@
 package declarative
 
 import (
+	"context"
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/filesys"
 
 	"github.com/darkowlzz/operator-toolkit/declarative/kustomize"
 	"github.com/darkowlzz/operator-toolkit/declarative/loader"
@@ -160,6 +166,60 @@ metadata:
 	}
 }
 
+func TestRenderObjects(t *testing.T) {
+	labels := map[string]string{"testkey": "testval"}
+	isController := true
+	ownerRef := metav1.OwnerReference{
+		APIVersion: "app.example.com/v1alpha1",
+		Kind:       "Game",
+		Name:       "test-game",
+		UID:        "a1b2c3",
+		Controller: &isController,
+	}
+
+	fs, err := loader.NewLoadedManifestFileSystem("testdata/channels", "")
+	assert.Nil(t, err)
+
+	b, err := NewBuilder("registry", fs,
+		WithManifestTransform(transform.ManifestTransform{
+			"registry/db.yaml": []transform.TransformFunc{transform.SetReplicaFunc(3)},
+		}),
+		WithCommonTransforms([]transform.TransformFunc{
+			transform.AddLabelsFunc(labels),
+			transform.SetOwnerReference([]metav1.OwnerReference{ownerRef}),
+		}),
+	)
+	assert.Nil(t, err)
+
+	rendered, err := b.Render()
+	assert.Nil(t, err)
+	assert.Equal(t, b.Manifest(), string(rendered))
+
+	objs, err := b.RenderObjects()
+	assert.Nil(t, err)
+	assert.Len(t, objs, 2)
+
+	wantReplicas := map[string]int64{"test-db": 3}
+	for _, obj := range objs {
+		assert.Equal(t, labels, obj.GetLabels())
+
+		assert.Len(t, obj.GetOwnerReferences(), 1)
+		gotRef := obj.GetOwnerReferences()[0]
+		assert.Equal(t, ownerRef.Kind, gotRef.Kind)
+		assert.Equal(t, ownerRef.Name, gotRef.Name)
+		assert.Equal(t, ownerRef.UID, gotRef.UID)
+		assert.Equal(t, isController, *gotRef.Controller)
+
+		u, ok := obj.(*unstructured.Unstructured)
+		assert.True(t, ok)
+		replicas, found, err := unstructured.NestedInt64(u.Object, "spec", "replicas")
+		assert.Nil(t, err)
+		want, wantFound := wantReplicas[obj.GetName()]
+		assert.Equal(t, wantFound, found)
+		assert.Equal(t, want, replicas)
+	}
+}
+
 func TestManifestTransformForPackage(t *testing.T) {
 	fs, err := loader.NewLoadedManifestFileSystem("testdata/channels", "")
 	assert.Nil(t, err)
@@ -180,3 +240,334 @@ func manifestFound(fileName string, mt transform.ManifestTransform) bool {
 	}
 	return false
 }
+
+func TestBuildCache(t *testing.T) {
+	labels := map[string]string{"testkey": "testval"}
+	labels2 := map[string]string{"testkey2": "testval2"}
+
+	c := NewBuildCache(0)
+
+	build := func(opts ...BuilderOption) string {
+		fs, err := loader.NewLoadedManifestFileSystem("testdata/channels", "")
+		assert.Nil(t, err)
+
+		opts = append(opts, WithBuildCache(c))
+		b, err := NewBuilder("guestbook", fs, opts...)
+		assert.Nil(t, err)
+		return b.Manifest()
+	}
+
+	withLabels := func(l map[string]string) BuilderOption {
+		return WithKustomizeMutationFunc([]kustomize.MutateFunc{kustomize.AddCommonLabels(l)})
+	}
+
+	// First build misses the cache.
+	m1 := build(withLabels(labels))
+	assert.Equal(t, 0, c.hits)
+	assert.Equal(t, 1, c.misses)
+
+	// Build with unchanged inputs hits the cache.
+	m2 := build(withLabels(labels))
+	assert.Equal(t, 1, c.hits)
+	assert.Equal(t, 1, c.misses)
+	assert.Equal(t, m1, m2)
+
+	// Build with changed inputs misses the cache.
+	m3 := build(withLabels(labels2))
+	assert.Equal(t, 1, c.hits)
+	assert.Equal(t, 2, c.misses)
+	assert.NotEqual(t, m1, m3)
+	assert.Contains(t, m3, "testkey2: testval2")
+
+	// Build without the cache returns the same result.
+	fs, err := loader.NewLoadedManifestFileSystem("testdata/channels", "")
+	assert.Nil(t, err)
+	b, err := NewBuilder("guestbook", fs, withLabels(labels2))
+	assert.Nil(t, err)
+	assert.Equal(t, b.Manifest(), m3)
+}
+
+func TestBuildCacheEviction(t *testing.T) {
+	c := NewBuildCache(2)
+	c.set("a", []byte("a"))
+	c.set("b", []byte("b"))
+	c.set("c", []byte("c"))
+
+	_, found := c.get("a")
+	assert.False(t, found)
+	_, found = c.get("b")
+	assert.True(t, found)
+	_, found = c.get("c")
+	assert.True(t, found)
+}
+
+// recordingKubectl is a KubectlClient that records the delete options.
+type recordingKubectl struct {
+	deleteOpts *client.DeleteOptions
+}
+
+func (k *recordingKubectl) Apply(ctx context.Context, namespace string, manifest string, validate bool, extraArgs ...string) error {
+	return nil
+}
+
+func (k *recordingKubectl) Delete(ctx context.Context, namespace string, manifest string, validate bool, extraArgs ...string) error {
+	return k.DeleteWithOptions(ctx, namespace, manifest, validate)
+}
+
+func (k *recordingKubectl) DeleteWithOptions(ctx context.Context, namespace string, manifest string, validate bool, opts ...client.DeleteOption) error {
+	k.deleteOpts = &client.DeleteOptions{}
+	k.deleteOpts.ApplyOptions(opts)
+	return nil
+}
+
+// basicKubectl is a KubectlClient that doesn't support delete options.
+type basicKubectl struct{}
+
+func (k basicKubectl) Apply(ctx context.Context, namespace string, manifest string, validate bool, extraArgs ...string) error {
+	return nil
+}
+
+func (k basicKubectl) Delete(ctx context.Context, namespace string, manifest string, validate bool, extraArgs ...string) error {
+	return nil
+}
+
+func TestDeleteOptions(t *testing.T) {
+	fs, err := loader.NewLoadedManifestFileSystem("testdata/channels", "")
+	assert.Nil(t, err)
+
+	k := &recordingKubectl{}
+	b, err := NewBuilder("guestbook", fs, WithKubectlClient(k))
+	assert.Nil(t, err)
+
+	// Without options, the default delete options are used.
+	assert.Nil(t, b.Delete(context.TODO()))
+	assert.Nil(t, k.deleteOpts.PropagationPolicy)
+
+	assert.Nil(t, b.Delete(context.TODO(),
+		client.PropagationPolicy(metav1.DeletePropagationOrphan),
+		client.GracePeriodSeconds(10),
+	))
+	assert.Equal(t, metav1.DeletePropagationOrphan, *k.deleteOpts.PropagationPolicy)
+	assert.Equal(t, int64(10), *k.deleteOpts.GracePeriodSeconds)
+
+	// The options can't be used with a kubectl client that doesn't support
+	// them.
+	b, err = NewBuilder("guestbook", fs, WithKubectlClient(basicKubectl{}))
+	assert.Nil(t, err)
+	assert.Nil(t, b.Delete(context.TODO()))
+	assert.Error(t, b.Delete(context.TODO(), client.PropagationPolicy(metav1.DeletePropagationBackground)))
+}
+
+// ssaKubectl is a KubectlClient that simulates the field ownership of
+// server-side apply. An apply conflicts with the objects owned by another
+// field manager, unless forced.
+type ssaKubectl struct {
+	basicKubectl
+	// owners are the field managers of the objects by name.
+	owners map[string]string
+	// manifests are the applied manifests by field manager.
+	manifests map[string]string
+}
+
+func (k *ssaKubectl) ApplyWithOptions(ctx context.Context, namespace string, manifest string, validate bool, opts ...client.PatchOption) error {
+	patchOpts := &client.PatchOptions{}
+	patchOpts.ApplyOptions(opts)
+	force := patchOpts.Force != nil && *patchOpts.Force
+
+	objs, err := decodeObjects(manifest)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if owner, found := k.owners[obj.GetName()]; found && owner != patchOpts.FieldManager && !force {
+			return fmt.Errorf("Apply failed with 1 conflict: conflict with %q", owner)
+		}
+	}
+	for _, obj := range objs {
+		k.owners[obj.GetName()] = patchOpts.FieldManager
+	}
+	k.manifests[patchOpts.FieldManager] = manifest
+	return nil
+}
+
+func TestServerSideApply(t *testing.T) {
+	fs, err := loader.NewLoadedManifestFileSystem("testdata/channels", "")
+	assert.Nil(t, err)
+
+	// The objects are owned by helm.
+	newKubectl := func() *ssaKubectl {
+		return &ssaKubectl{
+			owners:    map[string]string{"app-role": "helm", "test-sa": "helm"},
+			manifests: map[string]string{},
+		}
+	}
+
+	t.Run("conflict", func(t *testing.T) {
+		k := newKubectl()
+		b, err := NewBuilder("guestbook", fs, WithKubectlClient(k), WithServerSideApply("operator", false))
+		assert.Nil(t, err)
+		assert.Error(t, b.Apply(context.TODO()))
+		assert.Equal(t, map[string]string{"app-role": "helm", "test-sa": "helm"}, k.owners)
+	})
+
+	t.Run("force conflicts", func(t *testing.T) {
+		k := newKubectl()
+		b, err := NewBuilder("guestbook", fs, WithKubectlClient(k), WithServerSideApply("operator", true))
+		assert.Nil(t, err)
+		assert.Nil(t, b.Apply(context.TODO()))
+		assert.Equal(t, map[string]string{"app-role": "operator", "test-sa": "operator"}, k.owners)
+	})
+
+	t.Run("field manager per manifest", func(t *testing.T) {
+		k := newKubectl()
+		// Only the role is adopted from helm, forcing the conflicts.
+		k.owners = map[string]string{"app-role": "helm"}
+		b, err := NewBuilder("guestbook", fs,
+			WithKubectlClient(k),
+			WithServerSideApply("operator", false),
+			WithManifestTransform(transform.ManifestTransform{
+				"guestbook/role.yaml": []transform.TransformFunc{transform.SetFieldManagerFunc("adopter", true)},
+			}),
+		)
+		assert.Nil(t, err)
+		assert.Nil(t, b.Apply(context.TODO()))
+		assert.Equal(t, map[string]string{"app-role": "adopter", "test-sa": "operator"}, k.owners)
+
+		// The field manager annotations are removed before applying.
+		assert.Equal(t, `apiVersion: rbac.authorization.k8s.io/v1
+kind: Role
+metadata:
+  annotations:
+    foo1: bar1
+  labels:
+    foo: bar
+  name: app-role
+`, k.manifests["adopter"])
+		assert.Equal(t, `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: test-sa
+`, k.manifests["operator"])
+	})
+
+	t.Run("unsupported kubectl", func(t *testing.T) {
+		b, err := NewBuilder("guestbook", fs, WithKubectlClient(basicKubectl{}), WithServerSideApply("operator", false))
+		assert.Nil(t, err)
+		assert.Error(t, b.Apply(context.TODO()))
+	})
+}
+
+func TestOverlays(t *testing.T) {
+	files := map[string]string{
+		"app/base/kustomization.yaml": `resources:
+- config.yaml
+`,
+		"app/base/config.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  level: info
+`,
+		"app/overlays/dev/kustomization.yaml": `resources:
+- ../../base
+namePrefix: dev-
+commonLabels:
+  env: dev
+patchesStrategicMerge:
+- config.yaml
+`,
+		"app/overlays/dev/config.yaml": `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  level: debug
+`,
+		"app/overlays/prod/kustomization.yaml": `resources:
+- ../../base
+namePrefix: prod-
+commonLabels:
+  env: prod
+`,
+	}
+
+	cases := []struct {
+		name      string
+		opts      []BuilderOption
+		wantName  string
+		wantEnv   string
+		wantLevel string
+		wantNS    string
+		wantErr   bool
+	}{
+		{
+			name:      "dev overlay",
+			opts:      []BuilderOption{WithOverlay("overlays/dev")},
+			wantName:  "dev-config",
+			wantEnv:   "dev",
+			wantLevel: "debug",
+		},
+		{
+			name:      "prod overlay",
+			opts:      []BuilderOption{WithOverlay("overlays/prod")},
+			wantName:  "prod-config",
+			wantEnv:   "prod",
+			wantLevel: "info",
+		},
+		{
+			name: "overlay with kustomization mutation",
+			opts: []BuilderOption{
+				WithOverlay("overlays/prod"),
+				WithKustomizeMutationFunc([]kustomize.MutateFunc{kustomize.AddNamespace("prod-ns")}),
+			},
+			wantName:  "prod-config",
+			wantEnv:   "prod",
+			wantLevel: "info",
+			wantNS:    "prod-ns",
+		},
+		{
+			name:    "missing overlay",
+			opts:    []BuilderOption{WithOverlay("overlays/staging")},
+			wantErr: true,
+		},
+		{
+			name:    "overlay without kustomization",
+			opts:    []BuilderOption{WithOverlay("base/..")},
+			wantErr: true,
+		},
+		{
+			name:    "overlay with raw manifests",
+			opts:    []BuilderOption{WithOverlay("overlays/dev"), WithRawManifestDir("app/base")},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			fs := filesys.MakeFsInMemory()
+			for path, content := range files {
+				assert.Nil(t, fs.WriteFile(path, []byte(content)))
+			}
+
+			b, err := NewBuilder("app", fs, tc.opts...)
+			if tc.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			objs, err := b.RenderObjects()
+			assert.Nil(t, err)
+			assert.Len(t, objs, 1)
+			u := objs[0].(*unstructured.Unstructured)
+			assert.Equal(t, tc.wantName, u.GetName())
+			assert.Equal(t, tc.wantEnv, u.GetLabels()["env"])
+			assert.Equal(t, tc.wantNS, u.GetNamespace())
+			level, _, err := unstructured.NestedString(u.Object, "data", "level")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantLevel, level)
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package declarative
+
+import "sync"
+
+// defaultBuildCacheSize is the default number of build results stored in a
+// BuildCache.
+const defaultBuildCacheSize = 32
+
+// BuildCache stores the kustomize build results keyed on a hash of the build
+// input. A BuildCache can be shared between multiple builders to avoid
+// rebuilding the same package with the same inputs. When the cache is full,
+// the oldest entry is evicted.
+type BuildCache struct {
+	mu sync.Mutex
+	// size is the maximum number of entries in the cache.
+	size int
+	// entries are the build results keyed on the input hash.
+	entries map[string][]byte
+	// keys are the cache keys in the order of insertion, used for eviction.
+	keys []string
+	// hits and misses are the cache lookup counters.
+	hits   int
+	misses int
+}
+
+// NewBuildCache returns a new BuildCache that stores up to size build
+// results. A default size is used if size is not positive.
+func NewBuildCache(size int) *BuildCache {
+	if size <= 0 {
+		size = defaultBuildCacheSize
+	}
+	return &BuildCache{
+		size:    size,
+		entries: map[string][]byte{},
+	}
+}
+
+// get returns the build result for the given key, if any.
+func (c *BuildCache) get(key string) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	result, ok := c.entries[key]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return result, ok
+}
+
+// set stores the build result for the given key, evicting the oldest entry
+// if the cache is full.
+func (c *BuildCache) set(key string, result []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, exists := c.entries[key]; exists {
+		c.entries[key] = result
+		return
+	}
+
+	if len(c.keys) >= c.size {
+		oldest := c.keys[0]
+		c.keys = c.keys[1:]
+		delete(c.entries, oldest)
+	}
+	c.entries[key] = result
+	c.keys = append(c.keys, key)
+}
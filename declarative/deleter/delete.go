@@ -12,6 +12,7 @@ import (
 	"k8s.io/cli-runtime/pkg/resource"
 	"k8s.io/kubectl/pkg/cmd/delete"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // DirectDeleter deletes a given manifest. It is based on DirectApplier.
@@ -44,6 +45,14 @@ func (d *DirectDeleter) IOStreams(ioStreams genericclioptions.IOStreams) *Direct
 // Applier interface. They are not part of the DeleteOptions at the moment.
 // This will change as the upstream delete package is refactored in the future.
 func (d *DirectDeleter) Delete(ctx context.Context, namespace string, manifest string, validate bool, extraArgs ...string) error {
+	return d.DeleteWithOptions(ctx, namespace, manifest, validate)
+}
+
+// DeleteWithOptions deletes the given manifest with the given delete options.
+// The propagation policy and the grace period of the options are used,
+// defaulting to foreground deletion with the default grace period of the
+// objects. The other options are ignored.
+func (d *DirectDeleter) DeleteWithOptions(ctx context.Context, namespace string, manifest string, validate bool, opts ...client.DeleteOption) error {
 	// Create a new factory for the deleter.
 	restClient := genericclioptions.NewConfigFlags(true).WithDeprecatedPasswordFlag()
 	f := cmdutil.NewFactory(restClient)
@@ -75,12 +84,13 @@ func (d *DirectDeleter) Delete(ctx context.Context, namespace string, manifest s
 	}
 
 	// Create new delete options, populate the options and run delete.
-	opts := NewDeleteOptions(d.ioStreams, fopts)
-	if err := complete(opts, f, []string{}); err != nil {
+	deleteOpts := NewDeleteOptions(d.ioStreams, fopts)
+	setDeleteOptions(deleteOpts, opts...)
+	if err := complete(deleteOpts, f, []string{}); err != nil {
 		return err
 	}
 
-	return opts.RunDelete(f)
+	return deleteOpts.RunDelete(f)
 }
 
 func NewDeleteOptions(ioStreams genericclioptions.IOStreams, fopts resource.FilenameOptions) *delete.DeleteOptions {
@@ -92,6 +102,45 @@ func NewDeleteOptions(ioStreams genericclioptions.IOStreams, fopts resource.File
 	}
 }
 
+// setDeleteOptions sets the propagation policy and the grace period of the
+// given client delete options in the kubectl DeleteOptions.
+func setDeleteOptions(o *delete.DeleteOptions, opts ...client.DeleteOption) {
+	clientOpts := &client.DeleteOptions{}
+	clientOpts.ApplyOptions(opts)
+
+	if clientOpts.PropagationPolicy != nil {
+		o.CascadingStrategy = *clientOpts.PropagationPolicy
+	}
+	if clientOpts.GracePeriodSeconds != nil {
+		o.GracePeriod = int(*clientOpts.GracePeriodSeconds)
+		// A zero grace period deletes immediately, like kubectl's --force.
+		if o.GracePeriod == 0 {
+			o.ForceDeletion = true
+		}
+	}
+}
+
+// completeGracePeriod resolves the grace period of the DeleteOptions like
+// kubectl delete. The default zero grace period becomes 1 second, unless the
+// deletion is forced.
+func completeGracePeriod(o *delete.DeleteOptions) error {
+	if o.DeleteNow {
+		if o.GracePeriod != -1 {
+			return fmt.Errorf("--now and --grace-period cannot be specified together")
+		}
+		o.GracePeriod = 1
+	}
+	if o.GracePeriod == 0 && !o.ForceDeletion {
+		// To preserve backwards compatibility, but prevent accidental data loss, we convert --grace-period=0
+		// into --grace-period=1. Users may provide --force to bypass this conversion.
+		o.GracePeriod = 1
+	}
+	if o.ForceDeletion && o.GracePeriod < 0 {
+		o.GracePeriod = 0
+	}
+	return nil
+}
+
 // Complete is based on kubectl/pkg/cmd/delete DeleteOptions.Complete(). It
 // populates the DeleteOptions with the given Factory.
 // NOTE: The cobra dependency has been removed from the function to be used as
@@ -114,19 +163,8 @@ func complete(o *delete.DeleteOptions, f cmdutil.Factory, args []string) error {
 	//         o.IgnoreNotFound = true
 	//     }
 	// }
-	if o.DeleteNow {
-		if o.GracePeriod != -1 {
-			return fmt.Errorf("--now and --grace-period cannot be specified together")
-		}
-		o.GracePeriod = 1
-	}
-	if o.GracePeriod == 0 && !o.ForceDeletion {
-		// To preserve backwards compatibility, but prevent accidental data loss, we convert --grace-period=0
-		// into --grace-period=1. Users may provide --force to bypass this conversion.
-		o.GracePeriod = 1
-	}
-	if o.ForceDeletion && o.GracePeriod < 0 {
-		o.GracePeriod = 0
+	if err := completeGracePeriod(o); err != nil {
+		return err
 	}
 
 	// o.DryRunStrategy, err = cmdutil.GetDryRunStrategy(cmd)
@@ -6,7 +6,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/cli-runtime/pkg/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/darkowlzz/operator-toolkit/declarative/applier"
 )
@@ -36,3 +39,50 @@ metadata:
 	err = d.Delete(context.Background(), "", nsManifest, false)
 	assert.Nil(t, err)
 }
+
+func TestSetDeleteOptions(t *testing.T) {
+	cases := []struct {
+		name         string
+		opts         []client.DeleteOption
+		wantStrategy metav1.DeletionPropagation
+		wantGrace    int
+		wantForce    bool
+	}{
+		{
+			name:         "defaults",
+			wantStrategy: metav1.DeletePropagationForeground,
+			wantGrace:    1,
+		},
+		{
+			name:         "object grace period",
+			opts:         []client.DeleteOption{client.GracePeriodSeconds(-1)},
+			wantStrategy: metav1.DeletePropagationForeground,
+			wantGrace:    -1,
+		},
+		{
+			name:         "orphan with grace period",
+			opts:         []client.DeleteOption{client.PropagationPolicy(metav1.DeletePropagationOrphan), client.GracePeriodSeconds(30)},
+			wantStrategy: metav1.DeletePropagationOrphan,
+			wantGrace:    30,
+		},
+		{
+			name:         "background immediately",
+			opts:         []client.DeleteOption{client.PropagationPolicy(metav1.DeletePropagationBackground), client.GracePeriodSeconds(0)},
+			wantStrategy: metav1.DeletePropagationBackground,
+			wantGrace:    0,
+			wantForce:    true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewDeleteOptions(genericclioptions.IOStreams{}, resource.FilenameOptions{})
+			setDeleteOptions(o, tc.opts...)
+			assert.Nil(t, completeGracePeriod(o))
+			assert.Equal(t, tc.wantStrategy, o.CascadingStrategy)
+			assert.Equal(t, tc.wantGrace, o.GracePeriod)
+			assert.Equal(t, tc.wantForce, o.ForceDeletion)
+		})
+	}
+}
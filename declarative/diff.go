@@ -0,0 +1,434 @@
+package declarative
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// lastAppliedConfigAnnotation is the annotation set by kubectl apply to store
+// the last applied configuration of an object.
+const lastAppliedConfigAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// serverPopulatedMetadataFields are the metadata fields that are populated by
+// the API server and are ignored when diffing by default.
+var serverPopulatedMetadataFields = []string{
+	"uid",
+	"resourceVersion",
+	"generation",
+	"creationTimestamp",
+	"deletionTimestamp",
+	"deletionGracePeriodSeconds",
+	"managedFields",
+	"selfLink",
+}
+
+// FieldDiff is a difference in a single field of an object.
+type FieldDiff struct {
+	// Path is the dot separated path of the field in the object.
+	Path string
+	// Live is the value of the field in the live object.
+	Live interface{}
+	// Desired is the value of the field in the built manifest.
+	Desired interface{}
+}
+
+// ObjectDiff is the difference between a built object and its live version in
+// the cluster.
+type ObjectDiff struct {
+	// GroupVersionKind is the GVK of the object.
+	GroupVersionKind schema.GroupVersionKind
+	// Key is the namespaced name of the object.
+	Key types.NamespacedName
+	// NotFound is true when the object doesn't exist in the cluster.
+	NotFound bool
+	// Added are the fields present in the desired object only.
+	Added []FieldDiff
+	// Removed are the fields removed from the desired object since it was
+	// last applied, found with the last applied configuration annotation of
+	// the live object. With IncludeServerFields, these are all the fields
+	// present in the live object only.
+	Removed []FieldDiff
+	// Changed are the fields present in both the objects with different
+	// values.
+	Changed []FieldDiff
+}
+
+// IsEmpty returns true when there's no difference between the live and the
+// desired object.
+func (d ObjectDiff) IsEmpty() bool {
+	return !d.NotFound && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// diffOptions configures a diff.
+type diffOptions struct {
+	includeServerFields bool
+}
+
+// DiffOption is used to configure Diff.
+type DiffOption func(*diffOptions)
+
+// IncludeServerFields includes the server populated fields, like status and
+// metadata.resourceVersion, in the diff. The objects are compared as they
+// are, including the fields defaulted by the server.
+func IncludeServerFields() DiffOption {
+	return func(o *diffOptions) {
+		o.includeServerFields = true
+	}
+}
+
+// Diff fetches the live version of all the built objects and returns their
+// differences with the desired objects. The server populated and defaulted
+// fields are ignored by default. Diff requires a client to be set with WithClient.
+func (b *Builder) Diff(ctx context.Context, opts ...DiffOption) ([]ObjectDiff, error) {
+	if b.client == nil {
+		return nil, errors.New("diff requires a client, set one with WithClient")
+	}
+
+	o := &diffOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	objs, err := b.RenderObjects()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to render package %q", b.packageName)
+	}
+
+	result := []ObjectDiff{}
+	for _, obj := range objs {
+		desired, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			return nil, errors.Errorf("unexpected object type %T", obj)
+		}
+
+		d := ObjectDiff{
+			GroupVersionKind: desired.GroupVersionKind(),
+			Key:              client.ObjectKeyFromObject(desired),
+		}
+
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(desired.GroupVersionKind())
+		if err := b.client.Get(ctx, d.Key, live); err != nil {
+			if apierrors.IsNotFound(err) {
+				d.NotFound = true
+				result = append(result, d)
+				continue
+			}
+			return nil, errors.Wrapf(err, "failed to get %s %s", d.GroupVersionKind.Kind, d.Key)
+		}
+
+		liveObj := live.DeepCopy().Object
+		desiredObj := desired.DeepCopy().Object
+		df := &differ{full: o.includeServerFields, d: &d}
+		if !o.includeServerFields {
+			lastApplied := lastAppliedObject(live)
+			removeServerFields(liveObj)
+			removeServerFields(desiredObj)
+			df.diffRemoved(nil, lastApplied, liveObj, desiredObj)
+		}
+		df.diffMaps(nil, liveObj, desiredObj)
+
+		result = append(result, d)
+	}
+
+	return result, nil
+}
+
+// removeServerFields removes the server populated fields from an unstructured
+// object content.
+func removeServerFields(obj map[string]interface{}) {
+	delete(obj, "status")
+	for _, f := range serverPopulatedMetadataFields {
+		unstructured.RemoveNestedField(obj, "metadata", f)
+	}
+	unstructured.RemoveNestedField(obj, "metadata", "annotations", lastAppliedConfigAnnotation)
+
+	// Remove the annotations if empty after removing the server populated
+	// annotations.
+	if a, found, _ := unstructured.NestedMap(obj, "metadata", "annotations"); found && len(a) == 0 {
+		unstructured.RemoveNestedField(obj, "metadata", "annotations")
+	}
+}
+
+// lastAppliedObject returns the content of the last applied configuration
+// annotation of an object, or nil if it's not set or is invalid.
+func lastAppliedObject(obj *unstructured.Unstructured) map[string]interface{} {
+	data, ok := obj.GetAnnotations()[lastAppliedConfigAnnotation]
+	if !ok {
+		return nil
+	}
+	u := &unstructured.Unstructured{}
+	if err := u.UnmarshalJSON([]byte(data)); err != nil {
+		return nil
+	}
+	return u.Object
+}
+
+// differ compares the live and desired fields of an object and records the
+// differences in an ObjectDiff.
+//
+// By default, only the fields present in the desired object are compared.
+// The live object contains fields defaulted by the API server and other
+// controllers, like a Deployment's strategy or a container's
+// imagePullPolicy, that aren't in the manifest and aren't differences. The
+// removed fields are found with the last applied configuration instead, see
+// diffRemoved. When full is set, the objects are compared as they are and
+// all the fields present in the live object only are removed fields.
+type differ struct {
+	full bool
+	d    *ObjectDiff
+}
+
+// diffMaps compares the fields of the live and desired maps.
+func (df *differ) diffMaps(path []string, live, desired map[string]interface{}) {
+	for _, k := range sortedKeys(desired) {
+		fieldPath := appendPath(path, k)
+		lv, exists := live[k]
+		if !exists {
+			df.added(fieldPath, desired[k])
+			continue
+		}
+		df.diffValues(fieldPath, lv, desired[k])
+	}
+
+	if !df.full {
+		return
+	}
+	for _, k := range sortedKeys(live) {
+		if _, exists := desired[k]; !exists {
+			df.removed(appendPath(path, k), live[k])
+		}
+	}
+}
+
+// diffValues compares a live and a desired value, recursing into maps and
+// lists.
+func (df *differ) diffValues(path []string, live, desired interface{}) {
+	switch dv := desired.(type) {
+	case map[string]interface{}:
+		if lv, ok := live.(map[string]interface{}); ok {
+			df.diffMaps(path, lv, dv)
+			return
+		}
+	case []interface{}:
+		if lv, ok := live.([]interface{}); ok {
+			df.diffLists(path, lv, dv)
+			return
+		}
+	}
+
+	if !reflect.DeepEqual(live, desired) {
+		df.changed(path, live, desired)
+	}
+}
+
+// diffLists compares the items of the live and desired lists. Lists of
+// objects with a name, like containers, are matched by name. Other lists of
+// objects are matched by index. Lists of scalar values are compared as a
+// whole. When full is set, the live items that don't match any desired item
+// are removed fields. Otherwise, they're found with the last applied
+// configuration in diffRemoved, ignoring the items added by the server and
+// other controllers, like injected sidecar containers.
+func (df *differ) diffLists(path []string, live, desired []interface{}) {
+	if !isObjectList(desired) || !isObjectList(live) {
+		if !reflect.DeepEqual(live, desired) {
+			df.changed(path, live, desired)
+		}
+		return
+	}
+
+	if isNamedList(desired) && isNamedList(live) {
+		liveItems := map[string]interface{}{}
+		for _, item := range live {
+			liveItems[itemName(item)] = item
+		}
+		for _, item := range desired {
+			name := itemName(item)
+			itemPath := appendIndex(path, "name="+name)
+			if lv, exists := liveItems[name]; exists {
+				df.diffValues(itemPath, lv, item)
+				delete(liveItems, name)
+			} else {
+				df.added(itemPath, item)
+			}
+		}
+		if !df.full {
+			return
+		}
+		for _, item := range live {
+			if name := itemName(item); liveItems[name] != nil {
+				df.removed(appendIndex(path, "name="+name), item)
+			}
+		}
+		return
+	}
+
+	for i, item := range desired {
+		itemPath := appendIndex(path, strconv.Itoa(i))
+		if i < len(live) {
+			df.diffValues(itemPath, live[i], item)
+		} else {
+			df.added(itemPath, item)
+		}
+	}
+	if !df.full {
+		return
+	}
+	for i := len(desired); i < len(live); i++ {
+		df.removed(appendIndex(path, strconv.Itoa(i)), live[i])
+	}
+}
+
+// diffRemoved records the fields of the last applied configuration that are
+// no longer in the desired object but are still in the live object. These
+// are the fields removed from the manifest since it was last applied.
+func (df *differ) diffRemoved(path []string, lastApplied, live, desired map[string]interface{}) {
+	for _, k := range sortedKeys(lastApplied) {
+		lv, inLive := live[k]
+		if !inLive {
+			continue
+		}
+		dv, inDesired := desired[k]
+		if !inDesired {
+			df.removed(appendPath(path, k), lv)
+			continue
+		}
+
+		am, aok := lastApplied[k].(map[string]interface{})
+		lm, lok := lv.(map[string]interface{})
+		dm, dok := dv.(map[string]interface{})
+		if aok && lok && dok {
+			df.diffRemoved(appendPath(path, k), am, lm, dm)
+			continue
+		}
+
+		al, aok := lastApplied[k].([]interface{})
+		ll, lok := lv.([]interface{})
+		dl, dok := dv.([]interface{})
+		if aok && lok && dok {
+			df.diffRemovedList(appendPath(path, k), al, ll, dl)
+		}
+	}
+}
+
+// diffRemovedList is diffRemoved for lists of objects, matching the items
+// like diffLists. The live items that were last applied but are no longer
+// desired are removed fields.
+func (df *differ) diffRemovedList(path []string, lastApplied, live, desired []interface{}) {
+	if !isObjectList(lastApplied) || !isObjectList(live) || !isObjectList(desired) {
+		return
+	}
+
+	if isNamedList(lastApplied) && isNamedList(live) && isNamedList(desired) {
+		liveItems := map[string]interface{}{}
+		for _, item := range live {
+			liveItems[itemName(item)] = item
+		}
+		desiredItems := map[string]interface{}{}
+		for _, item := range desired {
+			desiredItems[itemName(item)] = item
+		}
+		for _, item := range lastApplied {
+			name := itemName(item)
+			lv, inLive := liveItems[name]
+			if !inLive {
+				continue
+			}
+			itemPath := appendIndex(path, "name="+name)
+			dv, inDesired := desiredItems[name]
+			if !inDesired {
+				df.removed(itemPath, lv)
+				continue
+			}
+			df.diffRemoved(itemPath, item.(map[string]interface{}), lv.(map[string]interface{}), dv.(map[string]interface{}))
+		}
+		return
+	}
+
+	for i, item := range lastApplied {
+		if i >= len(live) {
+			break
+		}
+		itemPath := appendIndex(path, strconv.Itoa(i))
+		if i >= len(desired) {
+			df.removed(itemPath, live[i])
+			continue
+		}
+		df.diffRemoved(itemPath, item.(map[string]interface{}), live[i].(map[string]interface{}), desired[i].(map[string]interface{}))
+	}
+}
+
+func (df *differ) added(path []string, desired interface{}) {
+	df.d.Added = append(df.d.Added, FieldDiff{Path: strings.Join(path, "."), Desired: desired})
+}
+
+func (df *differ) removed(path []string, live interface{}) {
+	df.d.Removed = append(df.d.Removed, FieldDiff{Path: strings.Join(path, "."), Live: live})
+}
+
+func (df *differ) changed(path []string, live, desired interface{}) {
+	df.d.Changed = append(df.d.Changed, FieldDiff{Path: strings.Join(path, "."), Live: live, Desired: desired})
+}
+
+// appendPath returns a copy of the path with the given field appended.
+func appendPath(path []string, field string) []string {
+	return append(append([]string{}, path...), field)
+}
+
+// appendIndex returns a copy of the path with the given list index appended
+// to the last field, like containers[0] or containers[name=web].
+func appendIndex(path []string, index string) []string {
+	result := append([]string{}, path...)
+	result[len(result)-1] += "[" + index + "]"
+	return result
+}
+
+// isObjectList returns true when all the items of a list are objects.
+func isObjectList(list []interface{}) bool {
+	for _, item := range list {
+		if _, ok := item.(map[string]interface{}); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// isNamedList returns true when all the items of a list of objects have a
+// unique name.
+func isNamedList(list []interface{}) bool {
+	names := map[string]bool{}
+	for _, item := range list {
+		name := itemName(item)
+		if name == "" || names[name] {
+			return false
+		}
+		names[name] = true
+	}
+	return true
+}
+
+// itemName returns the name field of a list item.
+func itemName(item interface{}) string {
+	name, _, _ := unstructured.NestedString(item.(map[string]interface{}), "name")
+	return name
+}
+
+// sortedKeys returns the keys of a map in sorted order.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
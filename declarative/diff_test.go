@@ -0,0 +1,266 @@
+package declarative
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/kustomize/api/filesys"
+
+	"github.com/darkowlzz/operator-toolkit/declarative/transform"
+)
+
+const webKustomization = `resources:
+- deployment.yaml
+`
+
+const webDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`
+
+func newWebFileSystem(t *testing.T) filesys.FileSystem {
+	fs := filesys.MakeFsInMemory()
+	assert.Nil(t, fs.WriteFile("web/kustomization.yaml", []byte(webKustomization)))
+	assert.Nil(t, fs.WriteFile("web/deployment.yaml", []byte(webDeployment)))
+	return fs
+}
+
+func TestDiff(t *testing.T) {
+	// Use the same manifest with one replica and a few server populated
+	// fields as the live object.
+	objs, err := decodeObjects(webDeployment)
+	assert.Nil(t, err)
+	liveDeployment := objs[0].(*unstructured.Unstructured)
+	liveDeployment.SetResourceVersion("10")
+	assert.Nil(t, unstructured.SetNestedField(liveDeployment.Object, int64(1), "status", "readyReplicas"))
+
+	cases := []struct {
+		name         string
+		liveObjs     bool
+		opts         []DiffOption
+		wantNotFound bool
+		wantChanged  []FieldDiff
+		wantRemoved  []string
+	}{
+		{
+			name:     "changed replicas",
+			liveObjs: true,
+			wantChanged: []FieldDiff{
+				{Path: "spec.replicas", Live: int64(1), Desired: int64(3)},
+			},
+		},
+		{
+			name:     "include server fields",
+			liveObjs: true,
+			opts:     []DiffOption{IncludeServerFields()},
+			wantChanged: []FieldDiff{
+				{Path: "spec.replicas", Live: int64(1), Desired: int64(3)},
+			},
+			wantRemoved: []string{"metadata.resourceVersion", "status"},
+		},
+		{
+			name:         "object not found",
+			wantNotFound: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cb := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+			if tc.liveObjs {
+				cb = cb.WithObjects(liveDeployment.DeepCopy())
+			}
+
+			b, err := NewBuilder("web", newWebFileSystem(t),
+				WithClient(cb.Build()),
+				WithManifestTransform(transform.ManifestTransform{
+					"web/deployment.yaml": []transform.TransformFunc{transform.SetReplicaFunc(3)},
+				}),
+			)
+			assert.Nil(t, err)
+
+			diffs, err := b.Diff(context.TODO(), tc.opts...)
+			assert.Nil(t, err)
+			assert.Len(t, diffs, 1)
+
+			d := diffs[0]
+			assert.Equal(t, types.NamespacedName{Name: "web", Namespace: "default"}, d.Key)
+			assert.Equal(t, "Deployment", d.GroupVersionKind.Kind)
+			assert.Equal(t, tc.wantNotFound, d.NotFound)
+			assert.Empty(t, d.Added)
+			assert.Equal(t, tc.wantChanged, d.Changed)
+
+			var gotRemoved []string
+			for _, r := range d.Removed {
+				gotRemoved = append(gotRemoved, r.Path)
+			}
+			assert.Equal(t, tc.wantRemoved, gotRemoved)
+		})
+	}
+}
+
+// defaultedWebDeployment is webDeployment as returned by the API server, with
+// the defaulted fields, an annotation set by the deployment controller, and a
+// label and a container that were removed from the manifest since it was last
+// applied.
+const defaultedWebDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  resourceVersion: "10"
+  generation: 2
+  labels:
+    tier: frontend
+  annotations:
+    deployment.kubernetes.io/revision: "2"
+    kubectl.kubernetes.io/last-applied-configuration: |
+      {"apiVersion":"apps/v1","kind":"Deployment","metadata":{"labels":{"tier":"frontend"},"name":"web","namespace":"default"},"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"web","image":"nginx:1.19"},{"name":"sidecar","image":"busybox"}]}}}}
+spec:
+  replicas: 1
+  revisionHistoryLimit: 10
+  progressDeadlineSeconds: 600
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 25%
+      maxUnavailable: 25%
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      restartPolicy: Always
+      dnsPolicy: ClusterFirst
+      containers:
+      - name: web
+        image: nginx:1.19
+        imagePullPolicy: Always
+        terminationMessagePath: /dev/termination-log
+        terminationMessagePolicy: File
+      - name: sidecar
+        image: busybox
+status:
+  readyReplicas: 1
+`
+
+func TestDiffDefaultedFields(t *testing.T) {
+	objs, err := decodeObjects(defaultedWebDeployment)
+	assert.Nil(t, err)
+
+	b, err := NewBuilder("web", newWebFileSystem(t),
+		WithClient(fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs[0]).Build()),
+		WithManifestTransform(transform.ManifestTransform{
+			"web/deployment.yaml": []transform.TransformFunc{transform.SetReplicaFunc(3)},
+		}),
+	)
+	assert.Nil(t, err)
+
+	diffs, err := b.Diff(context.TODO())
+	assert.Nil(t, err)
+	assert.Len(t, diffs, 1)
+
+	// Only the fields set in the manifest are compared and the list items are
+	// compared field by field. The removed fields are the label and the
+	// container removed since the last apply.
+	d := diffs[0]
+	assert.Empty(t, d.Added)
+	assert.Equal(t, []FieldDiff{
+		{Path: "spec.replicas", Live: int64(1), Desired: int64(3)},
+		{Path: "spec.template.spec.containers[name=web].image", Live: "nginx:1.19", Desired: "nginx"},
+	}, d.Changed)
+
+	var gotRemoved []string
+	for _, r := range d.Removed {
+		gotRemoved = append(gotRemoved, r.Path)
+	}
+	assert.Equal(t, []string{"metadata.labels", "spec.template.spec.containers[name=sidecar]"}, gotRemoved)
+}
+
+// injectedWebDeployment is webDeployment as returned by the API server, with
+// a sidecar container and a toleration injected by admission controllers.
+const injectedWebDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: default
+  annotations:
+    kubectl.kubernetes.io/last-applied-configuration: |
+      {"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"web","namespace":"default"},"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"web","image":"nginx"}]}}}}
+spec:
+  replicas: 1
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+      - name: proxy
+        image: envoy
+      tolerations:
+      - key: node.kubernetes.io/not-ready
+        operator: Exists
+`
+
+func TestDiffInjectedFields(t *testing.T) {
+	objs, err := decodeObjects(injectedWebDeployment)
+	assert.Nil(t, err)
+
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objs[0]).Build()
+	b, err := NewBuilder("web", newWebFileSystem(t), WithClient(cli))
+	assert.Nil(t, err)
+
+	// The injected items aren't in the last applied configuration and aren't
+	// differences.
+	diffs, err := b.Diff(context.TODO())
+	assert.Nil(t, err)
+	assert.Len(t, diffs, 1)
+	assert.True(t, diffs[0].IsEmpty(), "unexpected diff: %+v", diffs[0])
+
+	// All the live items are compared with IncludeServerFields.
+	diffs, err = b.Diff(context.TODO(), IncludeServerFields())
+	assert.Nil(t, err)
+	assert.Len(t, diffs, 1)
+	var gotRemoved []string
+	for _, r := range diffs[0].Removed {
+		gotRemoved = append(gotRemoved, r.Path)
+	}
+	assert.Contains(t, gotRemoved, "spec.template.spec.containers[name=proxy]")
+}
+
+func TestDiffWithoutClient(t *testing.T) {
+	b, err := NewBuilder("web", newWebFileSystem(t))
+	assert.Nil(t, err)
+
+	_, err = b.Diff(context.TODO())
+	assert.NotNil(t, err)
+}
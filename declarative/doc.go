@@ -4,5 +4,10 @@
 // process includes transforming specific manifests, common transformations for
 // all the manifests in a package and mutating the kustomization file in the
 // package. A builder instance can be used to apply or delete the built
-// resource manifest.
+// resource manifest, render it without applying or diff it against the live
+// objects in the cluster. A directory of plain manifests, without a
+// kustomization, can also be built with the same transformations. The
+// *.yaml.tmpl manifest templates can be rendered with Go templates before the
+// transformations. A kustomize overlay in the package can be selected to build
+// environment specific manifests from the same package.
 package declarative
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	"github.com/darkowlzz/operator-toolkit/declarative/applier"
 	"github.com/darkowlzz/operator-toolkit/declarative/deleter"
@@ -16,6 +17,18 @@ type KubectlClient interface {
 	Delete(ctx context.Context, namespace string, manifest string, validate bool, extraArgs ...string) error
 }
 
+// OptionsDeleter is a kubectl client that can delete resources with delete
+// options, like the propagation policy and the grace period.
+type OptionsDeleter interface {
+	DeleteWithOptions(ctx context.Context, namespace string, manifest string, validate bool, opts ...client.DeleteOption) error
+}
+
+// OptionsApplier is a kubectl client that can server-side apply resources
+// with patch options, like the field manager and forcing the conflicts.
+type OptionsApplier interface {
+	ApplyWithOptions(ctx context.Context, namespace string, manifest string, validate bool, opts ...client.PatchOption) error
+}
+
 // DefaultKubectl is the default implementation of the KubectlClient using
 // direct applier and deleter.
 type DefaultKubectl struct {
@@ -31,6 +44,9 @@ func New() *DefaultKubectl {
 	}
 }
 
+var _ OptionsApplier = &DefaultKubectl{}
+var _ OptionsDeleter = &DefaultKubectl{}
+
 // IOStreams sets the IOStreams of the applier and deleter.
 func (d *DefaultKubectl) IOStreams(ioStreams genericclioptions.IOStreams) *DefaultKubectl {
 	d.DirectApplier = d.DirectApplier.IOStreams(ioStreams)
@@ -106,18 +106,27 @@ func WriteKustomizationInPackage(fs filesys.FileSystem, k *apitypes.Kustomizatio
 	return fs.WriteFile(filepath.Join(path, kustomizationFile), y)
 }
 
-// MutateAndKustomize applies all the mutations to a kustomization in a
-// package, runs kustomize and returns the result.
-func MutateAndKustomize(fs filesys.FileSystem, mutateFuncs []MutateFunc, path string) ([]byte, error) {
+// MutatePackage applies all the mutations to a kustomization in a package and
+// writes the result in the same package.
+func MutatePackage(fs filesys.FileSystem, mutateFuncs []MutateFunc, path string) error {
 	k, err := LoadKustomizationFromPackage(fs, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load kustomization: %w", err)
+		return fmt.Errorf("failed to load kustomization: %w", err)
 	}
 
 	// Mutate kustomization file and write in the same package.
 	Mutate(k, mutateFuncs)
 	if err := WriteKustomizationInPackage(fs, k, path); err != nil {
-		return nil, fmt.Errorf("failed to write kustomization: %w", err)
+		return fmt.Errorf("failed to write kustomization: %w", err)
+	}
+	return nil
+}
+
+// MutateAndKustomize applies all the mutations to a kustomization in a
+// package, runs kustomize and returns the result.
+func MutateAndKustomize(fs filesys.FileSystem, mutateFuncs []MutateFunc, path string) ([]byte, error) {
+	if err := MutatePackage(fs, mutateFuncs, path); err != nil {
+		return nil, err
 	}
 
 	// Run kustomization in the given package.
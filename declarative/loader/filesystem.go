@@ -1,6 +1,8 @@
 package loader
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -131,3 +133,34 @@ func DeepCopy(fs1, fs2 filesys.FileSystem) error {
 	}
 	return nil
 }
+
+// Hash returns a hash of the content of the FileSystem. The hash covers the
+// paths and the content of all the files in the FileSystem and changes when
+// any of them change.
+func Hash(fs filesys.FileSystem) (string, error) {
+	h := sha256.New()
+	walkErr := fs.Walk("/", func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		content, err := fs.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		// Separate the path and the content to avoid ambiguity between
+		// adjacent entries.
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(content))
+		h.Write(content)
+
+		return nil
+	})
+	if walkErr != nil {
+		return "", walkErr
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -39,3 +39,24 @@ func TestDeepCopy(t *testing.T) {
 	assert.True(t, fs2.Exists("/registry/frontend.yaml"))
 	assert.True(t, fs2.Exists("/registry/kustomization.yaml"))
 }
+
+func TestHash(t *testing.T) {
+	fs1, err := NewLoadedManifestFileSystem("../testdata/channels", "")
+	assert.Nil(t, err)
+
+	fs2 := &ManifestFileSystem{FileSystem: filesys.MakeFsInMemory()}
+	assert.Nil(t, DeepCopy(fs1, fs2))
+
+	// Same content results in the same hash.
+	h1, err := Hash(fs1)
+	assert.Nil(t, err)
+	h2, err := Hash(fs2)
+	assert.Nil(t, err)
+	assert.Equal(t, h1, h2)
+
+	// Changed content results in a different hash.
+	assert.Nil(t, fs2.WriteFile("guestbook/role.yaml", []byte("kind: Role")))
+	h3, err := Hash(fs2)
+	assert.Nil(t, err)
+	assert.NotEqual(t, h1, h3)
+}
@@ -0,0 +1,157 @@
+package declarative
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/kustomize/api/filesys"
+
+	"github.com/darkowlzz/operator-toolkit/declarative/transform"
+)
+
+// isRawManifest returns true if the given path is a YAML manifest file that
+// isn't a kustomization.
+func isRawManifest(path string) bool {
+	ext := filepath.Ext(path)
+	return (ext == ".yaml" || ext == ".yml") && !IsKustomization(path)
+}
+
+// splitRawManifests splits all the multi-document YAML manifests in the given
+// directory of a filesystem into single-document manifests, so that they can
+// be transformed like any other manifest. The subdirectories are ignored. A
+// multi-document manifest "a.yaml" is replaced with "a-0.yaml", "a-1.yaml",
+// and so on. It returns the resulting manifest files in the build order and
+// the split files of every original manifest file.
+func splitRawManifests(fs filesys.FileSystem, dir string) ([]string, map[string][]string, error) {
+	// Collect the top level manifests of the directory. The root directory
+	// is visited first.
+	var root string
+	var manifests []string
+	err := fs.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "failed accessing path %q", path)
+		}
+		if info.IsDir() {
+			if root == "" {
+				root = path
+			}
+			return nil
+		}
+		if filepath.Dir(path) == root && isRawManifest(path) {
+			manifests = append(manifests, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	files := []string{}
+	splits := map[string][]string{}
+	for _, manifest := range manifests {
+		b, err := fs.ReadFile(manifest)
+		if err != nil {
+			return nil, nil, err
+		}
+		docs, err := splitDocuments(b)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "failed to split manifest %q", manifest)
+		}
+
+		// Keep the single document manifests as they are.
+		if len(docs) <= 1 {
+			if len(docs) == 1 {
+				files = append(files, manifest)
+				splits[cleanPath(manifest)] = []string{manifest}
+			}
+			continue
+		}
+
+		ext := filepath.Ext(manifest)
+		base := strings.TrimSuffix(manifest, ext)
+		for i, doc := range docs {
+			name := fmt.Sprintf("%s-%d%s", base, i, ext)
+			if err := fs.WriteFile(name, doc); err != nil {
+				return nil, nil, err
+			}
+			files = append(files, name)
+			splits[cleanPath(manifest)] = append(splits[cleanPath(manifest)], name)
+		}
+		if err := fs.RemoveAll(manifest); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return files, splits, nil
+}
+
+// splitDocuments splits a multi-document YAML into the individual documents.
+// Empty documents are skipped.
+func splitDocuments(b []byte) ([][]byte, error) {
+	docs := [][]byte{}
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(b)))
+	for {
+		doc, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
+
+// splitManifestTransform returns a ManifestTransform with the transforms of
+// the split manifests applied to all the documents of the manifests.
+func splitManifestTransform(mt transform.ManifestTransform, splits map[string][]string) transform.ManifestTransform {
+	result := transform.ManifestTransform{}
+	for manifest, transforms := range mt {
+		files, ok := splits[cleanPath(manifest)]
+		if !ok {
+			result[manifest] = transforms
+			continue
+		}
+		for _, f := range files {
+			result[f] = transforms
+		}
+	}
+	return result
+}
+
+// cleanPath returns a cleaned path relative to the filesystem root, used to
+// match the paths in a ManifestTransform with the filesystem paths.
+func cleanPath(path string) string {
+	return strings.TrimPrefix(filepath.Clean(path), string(filepath.Separator))
+}
+
+// buildRawManifests concatenates the given manifest files into a
+// multi-document manifest.
+func buildRawManifests(fs filesys.FileSystem, files []string) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, f := range files {
+		b, err := fs.ReadFile(f)
+		if err != nil {
+			return nil, err
+		}
+		if i > 0 {
+			buf.WriteString("---\n")
+		}
+		buf.Write(b)
+		if !bytes.HasSuffix(b, []byte("\n")) {
+			buf.WriteString("\n")
+		}
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,92 @@
+package declarative
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"sigs.k8s.io/kustomize/api/filesys"
+
+	"github.com/darkowlzz/operator-toolkit/declarative/transform"
+)
+
+const rawConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  foo: bar
+`
+
+const rawService = `apiVersion: v1
+kind: Service
+metadata:
+  name: web
+spec:
+  ports:
+  - port: 80
+`
+
+const rawMultiDoc = `apiVersion: v1
+kind: ServiceAccount
+metadata:
+  name: web
+---
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 1
+`
+
+func TestRawManifestDir(t *testing.T) {
+	fs := filesys.MakeFsInMemory()
+	assert.Nil(t, fs.WriteFile("raw/a.yaml", []byte(rawConfigMap)))
+	assert.Nil(t, fs.WriteFile("raw/b.yml", []byte(rawService)))
+	assert.Nil(t, fs.WriteFile("raw/c.yaml", []byte(rawMultiDoc)))
+	// Non-manifest files and subdirectories are ignored.
+	assert.Nil(t, fs.WriteFile("raw/README.md", []byte("docs")))
+	assert.Nil(t, fs.WriteFile("raw/extra/d.yaml", []byte(rawConfigMap)))
+
+	labels := map[string]string{"app": "web"}
+	b, err := NewBuilder("raw", fs,
+		WithRawManifestDir("raw"),
+		WithManifestTransform(transform.ManifestTransform{
+			"raw/c.yaml": []transform.TransformFunc{transform.AddAnnotationsFunc(map[string]string{"source": "c.yaml"})},
+		}),
+		WithCommonTransforms([]transform.TransformFunc{transform.AddLabelsFunc(labels)}),
+	)
+	assert.Nil(t, err)
+
+	objs, err := b.RenderObjects()
+	assert.Nil(t, err)
+
+	type objID struct{ kind, name string }
+	got := []objID{}
+	for _, obj := range objs {
+		got = append(got, objID{obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName()})
+
+		// Common transforms are applied to all the objects.
+		assert.Equal(t, labels, obj.GetLabels())
+
+		// Manifest transforms of a multi-doc file are applied to all its
+		// documents.
+		wantSplit := obj.GetObjectKind().GroupVersionKind().Kind == "ServiceAccount" ||
+			obj.GetObjectKind().GroupVersionKind().Kind == "Deployment"
+		assert.Equal(t, wantSplit, obj.GetAnnotations()["source"] == "c.yaml")
+	}
+
+	want := []objID{
+		{"ConfigMap", "config"},
+		{"Service", "web"},
+		{"ServiceAccount", "web"},
+		{"Deployment", "web"},
+	}
+	assert.Equal(t, want, got)
+
+	// The original filesystem is unchanged.
+	content, err := fs.ReadFile("raw/c.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, rawMultiDoc, string(content))
+}
@@ -0,0 +1,86 @@
+package declarative
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	"github.com/darkowlzz/operator-toolkit/declarative/transform"
+)
+
+// applyGroup is a group of objects server-side applied with the same field
+// manager and conflict resolution.
+type applyGroup struct {
+	fieldManager   string
+	forceConflicts bool
+	objs           []client.Object
+}
+
+// patchOptions returns the server-side apply options of the group.
+func (g *applyGroup) patchOptions() []client.PatchOption {
+	opts := []client.PatchOption{client.FieldOwner(g.fieldManager)}
+	if g.forceConflicts {
+		opts = append(opts, client.ForceOwnership)
+	}
+	return opts
+}
+
+// groupByFieldManager groups the objects of the given manifest by their field
+// manager and force conflicts annotations, set with
+// transform.SetFieldManagerFunc, in the order of their first appearance. The
+// objects without the annotations use the given defaults. The annotations are
+// removed from the objects.
+func groupByFieldManager(manifest string, fieldManager string, forceConflicts bool) ([]*applyGroup, error) {
+	objs, err := decodeObjects(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := []*applyGroup{}
+	index := map[string]*applyGroup{}
+	for _, obj := range objs {
+		manager, force := fieldManager, forceConflicts
+		annotations := obj.GetAnnotations()
+		if m, ok := annotations[transform.FieldManagerAnnotation]; ok {
+			manager = m
+			delete(annotations, transform.FieldManagerAnnotation)
+		}
+		if f, ok := annotations[transform.ForceConflictsAnnotation]; ok {
+			if force, err = strconv.ParseBool(f); err != nil {
+				return nil, errors.Wrapf(err, "invalid %s annotation of %s %q", transform.ForceConflictsAnnotation, obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName())
+			}
+			delete(annotations, transform.ForceConflictsAnnotation)
+		}
+		if len(annotations) == 0 {
+			annotations = nil
+		}
+		obj.SetAnnotations(annotations)
+
+		key := manager + "/" + strconv.FormatBool(force)
+		g, found := index[key]
+		if !found {
+			g = &applyGroup{fieldManager: manager, forceConflicts: force}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.objs = append(g.objs, obj)
+	}
+	return groups, nil
+}
+
+// encodeObjects encodes the given objects into a multi-document YAML
+// manifest.
+func encodeObjects(objs []client.Object) (string, error) {
+	docs := []string{}
+	for _, obj := range objs {
+		doc, err := yaml.Marshal(obj)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to encode manifest")
+		}
+		docs = append(docs, string(doc))
+	}
+	return strings.Join(docs, "---\n"), nil
+}
@@ -0,0 +1,69 @@
+package declarative
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/kustomize/api/filesys"
+)
+
+// templateSuffix is the file name suffix of the manifest templates.
+const templateSuffix = ".yaml.tmpl"
+
+// isTemplate returns true if the given path is a manifest template.
+func isTemplate(path string) bool {
+	return strings.HasSuffix(path, templateSuffix)
+}
+
+// renderTemplates renders all the manifest templates in the given directory
+// of a filesystem, recursively, with the given data. A template "a.yaml.tmpl"
+// is replaced with the rendered manifest "a.yaml". The other files are not
+// templated, even if they contain template actions. Referring to a key
+// missing in the data is an error.
+func renderTemplates(fs filesys.FileSystem, dir string, data map[string]interface{}) error {
+	var templates []string
+	err := fs.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrapf(err, "failed accessing path %q", path)
+		}
+		if !info.IsDir() && isTemplate(path) {
+			templates = append(templates, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, path := range templates {
+		// Don't overwrite an existing manifest with the rendered template.
+		target := strings.TrimSuffix(path, ".tmpl")
+		if fs.Exists(target) {
+			return errors.Errorf("template %q conflicts with the existing manifest %q", path, target)
+		}
+
+		content, err := fs.ReadFile(path)
+		if err != nil {
+			return errors.Wrapf(err, "failed to read template %q", path)
+		}
+		tmpl, err := template.New(path).Option("missingkey=error").Parse(string(content))
+		if err != nil {
+			return errors.Wrapf(err, "failed to parse template %q", path)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return errors.Wrapf(err, "failed to render template %q", path)
+		}
+
+		if err := fs.WriteFile(target, buf.Bytes()); err != nil {
+			return errors.Wrapf(err, "failed to write rendered template %q", target)
+		}
+		if err := fs.RemoveAll(path); err != nil {
+			return errors.Wrapf(err, "failed to remove template %q", path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package declarative
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/kustomize/api/filesys"
+
+	"github.com/darkowlzz/operator-toolkit/declarative/transform"
+)
+
+const templateKustomization = `resources:
+- deployment.yaml
+- config.yaml
+`
+
+const deploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: "example.com/web:{{ .tag }}"
+`
+
+// nonTemplateConfig is a manifest with template actions that must not be
+// templated.
+const nonTemplateConfig = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+data:
+  greeting: "{{ .tag }}"
+`
+
+func TestTemplates(t *testing.T) {
+	cases := []struct {
+		name      string
+		files     map[string]string
+		opts      []BuilderOption
+		wantImage string
+		wantErr   bool
+	}{
+		{
+			name: "kustomize package",
+			files: map[string]string{
+				"web/kustomization.yaml":   templateKustomization,
+				"web/deployment.yaml.tmpl": deploymentTemplate,
+				"web/config.yaml":          nonTemplateConfig,
+			},
+			opts:      []BuilderOption{WithTemplateData(map[string]interface{}{"tag": "v1.2.3"})},
+			wantImage: "example.com/web:v1.2.3",
+		},
+		{
+			name: "raw manifests",
+			files: map[string]string{
+				"web/deployment.yaml.tmpl": deploymentTemplate,
+				"web/config.yaml":          nonTemplateConfig,
+			},
+			opts: []BuilderOption{
+				WithRawManifestDir("web"),
+				WithTemplateData(map[string]interface{}{"tag": "v2"}),
+			},
+			wantImage: "example.com/web:v2",
+		},
+		{
+			name: "missing key",
+			files: map[string]string{
+				"web/kustomization.yaml":   templateKustomization,
+				"web/deployment.yaml.tmpl": deploymentTemplate,
+				"web/config.yaml":          nonTemplateConfig,
+			},
+			opts:    []BuilderOption{WithTemplateData(map[string]interface{}{})},
+			wantErr: true,
+		},
+		{
+			name: "conflicting manifest",
+			files: map[string]string{
+				"web/kustomization.yaml":   templateKustomization,
+				"web/deployment.yaml.tmpl": deploymentTemplate,
+				"web/deployment.yaml":      deploymentTemplate,
+				"web/config.yaml":          nonTemplateConfig,
+			},
+			opts:    []BuilderOption{WithTemplateData(map[string]interface{}{"tag": "v1"})},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			fs := filesys.MakeFsInMemory()
+			for path, content := range tc.files {
+				assert.Nil(t, fs.WriteFile(path, []byte(content)))
+			}
+
+			// The rendered manifests can be transformed.
+			opts := append(tc.opts, WithManifestTransform(transform.ManifestTransform{
+				"web/deployment.yaml": []transform.TransformFunc{transform.AddLabelsFunc(map[string]string{"app": "web"})},
+			}))
+			b, err := NewBuilder("web", fs, opts...)
+			if tc.wantErr {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+
+			objs, err := b.RenderObjects()
+			assert.Nil(t, err)
+			assert.Len(t, objs, 2)
+			for _, obj := range objs {
+				u := obj.(*unstructured.Unstructured)
+				switch u.GetKind() {
+				case "Deployment":
+					containers, _, err := unstructured.NestedSlice(u.Object, "spec", "template", "spec", "containers")
+					assert.Nil(t, err)
+					assert.Equal(t, tc.wantImage, containers[0].(map[string]interface{})["image"])
+					assert.Equal(t, "web", u.GetLabels()["app"])
+				case "ConfigMap":
+					// The non-template files are not templated.
+					greeting, _, err := unstructured.NestedString(u.Object, "data", "greeting")
+					assert.Nil(t, err)
+					assert.Equal(t, "{{ .tag }}", greeting)
+				default:
+					t.Errorf("unexpected object kind %q", u.GetKind())
+				}
+			}
+
+			// The original filesystem is unchanged.
+			assert.True(t, fs.Exists("web/deployment.yaml.tmpl"))
+			assert.False(t, fs.Exists("web/deployment.yaml"))
+		})
+	}
+}
@@ -1,15 +1,20 @@
 package transform
 
 import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"fmt"
 	"html/template"
 	"strconv"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/kustomize/api/filesys"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
 )
 
 // TransformFunc is the type of a transform function. A transformation must
@@ -61,6 +66,31 @@ func Transform(fs filesys.FileSystem, manifestTransform ManifestTransform, commo
 	return nil
 }
 
+// Annotations used to set the server-side apply options of the objects. They
+// are removed from the objects before applying them.
+const (
+	// FieldManagerAnnotation is the field manager of an object.
+	FieldManagerAnnotation = "operator-toolkit/field-manager"
+	// ForceConflictsAnnotation forces the apply of an object on conflicts,
+	// when set to "true".
+	ForceConflictsAnnotation = "operator-toolkit/force-conflicts"
+)
+
+// SetFieldManagerFunc returns a TransformFunc that sets the server-side apply
+// field manager of an object, overriding the builder's field manager. With
+// forceConflicts, the apply of the object takes the ownership of the fields
+// managed by the other field managers, like when adopting the objects
+// installed by another tool. This is used only with server-side apply.
+func SetFieldManagerFunc(fieldManager string, forceConflicts bool) TransformFunc {
+	return func(obj *yaml.RNode) error {
+		// Set the annotations quoted, to keep the boolean value a string.
+		if err := obj.PipeE(yaml.SetAnnotation(FieldManagerAnnotation, fieldManager)); err != nil {
+			return err
+		}
+		return obj.PipeE(yaml.SetAnnotation(ForceConflictsAnnotation, strconv.FormatBool(forceConflicts)))
+	}
+}
+
 // AddLabelsFunc returns a TransformFunc that adds the given labels to an
 // object.
 func AddLabelsFunc(labels map[string]string) TransformFunc {
@@ -113,6 +143,28 @@ func AddAnnotationsFunc(annotations map[string]string) TransformFunc {
 	}
 }
 
+// AddPodTemplateLabelsFunc returns a TransformFunc that adds the given labels
+// to the pod template metadata (spec.template.metadata.labels) of a given
+// workload, like Deployment, StatefulSet, DaemonSet, Job and CronJob. The
+// labels of the workload object itself are not changed.
+func AddPodTemplateLabelsFunc(labels map[string]string) TransformFunc {
+	return func(obj *yaml.RNode) error {
+		return addPodTemplateMetadata(obj, "labels", labels)
+	}
+}
+
+// AddPodTemplateAnnotationsFunc returns a TransformFunc that adds the given
+// annotations to the pod template metadata
+// (spec.template.metadata.annotations) of a given workload, like Deployment,
+// StatefulSet, DaemonSet, Job and CronJob. This can be used to trigger a
+// rollout of the workload, for example, with a hash of its configuration. The
+// annotations of the workload object itself are not changed.
+func AddPodTemplateAnnotationsFunc(annotations map[string]string) TransformFunc {
+	return func(obj *yaml.RNode) error {
+		return addPodTemplateMetadata(obj, "annotations", annotations)
+	}
+}
+
 // SetReplicaFunc returns a TransformFunc that sets the replicas
 // (spec.replicas) in a given object.
 func SetReplicaFunc(replica int) TransformFunc {
@@ -205,3 +257,251 @@ func SetOwnerReference(ownerRefs []metav1.OwnerReference) TransformFunc {
 		)
 	}
 }
+
+// podSpecPath returns the path of the pod spec in an object of the given
+// kind. Pods have the pod spec at spec, CronJobs in their job template and
+// the other workloads in their pod template.
+func podSpecPath(kind string) []string {
+	switch kind {
+	case "Pod":
+		return []string{"spec"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template", "spec"}
+	default:
+		return []string{"spec", "template", "spec"}
+	}
+}
+
+// SetConfigHashAnnotationFunc returns a TransformFunc that sets an annotation
+// with the given key in the pod template metadata of a given workload, with a
+// hash of the given data as the value. The data is usually the content of the
+// ConfigMaps and Secrets used by the workload. A change in the data changes the
+// pod template, which rolls out the workload with the new configuration. The
+// hash is stable for the same data in the same order.
+func SetConfigHashAnnotationFunc(key string, data ...[]byte) TransformFunc {
+	return func(obj *yaml.RNode) error {
+		return addPodTemplateMetadata(obj, "annotations", map[string]string{key: configHash(data...)})
+	}
+}
+
+// configHash returns the hex encoded sha256 hash of the given data. Each data
+// is prefixed with its length to make the hash unambiguous for the different
+// splits of the same bytes.
+func configHash(data ...[]byte) string {
+	h := sha256.New()
+	for _, d := range data {
+		var size [8]byte
+		binary.BigEndian.PutUint64(size[:], uint64(len(d)))
+		h.Write(size[:])
+		h.Write(d)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// addPodTemplateMetadata adds the given values to a map field, labels or
+// annotations, of the pod template metadata of a given workload. The pod
+// template metadata is created if not found. Pods don't have a pod template.
+func addPodTemplateMetadata(obj *yaml.RNode, field string, values map[string]string) error {
+	meta, err := obj.GetMeta()
+	if err != nil {
+		return err
+	}
+	if meta.Kind == "Pod" {
+		return fmt.Errorf("%s %q has no pod template", meta.Kind, meta.Name)
+	}
+
+	// The pod template is the parent of the pod spec.
+	path := podSpecPath(meta.Kind)
+	path = append(path[:len(path)-1:len(path)-1], "metadata")
+	tmplMeta, err := obj.Pipe(yaml.LookupCreate(yaml.MappingNode, path...))
+	if err != nil {
+		return err
+	}
+
+	m := map[string]string{}
+	if err := getField(tmplMeta, field, &m); err != nil {
+		return err
+	}
+	for k, v := range values {
+		m[k] = v
+	}
+	if len(m) == 0 {
+		return nil
+	}
+	return setField(tmplMeta, field, m)
+}
+
+// lookupCreatePodSpec returns the pod spec of a given object, creating it if
+// not found.
+func lookupCreatePodSpec(obj *yaml.RNode) (*yaml.RNode, error) {
+	meta, err := obj.GetMeta()
+	if err != nil {
+		return nil, err
+	}
+	return obj.Pipe(yaml.LookupCreate(yaml.MappingNode, podSpecPath(meta.Kind)...))
+}
+
+// SetServiceAccountFunc returns a TransformFunc that sets the service account
+// name (serviceAccountName) in the pod spec of a given workload or pod.
+func SetServiceAccountFunc(name string) TransformFunc {
+	return func(obj *yaml.RNode) error {
+		podSpec, err := lookupCreatePodSpec(obj)
+		if err != nil {
+			return err
+		}
+		return podSpec.PipeE(yaml.SetField("serviceAccountName", yaml.NewScalarRNode(name)))
+	}
+}
+
+// SetImagePullSecretsFunc returns a TransformFunc that adds the given image
+// pull secrets (imagePullSecrets) to the pod spec of a given workload or pod.
+// The existing image pull secrets are kept and the secrets that already exist
+// are not added again.
+func SetImagePullSecretsFunc(names ...string) TransformFunc {
+	return func(obj *yaml.RNode) error {
+		podSpec, err := lookupCreatePodSpec(obj)
+		if err != nil {
+			return err
+		}
+		secrets, err := podSpec.Pipe(yaml.LookupCreate(yaml.SequenceNode, "imagePullSecrets"))
+		if err != nil {
+			return err
+		}
+
+		// Get the existing secret names.
+		existing, err := secrets.ElementValues("name")
+		if err != nil {
+			return err
+		}
+		found := map[string]bool{}
+		for _, name := range existing {
+			found[name] = true
+		}
+
+		// Append the new secrets.
+		for _, name := range names {
+			if found[name] {
+				continue
+			}
+			found[name] = true
+			secret := yaml.NewMapRNode(&map[string]string{"name": name})
+			if err := secrets.PipeE(yaml.Append(secret.YNode())); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// SetNodeSelectorFunc returns a TransformFunc that adds the given node
+// selector labels to the pod spec (nodeSelector) of a given workload or pod.
+// The existing node selector labels are kept, unless they're set in the given
+// node selector.
+func SetNodeSelectorFunc(nodeSelector map[string]string) TransformFunc {
+	return func(obj *yaml.RNode) error {
+		podSpec, err := lookupCreatePodSpec(obj)
+		if err != nil {
+			return err
+		}
+
+		ns := map[string]string{}
+		if err := getField(podSpec, "nodeSelector", &ns); err != nil {
+			return err
+		}
+		for k, v := range nodeSelector {
+			ns[k] = v
+		}
+		if len(ns) == 0 {
+			return nil
+		}
+		return setField(podSpec, "nodeSelector", ns)
+	}
+}
+
+// SetTolerationsFunc returns a TransformFunc that adds the given tolerations
+// to the pod spec (tolerations) of a given workload or pod. The existing
+// tolerations are kept and the tolerations that already exist are not added
+// again.
+func SetTolerationsFunc(tolerations []corev1.Toleration) TransformFunc {
+	return func(obj *yaml.RNode) error {
+		podSpec, err := lookupCreatePodSpec(obj)
+		if err != nil {
+			return err
+		}
+
+		existing := []corev1.Toleration{}
+		if err := getField(podSpec, "tolerations", &existing); err != nil {
+			return err
+		}
+		for i := range tolerations {
+			if !hasToleration(existing, &tolerations[i]) {
+				existing = append(existing, tolerations[i])
+			}
+		}
+		if len(existing) == 0 {
+			return nil
+		}
+		return setField(podSpec, "tolerations", existing)
+	}
+}
+
+// hasToleration returns true if the given toleration exists in a list of
+// tolerations.
+func hasToleration(tolerations []corev1.Toleration, toleration *corev1.Toleration) bool {
+	for i := range tolerations {
+		if tolerations[i].MatchToleration(toleration) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetAffinityFunc returns a TransformFunc that sets the affinity in the pod
+// spec of a given workload or pod. Any existing affinity is replaced. A nil
+// affinity removes the existing affinity.
+func SetAffinityFunc(affinity *corev1.Affinity) TransformFunc {
+	return func(obj *yaml.RNode) error {
+		podSpec, err := lookupCreatePodSpec(obj)
+		if err != nil {
+			return err
+		}
+		if affinity == nil {
+			_, err := podSpec.Pipe(yaml.Clear("affinity"))
+			return err
+		}
+		return setField(podSpec, "affinity", affinity)
+	}
+}
+
+// getField decodes the value of a field of a given node into out. out is
+// unchanged if the field is not found.
+func getField(node *yaml.RNode, field string, out interface{}) error {
+	f, err := node.Pipe(yaml.Lookup(field))
+	if err != nil {
+		return err
+	}
+	if yaml.IsMissingOrNull(f) {
+		return nil
+	}
+	s, err := f.String()
+	if err != nil {
+		return err
+	}
+	if err := sigsyaml.Unmarshal([]byte(s), out); err != nil {
+		return fmt.Errorf("failed to decode %q: %w", field, err)
+	}
+	return nil
+}
+
+// setField encodes the given value and sets it as a field of a given node.
+func setField(node *yaml.RNode, field string, value interface{}) error {
+	b, err := sigsyaml.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %q: %w", field, err)
+	}
+	v, err := yaml.Parse(string(b))
+	if err != nil {
+		return fmt.Errorf("failed to parse %q: %w", field, err)
+	}
+	return node.PipeE(yaml.SetField(field, v))
+}
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/kustomize/kyaml/yaml"
 
@@ -181,3 +182,358 @@ func checkLabelsAnnotationsAndOwnerRefs(t *testing.T, fs *loader.ManifestFileSys
 	// lookup of the metadata field and get owner reference for comparison.
 	assert.True(t, strings.Contains(string(b), ownerRefs))
 }
+
+const testDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      imagePullSecrets:
+      - name: existing
+      containers:
+      - name: web
+        image: nginx
+`
+
+const testPod = `apiVersion: v1
+kind: Pod
+metadata:
+  name: web
+spec:
+  containers:
+  - name: web
+    image: nginx
+`
+
+func TestPodSpecTransforms(t *testing.T) {
+	cases := []struct {
+		name                 string
+		manifest             string
+		podSpecPath          []string
+		wantImagePullSecrets []string
+	}{
+		{
+			name:                 "deployment",
+			manifest:             testDeployment,
+			podSpecPath:          []string{"spec", "template", "spec"},
+			wantImagePullSecrets: []string{"existing", "regcred", "other"},
+		},
+		{
+			name:                 "pod",
+			manifest:             testPod,
+			podSpecPath:          []string{"spec"},
+			wantImagePullSecrets: []string{"regcred", "existing", "other"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			obj, err := yaml.Parse(tc.manifest)
+			assert.Nil(t, err)
+
+			transforms := []TransformFunc{
+				SetServiceAccountFunc("web-sa"),
+				SetImagePullSecretsFunc("regcred", "existing"),
+				// Applying again should not duplicate the secrets.
+				SetImagePullSecretsFunc("regcred", "other"),
+			}
+			for _, tf := range transforms {
+				assert.Nil(t, tf(obj))
+			}
+
+			podSpec, err := obj.Pipe(yaml.Lookup(tc.podSpecPath...))
+			assert.Nil(t, err)
+			assert.NotNil(t, podSpec)
+
+			sa, err := podSpec.Pipe(yaml.Lookup("serviceAccountName"))
+			assert.Nil(t, err)
+			assert.Equal(t, "web-sa", yaml.GetValue(sa))
+
+			secrets, err := podSpec.Pipe(yaml.Lookup("imagePullSecrets"))
+			assert.Nil(t, err)
+			names, err := secrets.ElementValues("name")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantImagePullSecrets, names)
+
+			// The containers must be unchanged.
+			containers, err := podSpec.Pipe(yaml.Lookup("containers"))
+			assert.Nil(t, err)
+			containerNames, err := containers.ElementValues("name")
+			assert.Nil(t, err)
+			assert.Equal(t, []string{"web"}, containerNames)
+		})
+	}
+}
+
+const testScheduledDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      nodeSelector:
+        disktype: hdd
+        zone: a
+      tolerations:
+      - key: dedicated
+        operator: Equal
+        value: web
+        effect: NoSchedule
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 1
+            podAffinityTerm:
+              topologyKey: kubernetes.io/hostname
+      containers:
+      - name: web
+        image: nginx
+`
+
+func TestSetNodeSelectorFunc(t *testing.T) {
+	cases := []struct {
+		name     string
+		manifest string
+		selector map[string]string
+		want     map[string]string
+	}{
+		{
+			name:     "merge with existing",
+			manifest: testScheduledDeployment,
+			selector: map[string]string{"gpu": "true"},
+			want:     map[string]string{"disktype": "hdd", "zone": "a", "gpu": "true"},
+		},
+		{
+			name:     "replace existing values",
+			manifest: testScheduledDeployment,
+			selector: map[string]string{"disktype": "ssd"},
+			want:     map[string]string{"disktype": "ssd", "zone": "a"},
+		},
+		{
+			name:     "no existing node selector",
+			manifest: testPod,
+			selector: map[string]string{"disktype": "ssd"},
+			want:     map[string]string{"disktype": "ssd"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			obj, err := yaml.Parse(tc.manifest)
+			assert.Nil(t, err)
+			assert.Nil(t, SetNodeSelectorFunc(tc.selector)(obj))
+
+			podSpec, err := lookupCreatePodSpec(obj)
+			assert.Nil(t, err)
+			got := map[string]string{}
+			assert.Nil(t, getField(podSpec, "nodeSelector", &got))
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestSetTolerationsFunc(t *testing.T) {
+	obj, err := yaml.Parse(testScheduledDeployment)
+	assert.Nil(t, err)
+
+	existing := corev1.Toleration{
+		Key:      "dedicated",
+		Operator: corev1.TolerationOpEqual,
+		Value:    "web",
+		Effect:   corev1.TaintEffectNoSchedule,
+	}
+	gpu := corev1.Toleration{
+		Key:      "gpu",
+		Operator: corev1.TolerationOpExists,
+		Effect:   corev1.TaintEffectNoExecute,
+	}
+	assert.Nil(t, SetTolerationsFunc([]corev1.Toleration{existing, gpu})(obj))
+
+	podSpec, err := lookupCreatePodSpec(obj)
+	assert.Nil(t, err)
+	got := []corev1.Toleration{}
+	assert.Nil(t, getField(podSpec, "tolerations", &got))
+	assert.Equal(t, []corev1.Toleration{existing, gpu}, got)
+}
+
+func TestSetAffinityFunc(t *testing.T) {
+	affinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{
+						MatchExpressions: []corev1.NodeSelectorRequirement{
+							{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a", "b"}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	obj, err := yaml.Parse(testScheduledDeployment)
+	assert.Nil(t, err)
+	assert.Nil(t, SetAffinityFunc(affinity)(obj))
+
+	// The existing affinity is replaced.
+	podSpec, err := lookupCreatePodSpec(obj)
+	assert.Nil(t, err)
+	got := &corev1.Affinity{}
+	assert.Nil(t, getField(podSpec, "affinity", got))
+	assert.Equal(t, affinity, got)
+
+	// A nil affinity removes the affinity.
+	assert.Nil(t, SetAffinityFunc(nil)(obj))
+	f, err := podSpec.Pipe(yaml.Lookup("affinity"))
+	assert.Nil(t, err)
+	assert.Nil(t, f)
+}
+
+const testLabeledDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  labels:
+    app: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`
+
+const testCronJob = `apiVersion: batch/v1beta1
+kind: CronJob
+metadata:
+  name: backup
+spec:
+  schedule: "0 * * * *"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: backup
+            image: busybox
+`
+
+func TestPodTemplateMetadataTransforms(t *testing.T) {
+	cases := []struct {
+		name             string
+		manifest         string
+		templatePath     []string
+		wantLabels       map[string]string
+		wantObjectLabels map[string]string
+		wantErr          bool
+	}{
+		{
+			name:             "deployment",
+			manifest:         testLabeledDeployment,
+			templatePath:     []string{"spec", "template"},
+			wantLabels:       map[string]string{"app": "web", "tier": "frontend"},
+			wantObjectLabels: map[string]string{"app": "web"},
+		},
+		{
+			name:         "cronjob",
+			manifest:     testCronJob,
+			templatePath: []string{"spec", "jobTemplate", "spec", "template"},
+			wantLabels:   map[string]string{"tier": "frontend"},
+		},
+		{
+			name:     "pod",
+			manifest: testPod,
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			obj, err := yaml.Parse(tc.manifest)
+			assert.Nil(t, err)
+
+			err = AddPodTemplateLabelsFunc(map[string]string{"tier": "frontend"})(obj)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Nil(t, AddPodTemplateAnnotationsFunc(map[string]string{"config-hash": "abc"})(obj))
+
+			tmpl, err := obj.Pipe(yaml.Lookup(tc.templatePath...))
+			assert.Nil(t, err)
+			assert.NotNil(t, tmpl)
+
+			labels, err := tmpl.GetLabels()
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantLabels, labels)
+
+			annotations, err := tmpl.GetAnnotations()
+			assert.Nil(t, err)
+			assert.Equal(t, map[string]string{"config-hash": "abc"}, annotations)
+
+			// The top-level metadata must be unchanged.
+			objLabels, err := obj.GetLabels()
+			assert.Nil(t, err)
+			if tc.wantObjectLabels == nil {
+				assert.Empty(t, objLabels)
+			} else {
+				assert.Equal(t, tc.wantObjectLabels, objLabels)
+			}
+			objAnnotations, err := obj.GetAnnotations()
+			assert.Nil(t, err)
+			assert.Empty(t, objAnnotations)
+
+			// The pod spec must be unchanged.
+			spec, err := tmpl.Pipe(yaml.Lookup("spec", "containers"))
+			assert.Nil(t, err)
+			assert.NotNil(t, spec)
+		})
+	}
+}
+
+func TestSetConfigHashAnnotationFunc(t *testing.T) {
+	const key = "example.com/config-hash"
+
+	// hashOf applies the transform with the given data to a deployment and
+	// returns the resulting pod template annotation.
+	hashOf := func(data ...[]byte) string {
+		obj, err := yaml.Parse(testLabeledDeployment)
+		assert.Nil(t, err)
+		assert.Nil(t, SetConfigHashAnnotationFunc(key, data...)(obj))
+
+		tmpl, err := obj.Pipe(yaml.Lookup("spec", "template"))
+		assert.Nil(t, err)
+		annotations, err := tmpl.GetAnnotations()
+		assert.Nil(t, err)
+		assert.NotEmpty(t, annotations[key])
+
+		// The top-level annotations must be unchanged.
+		objAnnotations, err := obj.GetAnnotations()
+		assert.Nil(t, err)
+		assert.Empty(t, objAnnotations)
+		return annotations[key]
+	}
+
+	configMap := []byte("foo: bar")
+	secret := []byte("password: secret")
+
+	// The hash is stable for the same data.
+	assert.Equal(t, hashOf(configMap, secret), hashOf(configMap, secret))
+
+	// The hash changes when the data changes.
+	assert.NotEqual(t, hashOf(configMap, secret), hashOf([]byte("foo: baz"), secret))
+	assert.NotEqual(t, hashOf(configMap, secret), hashOf(configMap))
+
+	// The hash depends on how the data is split.
+	assert.NotEqual(t, hashOf([]byte("ab"), []byte("c")), hashOf([]byte("a"), []byte("bc")))
+}
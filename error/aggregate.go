@@ -0,0 +1,48 @@
+package error
+
+import (
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+)
+
+// Aggregate is an aggregate of errors that preserves the retryable and
+// terminal behaviors of the aggregated errors. The aggregate is terminal if
+// any of the errors is terminal. It's retryable only if it's not terminal and
+// all the errors are retryable.
+type Aggregate struct {
+	kerrors.Aggregate
+}
+
+// NewAggregate converts a slice of errors into an Aggregate. The nil errors
+// are ignored. If the slice is empty or contains only nil errors, nil is
+// returned.
+func NewAggregate(errs []error) error {
+	agg := kerrors.NewAggregate(errs)
+	if agg == nil {
+		return nil
+	}
+	return Aggregate{Aggregate: agg}
+}
+
+// Terminal returns true if any of the aggregated errors is terminal.
+func (a Aggregate) Terminal() bool {
+	for _, err := range a.Errors() {
+		if IsTerminal(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// Retryable returns true if none of the aggregated errors is terminal and all
+// of them are retryable.
+func (a Aggregate) Retryable() bool {
+	if a.Terminal() {
+		return false
+	}
+	for _, err := range a.Errors() {
+		if !IsRetryable(err) {
+			return false
+		}
+	}
+	return true
+}
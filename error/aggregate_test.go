@@ -0,0 +1,95 @@
+package error
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// behaviorError is an error with configurable retryable and terminal
+// behaviors.
+type behaviorError struct {
+	retryable bool
+	terminal  bool
+}
+
+func (e behaviorError) Error() string   { return "behavior error" }
+func (e behaviorError) Retryable() bool { return e.retryable }
+func (e behaviorError) Terminal() bool  { return e.terminal }
+
+func TestAggregate(t *testing.T) {
+	retryableErr := behaviorError{retryable: true}
+	terminalErr := behaviorError{terminal: true}
+	plainErr := errors.New("plain error")
+
+	cases := []struct {
+		name          string
+		errs          []error
+		wantNil       bool
+		wantRetryable bool
+		wantTerminal  bool
+	}{
+		{
+			name:    "no errors",
+			errs:    []error{nil, nil},
+			wantNil: true,
+		},
+		{
+			name:          "all retryable",
+			errs:          []error{retryableErr, nil, fmt.Errorf("wrapped: %w", retryableErr)},
+			wantRetryable: true,
+		},
+		{
+			name: "retryable and plain",
+			errs: []error{retryableErr, plainErr},
+		},
+		{
+			name:         "terminal takes precedence over retryable",
+			errs:         []error{retryableErr, terminalErr},
+			wantTerminal: true,
+		},
+		{
+			name:         "terminal and plain",
+			errs:         []error{plainErr, terminalErr},
+			wantTerminal: true,
+		},
+		{
+			name:          "nested retryable aggregate",
+			errs:          []error{NewAggregate([]error{retryableErr, retryableErr}), retryableErr},
+			wantRetryable: true,
+		},
+		{
+			name:         "nested terminal aggregate",
+			errs:         []error{NewAggregate([]error{retryableErr, terminalErr}), retryableErr},
+			wantTerminal: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := NewAggregate(tc.errs)
+			if tc.wantNil {
+				assert.Nil(t, err)
+				return
+			}
+			assert.NotNil(t, err)
+			assert.Equal(t, tc.wantRetryable, IsRetryable(err))
+			assert.Equal(t, tc.wantTerminal, IsTerminal(err))
+		})
+	}
+}
+
+func TestAggregateIs(t *testing.T) {
+	target := errors.New("target")
+	err := NewAggregate([]error{errors.New("other"), fmt.Errorf("wrapped: %w", target)})
+	assert.True(t, errors.Is(err, target))
+	assert.EqualError(t, err, "[other, wrapped: target]")
+
+	// The behaviors are preserved when wrapped.
+	wrapped := fmt.Errorf("reconcile failed: %w", NewAggregate([]error{behaviorError{terminal: true}}))
+	assert.True(t, IsTerminal(wrapped))
+	assert.False(t, IsRetryable(wrapped))
+}
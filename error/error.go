@@ -1,5 +1,7 @@
 package error
 
+import "errors"
+
 // multipleInstances defines an interface for errors to implement when an error
 // is caused by multiple instances of something.
 type multipleInstances interface {
@@ -14,3 +16,34 @@ func IsMultipleInstancesFound(err error) (bool, int) {
 	}
 	return false, 0
 }
+
+// retryable defines an interface for errors to implement when an error is
+// temporary and the operation can be retried.
+type retryable interface {
+	Retryable() bool
+}
+
+// terminal defines an interface for errors to implement when an error is
+// permanent and the operation must not be retried.
+type terminal interface {
+	Terminal() bool
+}
+
+// IsRetryable checks if the given error, or any error it wraps, is
+// retryable.
+func IsRetryable(err error) bool {
+	var e retryable
+	if errors.As(err, &e) {
+		return e.Retryable()
+	}
+	return false
+}
+
+// IsTerminal checks if the given error, or any error it wraps, is terminal.
+func IsTerminal(err error) bool {
+	var e terminal
+	if errors.As(err, &e) {
+		return e.Terminal()
+	}
+	return false
+}
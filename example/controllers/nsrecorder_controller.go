@@ -53,6 +53,7 @@ func (r *NamespaceRecorderReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		instrumentation: telemetry.NewInstrumentationWithProviders(
 			InstrumentationName, nil, nil, log),
 		configmapNamespace: "default",
+		getObject:          r.Reconciler.GetObject,
 	}
 
 	// Initialize the reconciler with the namespace recorder controller.
@@ -77,15 +78,17 @@ type nsRecorder struct {
 
 	// configmapNamespace is the namespace where the configmaps will be created.
 	configmapNamespace string
+
+	// getObject fetches an object using the reconciler's reader.
+	getObject func(context.Context, client.ObjectKey, client.Object) (interface{}, error)
 }
 
 // GetObject implements the stateless-action controller interface. It returns
 // an object given an object key.
 func (n *nsRecorder) GetObject(ctx context.Context, key client.ObjectKey) (interface{}, error) {
-	// Fetch and return the target namespace object from k8s.
-	ns := &corev1.Namespace{}
-	err := n.Get(ctx, key, ns)
-	return ns, err
+	// Fetch and return the target namespace object using the reconciler's
+	// reader.
+	return n.getObject(ctx, key, &corev1.Namespace{})
 }
 
 // RequireAction implements the stateless-action controller interface. It
@@ -132,7 +132,9 @@ func main() {
 	// passing nil instead of the manager, the certificate manager is not
 	// managed by the controller manager. It starts immediately, in a blocking
 	// fashion, ensuring that the cert is created before the webhook server
-	// starts.
+	// starts. When the certificate manager is managed by the controller
+	// manager instead, add cert.ReadyCheck as a ready check to not receive
+	// the webhook traffic until the certificate is provisioned.
 	if err := cert.NewManager(nil, certOpts); err != nil {
 		setupLog.Error(err, "unable to provision certificate")
 		os.Exit(1)
@@ -146,10 +148,9 @@ func main() {
 		os.Exit(1)
 	}
 
-	// TODO: Create a cached client that reads form the created cache as a
-	// DelegatingClient, refer:
-	// https://github.com/kubernetes-sigs/controller-runtime/blob/v0.8.3/pkg/client/split.go#L44.
-	// This client can be passed to the controllers that use this cache.
+	// A cached client that reads from the created cache and writes through
+	// the API client can be created with extcache.NewCachedClient. This
+	// client can be passed to the controllers that use this cache.
 
 	if err = (&controllers.GameReconciler{
 		Client: mgr.GetClient(),
@@ -288,6 +289,10 @@ func main() {
 		setupLog.Error(err, "unable to set up ready check")
 		os.Exit(1)
 	}
+	if err := mgr.AddReadyzCheck("webhook-cert", cert.ReadyCheck(certOpts)); err != nil {
+		setupLog.Error(err, "unable to set up webhook cert ready check")
+		os.Exit(1)
+	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
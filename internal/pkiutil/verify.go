@@ -0,0 +1,64 @@
+package pkiutil
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+)
+
+// VerifyCertAgainstCA verifies that the PEM-encoded leaf certificate chains
+// to the PEM-encoded CA certificates and is valid for the given DNS name.
+// Any certificates after the leaf in certPEM are used as intermediates.
+func VerifyCertAgainstCA(certPEM, caPEM []byte, dnsName string) error {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		return errors.New("no valid CA certificate found")
+	}
+
+	certs, err := parseCertsPEM(certPEM)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse the certificate")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range certs[1:] {
+		intermediates.AddCert(c)
+	}
+
+	verifyOptions := x509.VerifyOptions{
+		DNSName:       dnsName,
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+	if _, err := certs[0].Verify(verifyOptions); err != nil {
+		return errors.Wrap(err, "certificate verification failed")
+	}
+
+	return nil
+}
+
+// parseCertsPEM returns the certificates in the given PEM-encoded data.
+func parseCertsPEM(pemCerts []byte) ([]*x509.Certificate, error) {
+	certs := []*x509.Certificate{}
+	for {
+		var block *pem.Block
+		block, pemCerts = pem.Decode(pemCerts)
+		if block == nil {
+			break
+		}
+		if block.Type != CertificateBlockType {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("data doesn't contain any valid certificate")
+	}
+	return certs, nil
+}
@@ -0,0 +1,119 @@
+package pkiutil
+
+import (
+	"crypto"
+	"crypto/x509"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	certutil "k8s.io/client-go/util/cert"
+)
+
+func newTestCA(t *testing.T, name string) (*x509.Certificate, crypto.Signer) {
+	caCert, caKey, err := NewCertificateAuthority(&CertConfig{
+		Config: certutil.Config{CommonName: name},
+	})
+	assert.Nil(t, err)
+	return caCert, caKey
+}
+
+func newTestLeaf(t *testing.T, caCert *x509.Certificate, caKey crypto.Signer, dnsName string) *x509.Certificate {
+	cert, _, err := NewCertAndKey(caCert, caKey, &CertConfig{
+		Config: certutil.Config{
+			CommonName: dnsName,
+			AltNames:   certutil.AltNames{DNSNames: []string{dnsName}},
+			Usages:     []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		},
+	})
+	assert.Nil(t, err)
+	return cert
+}
+
+func TestVerifyCertAgainstCA(t *testing.T) {
+	dnsName := "webhook.test-ns.svc"
+
+	caCert, caKey := newTestCA(t, "test-ca")
+	otherCACert, otherCAKey := newTestCA(t, "other-ca")
+	interCert, interKey, err := NewIntermediateCertificateAuthority(caCert, caKey, &CertConfig{
+		Config: certutil.Config{CommonName: "test-intermediate-ca"},
+	})
+	assert.Nil(t, err)
+
+	leaf := newTestLeaf(t, caCert, caKey, dnsName)
+	otherLeaf := newTestLeaf(t, otherCACert, otherCAKey, dnsName)
+	interLeaf := newTestLeaf(t, interCert, interKey, dnsName)
+	interChain, err := EncodeCertBundlePEM([]*x509.Certificate{interLeaf, interCert})
+	assert.Nil(t, err)
+
+	testcases := []struct {
+		name    string
+		certPEM []byte
+		caPEM   []byte
+		dnsName string
+		wantErr bool
+	}{
+		{
+			name:    "matching CA and leaf",
+			certPEM: EncodeCertPEM(leaf),
+			caPEM:   EncodeCertPEM(caCert),
+			dnsName: dnsName,
+		},
+		{
+			name:    "leaf signed by another CA",
+			certPEM: EncodeCertPEM(otherLeaf),
+			caPEM:   EncodeCertPEM(caCert),
+			dnsName: dnsName,
+			wantErr: true,
+		},
+		{
+			name:    "CA bundle with the signing CA",
+			certPEM: EncodeCertPEM(otherLeaf),
+			caPEM:   append(EncodeCertPEM(caCert), EncodeCertPEM(otherCACert)...),
+			dnsName: dnsName,
+		},
+		{
+			name:    "mismatching DNS name",
+			certPEM: EncodeCertPEM(leaf),
+			caPEM:   EncodeCertPEM(caCert),
+			dnsName: "other.test-ns.svc",
+			wantErr: true,
+		},
+		{
+			name:    "leaf with intermediate CA",
+			certPEM: interChain,
+			caPEM:   EncodeCertPEM(caCert),
+			dnsName: dnsName,
+		},
+		{
+			name:    "leaf without intermediate CA",
+			certPEM: EncodeCertPEM(interLeaf),
+			caPEM:   EncodeCertPEM(caCert),
+			dnsName: dnsName,
+			wantErr: true,
+		},
+		{
+			name:    "invalid CA",
+			certPEM: EncodeCertPEM(leaf),
+			caPEM:   []byte("invalid"),
+			dnsName: dnsName,
+			wantErr: true,
+		},
+		{
+			name:    "invalid certificate",
+			certPEM: []byte("invalid"),
+			caPEM:   EncodeCertPEM(caCert),
+			dnsName: dnsName,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := VerifyCertAgainstCA(tc.certPEM, tc.caPEM, tc.dnsName)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %t, actual: %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -16,6 +16,8 @@ limitations under the License.
 
 package generator
 
+import "time"
+
 // Artifacts hosts a private key, its corresponding serving certificate and
 // the CA certificate that signs the serving certificate.
 type Artifacts struct {
@@ -27,6 +29,22 @@ type Artifacts struct {
 	CAKey []byte
 	// PEM encoded CA certificate
 	CACert []byte
+	// PEM encoded CA certificate that was replaced by CACert in a CA
+	// rotation. It's trusted along with CACert until the end of the rotation
+	// grace period.
+	PreviousCACert []byte
+	// CARotationTime is the time of the last CA rotation.
+	CARotationTime time.Time
+	// External is true when the certificate is provided by an external
+	// issuer, like cert-manager, and must not be regenerated.
+	External bool
+}
+
+// CABundle returns the CA bundle to trust the serving certificate, the CA
+// certificate followed by the previous CA certificate, if any.
+func (a *Artifacts) CABundle() []byte {
+	bundle := append([]byte{}, a.CACert...)
+	return append(bundle, a.PreviousCACert...)
 }
 
 // CertGenerator is an interface to provision the serving certificate.
@@ -45,6 +45,9 @@ type SelfSignedCertGenerator struct {
 	// Validity is the length of the generated certificate's validity and signed by the
 	// root CA cert.
 	Validity time.Duration
+	// DNSNames are the additional DNS names of the generated certificate,
+	// along with the common name.
+	DNSNames []string
 }
 
 var _ CertGenerator = &SelfSignedCertGenerator{}
@@ -111,7 +114,7 @@ func (cp *SelfSignedCertGenerator) Generate(commonName string) (*Artifacts, erro
 				// Read more about the AltNames requirement since go 1.15 from
 				// https://github.com/golang/go/issues/39568#issuecomment-671424481.
 				AltNames: certutil.AltNames{
-					DNSNames: []string{commonName},
+					DNSNames: append([]string{commonName}, cp.DNSNames...),
 				},
 				Usages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 			},
@@ -24,6 +24,11 @@ import (
 type Provisioner struct {
 	// CertWriter knows how to persist the certificate.
 	CertWriter writer.CertWriter
+	// ExactCABundle sets the CABundle to the CA bundle of the certificate,
+	// the CA and the previous CA in a CA rotation, instead of appending the
+	// CA to the existing CABundle. The replaced CA is trimmed from the
+	// CABundle once the certificate no longer has a previous CA.
+	ExactCABundle bool
 }
 
 // Options are options for provisioning the certificate.
@@ -46,7 +51,7 @@ func (cp *Provisioner) Provision(ctx context.Context, options Options) (bool, er
 		return false, errors.New("CertWriter need to be set")
 	}
 
-	dnsName, err := dnsNameFromClientConfig(options.ClientConfig)
+	dnsName, err := DNSNameFromClientConfig(options.ClientConfig)
 	if err != nil {
 		return false, err
 	}
@@ -56,6 +61,15 @@ func (cp *Provisioner) Provision(ctx context.Context, options Options) (bool, er
 		return false, err
 	}
 
+	if cp.ExactCABundle {
+		caBundle := certs.CABundle()
+		if !bytes.Equal(options.ClientConfig.CABundle, caBundle) {
+			options.ClientConfig.CABundle = caBundle
+			changed = true
+		}
+		return changed, cp.inject(ctx, options.ClientConfig, options.Objects)
+	}
+
 	caBundle := options.ClientConfig.CABundle
 	caCert := certs.CACert
 	// TODO(mengqiy): limit the size of the CABundle by GC the old CA certificate
@@ -115,7 +129,9 @@ func injectForCRD(
 	webhook.ClientConfig.CABundle = cc.CABundle
 }
 
-func dnsNameFromClientConfig(config *admissionregistrationv1.WebhookClientConfig) (string, error) {
+// DNSNameFromClientConfig returns the DNS name of the webhook server in the
+// given WebhookClientConfig, that the server certificate must be valid for.
+func DNSNameFromClientConfig(config *admissionregistrationv1.WebhookClientConfig) (string, error) {
 	if config == nil {
 		return "", errors.New("clientConfig should not be empty")
 	}
@@ -38,6 +38,14 @@ const (
 	ServerKeyName = "key.pem"
 	// ServerCertName is the name of the serving certificate
 	ServerCertName = "cert.pem"
+	// PreviousCACertName is the name of the CA certificate replaced in the
+	// last CA rotation
+	PreviousCACertName = "ca-cert-previous.pem"
+	// CARotationTimeName is the name of the time of the last CA rotation
+	CARotationTimeName = "ca-rotation-time"
+	// ExternalCACertName is the name of the CA certificate of an externally
+	// provided certificate, stored along with tls.crt and tls.key
+	ExternalCACertName = "ca.crt"
 )
 
 // CertWriter provides method to handle webhooks.
@@ -52,7 +60,9 @@ type CertWriter interface {
 
 // handleCommon ensures the given webhook has a proper certificate.
 // It uses the given certReadWriter to read and (or) write the certificate.
-func handleCommon(ctx context.Context, dnsName string, ch certReadWriter) (*generator.Artifacts, bool, error) {
+// The certificate is renewed when it expires within renewBefore, or within
+// six months if renewBefore is zero.
+func handleCommon(ctx context.Context, dnsName string, renewBefore time.Duration, ch certReadWriter) (*generator.Artifacts, bool, error) {
 	if len(dnsName) == 0 {
 		return nil, false, errors.New("dnsName should not be empty")
 	}
@@ -65,8 +75,17 @@ func handleCommon(ctx context.Context, dnsName string, ch certReadWriter) (*gene
 		return nil, changed, err
 	}
 
+	// Reuse an externally provided cert as long as it's valid. Its renewal
+	// is up to the external issuer.
+	if certs != nil && certs.External {
+		if verifyCert(certs, dnsName, time.Now()) {
+			return certs, changed, nil
+		}
+		log.Info("external cert is invalid, regenerating a new one")
+	}
+
 	// Recreate the cert if it's invalid.
-	valid := validCert(certs, dnsName)
+	valid := validCert(certs, dnsName, renewBefore)
 	if !valid {
 		log.Info("cert is invalid or expiring, regenerating a new one")
 		certs, err = ch.overwrite(ctx)
@@ -106,7 +125,18 @@ type certReadWriter interface {
 	overwrite(context.Context) (*generator.Artifacts, error)
 }
 
-func validCert(certs *generator.Artifacts, dnsName string) bool {
+func validCert(certs *generator.Artifacts, dnsName string, renewBefore time.Duration) bool {
+	// Verify cert will be valid for desired period of time.
+	validUntil := time.Now().AddDate(0, 6, 0)
+	if renewBefore > 0 {
+		validUntil = time.Now().Add(renewBefore)
+	}
+	return verifyCert(certs, dnsName, validUntil)
+}
+
+// verifyCert verifies that the cert and key are a valid pair and that the
+// cert is good for the DNS name and signed by the CA at the given time.
+func verifyCert(certs *generator.Artifacts, dnsName string, at time.Time) bool {
 	if certs == nil {
 		return false
 	}
@@ -117,8 +147,7 @@ func validCert(certs *generator.Artifacts, dnsName string) bool {
 		return false
 	}
 
-	// Verify cert is good for desired DNS name and signed by CA and will be
-	// valid for desired period of time.
+	// Verify cert is good for desired DNS name and signed by CA.
 	pool := x509.NewCertPool()
 	if !pool.AppendCertsFromPEM(certs.CACert) {
 		return false
@@ -134,7 +163,7 @@ func validCert(certs *generator.Artifacts, dnsName string) bool {
 	ops := x509.VerifyOptions{
 		DNSName:     dnsName,
 		Roots:       pool,
-		CurrentTime: time.Now().AddDate(0, 6, 0),
+		CurrentTime: at,
 	}
 	_, err = cert.Verify(ops)
 	if err != nil {
@@ -19,6 +19,7 @@ package writer
 import (
 	"context"
 	goerrors "errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -112,14 +113,14 @@ var _ = Describe("handleCommon", func() {
 	Context("when DNS name is empty", func() {
 		It("should return an error", func() {
 			certrw := &fakeCertReadWriter{}
-			_, _, err := handleCommon(ctx, "", certrw)
+			_, _, err := handleCommon(ctx, "", 0, certrw)
 			Expect(err).To(MatchError("dnsName should not be empty"))
 		})
 	})
 
 	Context("when certReadWriter is nil", func() {
 		It("should return an error", func() {
-			_, _, err := handleCommon(ctx, dnsName, nil)
+			_, _, err := handleCommon(ctx, dnsName, 0, nil)
 			Expect(err).To(MatchError("certReaderWriter should not be nil"))
 		})
 	})
@@ -139,7 +140,7 @@ var _ = Describe("handleCommon", func() {
 				},
 			}
 
-			certs, changed, err := handleCommon(ctx, dnsName, certrw)
+			certs, changed, err := handleCommon(ctx, dnsName, 0, certrw)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(certrw.numReadCalled).To(Equal(1))
 			Expect(certrw.numWriteCalled).To(Equal(1))
@@ -162,7 +163,7 @@ var _ = Describe("handleCommon", func() {
 				},
 			}
 
-			_, _, err := handleCommon(ctx, dnsName, certrw)
+			_, _, err := handleCommon(ctx, dnsName, 0, certrw)
 			Expect(err).To(MatchError("failed to write"))
 			Expect(certrw.numReadCalled).To(Equal(1))
 			Expect(certrw.numWriteCalled).To(Equal(1))
@@ -180,7 +181,7 @@ var _ = Describe("handleCommon", func() {
 				},
 			}
 
-			certs, changed, err := handleCommon(ctx, dnsName, certrw)
+			certs, changed, err := handleCommon(ctx, dnsName, 0, certrw)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(certrw.numReadCalled).To(Equal(1))
 			Expect(certrw.numWriteCalled).To(Equal(0))
@@ -198,7 +199,7 @@ var _ = Describe("handleCommon", func() {
 				},
 			}
 
-			_, _, err := handleCommon(ctx, dnsName, certrw)
+			_, _, err := handleCommon(ctx, dnsName, 0, certrw)
 			Expect(err).To(MatchError("failed to read"))
 			Expect(certrw.numReadCalled).To(Equal(1))
 			Expect(certrw.numWriteCalled).To(Equal(0))
@@ -221,7 +222,7 @@ var _ = Describe("handleCommon", func() {
 				},
 			}
 
-			certs, changed, err := handleCommon(ctx, dnsName, certrw)
+			certs, changed, err := handleCommon(ctx, dnsName, 0, certrw)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(certrw.numReadCalled).To(Equal(1))
 			Expect(certrw.numWriteCalled).To(Equal(0))
@@ -244,7 +245,7 @@ var _ = Describe("handleCommon", func() {
 				},
 			}
 
-			certs, changed, err := handleCommon(ctx, dnsName, certrw)
+			certs, changed, err := handleCommon(ctx, dnsName, 0, certrw)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(certrw.numReadCalled).To(Equal(1))
 			Expect(certrw.numWriteCalled).To(Equal(0))
@@ -267,7 +268,7 @@ var _ = Describe("handleCommon", func() {
 				},
 			}
 
-			_, _, err := handleCommon(ctx, dnsName, certrw)
+			_, _, err := handleCommon(ctx, dnsName, 0, certrw)
 			Expect(err).To(MatchError("failed to overwrite"))
 			Expect(certrw.numReadCalled).To(Equal(1))
 			Expect(certrw.numOverwriteCalled).To(Equal(1))
@@ -292,7 +293,7 @@ var _ = Describe("handleCommon", func() {
 				},
 			}
 
-			certs, changed, err := handleCommon(ctx, dnsName, certrw)
+			certs, changed, err := handleCommon(ctx, dnsName, 0, certrw)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(certrw.numReadCalled).To(Equal(2))
 			Expect(certrw.numWriteCalled).To(Equal(1))
@@ -317,7 +318,7 @@ var _ = Describe("handleCommon", func() {
 				},
 			}
 
-			_, _, err := handleCommon(ctx, dnsName, certrw)
+			_, _, err := handleCommon(ctx, dnsName, 0, certrw)
 			Expect(err).To(MatchError("failed to read"))
 			Expect(certrw.numReadCalled).To(Equal(2))
 			Expect(certrw.numWriteCalled).To(Equal(1))
@@ -333,7 +334,7 @@ var _ = Describe("validate cert", func() {
 				Cert:   certs1.Cert,
 				Key:    certs2.Key,
 			}
-			valid := validCert(&certs, "example.com")
+			valid := validCert(&certs, "example.com", 0)
 			Expect(valid).To(BeFalse())
 		})
 	})
@@ -345,7 +346,7 @@ var _ = Describe("validate cert", func() {
 				Cert:   certs1.Cert,
 				Key:    certs1.Key,
 			}
-			valid := validCert(&certs, "example.com")
+			valid := validCert(&certs, "example.com", 0)
 			Expect(valid).To(BeFalse())
 		})
 	})
@@ -357,8 +358,19 @@ var _ = Describe("validate cert", func() {
 				Cert:   certs1.Cert,
 				Key:    certs1.Key,
 			}
-			valid := validCert(&certs, "foo.com")
+			valid := validCert(&certs, "foo.com", 0)
 			Expect(valid).To(BeFalse())
 		})
 	})
+
+	Context("short-lived cert with renew before", func() {
+		It("should be invalid within the renew before period", func() {
+			cp := generator.SelfSignedCertGenerator{Validity: 10 * time.Minute}
+			certs, err := cp.Generate("example.com")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(validCert(certs, "example.com", 3*time.Minute)).To(BeTrue())
+			Expect(validCert(certs, "example.com", 11*time.Minute)).To(BeFalse())
+		})
+	})
 })
@@ -17,8 +17,10 @@ limitations under the License.
 package writer
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -36,6 +38,9 @@ type secretCertWriter struct {
 
 	// dnsName is the DNS name that the certificate is for.
 	dnsName string
+
+	// current is the last read certificate.
+	current *generator.Artifacts
 }
 
 // SecretCertWriterOptions is options for constructing a secretCertWriter.
@@ -46,6 +51,29 @@ type SecretCertWriterOptions struct {
 	CertGenerator generator.CertGenerator
 	// secret points the secret that contains certificates that written by the CertWriter.
 	Secret *types.NamespacedName
+	// CARotationGracePeriod is the period for which the replaced CA
+	// certificate is kept along with the new CA certificate after a CA
+	// rotation. This allows publishing both the CAs in the CABundle of the
+	// webhook configurations during the rotation. If zero, the replaced CA
+	// certificate is not kept.
+	CARotationGracePeriod time.Duration
+	// RenewBefore is the period before the expiry of the serving
+	// certificate at which the certificate is renewed. If zero, the
+	// certificate is renewed when it expires within six months.
+	RenewBefore time.Duration
+	// SecretType is the type of the secret. With corev1.SecretTypeTLS, the
+	// serving certificate and key are also stored with the tls.crt and
+	// tls.key keys. If empty, the secret is created with the default type.
+	SecretType corev1.SecretType
+	// SecretLabels are the labels of the secret.
+	SecretLabels map[string]string
+	// SecretAnnotations are the annotations of the secret.
+	SecretAnnotations map[string]string
+	// DNSNames are the additional DNS names the certificate must be valid
+	// for, along with the DNS name of the webhook client config. The
+	// CertGenerator must generate the certificates for these names too. A
+	// generated certificate that isn't valid for all of them is regenerated.
+	DNSNames []string
 }
 
 var _ CertWriter = &secretCertWriter{}
@@ -82,7 +110,37 @@ func NewSecretCertWriter(ops SecretCertWriterOptions) (CertWriter, error) {
 func (s *secretCertWriter) EnsureCert(ctx context.Context, dnsName string) (*generator.Artifacts, bool, error) {
 	// Create or refresh the certs based on clientConfig
 	s.dnsName = dnsName
-	return handleCommon(ctx, s.dnsName, s)
+	previous := s.current
+	certs, changed, err := handleCommon(ctx, s.dnsName, s.RenewBefore, s)
+	if err != nil {
+		return certs, changed, err
+	}
+	// Regenerate a generated cert that isn't valid for the additional DNS
+	// names, like when a name was added.
+	if !certs.External && !s.validForDNSNames(certs) {
+		log.Info("cert is invalid for the additional DNS names, regenerating a new one")
+		if certs, err = s.overwrite(ctx); err != nil {
+			return nil, false, err
+		}
+		changed = true
+	}
+	// An external cert may be renewed by its issuer anytime. Report it as
+	// changed when it differs from the last read cert.
+	if certs.External && previous != nil && !bytes.Equal(previous.Cert, certs.Cert) {
+		changed = true
+	}
+	return certs, changed, nil
+}
+
+// validForDNSNames returns true if the given cert is valid for all the
+// additional DNS names.
+func (s *secretCertWriter) validForDNSNames(certs *generator.Artifacts) bool {
+	for _, name := range s.DNSNames {
+		if !verifyCert(certs, name, time.Now()) {
+			return false
+		}
+	}
+	return true
 }
 
 var _ certReadWriter = &secretCertWriter{}
@@ -92,10 +150,25 @@ func (s *secretCertWriter) buildSecret() (*corev1.Secret, *generator.Artifacts,
 	if err != nil {
 		return nil, nil, err
 	}
+	s.keepPreviousCA(certs)
 	secret := certsToSecret(certs, *s.Secret)
+	s.setSecretMeta(secret)
 	return secret, certs, err
 }
 
+// setSecretMeta sets the configured type, labels and annotations of the
+// given secret.
+func (s *secretCertWriter) setSecretMeta(secret *corev1.Secret) {
+	secret.Type = s.SecretType
+	secret.Labels = s.SecretLabels
+	secret.Annotations = s.SecretAnnotations
+	// A TLS secret must contain the TLS key pair.
+	if s.SecretType == corev1.SecretTypeTLS {
+		secret.Data[corev1.TLSCertKey] = secret.Data[ServerCertName]
+		secret.Data[corev1.TLSPrivateKeyKey] = secret.Data[ServerKeyName]
+	}
+}
+
 func (s *secretCertWriter) write(ctx context.Context) (*generator.Artifacts, error) {
 	secret, certs, err := s.buildSecret()
 	if err != nil {
@@ -127,30 +200,88 @@ func (s *secretCertWriter) read(ctx context.Context) (*generator.Artifacts, erro
 	}
 	err := s.Client.Get(ctx, *s.Secret, secret)
 	if apierrors.IsNotFound(err) {
+		s.current = nil
 		return nil, notFoundError{err}
 	}
 	certs := secretToCerts(secret)
-	if certs != nil {
+	if certs != nil && !certs.External {
+		s.trimPreviousCA(certs)
 		// Store the CA for next usage.
 		s.CertGenerator.SetCA(certs.CAKey, certs.CACert)
 	}
+	s.current = certs
 	return certs, nil
 }
 
+// keepPreviousCA sets the previous CA of the given generated certs when CA
+// rotation grace period is set. If the CA changed, the current CA becomes the
+// previous CA. Else, the previous CA of the current certs is kept.
+func (s *secretCertWriter) keepPreviousCA(certs *generator.Artifacts) {
+	if s.CARotationGracePeriod <= 0 || s.current == nil {
+		return
+	}
+	if len(s.current.CACert) > 0 && !bytes.Equal(s.current.CACert, certs.CACert) {
+		certs.PreviousCACert = s.current.CACert
+		certs.CARotationTime = time.Now()
+		return
+	}
+	certs.PreviousCACert = s.current.PreviousCACert
+	certs.CARotationTime = s.current.CARotationTime
+}
+
+// trimPreviousCA removes the previous CA from the given certs when the CA
+// rotation grace period is over.
+func (s *secretCertWriter) trimPreviousCA(certs *generator.Artifacts) {
+	if time.Now().Before(certs.CARotationTime.Add(s.CARotationGracePeriod)) {
+		return
+	}
+	certs.PreviousCACert = nil
+	certs.CARotationTime = time.Time{}
+}
+
+// secretToCerts returns the certs in the secret. A secret with a TLS key
+// pair and a CA certificate, but no generated certificate, is considered to
+// be provided by an external issuer, like cert-manager, and its certs are
+// marked external.
 func secretToCerts(secret *corev1.Secret) *generator.Artifacts {
 	if secret.Data == nil {
 		return nil
 	}
-	return &generator.Artifacts{
-		CAKey:  secret.Data[CAKeyName],
-		CACert: secret.Data[CACertName],
-		Cert:   secret.Data[ServerCertName],
-		Key:    secret.Data[ServerKeyName],
+	if isExternalSecret(secret) {
+		return &generator.Artifacts{
+			CACert:   secret.Data[ExternalCACertName],
+			Cert:     secret.Data[corev1.TLSCertKey],
+			Key:      secret.Data[corev1.TLSPrivateKeyKey],
+			External: true,
+		}
+	}
+	certs := &generator.Artifacts{
+		CAKey:          secret.Data[CAKeyName],
+		CACert:         secret.Data[CACertName],
+		Cert:           secret.Data[ServerCertName],
+		Key:            secret.Data[ServerKeyName],
+		PreviousCACert: secret.Data[PreviousCACertName],
 	}
+	// Ignore an invalid rotation time, the previous CA is then trimmed.
+	if rotationTime, err := time.Parse(time.RFC3339, string(secret.Data[CARotationTimeName])); err == nil {
+		certs.CARotationTime = rotationTime
+	}
+	return certs
+}
+
+// isExternalSecret checks if the secret contains an externally provided
+// certificate.
+func isExternalSecret(secret *corev1.Secret) bool {
+	if len(secret.Data[ServerCertName]) > 0 {
+		return false
+	}
+	return len(secret.Data[corev1.TLSCertKey]) > 0 &&
+		len(secret.Data[corev1.TLSPrivateKeyKey]) > 0 &&
+		len(secret.Data[ExternalCACertName]) > 0
 }
 
 func certsToSecret(certs *generator.Artifacts, sec types.NamespacedName) *corev1.Secret {
-	return &corev1.Secret{
+	secret := &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
 			Kind:       "Secret",
@@ -166,6 +297,11 @@ func certsToSecret(certs *generator.Artifacts, sec types.NamespacedName) *corev1
 			ServerCertName: certs.Cert,
 		},
 	}
+	if len(certs.PreviousCACert) > 0 {
+		secret.Data[PreviousCACertName] = certs.PreviousCACert
+		secret.Data[CARotationTimeName] = []byte(certs.CARotationTime.Format(time.RFC3339))
+	}
+	return secret
 }
 
 // Inject sets the ownerReference in the secret.
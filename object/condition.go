@@ -0,0 +1,157 @@
+package object
+
+import (
+	"fmt"
+
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// SetStatusCondition sets the given condition in the status conditions
+// (status.conditions) of an object. It works with any object with
+// metav1.Condition status conditions, typed or unstructured, without the
+// typed API of the object. The semantics of apimachinery's
+// meta.SetStatusCondition are followed: the lastTransitionTime is set to now,
+// if unset, only when the condition is added or its status changes. The
+// observedGeneration of the condition is set to the generation of the object,
+// if unset.
+func SetStatusCondition(obj client.Object, cond metav1.Condition) error {
+	if cond.ObservedGeneration == 0 {
+		cond.ObservedGeneration = obj.GetGeneration()
+	}
+	return updateUnstructured(obj, func(u map[string]interface{}) error {
+		conditions, err := getConditions(u)
+		if err != nil {
+			return err
+		}
+		apimeta.SetStatusCondition(&conditions, cond)
+		return setConditions(u, conditions)
+	})
+}
+
+// FindStatusCondition returns the status condition of the given type of an
+// object. It returns nil if the condition is not found.
+func FindStatusCondition(obj client.Object, condType string) (*metav1.Condition, error) {
+	conditions, err := statusConditions(obj)
+	if err != nil {
+		return nil, err
+	}
+	return apimeta.FindStatusCondition(conditions, condType), nil
+}
+
+// ChangedConditions returns the types of the status conditions that differ
+// between the given old and new versions of an object, in the order of the
+// new conditions followed by the removed conditions. A condition differs if
+// it's added, removed, or its status, reason, message or observed generation
+// changed.
+func ChangedConditions(oldObj, newObj client.Object) ([]string, error) {
+	oldConds, err := statusConditions(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	newConds, err := statusConditions(newObj)
+	if err != nil {
+		return nil, err
+	}
+
+	changed := []string{}
+	for _, cond := range newConds {
+		old := apimeta.FindStatusCondition(oldConds, cond.Type)
+		if old == nil || old.Status != cond.Status || old.Reason != cond.Reason ||
+			old.Message != cond.Message || old.ObservedGeneration != cond.ObservedGeneration {
+			changed = append(changed, cond.Type)
+		}
+	}
+	for _, cond := range oldConds {
+		if apimeta.FindStatusCondition(newConds, cond.Type) == nil {
+			changed = append(changed, cond.Type)
+		}
+	}
+	return changed, nil
+}
+
+// statusConditions returns the status conditions of an object.
+func statusConditions(obj client.Object) ([]metav1.Condition, error) {
+	u, err := toUnstructuredContent(obj)
+	if err != nil {
+		return nil, err
+	}
+	return getConditions(u)
+}
+
+// toUnstructuredContent returns the unstructured content of an object. For
+// unstructured objects, the object content is returned without a copy.
+func toUnstructuredContent(obj client.Object) (map[string]interface{}, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.Object, nil
+	}
+	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert %T to Unstructured: %v", obj, err)
+	}
+	return u, nil
+}
+
+// updateUnstructured runs the given update function on the unstructured
+// content of an object and writes the result back into typed objects.
+func updateUnstructured(obj client.Object, update func(map[string]interface{}) error) error {
+	u, err := toUnstructuredContent(obj)
+	if err != nil {
+		return err
+	}
+	if err := update(u); err != nil {
+		return err
+	}
+	if _, ok := obj.(*unstructured.Unstructured); ok {
+		return nil
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, obj); err != nil {
+		return fmt.Errorf("failed to convert Unstructured to %T: %v", obj, err)
+	}
+	return nil
+}
+
+// getConditions returns the status conditions in the given unstructured
+// object content.
+func getConditions(u map[string]interface{}) ([]metav1.Condition, error) {
+	conditions := []metav1.Condition{}
+	items, found, err := unstructured.NestedSlice(u, "status", "conditions")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status conditions: %v", err)
+	}
+	if !found {
+		return conditions, nil
+	}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("status condition was not of type map[string]interface{}")
+		}
+		cond := metav1.Condition{}
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(m, &cond); err != nil {
+			return nil, fmt.Errorf("failed to decode status condition: %v", err)
+		}
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// setConditions sets the status conditions in the given unstructured object
+// content.
+func setConditions(u map[string]interface{}, conditions []metav1.Condition) error {
+	items := make([]interface{}, 0, len(conditions))
+	for i := range conditions {
+		m, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&conditions[i])
+		if err != nil {
+			return fmt.Errorf("failed to encode status condition: %v", err)
+		}
+		items = append(items, m)
+	}
+	if err := unstructured.SetNestedSlice(u, items, "status", "conditions"); err != nil {
+		return fmt.Errorf("failed to set status conditions: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package object
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tdv1alpha1 "github.com/darkowlzz/operator-toolkit/testdata/api/v1alpha1"
+)
+
+func TestSetStatusCondition(t *testing.T) {
+	newUnstructuredGame := func() client.Object {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(tdv1alpha1.GroupVersion.String())
+		u.SetKind("Game")
+		u.SetName("zelda")
+		u.SetGeneration(2)
+		return u
+	}
+	newTypedGame := func() client.Object {
+		return &tdv1alpha1.Game{
+			ObjectMeta: metav1.ObjectMeta{Name: "zelda", Generation: 2},
+		}
+	}
+
+	cases := []struct {
+		name      string
+		newObject func() client.Object
+	}{
+		{name: "unstructured", newObject: newUnstructuredGame},
+		{name: "typed", newObject: newTypedGame},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			obj := tc.newObject()
+
+			cond, err := FindStatusCondition(obj, "Ready")
+			assert.Nil(t, err)
+			assert.Nil(t, cond)
+
+			// Add a new condition with a past transition time.
+			past := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+			assert.Nil(t, SetStatusCondition(obj, metav1.Condition{
+				Type:               "Ready",
+				Status:             metav1.ConditionFalse,
+				Reason:             "Pending",
+				LastTransitionTime: past,
+			}))
+			cond, err = FindStatusCondition(obj, "Ready")
+			assert.Nil(t, err)
+			assert.NotNil(t, cond)
+			assert.Equal(t, metav1.ConditionFalse, cond.Status)
+			assert.Equal(t, "Pending", cond.Reason)
+			assert.True(t, past.Equal(&cond.LastTransitionTime))
+			// The observed generation defaults to the object generation.
+			assert.Equal(t, int64(2), cond.ObservedGeneration)
+
+			// Update the condition without changing the status. The
+			// transition time must not change.
+			assert.Nil(t, SetStatusCondition(obj, metav1.Condition{
+				Type:               "Ready",
+				Status:             metav1.ConditionFalse,
+				Reason:             "Waiting",
+				Message:            "waiting for dependencies",
+				ObservedGeneration: 3,
+			}))
+			cond, err = FindStatusCondition(obj, "Ready")
+			assert.Nil(t, err)
+			assert.Equal(t, "Waiting", cond.Reason)
+			assert.Equal(t, "waiting for dependencies", cond.Message)
+			assert.Equal(t, int64(3), cond.ObservedGeneration)
+			assert.True(t, past.Equal(&cond.LastTransitionTime))
+
+			// Change the status. The transition time must change.
+			assert.Nil(t, SetStatusCondition(obj, metav1.Condition{
+				Type:   "Ready",
+				Status: metav1.ConditionTrue,
+				Reason: "Done",
+			}))
+			cond, err = FindStatusCondition(obj, "Ready")
+			assert.Nil(t, err)
+			assert.Equal(t, metav1.ConditionTrue, cond.Status)
+			assert.True(t, cond.LastTransitionTime.After(past.Time))
+
+			// Add another condition, the existing condition is kept.
+			assert.Nil(t, SetStatusCondition(obj, metav1.Condition{
+				Type:   "Degraded",
+				Status: metav1.ConditionFalse,
+				Reason: "Healthy",
+			}))
+			ready, err := FindStatusCondition(obj, "Ready")
+			assert.Nil(t, err)
+			assert.Equal(t, metav1.ConditionTrue, ready.Status)
+			degraded, err := FindStatusCondition(obj, "Degraded")
+			assert.Nil(t, err)
+			assert.NotNil(t, degraded)
+			assert.False(t, degraded.LastTransitionTime.IsZero())
+		})
+	}
+}
+
+func TestChangedConditions(t *testing.T) {
+	newGame := func(conds ...metav1.Condition) client.Object {
+		return &tdv1alpha1.Game{
+			ObjectMeta: metav1.ObjectMeta{Name: "zelda"},
+			Status:     tdv1alpha1.GameStatus{Conditions: conds},
+		}
+	}
+	ready := metav1.Condition{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Done"}
+	progressing := metav1.Condition{Type: "Progressing", Status: metav1.ConditionFalse, Reason: "Idle"}
+	notReady := ready
+	notReady.Status = metav1.ConditionFalse
+	readyMessage := ready
+	readyMessage.Message = "all good"
+	readyLater := ready
+	readyLater.LastTransitionTime = metav1.Now()
+
+	cases := []struct {
+		name        string
+		oldObj      client.Object
+		newObj      client.Object
+		wantChanged []string
+	}{
+		{
+			name:        "no conditions",
+			oldObj:      newGame(),
+			newObj:      newGame(),
+			wantChanged: []string{},
+		},
+		{
+			name:        "added",
+			oldObj:      newGame(progressing),
+			newObj:      newGame(progressing, ready),
+			wantChanged: []string{"Ready"},
+		},
+		{
+			name:        "removed",
+			oldObj:      newGame(progressing, ready),
+			newObj:      newGame(ready),
+			wantChanged: []string{"Progressing"},
+		},
+		{
+			name:        "status changed",
+			oldObj:      newGame(ready, progressing),
+			newObj:      newGame(notReady, progressing),
+			wantChanged: []string{"Ready"},
+		},
+		{
+			name:        "message changed",
+			oldObj:      newGame(ready),
+			newObj:      newGame(readyMessage),
+			wantChanged: []string{"Ready"},
+		},
+		{
+			name:        "transition time changed only",
+			oldObj:      newGame(ready),
+			newObj:      newGame(readyLater),
+			wantChanged: []string{},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			changed, err := ChangedConditions(tc.oldObj, tc.newObj)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantChanged, changed)
+		})
+	}
+}
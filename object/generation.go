@@ -0,0 +1,35 @@
+package object
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GetObservedGeneration returns the observed generation in the status
+// (status.observedGeneration) of an object. The second returned value is
+// false if the observed generation is not set.
+func GetObservedGeneration(obj client.Object) (int64, bool, error) {
+	u, err := toUnstructuredContent(obj)
+	if err != nil {
+		return 0, false, err
+	}
+	gen, found, err := unstructured.NestedInt64(u, "status", "observedGeneration")
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to get status observedGeneration: %v", err)
+	}
+	return gen, found, nil
+}
+
+// SetObservedGeneration sets the observed generation in the status
+// (status.observedGeneration) of an object. For typed objects, the status
+// type must have an observedGeneration field for it to be set.
+func SetObservedGeneration(obj client.Object, gen int64) error {
+	return updateUnstructured(obj, func(u map[string]interface{}) error {
+		if err := unstructured.SetNestedField(u, gen, "status", "observedGeneration"); err != nil {
+			return fmt.Errorf("failed to set status observedGeneration: %v", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,49 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	tdv1alpha1 "github.com/darkowlzz/operator-toolkit/testdata/api/v1alpha1"
+)
+
+func TestObservedGeneration(t *testing.T) {
+	cases := []struct {
+		name string
+		obj  client.Object
+	}{
+		{
+			name: "unstructured",
+			obj: func() client.Object {
+				u := &unstructured.Unstructured{}
+				u.SetAPIVersion(tdv1alpha1.GroupVersion.String())
+				u.SetKind("Game")
+				u.SetName("zelda")
+				return u
+			}(),
+		},
+		{
+			name: "typed",
+			obj:  &tdv1alpha1.Game{ObjectMeta: metav1.ObjectMeta{Name: "zelda"}},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			_, found, err := GetObservedGeneration(tc.obj)
+			assert.Nil(t, err)
+			assert.False(t, found)
+
+			assert.Nil(t, SetObservedGeneration(tc.obj, 3))
+			gen, found, err := GetObservedGeneration(tc.obj)
+			assert.Nil(t, err)
+			assert.True(t, found)
+			assert.Equal(t, int64(3), gen)
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 )
 
 // OwnerReferenceFromObject creates an owner reference with the given object.
@@ -30,6 +31,56 @@ func GetUnstructuredObject(scheme *runtime.Scheme, obj runtime.Object) (*unstruc
 	return u, nil
 }
 
+// FromUnstructured converts the given Unstructured object into the given
+// typed object. The GVK of the Unstructured object must be registered in the
+// scheme and must match the GVK of the typed object.
+func FromUnstructured(scheme *runtime.Scheme, u *unstructured.Unstructured, into runtime.Object) error {
+	gvk := u.GroupVersionKind()
+	if gvk.Empty() {
+		return fmt.Errorf("failed to convert Unstructured to %T: object has no GroupVersionKind", into)
+	}
+	if !scheme.Recognizes(gvk) {
+		return fmt.Errorf("failed to convert Unstructured to %T: unknown GroupVersionKind %s, not registered in the scheme", into, gvk)
+	}
+	intoGVK, err := apiutil.GVKForObject(into, scheme)
+	if err != nil {
+		return fmt.Errorf("failed to get GroupVersionKind of %T: %v", into, err)
+	}
+	if intoGVK != gvk {
+		return fmt.Errorf("failed to convert Unstructured to %T: GroupVersionKind mismatch, %s != %s", into, gvk, intoGVK)
+	}
+
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, into); err != nil {
+		return fmt.Errorf("failed to convert Unstructured to %T: %v", into, err)
+	}
+	into.GetObjectKind().SetGroupVersionKind(gvk)
+	return nil
+}
+
+// ToTyped converts the given Unstructured object into a new typed object of
+// the type registered in the scheme for the GVK of the Unstructured object.
+func ToTyped(scheme *runtime.Scheme, u *unstructured.Unstructured) (client.Object, error) {
+	gvk := u.GroupVersionKind()
+	if gvk.Empty() {
+		return nil, fmt.Errorf("failed to convert Unstructured to typed object: object has no GroupVersionKind")
+	}
+	obj, err := scheme.New(gvk)
+	if err != nil {
+		if runtime.IsNotRegisteredError(err) {
+			return nil, fmt.Errorf("failed to convert Unstructured to typed object: unknown GroupVersionKind %s, not registered in the scheme", gvk)
+		}
+		return nil, fmt.Errorf("failed to create a new %s object: %v", gvk, err)
+	}
+	cObj, ok := obj.(client.Object)
+	if !ok {
+		return nil, fmt.Errorf("failed to convert Unstructured to typed object: %T is not a client.Object", obj)
+	}
+	if err := FromUnstructured(scheme, u, cObj); err != nil {
+		return nil, err
+	}
+	return cObj, nil
+}
+
 // IsInitialized checks if an object is initialized by checking if there's
 // any status condition.
 func IsInitialized(scheme *runtime.Scheme, obj runtime.Object) (bool, error) {
@@ -51,21 +102,29 @@ func IsInitialized(scheme *runtime.Scheme, obj runtime.Object) (bool, error) {
 
 // GetObjectStatus returns the status of a given object, if any.
 func GetObjectStatus(obj map[string]interface{}) (map[string]interface{}, error) {
-	status, found, err := NestedFieldNoCopy(obj, "status")
+	return GetNestedMap(obj, "status")
+}
+
+// GetNestedMap returns the nested map at the given path of fields in a given
+// object, like "spec", "config" for the spec.config subtree. It returns an
+// error if the field isn't found or isn't a map.
+func GetNestedMap(obj map[string]interface{}, fields ...string) (map[string]interface{}, error) {
+	path := strings.Join(fields, ".")
+	val, found, err := NestedFieldNoCopy(obj, fields...)
 	if err != nil {
-		return nil, fmt.Errorf("error reading object status: %v", err)
+		return nil, fmt.Errorf("error reading object %s: %v", path, err)
 	}
 
 	if !found {
-		return nil, fmt.Errorf("object status not found")
+		return nil, fmt.Errorf("object %s not found", path)
 	}
 
-	objStatus, ok := status.(map[string]interface{})
+	m, ok := val.(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("status was not of type map[string]interface{}")
+		return nil, fmt.Errorf("%s was not of type map[string]interface{}", path)
 	}
 
-	return objStatus, nil
+	return m, nil
 }
 
 // StatusChanged gets the status of the given objects and compares them. It
@@ -98,6 +157,40 @@ func StatusChanged(scheme *runtime.Scheme, oldo runtime.Object, newo runtime.Obj
 	return false, nil
 }
 
+// NestedChanged gets the nested field at the given path of fields of the
+// given objects and compares them. It returns true if there's a change in the
+// field, like in the spec.config subtree with the fields "spec", "config". A
+// field that's added or removed is a change.
+func NestedChanged(scheme *runtime.Scheme, oldo runtime.Object, newo runtime.Object, fields ...string) (bool, error) {
+	path := strings.Join(fields, ".")
+
+	// Get the old field value.
+	ou, err := GetUnstructuredObject(scheme, oldo)
+	if err != nil {
+		return false, fmt.Errorf("failed to convert old Object to Unstructured: %v", err)
+	}
+	oldVal, oldFound, err := NestedFieldNoCopy(ou.Object, fields...)
+	if err != nil {
+		return false, fmt.Errorf("failed to get old Object %s: %v", path, err)
+	}
+
+	// Get the new field value.
+	nu, err := GetUnstructuredObject(scheme, newo)
+	if err != nil {
+		return false, fmt.Errorf("failed to convert new Object to Unstructured: %v", err)
+	}
+	newVal, newFound, err := NestedFieldNoCopy(nu.Object, fields...)
+	if err != nil {
+		return false, fmt.Errorf("failed to get new Object %s: %v", path, err)
+	}
+
+	// Compare the field values.
+	if oldFound != newFound || !reflect.DeepEqual(oldVal, newVal) {
+		return true, nil
+	}
+	return false, nil
+}
+
 // NestedFieldNoCopy returns the nested field from a given Object. The second
 // returned value is true if the field is found, else false.
 //
@@ -76,6 +76,107 @@ func TestGetUnstructuredObject(t *testing.T) {
 	}
 }
 
+func TestUnstructuredRoundTrip(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	game := &tdv1alpha1.Game{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: tdv1alpha1.GroupVersion.String(),
+			Kind:       "Game",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "zelda",
+			Namespace: "switch",
+			Labels:    map[string]string{"genre": "adventure"},
+		},
+		Spec: tdv1alpha1.GameSpec{Foo: "bar"},
+		Status: tdv1alpha1.GameStatus{
+			Conditions: []metav1.Condition{
+				{Type: "Ready", Status: metav1.ConditionTrue, Reason: "Done"},
+			},
+		},
+	}
+
+	u, err := GetUnstructuredObject(scheme, game)
+	assert.Nil(t, err)
+
+	// Convert into a given object.
+	into := &tdv1alpha1.Game{}
+	assert.Nil(t, FromUnstructured(scheme, u, into))
+	assert.Equal(t, game, into)
+
+	// Convert into a new object.
+	typed, err := ToTyped(scheme, u)
+	assert.Nil(t, err)
+	assert.Equal(t, game, typed)
+
+	// Convert back to unstructured.
+	u2, err := GetUnstructuredObject(scheme, typed)
+	assert.Nil(t, err)
+	assert.Equal(t, u, u2)
+}
+
+func TestUnstructuredConversionErrors(t *testing.T) {
+	// Create a scheme with testdata scheme info.
+	scheme := runtime.NewScheme()
+	assert.Nil(t, tdv1alpha1.AddToScheme(scheme))
+
+	newUnstructured := func(apiVersion, kind string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{}
+		u.SetAPIVersion(apiVersion)
+		u.SetKind(kind)
+		u.SetName("foo")
+		return u
+	}
+
+	cases := []struct {
+		name    string
+		u       *unstructured.Unstructured
+		into    runtime.Object
+		wantErr string
+	}{
+		{
+			name:    "unknown GVK",
+			u:       newUnstructured("example.com/v1", "Unknown"),
+			into:    &tdv1alpha1.Game{},
+			wantErr: "unknown GroupVersionKind",
+		},
+		{
+			name:    "no GVK",
+			u:       &unstructured.Unstructured{Object: map[string]interface{}{}},
+			into:    &tdv1alpha1.Game{},
+			wantErr: "no GroupVersionKind",
+		},
+		{
+			name:    "mismatched GVK",
+			u:       newUnstructured(tdv1alpha1.GroupVersion.String(), "GameList"),
+			into:    &tdv1alpha1.Game{},
+			wantErr: "GroupVersionKind mismatch",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := FromUnstructured(scheme, tc.u, tc.into)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+
+			// ToTyped creates the object based on the GVK, only fails for
+			// the unknown GVKs.
+			if tc.wantErr == "GroupVersionKind mismatch" {
+				return
+			}
+			got, err := ToTyped(scheme, tc.u)
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tc.wantErr)
+			assert.Nil(t, got)
+		})
+	}
+}
+
 func TestIsInitialized(t *testing.T) {
 	// Create a scheme with testdata scheme info.
 	scheme := runtime.NewScheme()
@@ -379,6 +480,149 @@ func TestStatusChanged(t *testing.T) {
 	}
 }
 
+func TestGetNestedMap(t *testing.T) {
+	obj := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"config": map[string]interface{}{
+				"replicas": int64(3),
+			},
+			"foo": "bar",
+		},
+	}
+
+	cases := []struct {
+		name    string
+		fields  []string
+		want    map[string]interface{}
+		wantErr bool
+	}{
+		{
+			name:   "nested subtree",
+			fields: []string{"spec", "config"},
+			want: map[string]interface{}{
+				"replicas": int64(3),
+			},
+		},
+		{
+			name:    "not found",
+			fields:  []string{"spec", "template"},
+			wantErr: true,
+		},
+		{
+			name:    "not a map",
+			fields:  []string{"spec", "foo"},
+			wantErr: true,
+		},
+		{
+			name:    "parent not a map",
+			fields:  []string{"spec", "foo", "bar"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := GetNestedMap(obj, tc.fields...)
+			if tc.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got, "result")
+		})
+	}
+}
+
+func TestNestedChanged(t *testing.T) {
+	scheme := runtime.NewScheme()
+
+	// newConfigObj returns an object with the given spec.config subtree and
+	// spec.foo field.
+	newConfigObj := func(config map[string]interface{}, foo string) *unstructured.Unstructured {
+		spec := map[string]interface{}{"foo": foo}
+		if config != nil {
+			spec["config"] = config
+		}
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "app.example.com/v1alpha1",
+			"kind":       "Game",
+			"spec":       spec,
+		}}
+	}
+
+	cases := []struct {
+		name    string
+		oldo    runtime.Object
+		newo    runtime.Object
+		fields  []string
+		want    bool
+		wantErr bool
+	}{
+		{
+			name:   "no change",
+			oldo:   newConfigObj(map[string]interface{}{"replicas": int64(3)}, "a"),
+			newo:   newConfigObj(map[string]interface{}{"replicas": int64(3)}, "a"),
+			fields: []string{"spec", "config"},
+			want:   false,
+		},
+		{
+			name:   "change outside the subtree",
+			oldo:   newConfigObj(map[string]interface{}{"replicas": int64(3)}, "a"),
+			newo:   newConfigObj(map[string]interface{}{"replicas": int64(3)}, "b"),
+			fields: []string{"spec", "config"},
+			want:   false,
+		},
+		{
+			name:   "subtree changed",
+			oldo:   newConfigObj(map[string]interface{}{"replicas": int64(3)}, "a"),
+			newo:   newConfigObj(map[string]interface{}{"replicas": int64(5)}, "a"),
+			fields: []string{"spec", "config"},
+			want:   true,
+		},
+		{
+			name:   "subtree added",
+			oldo:   newConfigObj(nil, "a"),
+			newo:   newConfigObj(map[string]interface{}{}, "a"),
+			fields: []string{"spec", "config"},
+			want:   true,
+		},
+		{
+			name:   "subtree removed",
+			oldo:   newConfigObj(map[string]interface{}{"replicas": int64(3)}, "a"),
+			newo:   newConfigObj(nil, "a"),
+			fields: []string{"spec", "config"},
+			want:   true,
+		},
+		{
+			name:   "not found in both",
+			oldo:   newConfigObj(nil, "a"),
+			newo:   newConfigObj(nil, "b"),
+			fields: []string{"spec", "config"},
+			want:   false,
+		},
+		{
+			name:    "invalid path",
+			oldo:    newConfigObj(nil, "a"),
+			newo:    newConfigObj(nil, "a"),
+			fields:  []string{"spec", "foo", "bar"},
+			wantErr: true,
+		},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := NestedChanged(scheme, tc.oldo, tc.newo, tc.fields...)
+			if tc.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, got, "result")
+		})
+	}
+}
+
 func TestNestedFieldNoCopy(t *testing.T) {
 	cases := []struct {
 		name      string
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel/metric"
@@ -23,18 +25,37 @@ import (
 // defaultRetryPeriod is used for waiting before a retry.
 const defaultRetryPeriod = 5 * time.Second
 
+// ErrRetryBudgetExceeded is returned by the operator when an object stays not
+// ready for more consecutive reconciles than the retry budget allows. The
+// returned error is terminal, the composite reconciler doesn't requeue on it.
+// It can be used to detect the terminal failures and surface them in the
+// object status.
+var ErrRetryBudgetExceeded = errors.New("retry budget exceeded")
+
 // CompositeOperator contains all the operands and the relationship between
-// them. It implements the Operator interface.
+// them. It implements the Operator interface. The operands can be replaced at
+// runtime with Rebuild. Operands and DAG must not be accessed directly while
+// the operator may be rebuilt concurrently.
 type CompositeOperator struct {
 	Operands          []operand.Operand
 	DAG               *dag.OperandDAG
+	mu                sync.RWMutex
 	isSuspended       func(context.Context, client.Object) bool
+	precondition      func(context.Context, client.Object) (bool, error)
 	order             operand.OperandOrder
+	stages            []string
 	executionStrategy executor.ExecutionStrategy
 	recorder          record.EventRecorder
 	executor          *executor.Executor
+	executorOpts      []executor.ExecutorOption
 	inst              *telemetry.Instrumentation
 	retryPeriod       time.Duration
+	retryBudget       int
+	retries           *retryCounter
+
+	requeueAlwaysThreshold int
+	requeueCycles          *retryCounter
+	requeueCyclesRecorder  metric.Int64ValueRecorder
 }
 
 // CompositeOperatorOption is used to configure CompositeOperator.
@@ -47,6 +68,35 @@ func WithExecutionStrategy(strategy executor.ExecutionStrategy) CompositeOperato
 	}
 }
 
+// WithErrorPolicy sets the error policy of the operand execution. With
+// executor.ContinueOnError, the operands independent of the failed operands
+// are still run and all the errors are returned aggregated. Defaults to
+// executor.FailFast.
+func WithErrorPolicy(policy executor.ErrorPolicy) CompositeOperatorOption {
+	return func(c *CompositeOperator) {
+		c.executorOpts = append(c.executorOpts, executor.WithErrorPolicy(policy))
+	}
+}
+
+// WithEventDeduplication enables suppressing the operand events that are
+// identical to an event recorded within the given window, to avoid recording
+// the same events on every reconcile.
+func WithEventDeduplication(window time.Duration) CompositeOperatorOption {
+	return func(c *CompositeOperator) {
+		c.executorOpts = append(c.executorOpts, executor.WithEventDeduplication(window))
+	}
+}
+
+// WithStages sets the stages of the operands, in their order of execution.
+// The operands that implement operand.StagedOperand run strictly in the order
+// of their stages, on top of their dependencies. Defaults to
+// operand.DefaultStages.
+func WithStages(stages ...string) CompositeOperatorOption {
+	return func(c *CompositeOperator) {
+		c.stages = stages
+	}
+}
+
 // WithOperands sets the set of operands in a CompositeOperator.
 func WithOperands(operands ...operand.Operand) CompositeOperatorOption {
 	return func(c *CompositeOperator) {
@@ -61,6 +111,18 @@ func WithSuspensionCheck(f func(context.Context, client.Object) bool) CompositeO
 	}
 }
 
+// WithPrecondition sets a check that must pass before the operands are run
+// by Ensure, like waiting for an external dependency to be available. When
+// the precondition isn't met, Ensure doesn't run the operands and requeues
+// the object after the retry period. Unlike suspension, which stops the
+// operator from running at all, a precondition means the operator can't run
+// yet. The retry budget doesn't apply to it. Cleanup isn't affected.
+func WithPrecondition(f func(context.Context, client.Object) (bool, error)) CompositeOperatorOption {
+	return func(c *CompositeOperator) {
+		c.precondition = f
+	}
+}
+
 // WithEventRecorder sets the EventRecorder of a CompositeOperator.
 func WithEventRecorder(recorder record.EventRecorder) CompositeOperatorOption {
 	return func(c *CompositeOperator) {
@@ -69,17 +131,31 @@ func WithEventRecorder(recorder record.EventRecorder) CompositeOperatorOption {
 }
 
 // WithRetryPeriod sets the wait period of the operator before performing a
-// retry in the event of a failure.
+// retry in the event of a failure. The operands can request a different wait
+// period with operand.NotReadyAfter, the shortest requested period is used.
 func WithRetryPeriod(duration time.Duration) CompositeOperatorOption {
 	return func(c *CompositeOperator) {
 		c.retryPeriod = duration
 	}
 }
 
+// WithRetryBudget sets the maximum number of consecutive not-ready results of
+// an object that are retried. Once exceeded, Ensure stops requeuing the
+// object and returns a terminal error matching ErrRetryBudgetExceeded, until
+// the object is ready. The object is then reconciled again only when it
+// changes. The count is tracked per object and is reset on a successful
+// Ensure or Cleanup. Defaults to 0, an unlimited budget.
+func WithRetryBudget(budget int) CompositeOperatorOption {
+	return func(c *CompositeOperator) {
+		c.retryBudget = budget
+	}
+}
+
 // WithInstrumentation configures the instrumentation of the CompositeOperator.
 func WithInstrumentation(tp trace.TracerProvider, mp metric.MeterProvider, log logr.Logger) CompositeOperatorOption {
 	return func(c *CompositeOperator) {
 		c.inst = telemetry.NewInstrumentationWithProviders(instrumentationName, tp, mp, log)
+		c.executorOpts = append(c.executorOpts, executor.WithInstrumentation(tp, mp, log))
 	}
 }
 
@@ -94,6 +170,11 @@ func NewCompositeOperator(opts ...CompositeOperatorOption) (*CompositeOperator,
 		isSuspended:       defaultIsSuspended,
 		executionStrategy: executor.Parallel,
 		retryPeriod:       defaultRetryPeriod,
+		retries:           newRetryCounter(),
+		requeueCycles:     newRetryCounter(),
+		stages:            operand.DefaultStages,
+
+		requeueAlwaysThreshold: defaultRequeueAlwaysThreshold,
 	}
 
 	// Loop through each option.
@@ -111,32 +192,103 @@ func NewCompositeOperator(opts ...CompositeOperatorOption) (*CompositeOperator,
 	if c.inst == nil {
 		WithInstrumentation(nil, nil, nil)(c)
 	}
+	c.requeueCyclesRecorder = newRequeueCyclesRecorder(c.inst.Meter())
 
-	// Initialize the operator DAG.
-	od, err := dag.NewOperandDAG(c.Operands)
+	// Initialize the operator DAG and compute the traversal order.
+	od, order, err := buildDAG(c.Operands, c.stages)
 	if err != nil {
 		return nil, err
 	}
 	c.DAG = od
-
-	// Compute traversal order in the DAG.
-	order, err := od.Order()
-	if err != nil {
-		return nil, err
-	}
 	c.order = order
 
 	// Create an executor.
-	c.executor = executor.NewExecutor(c.executionStrategy, c.recorder)
+	c.executor = executor.NewExecutor(c.executionStrategy, c.recorder, c.executorOpts...)
 
 	return c, nil
 }
 
+// buildDAG creates the DAG of the given operands and computes its traversal
+// order. If any of the operands is staged, the order respects the given
+// stages.
+func buildDAG(operands []operand.Operand, stages []string) (*dag.OperandDAG, operand.OperandOrder, error) {
+	od, err := dag.NewOperandDAG(operands)
+	if err != nil {
+		return nil, nil, err
+	}
+	var order operand.OperandOrder
+	if hasStagedOperands(operands) {
+		order, err = od.StagedOrder(stages)
+	} else {
+		order, err = od.Order()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return od, order, nil
+}
+
+// hasStagedOperands returns true if any of the given operands implements
+// operand.StagedOperand.
+func hasStagedOperands(operands []operand.Operand) bool {
+	for _, op := range operands {
+		if _, ok := op.(operand.StagedOperand); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Rebuild replaces the operands of the operator, recomputing the DAG and the
+// order of the operands. This can be used to add or remove operands at
+// runtime, for example, based on feature flags. If the new operands don't
+// form a proper DAG, an error is returned and the operator keeps using the
+// old operands. The operations already in progress continue with the old
+// operands.
+func (co *CompositeOperator) Rebuild(operands ...operand.Operand) error {
+	od, order, err := buildDAG(operands, co.stages)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild the operator: %w", err)
+	}
+
+	co.mu.Lock()
+	defer co.mu.Unlock()
+	co.Operands = operands
+	co.DAG = od
+	co.order = order
+	return nil
+}
+
+// snapshot returns the current DAG and order of the operands.
+func (co *CompositeOperator) snapshot() (*dag.OperandDAG, operand.OperandOrder) {
+	co.mu.RLock()
+	defer co.mu.RUnlock()
+	return co.DAG, co.order
+}
+
 // Order returns the order at which the operands depends on each other. This
 // can be used for creation and deletion of all the resource, if used in
 // reverse order.
 func (co *CompositeOperator) Order() operand.OperandOrder {
-	return co.order
+	_, order := co.snapshot()
+	return order
+}
+
+// OrderNames returns the names of the operands grouped by their execution
+// step. The operands in a group can be run in parallel. The names in a group
+// are sorted for deterministic results.
+func (co *CompositeOperator) OrderNames() [][]string {
+	order := co.Order()
+	result := make([][]string, 0, len(order))
+	for _, step := range order {
+		names := make([]string, 0, len(step))
+		for _, op := range step {
+			names = append(names, op.Name())
+		}
+		sort.Strings(names)
+		result = append(result, names)
+	}
+	return result
 }
 
 // IsSuspend implements the Operator interface. It checks if the operator can
@@ -155,21 +307,66 @@ func (co *CompositeOperator) Ensure(ctx context.Context, obj client.Object, owne
 	ctx, span, _, log := co.inst.Start(ctx, "Ensure")
 	defer span.End()
 
+	return co.ensure(ctx, span, log, co.Order(), obj, ownerRef)
+}
+
+// EnsureFrom runs the named operand and all the operands that depend on it,
+// directly or transitively, in the order of their dependencies. The other
+// operands are not run. An error is returned if the named operand is unknown.
+func (co *CompositeOperator) EnsureFrom(ctx context.Context, obj client.Object, ownerRef metav1.OwnerReference, operandName string) (ctrl.Result, error) {
+	ctx, span, _, log := co.inst.Start(ctx, "EnsureFrom")
+	defer span.End()
+
+	od, order := co.snapshot()
+	dependents, err := od.Dependents(operandName)
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("unknown operand %q: %w", operandName, err)
+	}
+	order = order.Filter(append([]string{operandName}, dependents...)...)
+
+	return co.ensure(ctx, span, log, order, obj, ownerRef)
+}
+
+// ensure runs the Ensure of the operands in the given order.
+func (co *CompositeOperator) ensure(ctx context.Context, span trace.Span, log logr.Logger, order operand.OperandOrder, obj client.Object, ownerRef metav1.OwnerReference) (ctrl.Result, error) {
 	result := ctrl.Result{}
 
 	if !co.IsSuspended(ctx, obj) {
-		res, err := co.executor.ExecuteOperands(co.order, operand.CallEnsure, ctx, obj, ownerRef)
+		if co.precondition != nil {
+			met, err := co.precondition(ctx, obj)
+			if err != nil {
+				return ctrl.Result{Requeue: true}, fmt.Errorf("failed to check the precondition: %w", err)
+			}
+			if !met {
+				span.AddEvent("CompositeOperator Ensure skipped because the precondition isn't met")
+				log.Info("precondition not met, retrying in a few seconds...", "waitPeriod", co.retryPeriod)
+				return ctrl.Result{Requeue: true, RequeueAfter: co.retryPeriod}, nil
+			}
+		}
+
+		res, err := co.executor.ExecuteOperands(order, operand.CallEnsure, ctx, obj, ownerRef)
+		co.trackRequeueAlways(ctx, log, obj, err == nil && res.Requeue)
 		if err != nil {
 			// Not ready error shouldn't be propagated to the caller. Handle
 			// the error gracefully by returning a requeue result with a wait
 			// period. Set explicit requeue regardless of the returned result
 			// because an error was found.
 			if errors.Is(err, operand.ErrNotReady) {
-				log.Info("components not ready, retrying in a few seconds...", "waitPeriod", co.retryPeriod, "failure", err)
-				return ctrl.Result{Requeue: true, RequeueAfter: co.retryPeriod}, nil
+				// Give up when the retry budget is exceeded.
+				if co.retryBudget > 0 {
+					if retries := co.retries.increment(client.ObjectKeyFromObject(obj)); retries > co.retryBudget {
+						return ctrl.Result{}, &retryBudgetError{budget: co.retryBudget, err: err}
+					}
+				}
+				// Wait for the shortest period requested by the not ready
+				// operands.
+				wait := operand.NotReadyWait(err, co.retryPeriod)
+				log.Info("components not ready, retrying in a few seconds...", "waitPeriod", wait, "failure", err)
+				return ctrl.Result{Requeue: true, RequeueAfter: wait}, nil
 			}
 			return ctrl.Result{Requeue: true}, err
 		}
+		co.retries.reset(client.ObjectKeyFromObject(obj))
 		result = res
 		span.AddEvent("CompositeOperator Ensure executed successfully")
 	} else {
@@ -178,13 +375,26 @@ func (co *CompositeOperator) Ensure(ctx context.Context, obj client.Object, owne
 	return result, nil
 }
 
-// Cleanup implements the Operator interface.
+// Cleanup implements the Operator interface. It runs all the operands in the
+// reverse order of their dependencies. An operand is deleted only after all
+// the operands that depend on it are confirmed deleted by their
+// CleanupReadyCheck, else the cleanup is retried after the retry period.
 func (co *CompositeOperator) Cleanup(ctx context.Context, obj client.Object) (result ctrl.Result, rerr error) {
-	ctx, span, _, _ := co.inst.Start(ctx, "Cleanup")
+	ctx, span, _, log := co.inst.Start(ctx, "Cleanup")
 	defer span.End()
 
 	if !co.IsSuspended(ctx, obj) {
-		return co.executor.ExecuteOperands(co.order.Reverse(), operand.CallCleanup, ctx, obj, metav1.OwnerReference{})
+		co.retries.reset(client.ObjectKeyFromObject(obj))
+		co.requeueCycles.reset(client.ObjectKeyFromObject(obj))
+		res, err := co.executor.ExecuteOperands(co.Order().Reverse(), operand.CallCleanup, ctx, obj, metav1.OwnerReference{})
+		if err != nil && errors.Is(err, operand.ErrNotReady) {
+			// Wait for the dependents to be deleted before deleting their
+			// dependencies.
+			wait := operand.NotReadyWait(err, co.retryPeriod)
+			log.Info("components not deleted, retrying in a few seconds...", "waitPeriod", wait, "failure", err)
+			return ctrl.Result{Requeue: true, RequeueAfter: wait}, nil
+		}
+		return res, err
 	}
 	return
 }
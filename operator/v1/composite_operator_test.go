@@ -2,18 +2,24 @@ package v1
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
+	tkerror "github.com/darkowlzz/operator-toolkit/error"
 	eventv1 "github.com/darkowlzz/operator-toolkit/event/v1"
+	"github.com/darkowlzz/operator-toolkit/operator/v1/dag"
 	"github.com/darkowlzz/operator-toolkit/operator/v1/executor"
 	"github.com/darkowlzz/operator-toolkit/operator/v1/operand"
 	"github.com/darkowlzz/operator-toolkit/operator/v1/operand/mocks"
@@ -254,4 +260,633 @@ func TestCompositeOperatorEnsure(t *testing.T) {
 	}
 }
 
-// TODO: Add TestCompositeOperatorCleanup.
+func TestCompositeOperatorEnsureFrom(t *testing.T) {
+	pod := &corev1.Pod{}
+
+	// expectEnsure sets the expectations of a successful Ensure on an operand.
+	expectEnsure := func(op *mocks.MockOperand) {
+		op.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any())
+		op.EXPECT().RequeueStrategy().AnyTimes()
+		op.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil)
+		op.EXPECT().PostReady(gomock.Any(), gomock.Any()).Return(nil)
+	}
+
+	tests := []struct {
+		name         string
+		operandName  string
+		wantErr      bool
+		expectations func(a, b, c, d *mocks.MockOperand)
+	}{
+		{
+			name:        "root operand with transitive dependents",
+			operandName: "opA",
+			expectations: func(opA, opB, opC, opD *mocks.MockOperand) {
+				expectEnsure(opA)
+				expectEnsure(opC)
+				expectEnsure(opD)
+				// No execution of opB.
+			},
+		},
+		{
+			name:        "operand with no dependents",
+			operandName: "opB",
+			expectations: func(opA, opB, opC, opD *mocks.MockOperand) {
+				expectEnsure(opB)
+			},
+		},
+		{
+			name:        "intermediate operand",
+			operandName: "opC",
+			expectations: func(opA, opB, opC, opD *mocks.MockOperand) {
+				expectEnsure(opC)
+				expectEnsure(opD)
+			},
+		},
+		{
+			name:         "unknown operand",
+			operandName:  "opX",
+			wantErr:      true,
+			expectations: func(opA, opB, opC, opD *mocks.MockOperand) {},
+		},
+	}
+
+	for _, tc := range tests {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			// Setup mock operands.
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			mA := mocks.NewMockOperand(mctrl)
+			mB := mocks.NewMockOperand(mctrl)
+			mC := mocks.NewMockOperand(mctrl)
+			mD := mocks.NewMockOperand(mctrl)
+
+			// A, B, C requires A and D requires C.
+			mA.EXPECT().Name().Return("opA").AnyTimes()
+			mA.EXPECT().Requires().Return([]string{})
+			mB.EXPECT().Name().Return("opB").AnyTimes()
+			mB.EXPECT().Requires().Return([]string{})
+			mC.EXPECT().Name().Return("opC").AnyTimes()
+			mC.EXPECT().Requires().Return([]string{"opA"})
+			mD.EXPECT().Name().Return("opD").AnyTimes()
+			mD.EXPECT().Requires().Return([]string{"opC"})
+
+			// Set the expectations on the mocked operands.
+			tc.expectations(mA, mB, mC, mD)
+
+			co, err := NewCompositeOperator(
+				WithEventRecorder(record.NewFakeRecorder(1)),
+				WithExecutionStrategy(executor.Serial),
+				WithOperands(mA, mB, mC, mD),
+			)
+			assert.Nil(t, err)
+
+			_, err = co.EnsureFrom(context.Background(), pod, metav1.OwnerReference{}, tc.operandName)
+			if tc.wantErr {
+				assert.NotNil(t, err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+// newMockOperand creates a mock operand with the given name and
+// requirements.
+func newMockOperand(mctrl *gomock.Controller, name string, requires ...string) *mocks.MockOperand {
+	op := mocks.NewMockOperand(mctrl)
+	op.EXPECT().Name().Return(name).AnyTimes()
+	op.EXPECT().Requires().Return(requires).AnyTimes()
+	return op
+}
+
+func TestCompositeOperatorOrderNames(t *testing.T) {
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	// B, A, C requires B, D requires A and C, E requires D and F requires C.
+	co, err := NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithOperands(
+			newMockOperand(mctrl, "B"),
+			newMockOperand(mctrl, "A"),
+			newMockOperand(mctrl, "C", "B"),
+			newMockOperand(mctrl, "D", "A", "C"),
+			newMockOperand(mctrl, "E", "D"),
+			newMockOperand(mctrl, "F", "C"),
+		),
+	)
+	assert.Nil(t, err)
+
+	want := [][]string{
+		{"A", "B"},
+		{"C"},
+		{"D", "F"},
+		{"E"},
+	}
+	assert.Equal(t, want, co.OrderNames())
+}
+
+// newStagedOperand creates a declarative operand with the given name, stage
+// and requirements.
+func newStagedOperand(name, stage string, requires ...string) operand.Operand {
+	return operand.NewDeclarativeOperand(name, name, nil, nil,
+		operand.WithStage(stage), operand.WithRequires(requires...))
+}
+
+func TestCompositeOperatorStages(t *testing.T) {
+	// The independent operands run in the order of their stages.
+	co, err := NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithOperands(
+			newStagedOperand("migrate", operand.StagePostInstall),
+			newStagedOperand("crds", operand.StagePreInstall),
+			newStagedOperand("app", operand.StageInstall),
+			newStagedOperand("config", ""),
+			newStagedOperand("service", operand.StageInstall, "app"),
+		),
+	)
+	assert.Nil(t, err)
+	want := [][]string{
+		{"crds"},
+		{"app", "config"},
+		{"service"},
+		{"migrate"},
+	}
+	assert.Equal(t, want, co.OrderNames())
+
+	// Custom stages.
+	co, err = NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithStages("first", "second"),
+		WithOperands(
+			newStagedOperand("B", "second"),
+			newStagedOperand("A", "first"),
+		),
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]string{{"A"}, {"B"}}, co.OrderNames())
+
+	// An operand requiring an operand of a later stage is invalid.
+	_, err = NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithOperands(
+			newStagedOperand("A", operand.StagePostInstall),
+			newStagedOperand("B", operand.StagePreInstall, "A"),
+		),
+	)
+	assert.NotNil(t, err)
+}
+
+func TestCompositeOperatorRebuild(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	// B requires A.
+	opA := newMockOperand(mctrl, "A")
+	opB := newMockOperand(mctrl, "B", "A")
+	co, err := NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithExecutionStrategy(executor.Serial),
+		WithOperands(opA, opB),
+	)
+	assert.Nil(t, err)
+
+	// X and Y require each other, forming a cycle.
+	err = co.Rebuild(newMockOperand(mctrl, "A"), newMockOperand(mctrl, "X", "Y"), newMockOperand(mctrl, "Y", "X"))
+	assert.True(t, errors.Is(err, dag.ErrCycle))
+
+	// The prior operands are still used.
+	assert.Equal(t, [][]string{{"A"}, {"B"}}, co.OrderNames())
+	for _, op := range []*mocks.MockOperand{opA, opB} {
+		op.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any())
+		op.EXPECT().RequeueStrategy().AnyTimes()
+		op.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil)
+		op.EXPECT().PostReady(gomock.Any(), gomock.Any()).Return(nil)
+	}
+	res, err := co.Ensure(context.TODO(), pod, metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+
+	// A valid rebuild replaces the operands. C requires A.
+	assert.Nil(t, co.Rebuild(opA, opB, newMockOperand(mctrl, "C", "A")))
+	assert.Equal(t, [][]string{{"A"}, {"B", "C"}}, co.OrderNames())
+	assert.Len(t, co.Operands, 3)
+}
+
+func TestCompositeOperatorCleanup(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	// opB depends on opA, opA is deleted after opB is cleaned up.
+	childCleanedUp := false
+	parentDeletes := 0
+
+	mA := mocks.NewMockOperand(mctrl)
+	mA.EXPECT().Name().Return("opA").AnyTimes()
+	mA.EXPECT().Requires().Return([]string{})
+	mA.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+	mA.EXPECT().Delete(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, obj client.Object) (eventv1.ReconcilerEvent, error) {
+			parentDeletes++
+			return nil, nil
+		},
+	).AnyTimes()
+	mA.EXPECT().CleanupReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+
+	mB := mocks.NewMockOperand(mctrl)
+	mB.EXPECT().Name().Return("opB").AnyTimes()
+	mB.EXPECT().Requires().Return([]string{"opA"})
+	mB.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+	mB.EXPECT().Delete(gomock.Any(), gomock.Any()).Times(2)
+	mB.EXPECT().CleanupReadyCheck(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, obj client.Object) (bool, error) {
+			return childCleanedUp, nil
+		},
+	).Times(2)
+
+	co, err := NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithOperands(mA, mB),
+		WithRetryPeriod(time.Second),
+	)
+	assert.Nil(t, err)
+
+	// The parent delete is deferred until the child is cleaned up.
+	res, err := co.Cleanup(context.TODO(), pod)
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{Requeue: true, RequeueAfter: time.Second}, res)
+	assert.Equal(t, 0, parentDeletes)
+
+	// Once the child is cleaned up, the parent is deleted.
+	childCleanedUp = true
+	res, err = co.Cleanup(context.TODO(), pod)
+	assert.Nil(t, err)
+	assert.Equal(t, ctrl.Result{}, res)
+	assert.Equal(t, 1, parentDeletes)
+}
+
+func TestCompositeOperatorCleanupError(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	mA := mocks.NewMockOperand(mctrl)
+	mA.EXPECT().Name().Return("opA").AnyTimes()
+	mA.EXPECT().Requires().Return([]string{})
+	mA.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+	mA.EXPECT().Delete(gomock.Any(), gomock.Any())
+	mA.EXPECT().CleanupReadyCheck(gomock.Any(), gomock.Any()).Return(false, errors.New("failed"))
+
+	co, err := NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithOperands(mA),
+	)
+	assert.Nil(t, err)
+
+	res, err := co.Cleanup(context.TODO(), pod)
+	assert.Error(t, err)
+	assert.True(t, res.Requeue)
+}
+
+func TestCompositeOperatorCleanupNotFound(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	// The operand objects are already gone, Delete returns not found.
+	mA := mocks.NewMockOperand(mctrl)
+	mA.EXPECT().Name().Return("opA").AnyTimes()
+	mA.EXPECT().Requires().Return([]string{})
+	mA.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+	mA.EXPECT().Delete(gomock.Any(), gomock.Any()).
+		Return(nil, apierrors.NewNotFound(corev1.Resource("configmaps"), "config")).Times(2)
+	mA.EXPECT().CleanupReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil).Times(2)
+
+	co, err := NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithOperands(mA),
+	)
+	assert.Nil(t, err)
+
+	// A double cleanup returns cleanly without a requeue.
+	for i := 0; i < 2; i++ {
+		res, err := co.Cleanup(context.TODO(), pod)
+		assert.Nil(t, err)
+		assert.Equal(t, ctrl.Result{}, res)
+	}
+}
+
+func TestCompositeOperatorRetryBudget(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	otherPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"}}
+	budget := 3
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	ready := false
+	mA := mocks.NewMockOperand(mctrl)
+	mA.EXPECT().Name().Return("opA").AnyTimes()
+	mA.EXPECT().Requires().Return([]string{})
+	mA.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+	mA.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+	mA.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, obj client.Object) (bool, error) {
+			return ready, nil
+		},
+	).AnyTimes()
+	mA.EXPECT().PostReady(gomock.Any(), gomock.Any()).AnyTimes()
+
+	co, err := NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithOperands(mA),
+		WithRetryBudget(budget),
+	)
+	assert.Nil(t, err)
+
+	// The not-ready results within the budget are requeued.
+	for i := 0; i < budget; i++ {
+		res, err := co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+		assert.Nil(t, err)
+		assert.True(t, res.Requeue)
+	}
+
+	// The budget is tracked per object.
+	res, err := co.Ensure(context.Background(), otherPod, metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.True(t, res.Requeue)
+
+	// The budget trips after the budget is exhausted with a terminal error.
+	res, err = co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+	assert.True(t, errors.Is(err, ErrRetryBudgetExceeded))
+	assert.True(t, errors.Is(err, operand.ErrNotReady))
+	assert.True(t, tkerror.IsTerminal(err))
+	assert.Equal(t, ctrl.Result{}, res)
+
+	// A successful Ensure resets the count.
+	ready = true
+	res, err = co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.False(t, res.Requeue)
+
+	ready = false
+	res, err = co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.True(t, res.Requeue)
+}
+
+func TestCompositeOperatorPrecondition(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	retryPeriod := 10 * time.Second
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	// The operand doesn't run until the precondition passes.
+	mA := mocks.NewMockOperand(mctrl)
+	mA.EXPECT().Name().Return("opA").AnyTimes()
+	mA.EXPECT().Requires().Return([]string{})
+	mA.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+	mA.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+	mA.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+	mA.EXPECT().PostReady(gomock.Any(), gomock.Any()).Times(1)
+
+	var met bool
+	var checkErr error
+	co, err := NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(1)),
+		WithOperands(mA),
+		WithRetryPeriod(retryPeriod),
+		WithRetryBudget(1),
+		WithPrecondition(func(ctx context.Context, obj client.Object) (bool, error) {
+			return met, checkErr
+		}),
+	)
+	assert.Nil(t, err)
+
+	// Not met, requeued after the retry period. The retry budget isn't
+	// consumed.
+	for i := 0; i < 3; i++ {
+		res, err := co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+		assert.Nil(t, err)
+		assert.Equal(t, ctrl.Result{Requeue: true, RequeueAfter: retryPeriod}, res)
+	}
+
+	// A precondition check failure is returned.
+	checkErr = errors.New("dependency unavailable")
+	res, err := co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+	assert.True(t, errors.Is(err, checkErr))
+	assert.True(t, res.Requeue)
+
+	// Met, the operands run.
+	met, checkErr = true, nil
+	res, err = co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.False(t, res.Requeue)
+}
+
+// cyclesLogger is a logr.Logger that records the requeueAlwaysCycles value
+// and the message of the info logs.
+type cyclesLogger struct {
+	cycles   *[]int
+	messages *[]string
+}
+
+func (l cyclesLogger) Enabled() bool { return true }
+
+func (l cyclesLogger) Info(msg string, keysAndValues ...interface{}) {
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		if keysAndValues[i] == "requeueAlwaysCycles" {
+			*l.cycles = append(*l.cycles, keysAndValues[i+1].(int))
+			*l.messages = append(*l.messages, msg)
+		}
+	}
+}
+
+func (l cyclesLogger) Error(err error, msg string, keysAndValues ...interface{}) {}
+
+func (l cyclesLogger) V(level int) logr.Logger { return l }
+
+func (l cyclesLogger) WithValues(keysAndValues ...interface{}) logr.Logger { return l }
+
+func (l cyclesLogger) WithName(name string) logr.Logger { return l }
+
+func TestCompositeOperatorRequeueAlwaysCycles(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	otherPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"}}
+
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	// An operand that makes a change on every Ensure, until converged.
+	converged := false
+	mA := mocks.NewMockOperand(mctrl)
+	mA.EXPECT().Name().Return("opA").AnyTimes()
+	mA.EXPECT().Requires().Return([]string{})
+	mA.EXPECT().RequeueStrategy().Return(operand.RequeueAlways).AnyTimes()
+	mA.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, obj client.Object, ownerRef metav1.OwnerReference) (eventv1.ReconcilerEvent, error) {
+			if converged {
+				return nil, nil
+			}
+			return &fooCreatedEvent{Object: obj, FooName: "foo"}, nil
+		},
+	).AnyTimes()
+	mA.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	mA.EXPECT().PostReady(gomock.Any(), gomock.Any()).AnyTimes()
+
+	cycles := []int{}
+	messages := []string{}
+	co, err := NewCompositeOperator(
+		WithEventRecorder(record.NewFakeRecorder(10)),
+		WithOperands(mA),
+		WithRequeueAlwaysThreshold(2),
+		WithInstrumentation(nil, nil, cyclesLogger{cycles: &cycles, messages: &messages}),
+	)
+	assert.Nil(t, err)
+
+	// The cycles grow across the repeated Ensure calls returning events.
+	for i := 0; i < 3; i++ {
+		res, err := co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+		assert.Nil(t, err)
+		assert.True(t, res.Requeue)
+	}
+	// The last cycle exceeds the threshold and logs a warning.
+	assert.Equal(t, []int{1, 2, 3, 3}, cycles)
+	assert.Contains(t, messages[3], "warning")
+
+	// The cycles are counted per object.
+	_, err = co.Ensure(context.Background(), otherPod, metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cycles[len(cycles)-1])
+
+	// The count is reset once the operands converge.
+	converged = true
+	res, err := co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.False(t, res.Requeue)
+
+	converged = false
+	_, err = co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, cycles[len(cycles)-1])
+}
+
+func TestCompositeOperatorNotReadyWait(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+
+	cases := []struct {
+		name string
+		// readyErrs are the ReadyCheck errors of the operands, nil for a
+		// ready operand.
+		readyErrs []error
+		wantAfter time.Duration
+	}{
+		{
+			name:      "not ready without requested wait",
+			readyErrs: []error{nil, operand.ErrNotReady},
+			wantAfter: 10 * time.Second,
+		},
+		{
+			name:      "shortest requested wait",
+			readyErrs: []error{nil, operand.NotReadyAfter(30 * time.Second), operand.NotReadyAfter(3 * time.Second)},
+			wantAfter: 3 * time.Second,
+		},
+		{
+			name:      "retry period shorter than requested wait",
+			readyErrs: []error{operand.ErrNotReady, operand.NotReadyAfter(30 * time.Second)},
+			wantAfter: 10 * time.Second,
+		},
+		{
+			name:      "requested wait shorter than retry period",
+			readyErrs: []error{operand.ErrNotReady, operand.NotReadyAfter(time.Second), nil},
+			wantAfter: time.Second,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+
+			operands := []operand.Operand{}
+			for i, readyErr := range tc.readyErrs {
+				readyErr := readyErr
+				m := mocks.NewMockOperand(mctrl)
+				m.EXPECT().Name().Return(fmt.Sprintf("op%d", i)).AnyTimes()
+				m.EXPECT().Requires().Return([]string{}).AnyTimes()
+				m.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+				m.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any())
+				m.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(readyErr == nil, readyErr)
+				if readyErr == nil {
+					m.EXPECT().PostReady(gomock.Any(), gomock.Any())
+				}
+				operands = append(operands, m)
+			}
+
+			co, err := NewCompositeOperator(
+				WithEventRecorder(record.NewFakeRecorder(len(operands))),
+				WithOperands(operands...),
+				WithRetryPeriod(10*time.Second),
+			)
+			assert.Nil(t, err)
+
+			res, err := co.Ensure(context.Background(), pod, metav1.OwnerReference{})
+			assert.Nil(t, err)
+			assert.Equal(t, ctrl.Result{Requeue: true, RequeueAfter: tc.wantAfter}, res)
+		})
+	}
+}
+
+func TestSuspendByAnnotation(t *testing.T) {
+	const suspendKey = "operator.example.com/suspend"
+
+	cases := []struct {
+		name          string
+		annotations   map[string]string
+		wantSuspended bool
+	}{
+		{
+			name:          "no annotations",
+			wantSuspended: false,
+		},
+		{
+			name:          "suspend annotation true",
+			annotations:   map[string]string{suspendKey: "true"},
+			wantSuspended: true,
+		},
+		{
+			name:          "suspend annotation false",
+			annotations:   map[string]string{suspendKey: "false"},
+			wantSuspended: false,
+		},
+		{
+			name:          "other annotation",
+			annotations:   map[string]string{"foo": "true"},
+			wantSuspended: false,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			pod := &corev1.Pod{}
+			pod.SetAnnotations(tc.annotations)
+
+			co, err := NewCompositeOperator(
+				WithEventRecorder(record.NewFakeRecorder(0)),
+				WithSuspensionCheck(SuspendByAnnotation(suspendKey)),
+			)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantSuspended, co.IsSuspended(context.TODO(), pod))
+		})
+	}
+}
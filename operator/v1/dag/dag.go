@@ -1,16 +1,26 @@
 package dag
 
 import (
+	"errors"
+	"fmt"
+	"sort"
+
 	"github.com/goombaio/dag"
 
 	"github.com/darkowlzz/operator-toolkit/operator/v1/operand"
 )
 
+// ErrCycle is returned when the dependencies of the operands form a cycle.
+var ErrCycle = errors.New("operand dependencies form a cycle")
+
 // OperandDAG is a directed acyclic graph representation of the opereand
 // dependencies. This is used to resolve the dependencies of the operands on
 // each other and find an optimal execution path.
 type OperandDAG struct {
 	*dag.DAG
+
+	// names are the names of the operands in the DAG.
+	names []string
 }
 
 func NewOperandDAG(operands []operand.Operand) (*OperandDAG, error) {
@@ -22,6 +32,7 @@ func NewOperandDAG(operands []operand.Operand) (*OperandDAG, error) {
 		if err := od.AddVertex(v); err != nil {
 			return nil, err
 		}
+		od.names = append(od.names, op.Name())
 	}
 
 	// Create edges between the vertices based on the operand's depends on
@@ -44,9 +55,97 @@ func NewOperandDAG(operands []operand.Operand) (*OperandDAG, error) {
 		}
 	}
 
+	if err := od.checkCycles(operands); err != nil {
+		return nil, err
+	}
+
 	return od, nil
 }
 
+// checkCycles returns an error matching ErrCycle if the dependencies of the
+// operands form a cycle. It removes the vertices with no remaining
+// predecessors until none are left. The vertices that can't be removed are
+// part of or depend on a cycle.
+func (od *OperandDAG) checkCycles(operands []operand.Operand) error {
+	inDegree := map[string]int{}
+	queue := []*dag.Vertex{}
+	for _, op := range operands {
+		v, err := od.GetVertex(op.Name())
+		if err != nil {
+			return err
+		}
+		pp, err := od.Predecessors(v)
+		if err != nil {
+			return err
+		}
+		inDegree[v.ID] = len(pp)
+		if len(pp) == 0 {
+			queue = append(queue, v)
+		}
+	}
+
+	for len(queue) > 0 {
+		v := queue[0]
+		queue = queue[1:]
+		delete(inDegree, v.ID)
+
+		ss, err := od.Successors(v)
+		if err != nil {
+			return err
+		}
+		for _, s := range ss {
+			inDegree[s.ID]--
+			if inDegree[s.ID] == 0 {
+				queue = append(queue, s)
+			}
+		}
+	}
+
+	if len(inDegree) > 0 {
+		names := make([]string, 0, len(inDegree))
+		for name := range inDegree {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("%w: %v", ErrCycle, names)
+	}
+	return nil
+}
+
+// Dependents returns the names of all the operands that depend on the given
+// operand, directly or transitively. An error is returned if the operand is
+// not found in the DAG.
+func (od *OperandDAG) Dependents(name string) ([]string, error) {
+	v, err := od.GetVertex(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Traverse the successors breadth-first, collecting every vertex once.
+	visited := map[string]bool{}
+	result := []string{}
+	queue := []*dag.Vertex{v}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		ss, serr := od.Successors(current)
+		if serr != nil {
+			return nil, serr
+		}
+		for _, s := range ss {
+			if visited[s.ID] {
+				continue
+			}
+			visited[s.ID] = true
+			result = append(result, s.ID)
+			queue = append(queue, s)
+		}
+	}
+
+	return result, nil
+}
+
 func (od *OperandDAG) Order() (operand.OperandOrder, error) {
 	soln, steps, err := od.solve()
 	if err != nil {
@@ -66,6 +165,102 @@ func (od *OperandDAG) Order() (operand.OperandOrder, error) {
 	return result, nil
 }
 
+// StagedOrder returns the order of the operands grouped by the given stages,
+// in the order of the stages. All the operands of a stage run after the
+// operands of the previous stages, even if they are independent. Within a
+// stage, the operands are ordered by their dependencies. An error is
+// returned if an operand is in an unknown stage or requires an operand in a
+// later stage.
+func (od *OperandDAG) StagedOrder(stages []string) (operand.OperandOrder, error) {
+	stageIndex := map[string]int{}
+	for i, stage := range stages {
+		stageIndex[stage] = i
+	}
+
+	// Group the operands by their stage, sorted by name for deterministic
+	// results.
+	names := append([]string{}, od.names...)
+	sort.Strings(names)
+	vertices := make([]*dag.Vertex, 0, len(names))
+	for _, name := range names {
+		v, err := od.GetVertex(name)
+		if err != nil {
+			return nil, err
+		}
+		vertices = append(vertices, v)
+	}
+
+	vertexStage := map[string]int{}
+	for _, v := range vertices {
+		stage := operand.StageOf(v.Value.(operand.Operand))
+		index, ok := stageIndex[stage]
+		if !ok {
+			return nil, fmt.Errorf("operand %q is in unknown stage %q", v.ID, stage)
+		}
+		vertexStage[v.ID] = index
+	}
+
+	// Compute the step of the operands within their stage.
+	steps := map[string]int{}
+	for _, v := range vertices {
+		if _, err := od.stageStep(v, vertexStage, steps); err != nil {
+			return nil, err
+		}
+	}
+
+	result := operand.OperandOrder{}
+	for index := range stages {
+		stageSteps := [][]operand.Operand{}
+		for _, v := range vertices {
+			if vertexStage[v.ID] != index {
+				continue
+			}
+			step := steps[v.ID]
+			for len(stageSteps) <= step {
+				stageSteps = append(stageSteps, []operand.Operand{})
+			}
+			stageSteps[step] = append(stageSteps[step], v.Value.(operand.Operand))
+		}
+		result = append(result, stageSteps...)
+	}
+
+	return result, nil
+}
+
+// stageStep returns the step of a vertex within its stage, one after the
+// last step of its predecessors in the same stage. The computed steps are
+// stored in the given steps.
+func (od *OperandDAG) stageStep(v *dag.Vertex, vertexStage map[string]int, steps map[string]int) (int, error) {
+	if step, exists := steps[v.ID]; exists {
+		return step, nil
+	}
+
+	pp, err := od.Predecessors(v)
+	if err != nil {
+		return 0, err
+	}
+
+	step := 0
+	for _, p := range pp {
+		if vertexStage[p.ID] > vertexStage[v.ID] {
+			return 0, fmt.Errorf("operand %q requires operand %q of a later stage", v.ID, p.ID)
+		}
+		if vertexStage[p.ID] < vertexStage[v.ID] {
+			continue
+		}
+		pStep, perr := od.stageStep(p, vertexStage, steps)
+		if perr != nil {
+			return 0, perr
+		}
+		if pStep+1 > step {
+			step = pStep + 1
+		}
+	}
+
+	steps[v.ID] = step
+	return step, nil
+}
+
 // Solve solves the graph traversal in DAG with steps. Returns a map containing
 // vertex name with step number and total number of steps in the solution.
 func (od *OperandDAG) solve() (map[string]int, int, error) {
@@ -114,9 +309,11 @@ func (od *OperandDAG) solveStep(step int, currentRoots []*dag.Vertex, order map[
 				continue
 			}
 
+			// The predecessors must be in a previous step. A predecessor
+			// added in the current step can't run in parallel with it.
 			satisfied := true
 			for _, p := range pp {
-				if _, exists := order[p.ID]; !exists {
+				if pStep, exists := order[p.ID]; !exists || pStep >= step {
 					satisfied = false
 				}
 			}
@@ -1,6 +1,9 @@
 package dag
 
 import (
+	"errors"
+	"reflect"
+	"sort"
 	"testing"
 
 	"github.com/golang/mock/gomock"
@@ -92,3 +95,194 @@ func TestDAG(t *testing.T) {
 		t.Errorf("unexpected results after reverse:\n\t(WNT) %q\n\t(GOT) %q", expectedResult, ordered)
 	}
 }
+
+func TestDependents(t *testing.T) {
+	// A <- C <- D, B <- D, C <- E
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	mA := mocks.NewMockOperand(mctrl)
+	mA.EXPECT().Name().Return("A").AnyTimes()
+	mA.EXPECT().Requires().Return([]string{})
+
+	mB := mocks.NewMockOperand(mctrl)
+	mB.EXPECT().Name().Return("B").AnyTimes()
+	mB.EXPECT().Requires().Return([]string{})
+
+	mC := mocks.NewMockOperand(mctrl)
+	mC.EXPECT().Name().Return("C").AnyTimes()
+	mC.EXPECT().Requires().Return([]string{"A"})
+
+	mD := mocks.NewMockOperand(mctrl)
+	mD.EXPECT().Name().Return("D").AnyTimes()
+	mD.EXPECT().Requires().Return([]string{"B", "C"})
+
+	mE := mocks.NewMockOperand(mctrl)
+	mE.EXPECT().Name().Return("E").AnyTimes()
+	mE.EXPECT().Requires().Return([]string{"C"})
+
+	opd, err := NewOperandDAG([]operand.Operand{mA, mB, mC, mD, mE})
+	if err != nil {
+		t.Fatalf("unexpected error while creating OperandDAG: %v", err)
+	}
+
+	cases := []struct {
+		name    string
+		operand string
+		want    []string
+		wantErr bool
+	}{
+		{name: "root with transitive dependents", operand: "A", want: []string{"C", "D", "E"}},
+		{name: "root with direct dependent", operand: "B", want: []string{"D"}},
+		{name: "leaf", operand: "E", want: []string{}},
+		{name: "unknown operand", operand: "X", wantErr: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := opd.Dependents(tc.operand)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			sort.Strings(got)
+			if !tc.wantErr && !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("unexpected dependents:\n\t(WNT) %v\n\t(GOT) %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestDAGCycle(t *testing.T) {
+	// A <- B <- C <- B, D <- C
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	mA := mocks.NewMockOperand(mctrl)
+	mA.EXPECT().Name().Return("A").AnyTimes()
+	mA.EXPECT().Requires().Return([]string{})
+
+	mB := mocks.NewMockOperand(mctrl)
+	mB.EXPECT().Name().Return("B").AnyTimes()
+	mB.EXPECT().Requires().Return([]string{"A", "C"})
+
+	mC := mocks.NewMockOperand(mctrl)
+	mC.EXPECT().Name().Return("C").AnyTimes()
+	mC.EXPECT().Requires().Return([]string{"B"})
+
+	mD := mocks.NewMockOperand(mctrl)
+	mD.EXPECT().Name().Return("D").AnyTimes()
+	mD.EXPECT().Requires().Return([]string{"C"})
+
+	_, err := NewOperandDAG([]operand.Operand{mA, mB, mC, mD})
+	if !errors.Is(err, ErrCycle) {
+		t.Fatalf("expected cycle error, got: %v", err)
+	}
+	if err.Error() != "operand dependencies form a cycle: [B C D]" {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
+
+// stagedOperand is a mock operand assigned to a stage.
+type stagedOperand struct {
+	*mocks.MockOperand
+	stage string
+}
+
+func (s stagedOperand) Stage() string { return s.stage }
+
+// newStagedOperand creates a mock operand in the given stage with the given
+// requirements.
+func newStagedOperand(mctrl *gomock.Controller, name, stage string, requires ...string) operand.Operand {
+	op := mocks.NewMockOperand(mctrl)
+	op.EXPECT().Name().Return(name).AnyTimes()
+	op.EXPECT().Requires().Return(requires)
+	if stage == "" {
+		return op
+	}
+	return stagedOperand{MockOperand: op, stage: stage}
+}
+
+func TestStagedOrder(t *testing.T) {
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	cases := []struct {
+		name     string
+		operands func() []operand.Operand
+		want     string
+		wantErr  bool
+	}{
+		{
+			name: "independent operands in stages",
+			operands: func() []operand.Operand {
+				return []operand.Operand{
+					newStagedOperand(mctrl, "A", operand.StagePostInstall),
+					newStagedOperand(mctrl, "B", operand.StageInstall),
+					newStagedOperand(mctrl, "C", operand.StagePreInstall),
+					newStagedOperand(mctrl, "D", ""),
+				}
+			},
+			want: `[
+  0: [ C ]
+  1: [ B D ]
+  2: [ A ]
+]`,
+		},
+		{
+			name: "dependencies within and across stages",
+			operands: func() []operand.Operand {
+				// B requires A, C requires B, D requires A.
+				return []operand.Operand{
+					newStagedOperand(mctrl, "A", operand.StagePreInstall),
+					newStagedOperand(mctrl, "B", operand.StageInstall, "A"),
+					newStagedOperand(mctrl, "C", operand.StageInstall, "B"),
+					newStagedOperand(mctrl, "D", operand.StageInstall, "A"),
+					newStagedOperand(mctrl, "E", operand.StagePostInstall),
+				}
+			},
+			want: `[
+  0: [ A ]
+  1: [ B D ]
+  2: [ C ]
+  3: [ E ]
+]`,
+		},
+		{
+			name: "requires operand of a later stage",
+			operands: func() []operand.Operand {
+				return []operand.Operand{
+					newStagedOperand(mctrl, "A", operand.StagePostInstall),
+					newStagedOperand(mctrl, "B", operand.StagePreInstall, "A"),
+				}
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown stage",
+			operands: func() []operand.Operand {
+				return []operand.Operand{
+					newStagedOperand(mctrl, "A", "unknown"),
+				}
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			opd, err := NewOperandDAG(tc.operands())
+			if err != nil {
+				t.Fatalf("unexpected error while creating OperandDAG: %v", err)
+			}
+			ordered, err := opd.StagedOrder(operand.DefaultStages)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !tc.wantErr && ordered.String() != tc.want {
+				t.Errorf("unexpected results:\n\t(WNT) %q\n\t(GOT) %q", tc.want, ordered)
+			}
+		})
+	}
+}
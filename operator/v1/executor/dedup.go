@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// WithEventDeduplication enables suppressing the operand events that are
+// identical to an event recorded within the given window. The events are
+// identical if they're of the same object, type, reason and message. This
+// avoids recording the same events on every reconcile of an object that's
+// requeued repeatedly.
+func WithEventDeduplication(window time.Duration) ExecutorOption {
+	return func(exe *Executor) {
+		exe.dedupWindow = window
+	}
+}
+
+// eventKey identifies identical events.
+type eventKey struct {
+	object    string
+	eventType string
+	reason    string
+	message   string
+}
+
+// dedupRecorder is an EventRecorder that suppresses the events identical to
+// an event recorded within a window.
+type dedupRecorder struct {
+	record.EventRecorder
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[eventKey]time.Time
+}
+
+func newDedupRecorder(recorder record.EventRecorder, window time.Duration) *dedupRecorder {
+	return &dedupRecorder{
+		EventRecorder: recorder,
+		window:        window,
+		now:           time.Now,
+		seen:          map[eventKey]time.Time{},
+	}
+}
+
+// Event implements record.EventRecorder.
+func (r *dedupRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if r.allow(object, eventtype, reason, message) {
+		r.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// Eventf implements record.EventRecorder.
+func (r *dedupRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if r.allow(object, eventtype, reason, message) {
+		r.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+// AnnotatedEventf implements record.EventRecorder.
+func (r *dedupRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if r.allow(object, eventtype, reason, message) {
+		r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, "%s", message)
+	}
+}
+
+// allow returns true if the given event isn't identical to an event recorded
+// within the window, and remembers it. The expired events are forgotten.
+func (r *dedupRecorder) allow(object runtime.Object, eventtype, reason, message string) bool {
+	key := eventKey{object: objectID(object), eventType: eventtype, reason: reason, message: message}
+	now := r.now()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for k, t := range r.seen {
+		if now.Sub(t) >= r.window {
+			delete(r.seen, k)
+		}
+	}
+	if _, found := r.seen[key]; found {
+		return false
+	}
+	r.seen[key] = now
+	return true
+}
+
+// objectID returns an identifier of the given object from its type,
+// namespace, name and UID.
+func objectID(object runtime.Object) string {
+	m, err := meta.Accessor(object)
+	if err != nil {
+		return fmt.Sprintf("%T", object)
+	}
+	return fmt.Sprintf("%T/%s/%s/%s", object, m.GetNamespace(), m.GetName(), m.GetUID())
+}
@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	eventv1 "github.com/darkowlzz/operator-toolkit/event/v1"
+	"github.com/darkowlzz/operator-toolkit/operator/v1/operand"
+	"github.com/darkowlzz/operator-toolkit/operator/v1/operand/mocks"
+)
+
+// changeEvent is a ReconcilerEvent of a change made to an object.
+type changeEvent struct {
+	obj     client.Object
+	reason  string
+	message string
+}
+
+func (e changeEvent) Record(recorder record.EventRecorder) {
+	recorder.Event(e.obj, eventv1.K8sEventTypeNormal, e.reason, e.message)
+}
+
+// newEventOperand returns an operand that records a change event with the
+// given reason and message on every Ensure.
+func newEventOperand(mctrl *gomock.Controller, name, reason, message string) *mocks.MockOperand {
+	m := mocks.NewMockOperand(mctrl)
+	m.EXPECT().Name().Return(name).AnyTimes()
+	m.EXPECT().Requires().Return([]string{}).AnyTimes()
+	m.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+	m.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, obj client.Object, ownerRef metav1.OwnerReference) (eventv1.ReconcilerEvent, error) {
+			return changeEvent{obj: obj, reason: reason, message: message}, nil
+		}).AnyTimes()
+	m.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	m.EXPECT().PostReady(gomock.Any(), gomock.Any()).AnyTimes()
+	return m
+}
+
+// drainEvents returns the events recorded by the given fake recorder.
+func drainEvents(recorder *record.FakeRecorder) []string {
+	events := []string{}
+	for {
+		select {
+		case e := <-recorder.Events:
+			events = append(events, e)
+		default:
+			sort.Strings(events)
+			return events
+		}
+	}
+}
+
+func TestEventDeduplication(t *testing.T) {
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+
+	a := newEventOperand(mctrl, "A", "Created", "created configmap")
+	b := newEventOperand(mctrl, "B", "Created", "created secret")
+	order := operand.OperandOrder{{a, b}}
+
+	window := time.Minute
+	recorder := record.NewFakeRecorder(10)
+	exe := NewExecutor(Parallel, recorder, WithEventDeduplication(window))
+	now := time.Now()
+	exe.recorder.(*dedupRecorder).now = func() time.Time { return now }
+
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"}}
+	otherPod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "bar", Namespace: "default"}}
+	execute := func(obj client.Object) {
+		_, err := exe.ExecuteOperands(order, operand.CallEnsure, context.TODO(), obj, metav1.OwnerReference{})
+		assert.Nil(t, err)
+	}
+	wantEvents := []string{
+		"Normal Created created configmap",
+		"Normal Created created secret",
+	}
+
+	// The distinct events are recorded.
+	execute(pod)
+	assert.Equal(t, wantEvents, drainEvents(recorder))
+
+	// The identical events within the window are suppressed.
+	now = now.Add(window / 2)
+	execute(pod)
+	assert.Empty(t, drainEvents(recorder))
+
+	// The identical events of other objects are recorded.
+	execute(otherPod)
+	assert.Equal(t, wantEvents, drainEvents(recorder))
+
+	// The identical events are recorded again after the window.
+	now = now.Add(window / 2)
+	execute(pod)
+	assert.Equal(t, wantEvents, drainEvents(recorder))
+}
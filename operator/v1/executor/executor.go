@@ -2,10 +2,15 @@ package executor
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
+	"github.com/go-logr/logr"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kerrors "k8s.io/apimachinery/pkg/util/errors"
@@ -22,6 +27,19 @@ import (
 // Name of the instrumentation.
 const instrumentationName = constant.LibraryName + "/operator/executor"
 
+// Keys of the operand metric labels.
+const (
+	OperandKey = attribute.Key("operand")
+	CallKey    = attribute.Key("call")
+	ResultKey  = attribute.Key("result")
+)
+
+// Names of the operand metrics.
+const (
+	OperandDurationMetric = "operand.duration"
+	OperandCallsMetric    = "operand.calls"
+)
+
 // ExecutionStrategy is the operands execution strategy of an operator.
 type ExecutionStrategy int
 
@@ -30,22 +48,96 @@ const (
 	Serial
 )
 
+// ErrorPolicy is the policy of an executor on the operand errors.
+type ErrorPolicy int
+
+const (
+	// FailFast stops the execution at the first failed step. With the serial
+	// execution, the remaining operands of the step are not run.
+	FailFast ErrorPolicy = iota
+	// ContinueOnError continues the execution past the failed operands and
+	// returns all the errors aggregated. The operands that depend on a failed
+	// operand are not run.
+	ContinueOnError
+)
+
 // Executor is an operand executor. It is used to configure how the operands
 // are executed. The event recorder is used to broadcast an event right after
 // executing an operand.
 type Executor struct {
 	execStrategy ExecutionStrategy
+	errorPolicy  ErrorPolicy
 	recorder     record.EventRecorder
+	dedupWindow  time.Duration
 
 	inst *telemetry.Instrumentation
+
+	// duration records the operand execution durations in seconds.
+	duration metric.Float64ValueRecorder
+	// calls counts the operand executions by result.
+	calls metric.Int64Counter
+}
+
+// ExecutorOption is used to configure the Executor.
+type ExecutorOption func(*Executor)
+
+// WithInstrumentation configures the instrumentation of the Executor.
+func WithInstrumentation(tp trace.TracerProvider, mp metric.MeterProvider, log logr.Logger) ExecutorOption {
+	return func(exe *Executor) {
+		exe.inst = telemetry.NewInstrumentationWithProviders(instrumentationName, tp, mp, log)
+	}
 }
 
-// NewExecutor initializes and returns an Executor.
-func NewExecutor(e ExecutionStrategy, r record.EventRecorder) *Executor {
-	return &Executor{
+// WithErrorPolicy sets the ErrorPolicy of the Executor. Defaults to
+// FailFast.
+func WithErrorPolicy(policy ErrorPolicy) ExecutorOption {
+	return func(exe *Executor) {
+		exe.errorPolicy = policy
+	}
+}
+
+// NewExecutor initializes and returns an Executor. The Executor records the
+// execution duration and the result of every operand, labeled by the operand
+// name and the call type, ensure or cleanup.
+func NewExecutor(e ExecutionStrategy, r record.EventRecorder, opts ...ExecutorOption) *Executor {
+	exe := &Executor{
 		execStrategy: e,
 		recorder:     r,
-		inst:         telemetry.NewInstrumentation(instrumentationName),
+	}
+
+	for _, opt := range opts {
+		opt(exe)
+	}
+
+	if exe.inst == nil {
+		exe.inst = telemetry.NewInstrumentation(instrumentationName)
+	}
+
+	if exe.dedupWindow > 0 {
+		exe.recorder = newDedupRecorder(exe.recorder, exe.dedupWindow)
+	}
+
+	meter := metric.Must(exe.inst.Meter())
+	exe.duration = meter.NewFloat64ValueRecorder(OperandDurationMetric,
+		metric.WithDescription("Duration of the operand executions in seconds"),
+	)
+	exe.calls = meter.NewInt64Counter(OperandCallsMetric,
+		metric.WithDescription("Number of the operand executions by result"),
+	)
+
+	return exe
+}
+
+// callType returns the type of the given OperandRunCall used in the metric
+// labels.
+func callType(call operand.OperandRunCall) string {
+	switch reflect.ValueOf(call).Pointer() {
+	case reflect.ValueOf(operand.CallEnsure).Pointer():
+		return "ensure"
+	case reflect.ValueOf(operand.CallCleanup).Pointer():
+		return "cleanup"
+	default:
+		return "custom"
 	}
 }
 
@@ -66,10 +158,17 @@ func (exe *Executor) ExecuteOperands(
 	span.AddEvent("Start operand execution")
 	// Iterate through the order steps and run the operands in the steps as per
 	// the execution strategy.
+	// failed are the operands that failed or were not run due to a failed
+	// dependency, with the operands they require.
+	failed := map[string][]string{}
+
 	for _, ops := range order {
 		// Error in the current execution step.
 		var execErr error
 
+		// Skip the operands related to the failed operands.
+		ops = exe.runnableOperands(ops, failed)
+
 		// res is the Result of the step.
 		// TODO: Change the type of res to something that reflects that a
 		// change took place. The value of Result is not propagated to the
@@ -99,8 +198,14 @@ func (exe *Executor) ExecuteOperands(
 
 		if execErr != nil {
 			result = ctrl.Result{Requeue: true}
-			rerr = execErr
-			break
+			if exe.errorPolicy == FailFast {
+				rerr = execErr
+				break
+			}
+			rerr = kerrors.NewAggregate([]error{rerr, execErr})
+			for _, op := range failedOperands(ops, execErr) {
+				failed[op.Name()] = op.Requires()
+			}
 		}
 
 		// If a change was made with a Result received after the execution and
@@ -116,6 +221,66 @@ func (exe *Executor) ExecuteOperands(
 	return
 }
 
+// runnableOperands returns the operands of a step that aren't related to the
+// given failed operands. An operand is related to a failed operand if it
+// requires the failed operand, or is required by it, which applies to the
+// reverse order of the cleanup. The skipped operands are added to the failed
+// operands to skip their related operands in the subsequent steps.
+func (exe *Executor) runnableOperands(ops []operand.Operand, failed map[string][]string) []operand.Operand {
+	if len(failed) == 0 {
+		return ops
+	}
+
+	runnable := []operand.Operand{}
+	for _, op := range ops {
+		if isRelated(op, failed) {
+			failed[op.Name()] = op.Requires()
+			continue
+		}
+		runnable = append(runnable, op)
+	}
+	return runnable
+}
+
+// isRelated checks if the given operand requires or is required by any of the
+// given operands.
+func isRelated(op operand.Operand, operands map[string][]string) bool {
+	for _, r := range op.Requires() {
+		if _, found := operands[r]; found {
+			return true
+		}
+	}
+	for _, requires := range operands {
+		for _, r := range requires {
+			if r == op.Name() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// failedOperands returns the operands that failed in the given execution
+// error of a step.
+func failedOperands(ops []operand.Operand, execErr error) []operand.Operand {
+	result := []operand.Operand{}
+	errs := []error{execErr}
+	if agg, ok := execErr.(kerrors.Aggregate); ok {
+		errs = kerrors.Flatten(agg).Errors()
+	}
+	for _, err := range errs {
+		var opErr *operandError
+		if errors.As(err, &opErr) {
+			for _, op := range ops {
+				if op.Name() == opErr.name {
+					result = append(result, op)
+				}
+			}
+		}
+	}
+	return result
+}
+
 // serialExec runs the given set of operands serially with the given call
 // function. An event is used to know if a change was applied. When an event is
 // found, a result object is returned, else nil.
@@ -143,10 +308,13 @@ func (exe *Executor) serialExec(
 		)
 		// Call the run call function. Since this is serial execution, return
 		// if an error occurs.
-		event, err := call(op)(ctx, obj, ownerRef)
+		event, err := exe.runOperand(op, call, ctx, obj, ownerRef)
 		if err != nil {
 			rerr = kerrors.NewAggregate([]error{rerr, err})
-			return
+			if exe.errorPolicy == FailFast {
+				return
+			}
+			continue
 		}
 		if event != nil {
 			event.Record(exe.recorder)
@@ -196,7 +364,7 @@ func (exe *Executor) concurrentExec(
 			"Executing operand",
 			trace.WithAttributes(attribute.String("operand-name", op.Name())),
 		)
-		go exe.operateWithWaitGroup(&wg, resultChan, errChan, call(op), ctx, obj, ownerRef)
+		go exe.operateWithWaitGroup(&wg, resultChan, errChan, op, call, ctx, obj, ownerRef)
 	}
 	wg.Wait()
 	close(errChan)
@@ -221,22 +389,73 @@ func (exe *Executor) concurrentExec(
 	return
 }
 
-// operateWithWaitGroup runs the given function f and calls done on the wait
-// group at the end. This is a goroutine function used for running the operands
-// concurrently. The result from events and errors from the execution are
-// communicated via the respective channels.
+// runOperand runs the given operand with the given call function in a span
+// named after the operand. Any error returned by the operand is recorded in
+// the span.
+func (exe *Executor) runOperand(
+	op operand.Operand,
+	call operand.OperandRunCall,
+	ctx context.Context,
+	obj client.Object,
+	ownerRef metav1.OwnerReference,
+) (eventv1.ReconcilerEvent, error) {
+	ctx, span, _, _ := exe.inst.Start(ctx, op.Name())
+	defer span.End()
+
+	start := time.Now()
+	event, err := call(op)(ctx, obj, ownerRef)
+	exe.recordMetrics(ctx, op.Name(), callType(call), time.Since(start), err)
+	if err != nil {
+		span.RecordError(err)
+		return event, &operandError{name: op.Name(), err: err}
+	}
+	return event, nil
+}
+
+// operandError is the error of an operand execution. It's used to find the
+// failed operands of a step.
+type operandError struct {
+	name string
+	err  error
+}
+
+func (e *operandError) Error() string {
+	return e.err.Error()
+}
+
+func (e *operandError) Unwrap() error {
+	return e.err
+}
+
+// recordMetrics records the duration and the result of an operand execution.
+func (exe *Executor) recordMetrics(ctx context.Context, name, call string, duration time.Duration, err error) {
+	labels := []attribute.KeyValue{OperandKey.String(name), CallKey.String(call)}
+	exe.duration.Record(ctx, duration.Seconds(), labels...)
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	exe.calls.Add(ctx, 1, append(labels, ResultKey.String(result))...)
+}
+
+// operateWithWaitGroup runs the given operand with the given call function
+// and calls done on the wait group at the end. This is a goroutine function
+// used for running the operands concurrently. The result from events and
+// errors from the execution are communicated via the respective channels.
 func (exe *Executor) operateWithWaitGroup(
 	wg *sync.WaitGroup,
 	resultChan chan ctrl.Result,
 	errChan chan error,
-	f func(context.Context, client.Object, metav1.OwnerReference) (eventv1.ReconcilerEvent, error),
+	op operand.Operand,
+	call operand.OperandRunCall,
 	ctx context.Context,
 	obj client.Object,
 	ownerRef metav1.OwnerReference,
 ) {
 	defer wg.Done()
 
-	event, err := f(ctx, obj, ownerRef)
+	event, err := exe.runOperand(op, call, ctx, obj, ownerRef)
 	if err != nil {
 		errChan <- err
 	}
@@ -0,0 +1,363 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/number"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/semconv"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	eventv1 "github.com/darkowlzz/operator-toolkit/event/v1"
+	"github.com/darkowlzz/operator-toolkit/operator/v1/operand"
+	"github.com/darkowlzz/operator-toolkit/operator/v1/operand/mocks"
+)
+
+func TestOperandSpans(t *testing.T) {
+	// Record the spans in memory.
+	exporter := tracetest.NewInMemoryExporter()
+	prevTP := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	defer otel.SetTracerProvider(prevTP)
+
+	cases := []struct {
+		name         string
+		strategy     ExecutionStrategy
+		execSpanName string
+	}{
+		{name: "serial", strategy: Serial, execSpanName: "serial-exec"},
+		{name: "parallel", strategy: Parallel, execSpanName: "concurrent-exec"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			exporter.Reset()
+
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+
+			// opA succeeds and opB fails.
+			mA := mocks.NewMockOperand(mctrl)
+			mA.EXPECT().Name().Return("opA").AnyTimes()
+			mA.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any())
+			mA.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil)
+			mA.EXPECT().PostReady(gomock.Any(), gomock.Any())
+			mA.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+
+			mB := mocks.NewMockOperand(mctrl)
+			mB.EXPECT().Name().Return("opB").AnyTimes()
+			mB.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("failed"))
+			mB.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+
+			exe := NewExecutor(tc.strategy, record.NewFakeRecorder(1))
+			order := operand.OperandOrder{{mA, mB}}
+			_, err := exe.ExecuteOperands(order, operand.CallEnsure, context.TODO(), &corev1.Pod{}, metav1.OwnerReference{})
+			assert.Error(t, err)
+
+			spans := map[string][]*sdktrace.SpanSnapshot{}
+			for _, s := range exporter.GetSpans() {
+				spans[s.Name] = append(spans[s.Name], s)
+			}
+
+			// One span per operand, children of the execution span.
+			assert.Len(t, spans[tc.execSpanName], 1)
+			execSpan := spans[tc.execSpanName][0]
+			for _, name := range []string{"opA", "opB"} {
+				assert.Len(t, spans[name], 1, "span of %s", name)
+				assert.Equal(t, execSpan.SpanContext.SpanID(), spans[name][0].Parent.SpanID(), "parent of %s", name)
+			}
+
+			// The error is recorded on the failing operand span only.
+			hasErrorEvent := func(s *sdktrace.SpanSnapshot) bool {
+				for _, e := range s.MessageEvents {
+					if e.Name == semconv.ExceptionEventName {
+						return true
+					}
+				}
+				return false
+			}
+			assert.False(t, hasErrorEvent(spans["opA"][0]))
+			assert.True(t, hasErrorEvent(spans["opB"][0]))
+		})
+	}
+}
+
+// measurement is a metric event recorded by the mockMeter.
+type measurement struct {
+	name   string
+	value  number.Number
+	labels map[attribute.Key]string
+}
+
+// mockMeter is a MeterImpl that records the synchronous metric events.
+type mockMeter struct {
+	mu           sync.Mutex
+	measurements []measurement
+}
+
+func (m *mockMeter) Meter(name string, opts ...metric.MeterOption) metric.Meter {
+	return metric.WrapMeterImpl(m, name, opts...)
+}
+
+func (m *mockMeter) RecordBatch(ctx context.Context, labels []attribute.KeyValue, ms ...metric.Measurement) {
+	for _, ms := range ms {
+		ms.SyncImpl().RecordOne(ctx, ms.Number(), labels)
+	}
+}
+
+func (m *mockMeter) NewSyncInstrument(descriptor metric.Descriptor) (metric.SyncImpl, error) {
+	return &mockInstrument{meter: m, descriptor: descriptor}, nil
+}
+
+func (m *mockMeter) NewAsyncInstrument(descriptor metric.Descriptor, runner metric.AsyncRunner) (metric.AsyncImpl, error) {
+	return nil, errors.New("async instruments are not supported")
+}
+
+// byName returns the recorded measurements of the instrument with the given
+// name.
+func (m *mockMeter) byName(name string) []measurement {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := []measurement{}
+	for _, ms := range m.measurements {
+		if ms.name == name {
+			result = append(result, ms)
+		}
+	}
+	return result
+}
+
+// mockInstrument is a synchronous instrument of the mockMeter.
+type mockInstrument struct {
+	meter      *mockMeter
+	descriptor metric.Descriptor
+}
+
+func (i *mockInstrument) Implementation() interface{} { return i }
+
+func (i *mockInstrument) Descriptor() metric.Descriptor { return i.descriptor }
+
+func (i *mockInstrument) Bind(labels []attribute.KeyValue) metric.BoundSyncImpl {
+	panic("bound instruments are not supported")
+}
+
+func (i *mockInstrument) RecordOne(ctx context.Context, n number.Number, labels []attribute.KeyValue) {
+	i.meter.mu.Lock()
+	defer i.meter.mu.Unlock()
+	ls := map[attribute.Key]string{}
+	for _, l := range labels {
+		ls[l.Key] = l.Value.Emit()
+	}
+	i.meter.measurements = append(i.meter.measurements, measurement{
+		name:   i.descriptor.Name(),
+		value:  n,
+		labels: ls,
+	})
+}
+
+func TestOperandMetrics(t *testing.T) {
+	cases := []struct {
+		name     string
+		call     operand.OperandRunCall
+		callType string
+	}{
+		{name: "ensure", call: operand.CallEnsure, callType: "ensure"},
+		{name: "cleanup", call: operand.CallCleanup, callType: "cleanup"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+
+			// opA succeeds and opB fails.
+			mA := mocks.NewMockOperand(mctrl)
+			mA.EXPECT().Name().Return("opA").AnyTimes()
+			mA.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+			mA.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+			mA.EXPECT().PostReady(gomock.Any(), gomock.Any()).AnyTimes()
+			mA.EXPECT().Delete(gomock.Any(), gomock.Any()).AnyTimes()
+			mA.EXPECT().CleanupReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+			mA.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+
+			mB := mocks.NewMockOperand(mctrl)
+			mB.EXPECT().Name().Return("opB").AnyTimes()
+			mB.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("failed")).AnyTimes()
+			mB.EXPECT().Delete(gomock.Any(), gomock.Any()).Return(nil, errors.New("failed")).AnyTimes()
+			mB.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+
+			meter := &mockMeter{}
+			exe := NewExecutor(Serial, record.NewFakeRecorder(1), WithInstrumentation(nil, meter, nil))
+			order := operand.OperandOrder{{mA, mB}}
+			_, err := exe.ExecuteOperands(order, tc.call, context.TODO(), &corev1.Pod{}, metav1.OwnerReference{})
+			assert.Error(t, err)
+
+			// A duration is recorded per operand.
+			durations := meter.byName(OperandDurationMetric)
+			assert.Len(t, durations, 2)
+			for i, name := range []string{"opA", "opB"} {
+				assert.Equal(t, name, durations[i].labels[OperandKey])
+				assert.Equal(t, tc.callType, durations[i].labels[CallKey])
+				assert.GreaterOrEqual(t, durations[i].value.AsFloat64(), float64(0))
+			}
+
+			// The results are counted per operand.
+			calls := meter.byName(OperandCallsMetric)
+			assert.Len(t, calls, 2)
+			results := map[string]string{}
+			for _, c := range calls {
+				assert.Equal(t, int64(1), c.value.AsInt64())
+				assert.Equal(t, tc.callType, c.labels[CallKey])
+				results[c.labels[OperandKey]] = c.labels[ResultKey]
+			}
+			assert.Equal(t, map[string]string{"opA": "success", "opB": "failure"}, results)
+		})
+	}
+}
+
+// newPolicyOperand returns a mock operand with the given name and
+// requirements that records its executions in ran. The operand fails if fail
+// is true.
+func newPolicyOperand(mctrl *gomock.Controller, mu *sync.Mutex, ran *[]string, name string, fail bool, requires ...string) *mocks.MockOperand {
+	var err error
+	if fail {
+		err = errors.New(name + " failed")
+	}
+	record := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		*ran = append(*ran, name)
+	}
+
+	m := mocks.NewMockOperand(mctrl)
+	m.EXPECT().Name().Return(name).AnyTimes()
+	m.EXPECT().Requires().Return(requires).AnyTimes()
+	m.EXPECT().RequeueStrategy().Return(operand.RequeueOnError).AnyTimes()
+	m.EXPECT().Ensure(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, obj client.Object, ownerRef metav1.OwnerReference) (eventv1.ReconcilerEvent, error) {
+			record()
+			return nil, err
+		}).AnyTimes()
+	m.EXPECT().Delete(gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, obj client.Object) (eventv1.ReconcilerEvent, error) {
+			record()
+			return nil, err
+		}).AnyTimes()
+	m.EXPECT().ReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	m.EXPECT().PostReady(gomock.Any(), gomock.Any()).AnyTimes()
+	m.EXPECT().CleanupReadyCheck(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+	return m
+}
+
+func TestErrorPolicy(t *testing.T) {
+	cases := []struct {
+		name     string
+		strategy ExecutionStrategy
+		policy   ErrorPolicy
+		cleanup  bool
+		failing  string
+		wantRan  []string
+		wantErrs []string
+	}{
+		{
+			name:     "serial fail fast",
+			strategy: Serial,
+			policy:   FailFast,
+			failing:  "A",
+			wantRan:  []string{"A"},
+			wantErrs: []string{"A failed"},
+		},
+		{
+			name:     "serial continue on error",
+			strategy: Serial,
+			policy:   ContinueOnError,
+			failing:  "A",
+			wantRan:  []string{"A", "B", "D"},
+			wantErrs: []string{"A failed"},
+		},
+		{
+			name:     "parallel fail fast",
+			strategy: Parallel,
+			policy:   FailFast,
+			failing:  "A",
+			wantRan:  []string{"A", "B"},
+			wantErrs: []string{"A failed"},
+		},
+		{
+			name:     "parallel continue on error",
+			strategy: Parallel,
+			policy:   ContinueOnError,
+			failing:  "A",
+			wantRan:  []string{"A", "B", "D"},
+			wantErrs: []string{"A failed"},
+		},
+		{
+			// In the reverse order, the operands required by a failed
+			// operand are not cleaned up.
+			name:     "cleanup continue on error",
+			strategy: Serial,
+			policy:   ContinueOnError,
+			cleanup:  true,
+			failing:  "C",
+			wantRan:  []string{"E", "C", "D", "B"},
+			wantErrs: []string{"C failed"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+
+			var mu sync.Mutex
+			ran := []string{}
+
+			// C requires A, D requires B and E requires C.
+			a := newPolicyOperand(mctrl, &mu, &ran, "A", tc.failing == "A")
+			b := newPolicyOperand(mctrl, &mu, &ran, "B", tc.failing == "B")
+			c := newPolicyOperand(mctrl, &mu, &ran, "C", tc.failing == "C", "A")
+			d := newPolicyOperand(mctrl, &mu, &ran, "D", tc.failing == "D", "B")
+			e := newPolicyOperand(mctrl, &mu, &ran, "E", tc.failing == "E", "C")
+			order := operand.OperandOrder{{a, b}, {c, d}, {e}}
+			call := operand.CallEnsure
+			if tc.cleanup {
+				order = order.Reverse()
+				call = operand.CallCleanup
+			}
+
+			exe := NewExecutor(tc.strategy, record.NewFakeRecorder(10), WithErrorPolicy(tc.policy))
+			res, err := exe.ExecuteOperands(order, call, context.TODO(), &corev1.Pod{}, metav1.OwnerReference{})
+			assert.True(t, res.Requeue)
+			assert.Error(t, err)
+
+			errs := []string{}
+			for _, e := range kerrors.Flatten(err.(kerrors.Aggregate)).Errors() {
+				errs = append(errs, e.Error())
+			}
+			assert.Equal(t, tc.wantErrs, errs)
+
+			// The order of the concurrent executions isn't deterministic.
+			sort.Strings(ran)
+			wantRan := append([]string{}, tc.wantRan...)
+			sort.Strings(wantRan)
+			assert.Equal(t, wantRan, ran)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/kustomize/api/filesys"
+
+	"github.com/darkowlzz/operator-toolkit/declarative"
+	eventv1 "github.com/darkowlzz/operator-toolkit/event/v1"
+)
+
+// readyConditionTypes are the status condition types checked by the
+// declarative operand ready check.
+var readyConditionTypes = []string{"Ready", "Available"}
+
+// BuilderOptionsFunc returns the declarative builder options used to build
+// the package of a declarative operand for the given object. It can be used
+// to transform the manifests based on the object.
+type BuilderOptionsFunc func(ctx context.Context, obj client.Object) ([]declarative.BuilderOption, error)
+
+// DeclarativeOperand is an Operand that applies and deletes the manifests of
+// a declarative package. Ensure applies the manifests with the owner
+// reference of the object set, Delete deletes the manifests, ReadyCheck
+// checks if all the objects in the manifests are ready and CleanupReadyCheck
+// checks if all the objects in the manifests are deleted.
+type DeclarativeOperand struct {
+	name            string
+	packageName     string
+	fs              filesys.FileSystem
+	client          client.Client
+	requires        []string
+	stage           string
+	requeueStrategy RequeueStrategy
+	builderOptions  BuilderOptionsFunc
+}
+
+var _ StagedOperand = &DeclarativeOperand{}
+
+// DeclarativeOption is used to configure DeclarativeOperand.
+type DeclarativeOption func(*DeclarativeOperand)
+
+// WithRequires sets the operands required by the declarative operand.
+func WithRequires(requires ...string) DeclarativeOption {
+	return func(d *DeclarativeOperand) {
+		d.requires = requires
+	}
+}
+
+// WithStage sets the stage of the declarative operand. Defaults to
+// StageInstall.
+func WithStage(stage string) DeclarativeOption {
+	return func(d *DeclarativeOperand) {
+		d.stage = stage
+	}
+}
+
+// WithRequeueStrategy sets the requeue strategy of the declarative operand.
+func WithRequeueStrategy(strategy RequeueStrategy) DeclarativeOption {
+	return func(d *DeclarativeOperand) {
+		d.requeueStrategy = strategy
+	}
+}
+
+// WithBuilderOptions sets the function that returns the declarative builder
+// options for an object.
+func WithBuilderOptions(f BuilderOptionsFunc) DeclarativeOption {
+	return func(d *DeclarativeOperand) {
+		d.builderOptions = f
+	}
+}
+
+// NewDeclarativeOperand returns a DeclarativeOperand for the given package in
+// the filesystem. The client is used to read the live objects for the ready
+// check.
+func NewDeclarativeOperand(name, packageName string, fs filesys.FileSystem, c client.Client, opts ...DeclarativeOption) *DeclarativeOperand {
+	d := &DeclarativeOperand{
+		name:        name,
+		packageName: packageName,
+		fs:          fs,
+		client:      c,
+	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
+}
+
+func (d *DeclarativeOperand) Name() string                     { return d.name }
+func (d *DeclarativeOperand) Requires() []string               { return d.requires }
+func (d *DeclarativeOperand) Stage() string                    { return d.stage }
+func (d *DeclarativeOperand) RequeueStrategy() RequeueStrategy { return d.requeueStrategy }
+func (d *DeclarativeOperand) PostReady(ctx context.Context, obj client.Object) error {
+	return nil
+}
+
+// Ensure builds the package with the owner reference set on all the
+// manifests and applies it.
+func (d *DeclarativeOperand) Ensure(ctx context.Context, obj client.Object, ownerRef metav1.OwnerReference) (eventv1.ReconcilerEvent, error) {
+	var extraOpts []declarative.BuilderOption
+	if ownerRef.UID != "" {
+		extraOpts = append(extraOpts, declarative.WithOwnerReferences(ownerRef))
+	}
+	b, err := d.builder(ctx, obj, extraOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return nil, b.Apply(ctx)
+}
+
+// Delete builds the package and deletes it.
+func (d *DeclarativeOperand) Delete(ctx context.Context, obj client.Object) (eventv1.ReconcilerEvent, error) {
+	b, err := d.builder(ctx, obj)
+	if err != nil {
+		return nil, err
+	}
+	return nil, b.Delete(ctx)
+}
+
+// ReadyCheck checks if all the objects in the package exist and are ready.
+// An object is ready when its status observedGeneration, if any, is up to
+// date and its Ready and Available status conditions, if any, are true.
+func (d *DeclarativeOperand) ReadyCheck(ctx context.Context, obj client.Object) (bool, error) {
+	b, err := d.builder(ctx, obj)
+	if err != nil {
+		return false, err
+	}
+	objs, err := b.RenderObjects()
+	if err != nil {
+		return false, err
+	}
+
+	for _, o := range objs {
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(o.GetObjectKind().GroupVersionKind())
+		if err := d.client.Get(ctx, client.ObjectKeyFromObject(o), live); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+		ready, err := objectReady(live)
+		if err != nil || !ready {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// CleanupReadyCheck checks if all the objects in the package are deleted.
+func (d *DeclarativeOperand) CleanupReadyCheck(ctx context.Context, obj client.Object) (bool, error) {
+	b, err := d.builder(ctx, obj)
+	if err != nil {
+		return false, err
+	}
+	objs, err := b.RenderObjects()
+	if err != nil {
+		return false, err
+	}
+
+	for _, o := range objs {
+		live := &unstructured.Unstructured{}
+		live.SetGroupVersionKind(o.GetObjectKind().GroupVersionKind())
+		if err := d.client.Get(ctx, client.ObjectKeyFromObject(o), live); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		return false, nil
+	}
+	return true, nil
+}
+
+// builder builds the package of the operand for the given object.
+func (d *DeclarativeOperand) builder(ctx context.Context, obj client.Object, extraOpts ...declarative.BuilderOption) (*declarative.Builder, error) {
+	var opts []declarative.BuilderOption
+	if d.builderOptions != nil {
+		o, err := d.builderOptions(ctx, obj)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get builder options of operand %q: %w", d.name, err)
+		}
+		opts = append(opts, o...)
+	}
+	opts = append(opts, extraOpts...)
+	return declarative.NewBuilder(d.packageName, d.fs, opts...)
+}
+
+// objectReady checks the observed generation and the ready conditions of an
+// object status.
+func objectReady(obj *unstructured.Unstructured) (bool, error) {
+	observed, found, err := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+	if err != nil {
+		return false, err
+	}
+	if found && observed < obj.GetGeneration() {
+		return false, nil
+	}
+
+	conditions, _, err := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	if err != nil {
+		return false, err
+	}
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, t := range readyConditionTypes {
+			if cond["type"] == t && cond["status"] != string(metav1.ConditionTrue) {
+				return false, nil
+			}
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,211 @@
+package operand
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/kustomize/api/filesys"
+
+	"github.com/darkowlzz/operator-toolkit/declarative"
+)
+
+const testConfigMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: default
+data:
+  foo: bar
+`
+
+// fakeKubectl is a KubectlClient that applies and deletes the manifests
+// using a client.
+type fakeKubectl struct {
+	client client.Client
+}
+
+func (k *fakeKubectl) Apply(ctx context.Context, namespace string, manifest string, validate bool, extraArgs ...string) error {
+	objs, err := decodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err := k.client.Create(ctx, obj); err != nil {
+			if !apierrors.IsAlreadyExists(err) {
+				return err
+			}
+			if err := k.client.Update(ctx, obj); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (k *fakeKubectl) Delete(ctx context.Context, namespace string, manifest string, validate bool, extraArgs ...string) error {
+	objs, err := decodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err := client.IgnoreNotFound(k.client.Delete(ctx, obj)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decodeManifest(manifest string) ([]*unstructured.Unstructured, error) {
+	objs := []*unstructured.Unstructured{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(bytes.NewBufferString(manifest), 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if err == io.EOF {
+				return objs, nil
+			}
+			return nil, err
+		}
+		if len(u.Object) > 0 {
+			objs = append(objs, u)
+		}
+	}
+}
+
+func TestDeclarativeOperand(t *testing.T) {
+	fs := filesys.MakeFsInMemory()
+	assert.Nil(t, fs.WriteFile("config/configmap.yaml", []byte(testConfigMap)))
+
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	owner := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "default", UID: "a1b2c3"}}
+	ownerRef := metav1.OwnerReference{APIVersion: "v1", Kind: "Pod", Name: owner.Name, UID: owner.UID}
+
+	op := NewDeclarativeOperand("config", "config", fs, cli,
+		WithRequires("other"),
+		WithRequeueStrategy(RequeueAlways),
+		WithBuilderOptions(func(ctx context.Context, obj client.Object) ([]declarative.BuilderOption, error) {
+			return []declarative.BuilderOption{
+				declarative.WithRawManifestDir("config"),
+				declarative.WithKubectlClient(&fakeKubectl{client: cli}),
+			}, nil
+		}),
+	)
+	assert.Equal(t, "config", op.Name())
+	assert.Equal(t, []string{"other"}, op.Requires())
+	assert.Equal(t, RequeueAlways, op.RequeueStrategy())
+
+	key := client.ObjectKey{Name: "config", Namespace: "default"}
+
+	// Not ready before applying.
+	ready, err := op.ReadyCheck(context.TODO(), owner)
+	assert.Nil(t, err)
+	assert.False(t, ready)
+
+	// Ensure applies the manifests with the owner reference.
+	_, err = op.Ensure(context.TODO(), owner, ownerRef)
+	assert.Nil(t, err)
+	cm := &corev1.ConfigMap{}
+	assert.Nil(t, cli.Get(context.TODO(), key, cm))
+	assert.Equal(t, map[string]string{"foo": "bar"}, cm.Data)
+	assert.Len(t, cm.OwnerReferences, 1)
+	assert.Equal(t, ownerRef.UID, cm.OwnerReferences[0].UID)
+
+	ready, err = op.ReadyCheck(context.TODO(), owner)
+	assert.Nil(t, err)
+	assert.True(t, ready)
+
+	cleanedUp, err := op.CleanupReadyCheck(context.TODO(), owner)
+	assert.Nil(t, err)
+	assert.False(t, cleanedUp)
+
+	// Delete deletes the manifests.
+	_, err = op.Delete(context.TODO(), owner)
+	assert.Nil(t, err)
+	assert.True(t, apierrors.IsNotFound(cli.Get(context.TODO(), key, cm)))
+
+	cleanedUp, err = op.CleanupReadyCheck(context.TODO(), owner)
+	assert.Nil(t, err)
+	assert.True(t, cleanedUp)
+
+	// A repeated cleanup of the deleted manifests succeeds.
+	_, err = CallCleanup(op)(context.TODO(), owner, ownerRef)
+	assert.Nil(t, err)
+}
+
+func TestDeleteObjects(t *testing.T) {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "default"}}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "secret", Namespace: "default"}}
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cm, secret).Build()
+
+	objs := []client.Object{cm.DeepCopy(), secret.DeepCopy()}
+	assert.Nil(t, DeleteObjects(context.TODO(), cli, objs))
+	assert.True(t, apierrors.IsNotFound(cli.Get(context.TODO(), client.ObjectKeyFromObject(cm), &corev1.ConfigMap{})))
+	assert.True(t, apierrors.IsNotFound(cli.Get(context.TODO(), client.ObjectKeyFromObject(secret), &corev1.Secret{})))
+
+	// Deleting the deleted objects again succeeds.
+	assert.Nil(t, DeleteObjects(context.TODO(), cli, objs))
+}
+
+func TestObjectReady(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    map[string]interface{}
+		wantReady bool
+	}{
+		{
+			name:      "no status",
+			wantReady: true,
+		},
+		{
+			name:      "stale observed generation",
+			status:    map[string]interface{}{"observedGeneration": int64(1)},
+			wantReady: false,
+		},
+		{
+			name: "ready condition false",
+			status: map[string]interface{}{
+				"observedGeneration": int64(2),
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Ready", "status": "False"},
+				},
+			},
+			wantReady: false,
+		},
+		{
+			name: "available condition true",
+			status: map[string]interface{}{
+				"observedGeneration": int64(2),
+				"conditions": []interface{}{
+					map[string]interface{}{"type": "Available", "status": "True"},
+					map[string]interface{}{"type": "Progressing", "status": "False"},
+				},
+			},
+			wantReady: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+			obj.SetGeneration(2)
+			if tc.status != nil {
+				obj.Object["status"] = tc.status
+			}
+			ready, err := objectReady(obj)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.wantReady, ready)
+		})
+	}
+}
@@ -38,6 +38,21 @@ func (m *MockOperand) EXPECT() *MockOperandMockRecorder {
 	return m.recorder
 }
 
+// CleanupReadyCheck mocks base method.
+func (m *MockOperand) CleanupReadyCheck(arg0 context.Context, arg1 client.Object) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CleanupReadyCheck", arg0, arg1)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CleanupReadyCheck indicates an expected call of CleanupReadyCheck.
+func (mr *MockOperandMockRecorder) CleanupReadyCheck(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanupReadyCheck", reflect.TypeOf((*MockOperand)(nil).CleanupReadyCheck), arg0, arg1)
+}
+
 // Delete mocks base method.
 func (m *MockOperand) Delete(arg0 context.Context, arg1 client.Object) (v1.ReconcilerEvent, error) {
 	m.ctrl.T.Helper()
@@ -6,8 +6,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	eventv1 "github.com/darkowlzz/operator-toolkit/event/v1"
@@ -27,6 +30,60 @@ const (
 // ErrNotReady is returned by operand when the ready check fails.
 var ErrNotReady = errors.New("operand not ready")
 
+// NotReadyError is a not ready error that requests a wait period before the
+// next attempt. It matches ErrNotReady.
+type NotReadyError struct {
+	// RequeueAfter is the requested wait period.
+	RequeueAfter time.Duration
+}
+
+func (e *NotReadyError) Error() string {
+	return fmt.Sprintf("%v, retry after %v", ErrNotReady, e.RequeueAfter)
+}
+
+// Is implements the errors.Is interface to match ErrNotReady.
+func (e *NotReadyError) Is(target error) bool { return target == ErrNotReady }
+
+// NotReadyAfter returns a not ready error that requests the operator to retry
+// after the given wait period. It can be returned by the ReadyCheck and the
+// CleanupReadyCheck of an operand to override the retry period of the
+// operator.
+func NotReadyAfter(wait time.Duration) error {
+	return &NotReadyError{RequeueAfter: wait}
+}
+
+// NotReadyWait returns the shortest wait period requested by the not ready
+// errors in the given error, which may be an aggregate of the errors of
+// multiple operands. The not ready errors that don't request a wait period
+// wait for the given default period.
+func NotReadyWait(err error, defaultWait time.Duration) time.Duration {
+	errs := []error{err}
+	if agg, ok := err.(kerrors.Aggregate); ok {
+		errs = kerrors.Flatten(agg).Errors()
+	}
+
+	var wait time.Duration
+	found := false
+	for _, e := range errs {
+		if !errors.Is(e, ErrNotReady) {
+			continue
+		}
+		w := defaultWait
+		var nrErr *NotReadyError
+		if errors.As(e, &nrErr) && nrErr.RequeueAfter > 0 {
+			w = nrErr.RequeueAfter
+		}
+		if !found || w < wait {
+			wait = w
+			found = true
+		}
+	}
+	if !found {
+		return defaultWait
+	}
+	return wait
+}
+
 // Operand defines a single operation that's part of a composite operator. It
 // contains implementation details about how an action is performed, maybe for
 // creating a resource, and how to reverse/undo the action, maybe for cleanup
@@ -59,6 +116,48 @@ type Operand interface {
 	// PostReady allows performing actions once the target object of the
 	// operand is ready.
 	PostReady(context.Context, client.Object) error
+
+	// CleanupReadyCheck allows writing custom logic for checking if the
+	// target objects of the operand are deleted. The operands that this
+	// operand requires are deleted only after it returns true.
+	CleanupReadyCheck(context.Context, client.Object) (bool, error)
+}
+
+// Stages of the operands. The operands of a stage run only after all the
+// operands of the previous stages, regardless of their dependencies.
+const (
+	// StagePreInstall is the stage of the operands that prepare for the
+	// install, like creating the CRDs.
+	StagePreInstall = "pre-install"
+
+	// StageInstall is the default stage of the operands.
+	StageInstall = "install"
+
+	// StagePostInstall is the stage of the operands that run after the
+	// install, like running the migrations.
+	StagePostInstall = "post-install"
+)
+
+// DefaultStages are the stages of the operands, in their order of execution.
+var DefaultStages = []string{StagePreInstall, StageInstall, StagePostInstall}
+
+// StagedOperand is an Operand that's assigned to a named stage. The stages
+// provide a coarse ordering of the operands on top of their dependencies.
+// The operands that don't implement StagedOperand are in StageInstall.
+type StagedOperand interface {
+	Operand
+
+	// Stage is the name of the stage of the operand.
+	Stage() string
+}
+
+// StageOf returns the stage of the given operand. An operand with no stage
+// is in StageInstall.
+func StageOf(op Operand) string {
+	if so, ok := op.(StagedOperand); ok && so.Stage() != "" {
+		return so.Stage()
+	}
+	return StageInstall
 }
 
 // OperandRunCall defines a function type used to define a function that
@@ -95,14 +194,44 @@ func CallEnsure(op Operand) func(context.Context, client.Object, metav1.OwnerRef
 	}
 }
 
-// CallCleanup is an OperandRunCall type function that calls the Cleanup
-// function of a given operand.
+// CallCleanup is an OperandRunCall type function that calls the Delete
+// function and the CleanupReadyCheck of a given operand. The
+// CleanupReadyCheck helps proceed with deleting the operands this operand
+// requires only when its deletion is complete. A not found error from Delete
+// is a successful delete, since the target objects are already gone, which
+// makes a repeated cleanup succeed without a requeue.
 func CallCleanup(op Operand) func(context.Context, client.Object, metav1.OwnerReference) (eventv1.ReconcilerEvent, error) {
 	// Wrap Delete with OperandRunCall, ignoring the arguments that aren't
 	// required, to have the ability to call both Ensure and Delete with
 	// OperandRunCall.
-	return func(ctx context.Context, obj client.Object, ownerRef metav1.OwnerReference) (event eventv1.ReconcilerEvent, err error) {
-		event, err = op.Delete(ctx, obj)
-		return
+	return func(ctx context.Context, obj client.Object, ownerRef metav1.OwnerReference) (eventv1.ReconcilerEvent, error) {
+		event, err := op.Delete(ctx, obj)
+		if err != nil && !apierrors.IsNotFound(err) {
+			return nil, err
+		}
+
+		cleanedUp, err := op.CleanupReadyCheck(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+
+		if !cleanedUp {
+			return nil, fmt.Errorf("operand %q cleanup check failed: not deleted yet: %w", op.Name(), ErrNotReady)
+		}
+
+		return event, nil
+	}
+}
+
+// DeleteObjects deletes the given objects with the given client, treating the
+// objects that are already gone as deleted. This can be used by the operand
+// Delete implementations to be idempotent, succeeding when the objects were
+// deleted by a previous cleanup.
+func DeleteObjects(ctx context.Context, c client.Client, objs []client.Object, opts ...client.DeleteOption) error {
+	for _, o := range objs {
+		if err := client.IgnoreNotFound(c.Delete(ctx, o, opts...)); err != nil {
+			return fmt.Errorf("failed to delete %T %q: %w", o, client.ObjectKeyFromObject(o), err)
+		}
 	}
+	return nil
 }
@@ -42,12 +42,36 @@ func (o OperandOrder) String() string {
 func (o OperandOrder) Reverse() OperandOrder {
 	// Refer: https://github.com/golang/go/wiki/SliceTricks#reversing
 	r := make(OperandOrder, len(o))
-	for left, right := 0, len(o)-1; left < right; left, right = left+1, right-1 {
+	for left, right := 0, len(o)-1; left <= right; left, right = left+1, right-1 {
 		r[left], r[right] = o[right], o[left]
 	}
 	return r
 }
 
+// Filter returns a new OperandOrder containing only the operands with the
+// given names. The relative order of the operands is preserved and the steps
+// left empty are dropped.
+func (o OperandOrder) Filter(names ...string) OperandOrder {
+	include := map[string]bool{}
+	for _, name := range names {
+		include[name] = true
+	}
+
+	r := OperandOrder{}
+	for _, s := range o {
+		step := []Operand{}
+		for _, op := range s {
+			if include[op.Name()] {
+				step = append(step, op)
+			}
+		}
+		if len(step) > 0 {
+			r = append(r, step)
+		}
+	}
+	return r
+}
+
 // StepRequeueStrategy returns the requeue strategy of a step. By default, the
 // operands are requeued on error. Since the operands in a step run
 // concurrently, if an operand has RequeueAlways strategy, the whole step gets
@@ -0,0 +1,82 @@
+package operand
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// WorkloadReady returns a ReadyCheck function that checks if the workload
+// with the given key has completed its rollout. The workload must be a
+// *appsv1.Deployment, *appsv1.StatefulSet or *appsv1.DaemonSet, which is used
+// as the type of the object to fetch. A workload is ready when its status
+// observes the latest generation and the updated and available replicas
+// match the desired replicas. A workload that doesn't exist isn't ready.
+func WorkloadReady(reader client.Reader, key client.ObjectKey, workload client.Object) func(context.Context, client.Object) (bool, error) {
+	return func(ctx context.Context, obj client.Object) (bool, error) {
+		live := workload.DeepCopyObject().(client.Object)
+		if err := reader.Get(ctx, key, live); err != nil {
+			if apierrors.IsNotFound(err) {
+				return false, nil
+			}
+			return false, err
+		}
+
+		switch w := live.(type) {
+		case *appsv1.Deployment:
+			return deploymentReady(w), nil
+		case *appsv1.StatefulSet:
+			return statefulSetReady(w), nil
+		case *appsv1.DaemonSet:
+			return daemonSetReady(w), nil
+		default:
+			return false, fmt.Errorf("unsupported workload type %T", live)
+		}
+	}
+}
+
+// deploymentReady checks if all the replicas of a deployment are updated and
+// available, with no old replicas left.
+func deploymentReady(d *appsv1.Deployment) bool {
+	if d.Status.ObservedGeneration < d.Generation {
+		return false
+	}
+	desired := desiredReplicas(d.Spec.Replicas)
+	return d.Status.UpdatedReplicas == desired &&
+		d.Status.Replicas == desired &&
+		d.Status.AvailableReplicas == desired
+}
+
+// statefulSetReady checks if all the replicas of a statefulset are updated
+// and ready.
+func statefulSetReady(s *appsv1.StatefulSet) bool {
+	if s.Status.ObservedGeneration < s.Generation {
+		return false
+	}
+	desired := desiredReplicas(s.Spec.Replicas)
+	return s.Status.UpdatedReplicas == desired &&
+		s.Status.ReadyReplicas == desired
+}
+
+// daemonSetReady checks if the daemonset pods on all the desired nodes are
+// updated and available.
+func daemonSetReady(d *appsv1.DaemonSet) bool {
+	if d.Status.ObservedGeneration < d.Generation {
+		return false
+	}
+	desired := d.Status.DesiredNumberScheduled
+	return d.Status.UpdatedNumberScheduled == desired &&
+		d.Status.NumberAvailable == desired
+}
+
+// desiredReplicas returns the desired replicas of a workload, defaulting to
+// one if unset.
+func desiredReplicas(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+	return *replicas
+}
@@ -0,0 +1,187 @@
+package operand
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestWorkloadReady(t *testing.T) {
+	key := client.ObjectKey{Name: "web", Namespace: "default"}
+	meta := metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace, Generation: 2}
+	replicas := int32(3)
+
+	cases := []struct {
+		name      string
+		existing  client.Object
+		workload  client.Object
+		wantReady bool
+		wantErr   bool
+	}{
+		{
+			name:      "deployment not found",
+			workload:  &appsv1.Deployment{},
+			wantReady: false,
+		},
+		{
+			name: "deployment rolled out",
+			existing: &appsv1.Deployment{
+				ObjectMeta: meta,
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					Replicas:           3,
+					UpdatedReplicas:    3,
+					AvailableReplicas:  3,
+				},
+			},
+			workload:  &appsv1.Deployment{},
+			wantReady: true,
+		},
+		{
+			name: "deployment generation not observed",
+			existing: &appsv1.Deployment{
+				ObjectMeta: meta,
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 1,
+					Replicas:           3,
+					UpdatedReplicas:    3,
+					AvailableReplicas:  3,
+				},
+			},
+			workload:  &appsv1.Deployment{},
+			wantReady: false,
+		},
+		{
+			name: "deployment rollout in progress",
+			existing: &appsv1.Deployment{
+				ObjectMeta: meta,
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					Replicas:           4,
+					UpdatedReplicas:    2,
+					AvailableReplicas:  3,
+				},
+			},
+			workload:  &appsv1.Deployment{},
+			wantReady: false,
+		},
+		{
+			name: "deployment old replicas terminating",
+			existing: &appsv1.Deployment{
+				ObjectMeta: meta,
+				Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					Replicas:           4,
+					UpdatedReplicas:    3,
+					AvailableReplicas:  3,
+				},
+			},
+			workload:  &appsv1.Deployment{},
+			wantReady: false,
+		},
+		{
+			name: "deployment default replicas",
+			existing: &appsv1.Deployment{
+				ObjectMeta: meta,
+				Status: appsv1.DeploymentStatus{
+					ObservedGeneration: 2,
+					Replicas:           1,
+					UpdatedReplicas:    1,
+					AvailableReplicas:  1,
+				},
+			},
+			workload:  &appsv1.Deployment{},
+			wantReady: true,
+		},
+		{
+			name: "statefulset rolled out",
+			existing: &appsv1.StatefulSet{
+				ObjectMeta: meta,
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+				Status: appsv1.StatefulSetStatus{
+					ObservedGeneration: 2,
+					Replicas:           3,
+					UpdatedReplicas:    3,
+					ReadyReplicas:      3,
+				},
+			},
+			workload:  &appsv1.StatefulSet{},
+			wantReady: true,
+		},
+		{
+			name: "statefulset replicas not ready",
+			existing: &appsv1.StatefulSet{
+				ObjectMeta: meta,
+				Spec:       appsv1.StatefulSetSpec{Replicas: &replicas},
+				Status: appsv1.StatefulSetStatus{
+					ObservedGeneration: 2,
+					Replicas:           3,
+					UpdatedReplicas:    3,
+					ReadyReplicas:      1,
+				},
+			},
+			workload:  &appsv1.StatefulSet{},
+			wantReady: false,
+		},
+		{
+			name: "daemonset rolled out",
+			existing: &appsv1.DaemonSet{
+				ObjectMeta: meta,
+				Status: appsv1.DaemonSetStatus{
+					ObservedGeneration:     2,
+					DesiredNumberScheduled: 5,
+					UpdatedNumberScheduled: 5,
+					NumberAvailable:        5,
+				},
+			},
+			workload:  &appsv1.DaemonSet{},
+			wantReady: true,
+		},
+		{
+			name: "daemonset rollout in progress",
+			existing: &appsv1.DaemonSet{
+				ObjectMeta: meta,
+				Status: appsv1.DaemonSetStatus{
+					ObservedGeneration:     2,
+					DesiredNumberScheduled: 5,
+					UpdatedNumberScheduled: 3,
+					NumberAvailable:        5,
+				},
+			},
+			workload:  &appsv1.DaemonSet{},
+			wantReady: false,
+		},
+		{
+			name:     "unsupported workload",
+			existing: &corev1.ConfigMap{ObjectMeta: meta},
+			workload: &corev1.ConfigMap{},
+			wantErr:  true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			builder := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+			if tc.existing != nil {
+				builder = builder.WithObjects(tc.existing)
+			}
+			cli := builder.Build()
+
+			ready, err := WorkloadReady(cli, key, tc.workload)(context.TODO(), nil)
+			assert.Equal(t, tc.wantErr, err != nil)
+			assert.Equal(t, tc.wantReady, ready)
+		})
+	}
+}
@@ -33,3 +33,13 @@ type Operator interface {
 func defaultIsSuspended(ctx context.Context, obj client.Object) bool {
 	return false
 }
+
+// SuspendByAnnotation returns a suspension check that reports an object as
+// suspended when it has the given annotation with the value "true", for
+// example `operator.example.com/suspend: "true"`. It can be used with
+// WithSuspensionCheck.
+func SuspendByAnnotation(key string) func(context.Context, client.Object) bool {
+	return func(ctx context.Context, obj client.Object) bool {
+		return obj.GetAnnotations()[key] == "true"
+	}
+}
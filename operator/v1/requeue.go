@@ -0,0 +1,58 @@
+package v1
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/metric"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// RequeueAlwaysCyclesMetric is the name of the metric of the consecutive
+// requeue-always cycles of the objects, recorded on every cycle.
+const RequeueAlwaysCyclesMetric = "operator.requeue_always.cycles"
+
+// defaultRequeueAlwaysThreshold is the default number of consecutive
+// requeue-always cycles of an object after which a warning is logged.
+const defaultRequeueAlwaysThreshold = 10
+
+// WithRequeueAlwaysThreshold sets the number of consecutive requeue-always
+// cycles of an object after which a warning is logged on every cycle. An
+// Ensure is a requeue-always cycle when an operand with the
+// operand.RequeueAlways strategy makes a change and the object is requeued.
+// The operands that make a change on every Ensure never converge and requeue
+// the object forever. The cycles are counted per object and are reset when
+// an Ensure completes without a requeue. The count is also recorded in the
+// RequeueAlwaysCyclesMetric metric. Defaults to 10, 0 disables the warning.
+func WithRequeueAlwaysThreshold(threshold int) CompositeOperatorOption {
+	return func(c *CompositeOperator) {
+		c.requeueAlwaysThreshold = threshold
+	}
+}
+
+// trackRequeueAlways counts the consecutive requeue-always cycles of the
+// given object, recording the count and logging a warning when it exceeds the
+// threshold. The count is reset if the Ensure wasn't a requeue-always cycle.
+func (co *CompositeOperator) trackRequeueAlways(ctx context.Context, log logr.Logger, obj client.Object, requeued bool) {
+	key := client.ObjectKeyFromObject(obj)
+	if !requeued {
+		co.requeueCycles.reset(key)
+		return
+	}
+
+	cycles := co.requeueCycles.increment(key)
+	co.requeueCyclesRecorder.Record(ctx, int64(cycles))
+	log.V(1).Info("operands changed, requeuing", "requeueAlwaysCycles", cycles)
+	if co.requeueAlwaysThreshold > 0 && cycles > co.requeueAlwaysThreshold {
+		log.Info("warning: operands requeued on every change without converging",
+			"requeueAlwaysCycles", cycles, "threshold", co.requeueAlwaysThreshold)
+	}
+}
+
+// newRequeueCyclesRecorder returns the recorder of the requeue-always cycles
+// metric.
+func newRequeueCyclesRecorder(meter metric.Meter) metric.Int64ValueRecorder {
+	return metric.Must(meter).NewInt64ValueRecorder(RequeueAlwaysCyclesMetric,
+		metric.WithDescription("Number of the consecutive requeue-always cycles of an object"),
+	)
+}
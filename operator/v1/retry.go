@@ -0,0 +1,56 @@
+package v1
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// retryCounter counts the consecutive results of the objects, like the
+// not-ready results or the requeue-always cycles.
+type retryCounter struct {
+	mu     sync.Mutex
+	counts map[types.NamespacedName]int
+}
+
+// newRetryCounter returns a new retryCounter.
+func newRetryCounter() *retryCounter {
+	return &retryCounter{counts: map[types.NamespacedName]int{}}
+}
+
+// increment increments the count of the given object and returns the new
+// count.
+func (r *retryCounter) increment(key types.NamespacedName) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.counts[key]++
+	return r.counts[key]
+}
+
+// reset removes the count of the given object.
+func (r *retryCounter) reset(key types.NamespacedName) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.counts, key)
+}
+
+// retryBudgetError is returned when the retry budget of an object is
+// exceeded. It's a terminal error that matches ErrRetryBudgetExceeded and
+// wraps the not ready error of the last retry.
+type retryBudgetError struct {
+	budget int
+	err    error
+}
+
+func (e *retryBudgetError) Error() string {
+	return fmt.Sprintf("%v: not ready after %d consecutive retries: %v", ErrRetryBudgetExceeded, e.budget, e.err)
+}
+
+// Is implements the errors.Is interface to match ErrRetryBudgetExceeded.
+func (e *retryBudgetError) Is(target error) bool { return target == ErrRetryBudgetExceeded }
+
+func (e *retryBudgetError) Unwrap() error  { return e.err }
+func (e *retryBudgetError) Terminal() bool { return true }
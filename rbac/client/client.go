@@ -2,11 +2,13 @@ package client
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/go-logr/logr"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -25,6 +27,7 @@ const (
 	VerbDelete = "delete"
 	VerbUpdate = "update"
 	VerbPatch  = "patch"
+	VerbWatch  = "watch"
 )
 
 // Client embeds a controller-runtime generic Client. It implements the
@@ -37,6 +40,18 @@ type Client struct {
 	ClusterRole *rbacv1.ClusterRole
 	Log         logr.Logger
 	errors      []error
+
+	// includedGroups and excludedGroups are the API groups used to filter
+	// the recorded API calls.
+	includedGroups map[string]struct{}
+	excludedGroups map[string]struct{}
+}
+
+// WithWatch is a Client that can also watch the objects. Watch is recorded
+// only when the wrapped client implements WithWatch.
+type WithWatch interface {
+	client.Client
+	Watch(ctx context.Context, obj client.ObjectList, opts ...client.ListOption) (watch.Interface, error)
 }
 
 // ClientOption is used to configure Client.
@@ -63,6 +78,32 @@ func WithLogger(log logr.Logger) ClientOption {
 	}
 }
 
+// WithIncludedGroups sets the API groups to record. When set, the API calls
+// of objects in any other group are not recorded. The core group is "".
+func WithIncludedGroups(groups ...string) ClientOption {
+	return func(c *Client) {
+		c.includedGroups = groupSet(groups)
+	}
+}
+
+// WithExcludedGroups sets the API groups to not record, for example
+// "coordination.k8s.io" to exclude the leader election leases. Excluded
+// groups take precedence over the included groups. The core group is "".
+func WithExcludedGroups(groups ...string) ClientOption {
+	return func(c *Client) {
+		c.excludedGroups = groupSet(groups)
+	}
+}
+
+// groupSet returns a set of the given API groups.
+func groupSet(groups []string) map[string]struct{} {
+	set := map[string]struct{}{}
+	for _, g := range groups {
+		set[g] = struct{}{}
+	}
+	return set
+}
+
 // NewClient returns a new RBAC Client from a given Client.
 func NewClient(c client.Client, opts ...ClientOption) *Client {
 	// Create defult Client.
@@ -134,6 +175,18 @@ func (c *Client) List(ctx context.Context, obj client.ObjectList, opts ...client
 	return c.Client.List(ctx, obj, opts...)
 }
 
+// Watch implements WithWatch. It's recorded with the watch verb, separately
+// from list. An error is returned if the wrapped client doesn't implement
+// WithWatch.
+func (c *Client) Watch(ctx context.Context, obj client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	c.recordRule(obj, VerbWatch)
+	wc, ok := c.Client.(WithWatch)
+	if !ok {
+		return nil, fmt.Errorf("client %T doesn't support watch", c.Client)
+	}
+	return wc.Watch(ctx, obj, opts...)
+}
+
 func (c *Client) Status() client.StatusWriter {
 	return &StatusWriter{client: c.Client, rbacClient: c}
 }
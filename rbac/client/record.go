@@ -2,11 +2,11 @@ package client
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/pkg/errors"
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
-	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -34,10 +34,19 @@ func (c *Client) recordRuleWithStatus(obj runtime.Object, verb string, status bo
 		c.errors = append(c.errors, err)
 		c.Log.Error(err, "failed to get GVK")
 	}
+	// The API calls with list objects, list and watch, are authorized on the
+	// resource of the list items.
+	if meta.IsListType(obj) {
+		gvk.Kind = strings.TrimSuffix(gvk.Kind, "List")
+	}
 	// We need only the plural form of resource.
 	gvr, _ := meta.UnsafeGuessKindToResource(gvk)
 
-	namespaced, err := isNamespaced(c, obj)
+	if !c.shouldRecordGroup(gvr.Group) {
+		return
+	}
+
+	namespaced, err := isNamespaced(c, gvk)
 	if err != nil {
 		c.errors = append(c.errors, err)
 		c.Log.Error(err, "failed to find isNamespaced")
@@ -63,26 +72,23 @@ func (c *Client) recordRuleWithStatus(obj runtime.Object, verb string, status bo
 	}
 }
 
-// isNamespaced returns true if the object is namespace scoped.
-// For unstructured objects the gvk is found from the object itself.
-// NOTE: Taken from https://github.com/kubernetes-sigs/controller-runtime/blob/v0.8.0/pkg/client/namespaced_client.go#L60
-func isNamespaced(c client.Client, obj runtime.Object) (bool, error) {
-	var gvk schema.GroupVersionKind
-	var err error
-
-	_, isUnstructured := obj.(*unstructured.Unstructured)
-	_, isUnstructuredList := obj.(*unstructured.UnstructuredList)
-
-	isUnstructured = isUnstructured || isUnstructuredList
-	if isUnstructured {
-		gvk = obj.GetObjectKind().GroupVersionKind()
-	} else {
-		gvk, err = apiutil.GVKForObject(obj, c.Scheme())
-		if err != nil {
-			return false, err
-		}
+// shouldRecordGroup returns true if the API calls of a given API group should
+// be recorded, based on the included and excluded groups.
+func (c *Client) shouldRecordGroup(group string) bool {
+	if _, excluded := c.excludedGroups[group]; excluded {
+		return false
 	}
+	if len(c.includedGroups) > 0 {
+		_, included := c.includedGroups[group]
+		return included
+	}
+	return true
+}
 
+// isNamespaced returns true if the objects of the given GVK are namespace
+// scoped.
+// NOTE: Based on https://github.com/kubernetes-sigs/controller-runtime/blob/v0.8.0/pkg/client/namespaced_client.go#L60
+func isNamespaced(c client.Client, gvk schema.GroupVersionKind) (bool, error) {
 	gk := schema.GroupKind{
 		Group: gvk.Group,
 		Kind:  gvk.Kind,
@@ -0,0 +1,143 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// restMapperClient is a fake client with a static REST mapper.
+type restMapperClient struct {
+	client.Client
+	mapper meta.RESTMapper
+}
+
+func (c *restMapperClient) RESTMapper() meta.RESTMapper {
+	return c.mapper
+}
+
+func newRESTMapperClient() client.Client {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{
+		corev1.SchemeGroupVersion,
+		coordinationv1.SchemeGroupVersion,
+		rbacv1.SchemeGroupVersion,
+	})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Event"), meta.RESTScopeNamespace)
+	mapper.Add(coordinationv1.SchemeGroupVersion.WithKind("Lease"), meta.RESTScopeNamespace)
+	mapper.Add(rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), meta.RESTScopeRoot)
+
+	return &restMapperClient{
+		Client: fake.NewClientBuilder().WithScheme(scheme.Scheme).Build(),
+		mapper: mapper,
+	}
+}
+
+func TestRecordGroupFilter(t *testing.T) {
+	cases := []struct {
+		name       string
+		opts       []ClientOption
+		wantGroups []string
+	}{
+		{
+			name:       "no filter",
+			wantGroups: []string{"", "", "coordination.k8s.io", "rbac.authorization.k8s.io"},
+		},
+		{
+			name:       "excluded groups",
+			opts:       []ClientOption{WithExcludedGroups("coordination.k8s.io")},
+			wantGroups: []string{"", "", "rbac.authorization.k8s.io"},
+		},
+		{
+			name:       "included groups",
+			opts:       []ClientOption{WithIncludedGroups("", "coordination.k8s.io")},
+			wantGroups: []string{"", "", "coordination.k8s.io"},
+		},
+		{
+			name: "excluded takes precedence",
+			opts: []ClientOption{
+				WithIncludedGroups("", "coordination.k8s.io"),
+				WithExcludedGroups("coordination.k8s.io"),
+			},
+			wantGroups: []string{"", ""},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := NewClient(newRESTMapperClient(), tc.opts...)
+			objs := []client.Object{
+				&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm", Namespace: "default"}},
+				&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "event", Namespace: "default"}},
+				&coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: "leader", Namespace: "default"}},
+				&rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "role"}},
+			}
+			for _, obj := range objs {
+				assert.Nil(t, c.Create(context.TODO(), obj))
+			}
+
+			groups := []string{}
+			for _, rule := range append(c.Role.Rules, c.ClusterRole.Rules...) {
+				groups = append(groups, rule.APIGroups...)
+			}
+			assert.Equal(t, tc.wantGroups, groups)
+			assert.Empty(t, c.errors)
+
+			// The excluded calls don't appear in the output.
+			var b bytes.Buffer
+			assert.Nil(t, Result(c, &b, nil))
+			assert.Equal(t, contains(tc.wantGroups, "coordination.k8s.io"), strings.Contains(b.String(), "leases"))
+		})
+	}
+}
+
+// watchClient is a restMapperClient that can watch the objects.
+type watchClient struct {
+	client.Client
+}
+
+func (c *watchClient) Watch(ctx context.Context, obj client.ObjectList, opts ...client.ListOption) (watch.Interface, error) {
+	return watch.NewFake(), nil
+}
+
+func TestRecordWatch(t *testing.T) {
+	c := NewClient(&watchClient{Client: newRESTMapperClient()})
+
+	assert.Nil(t, c.List(context.TODO(), &corev1.ConfigMapList{}))
+	w, err := c.Watch(context.TODO(), &corev1.ConfigMapList{})
+	assert.Nil(t, err)
+	assert.NotNil(t, w)
+
+	// Watch is recorded with its own verb.
+	wantRules := []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{VerbList}},
+		{APIGroups: []string{""}, Resources: []string{"configmaps"}, Verbs: []string{VerbWatch}},
+	}
+	assert.Equal(t, wantRules, c.Role.Rules)
+	assert.Empty(t, c.ClusterRole.Rules)
+	assert.Empty(t, c.errors)
+
+	var b bytes.Buffer
+	assert.Nil(t, Result(c, &b, nil))
+	assert.Contains(t, b.String(), "- watch")
+
+	// Watch is still recorded when the wrapped client can't watch.
+	c = NewClient(newRESTMapperClient())
+	_, err = c.Watch(context.TODO(), &corev1.ConfigMapList{})
+	assert.NotNil(t, err)
+	assert.Equal(t, []string{VerbWatch}, c.Role.Rules[0].Verbs)
+}
@@ -6,12 +6,17 @@ import (
 
 	"github.com/pkg/errors"
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
 )
 
 const (
 	groupResourceSeparator = "_"
 	yamlSeparator          = "\n---\n"
+
+	// manifestHeader is the header comment of the manifests written by
+	// WriteManifest.
+	manifestHeader = "# Code generated by operator-toolkit rbac client. DO NOT EDIT."
 )
 
 // Result marshals and writes the observed RBAC rules into a given Writer. It
@@ -49,6 +54,49 @@ func Result(c *Client, manifestWriter io.Writer, errorWriter io.Writer) error {
 	return nil
 }
 
+// WriteManifest writes the deduplicated recorded Role and ClusterRole as
+// yaml manifests into a given writer, with a header comment noting that the
+// manifests are generated. The name, labels and annotations of the given
+// metadata are set in both the roles, the namespace only in the Role. Roles
+// without any rules are skipped. The recorded roles are not modified.
+func (c *Client) WriteManifest(w io.Writer, meta metav1.ObjectMeta) error {
+	role := c.Role.DeepCopy()
+	role.Rules = reorderRules(role.Rules)
+	applyManifestMeta(&role.ObjectMeta, meta)
+	role.Namespace = meta.Namespace
+
+	clusterRole := c.ClusterRole.DeepCopy()
+	clusterRole.Rules = reorderRules(clusterRole.Rules)
+	applyManifestMeta(&clusterRole.ObjectMeta, meta)
+
+	if _, err := w.Write([]byte(manifestHeader)); err != nil {
+		return errors.Wrap(err, "failed to write manifest header")
+	}
+
+	if len(role.Rules) > 0 {
+		if err := WriteResult(role, w); err != nil {
+			return err
+		}
+	}
+	if len(clusterRole.Rules) > 0 {
+		if err := WriteResult(clusterRole, w); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyManifestMeta sets the name, labels and annotations of a given
+// metadata in the target metadata. An empty name keeps the target name.
+func applyManifestMeta(target *metav1.ObjectMeta, meta metav1.ObjectMeta) {
+	if meta.Name != "" {
+		target.Name = meta.Name
+	}
+	target.Labels = meta.Labels
+	target.Annotations = meta.Annotations
+}
+
 // WriteResult writes a given result into a given writer.
 func WriteResult(role interface{}, writer io.Writer) error {
 	m, err := yaml.Marshal(role)
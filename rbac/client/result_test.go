@@ -2,10 +2,13 @@ package client
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
 )
 
 func TestReorderRules(t *testing.T) {
@@ -91,3 +94,68 @@ rules:
 
 	assert.Equal(t, wantResult, res.String())
 }
+
+func TestWriteManifest(t *testing.T) {
+	c := NewClient(nil)
+	c.Role.Rules = []rbacv1.PolicyRule{
+		{APIGroups: []string{"app.example.com"}, Resources: []string{"database"}, Verbs: []string{"get"}},
+		{APIGroups: []string{"app.example.com"}, Resources: []string{"database"}, Verbs: []string{"list"}},
+		{APIGroups: []string{"app.example.com"}, Resources: []string{"database"}, Verbs: []string{"get"}},
+	}
+	c.ClusterRole.Rules = []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"get"}},
+	}
+
+	meta := metav1.ObjectMeta{
+		Name:      "manager-role",
+		Namespace: "system",
+		Labels:    map[string]string{"app": "operator"},
+	}
+
+	var b bytes.Buffer
+	assert.Nil(t, c.WriteManifest(&b, meta))
+
+	docs := strings.Split(b.String(), yamlSeparator)
+	assert.Len(t, docs, 3)
+	assert.Equal(t, manifestHeader, docs[0])
+
+	// The manifests round-trip to valid roles.
+	var role rbacv1.Role
+	assert.Nil(t, yaml.UnmarshalStrict([]byte(docs[1]), &role))
+	assert.Equal(t, RoleKind, role.Kind)
+	assert.Equal(t, APIVersion, role.APIVersion)
+	assert.Equal(t, "manager-role", role.Name)
+	assert.Equal(t, "system", role.Namespace)
+	assert.Equal(t, meta.Labels, role.Labels)
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{"app.example.com"}, Resources: []string{"database"}, Verbs: []string{"get", "list"}},
+	}, role.Rules)
+
+	var clusterRole rbacv1.ClusterRole
+	assert.Nil(t, yaml.UnmarshalStrict([]byte(docs[2]), &clusterRole))
+	assert.Equal(t, ClusterRoleKind, clusterRole.Kind)
+	assert.Equal(t, "manager-role", clusterRole.Name)
+	assert.Empty(t, clusterRole.Namespace)
+	assert.Equal(t, c.ClusterRole.Rules, clusterRole.Rules)
+
+	// The recorded rules are not modified.
+	assert.Len(t, c.Role.Rules, 3)
+	assert.Equal(t, DefaultRoleName, c.Role.Name)
+}
+
+func TestWriteManifestSkipsEmptyRoles(t *testing.T) {
+	c := NewClient(nil)
+	c.ClusterRole.Rules = []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"namespaces"}, Verbs: []string{"get"}},
+	}
+
+	var b bytes.Buffer
+	assert.Nil(t, c.WriteManifest(&b, metav1.ObjectMeta{}))
+
+	docs := strings.Split(b.String(), yamlSeparator)
+	assert.Len(t, docs, 2)
+
+	var clusterRole rbacv1.ClusterRole
+	assert.Nil(t, yaml.UnmarshalStrict([]byte(docs[1]), &clusterRole))
+	assert.Equal(t, DefaultClusterRoleName, clusterRole.Name)
+}
@@ -3,4 +3,8 @@
 // to register them with the k8s API server. This source is meant to be used
 // when the event source is not k8s and the event object cache is based on
 // client-go informers.
+//
+// The Kind source records the number of the received events and the depth of
+// the queue after handling them as prometheus metrics in the
+// controller-runtime metrics registry.
 package source
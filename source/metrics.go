@@ -0,0 +1,87 @@
+package source
+
+import (
+	"reflect"
+
+	"github.com/prometheus/client_golang/prometheus"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Types of the events in the metrics.
+const (
+	eventCreate = "create"
+	eventUpdate = "update"
+	eventDelete = "delete"
+)
+
+var (
+	// sourceEvents counts the events received by the sources from their
+	// informers, by kind and event type.
+	sourceEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "operator_toolkit_source_events_total",
+			Help: "Total number of events received by the sources per kind and event type.",
+		},
+		[]string{"kind", "event"},
+	)
+
+	// sourceQueueDepth is the depth of the queue after the last event of a
+	// source is handled. It approximates the lag between an event and its
+	// reconciliation.
+	sourceQueueDepth = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "operator_toolkit_source_queue_depth",
+			Help: "Depth of the queue after handling the last event of the sources per kind.",
+		},
+		[]string{"kind"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(sourceEvents, sourceQueueDepth)
+}
+
+// metricsEventHandler is a ResourceEventHandler that records the metrics of
+// the events handled by the wrapped handler.
+type metricsEventHandler struct {
+	kind    string
+	queue   workqueue.Interface
+	handler toolscache.ResourceEventHandler
+}
+
+var _ toolscache.ResourceEventHandler = metricsEventHandler{}
+
+func (m metricsEventHandler) OnAdd(obj interface{}) {
+	m.handler.OnAdd(obj)
+	m.observe(eventCreate)
+}
+
+func (m metricsEventHandler) OnUpdate(oldObj, newObj interface{}) {
+	m.handler.OnUpdate(oldObj, newObj)
+	m.observe(eventUpdate)
+}
+
+func (m metricsEventHandler) OnDelete(obj interface{}) {
+	m.handler.OnDelete(obj)
+	m.observe(eventDelete)
+}
+
+// observe records a handled event of the given type and the resulting queue
+// depth.
+func (m metricsEventHandler) observe(event string) {
+	sourceEvents.WithLabelValues(m.kind, event).Inc()
+	sourceQueueDepth.WithLabelValues(m.kind).Set(float64(m.queue.Len()))
+}
+
+// kindName returns the kind of the given object for the metrics. The kind in
+// the object's GVK is preferred, with a fallback to the object's type name
+// for the typed objects without a GVK.
+func kindName(obj client.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	return reflect.Indirect(reflect.ValueOf(obj)).Type().Name()
+}
@@ -8,8 +8,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
+	toolscache "k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
@@ -20,11 +23,27 @@ import (
 	"github.com/darkowlzz/operator-toolkit/source/internal"
 )
 
+var log = ctrl.Log.WithName("source")
+
+// KindOption is used to configure a Kind source.
+type KindOption func(*Kind)
+
+// WithResyncPeriod sets the ResyncPeriod of a Kind source.
+func WithResyncPeriod(period time.Duration) KindOption {
+	return func(k *Kind) {
+		k.ResyncPeriod = period
+	}
+}
+
 // NewKindWithCache creates a Source without InjectCache, so that it is assured that the given cache is used
 // and not overwritten. It can be used to watch objects in a different cluster by passing the cache
 // from that other cluster
-func NewKindWithCache(object client.Object, cache cache.Cache) source.SyncingSource {
-	return &kindWithCache{kind: Kind{Type: object, cache: cache}}
+func NewKindWithCache(object client.Object, cache cache.Cache, opts ...KindOption) source.SyncingSource {
+	kind := Kind{Type: object, cache: cache}
+	for _, opt := range opts {
+		opt(&kind)
+	}
+	return &kindWithCache{kind: kind}
 }
 
 type kindWithCache struct {
@@ -45,6 +64,14 @@ type Kind struct {
 	// Type is the type of object to watch.  e.g. &v1.Pod{}
 	Type client.Object
 
+	// ResyncPeriod is the interval at which all the cached objects are
+	// re-enqueued with update events. This ensures that the objects are
+	// eventually reconciled even if a reconciliation is dropped. The event
+	// handler is added to the informer with this resync period. An informer
+	// that has already started doesn't resync more often than its own resync
+	// period. Resync is disabled if zero.
+	ResyncPeriod time.Duration
+
 	// cache used to watch APIs
 	cache cache.Cache
 }
@@ -77,10 +104,55 @@ func (ks *Kind) Start(ctx context.Context, handler handler.EventHandler, queue w
 		// }
 		return err
 	}
-	i.AddEventHandler(internal.EventHandler{Queue: queue, EventHandler: handler, Predicates: prct})
+	eventHandler := metricsEventHandler{
+		kind:    kindName(ks.Type),
+		queue:   queue,
+		handler: internal.EventHandler{Queue: queue, EventHandler: handler, Predicates: prct},
+	}
+	if ks.ResyncPeriod <= 0 {
+		i.AddEventHandler(eventHandler)
+		return nil
+	}
+
+	// Prefer the resync of the informer. Informers without a controller,
+	// like the fake informers, don't resync. Resync from their store, if
+	// any.
+	si, ok := i.(toolscache.SharedInformer)
+	if !ok || si.GetController() != nil {
+		i.AddEventHandlerWithResyncPeriod(eventHandler, ks.ResyncPeriod)
+		return nil
+	}
+
+	i.AddEventHandler(eventHandler)
+	if store := si.GetStore(); store != nil {
+		go ks.resync(ctx, store, eventHandler)
+	} else {
+		log.Info("informer doesn't resync and has no store, resync disabled", "source", ks.String())
+	}
 	return nil
 }
 
+// resync re-enqueues all the objects in the given store with update events at
+// every resync period until the context is done. It's used for the informers
+// that don't resync.
+func (ks *Kind) resync(ctx context.Context, store toolscache.Store, handler toolscache.ResourceEventHandler) {
+	ticker := time.NewTicker(ks.ResyncPeriod)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			objs := store.List()
+			log.V(1).Info("resyncing cached objects", "source", ks.String(), "count", len(objs))
+			for _, obj := range objs {
+				handler.OnUpdate(obj, obj)
+			}
+		}
+	}
+}
+
 func (ks *Kind) String() string {
 	if ks.Type != nil && ks.Type.GetObjectKind() != nil {
 		return fmt.Sprintf("kind source: %v", ks.Type.GetObjectKind().GroupVersionKind().String())
@@ -0,0 +1,157 @@
+package source
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/cache/informertest"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllertest"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// storeInformer is a fake informer with a store.
+type storeInformer struct {
+	*controllertest.FakeInformer
+	store toolscache.Store
+}
+
+func (i *storeInformer) GetStore() toolscache.Store {
+	return i.store
+}
+
+func TestKindResync(t *testing.T) {
+	store := toolscache.NewStore(toolscache.MetaNamespaceKeyFunc)
+	for _, name := range []string{"pod-a", "pod-b", "pod-c"} {
+		assert.Nil(t, store.Add(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}))
+	}
+
+	informers := &informertest.FakeInformers{
+		InformersByGVK: map[schema.GroupVersionKind]toolscache.SharedIndexInformer{
+			corev1.SchemeGroupVersion.WithKind("Pod"): &storeInformer{
+				FakeInformer: &controllertest.FakeInformer{},
+				store:        store,
+			},
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	src := NewKindWithCache(&corev1.Pod{}, informers, WithResyncPeriod(10*time.Millisecond))
+	assert.Nil(t, src.Start(ctx, &handler.EnqueueRequestForObject{}, queue))
+
+	// All the cached objects are re-enqueued on resync tick.
+	assert.Eventually(t, func() bool {
+		return queue.Len() == 3
+	}, time.Second, 10*time.Millisecond)
+
+	got := []string{}
+	for i := 0; i < 3; i++ {
+		item, _ := queue.Get()
+		got = append(got, item.(reconcile.Request).Name)
+		queue.Done(item)
+	}
+	assert.ElementsMatch(t, []string{"pod-a", "pod-b", "pod-c"}, got)
+}
+
+func TestKindResyncWithoutStore(t *testing.T) {
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	// The fake informer has no store to resync, the source starts without
+	// resync.
+	src := NewKindWithCache(&corev1.Pod{}, &informertest.FakeInformers{}, WithResyncPeriod(time.Second))
+	assert.Nil(t, src.Start(context.TODO(), &handler.EnqueueRequestForObject{}, queue))
+}
+
+func TestKindResyncWithInformer(t *testing.T) {
+	// A real informer resyncs the objects itself.
+	lw := &toolscache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			return &corev1.PodList{Items: []corev1.Pod{
+				{ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: "default", ResourceVersion: "1"}},
+			}}, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			return watch.NewFake(), nil
+		},
+	}
+	informer := toolscache.NewSharedIndexInformer(lw, &corev1.Pod{}, time.Hour, toolscache.Indexers{})
+	informers := &informertest.FakeInformers{
+		InformersByGVK: map[schema.GroupVersionKind]toolscache.SharedIndexInformer{
+			corev1.SchemeGroupVersion.WithKind("Pod"): informer,
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	// Add the handler before starting the informer, the minimum resync
+	// period of an informer is a second.
+	src := NewKindWithCache(&corev1.Pod{}, informers, WithResyncPeriod(time.Second))
+	assert.Nil(t, src.Start(ctx, &handler.EnqueueRequestForObject{}, queue))
+	go informer.Run(ctx.Done())
+
+	// The object is enqueued on add and again on resync.
+	item, _ := queue.Get()
+	assert.Equal(t, "pod-a", item.(reconcile.Request).Name)
+	queue.Done(item)
+	queue.Forget(item)
+
+	assert.Eventually(t, func() bool {
+		return queue.Len() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestKindMetrics(t *testing.T) {
+	informer := &controllertest.FakeInformer{}
+	informers := &informertest.FakeInformers{
+		InformersByGVK: map[schema.GroupVersionKind]toolscache.SharedIndexInformer{
+			corev1.SchemeGroupVersion.WithKind("Pod"): informer,
+		},
+	}
+
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	created := sourceEvents.WithLabelValues("Pod", eventCreate)
+	updated := sourceEvents.WithLabelValues("Pod", eventUpdate)
+	deleted := sourceEvents.WithLabelValues("Pod", eventDelete)
+	wantCreated := testutil.ToFloat64(created) + 2
+	wantUpdated := testutil.ToFloat64(updated) + 1
+	wantDeleted := testutil.ToFloat64(deleted)
+
+	src := NewKindWithCache(&corev1.Pod{}, informers)
+	assert.Nil(t, src.Start(context.TODO(), &handler.EnqueueRequestForObject{}, queue))
+
+	podA := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-a", Namespace: "default"}}
+	podB := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-b", Namespace: "default"}}
+	informer.Add(podA)
+	informer.Add(podB)
+	informer.Update(podA, podA)
+
+	// The counters increment per handled event.
+	assert.Equal(t, wantCreated, testutil.ToFloat64(created))
+	assert.Equal(t, wantUpdated, testutil.ToFloat64(updated))
+	assert.Equal(t, wantDeleted, testutil.ToFloat64(deleted))
+
+	// The queue depth is the number of the enqueued objects.
+	assert.Equal(t, float64(2), testutil.ToFloat64(sourceQueueDepth.WithLabelValues("Pod")))
+}
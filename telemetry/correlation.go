@@ -0,0 +1,50 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// CorrelationIDKey is the key of the correlation ID attribute and log value.
+const CorrelationIDKey = "correlationID"
+
+// correlationIDContextKey is the context key of the correlation ID.
+type correlationIDContextKey struct{}
+
+// ContextWithCorrelationID returns a copy of the given context with the given
+// correlation ID.
+func ContextWithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDContextKey{}, id)
+}
+
+// CorrelationIDFromContext returns the correlation ID in the given context, if
+// any.
+func CorrelationIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(correlationIDContextKey{}).(string)
+	return id, ok && id != ""
+}
+
+// WithCorrelationID returns the given context with a correlation ID and the
+// ID. The correlation ID already in the context is kept, else a new ID is
+// generated. The spans and the loggers created with Instrumentation.Start from
+// the returned context carry the ID, correlating the logs and the traces of a
+// reconciliation.
+func WithCorrelationID(ctx context.Context) (context.Context, string) {
+	if id, ok := CorrelationIDFromContext(ctx); ok {
+		return ctx, id
+	}
+	id := newCorrelationID()
+	return ContextWithCorrelationID(ctx, id), id
+}
+
+// newCorrelationID returns a new random correlation ID.
+func newCorrelationID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		// The ID is only used for correlation, an all zero ID is better than
+		// failing the reconciliation.
+		return "0000000000000000"
+	}
+	return hex.EncodeToString(b)
+}
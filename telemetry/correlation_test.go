@@ -0,0 +1,113 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// valuesLogger is a logger that records the values of the logged messages.
+type valuesLogger struct {
+	values  map[string]interface{}
+	records *[]map[string]interface{}
+}
+
+func (l valuesLogger) Enabled() bool { return true }
+
+func (l valuesLogger) Info(msg string, keysAndValues ...interface{}) {
+	*l.records = append(*l.records, l.withValues(keysAndValues...).values)
+}
+
+func (l valuesLogger) Error(err error, msg string, keysAndValues ...interface{}) {
+	l.Info(msg, keysAndValues...)
+}
+
+func (l valuesLogger) V(level int) logr.Logger { return l }
+
+func (l valuesLogger) WithValues(keysAndValues ...interface{}) logr.Logger {
+	return l.withValues(keysAndValues...)
+}
+
+func (l valuesLogger) WithName(name string) logr.Logger { return l }
+
+func (l valuesLogger) withValues(keysAndValues ...interface{}) valuesLogger {
+	values := map[string]interface{}{}
+	for k, v := range l.values {
+		values[k] = v
+	}
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		values[keysAndValues[i].(string)] = keysAndValues[i+1]
+	}
+	return valuesLogger{values: values, records: l.records}
+}
+
+func TestWithCorrelationID(t *testing.T) {
+	ctx, id := WithCorrelationID(context.TODO())
+	assert.NotEmpty(t, id)
+	got, ok := CorrelationIDFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, id, got)
+
+	// The ID in the context is kept.
+	ctx2, id2 := WithCorrelationID(ctx)
+	assert.Equal(t, id, id2)
+	assert.Equal(t, ctx, ctx2)
+
+	// An ID set by the caller is extracted.
+	_, id3 := WithCorrelationID(ContextWithCorrelationID(context.TODO(), "abc"))
+	assert.Equal(t, "abc", id3)
+
+	// New IDs are generated for new contexts.
+	_, id4 := WithCorrelationID(context.TODO())
+	assert.NotEqual(t, id, id4)
+}
+
+func TestStartCorrelationID(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	records := []map[string]interface{}{}
+	log := valuesLogger{records: &records}
+	inst := NewInstrumentationWithProviders("test", tp, nil, log).
+		WithAttributes(ControllerKey.String("game-controller"))
+
+	ctx, id := WithCorrelationID(context.TODO())
+
+	// The ID is in the span and the logger of the reconcile, and in the
+	// child spans started from its context.
+	ctx, span, _, l := inst.Start(ctx, "Reconcile")
+	l.Info("reconciling")
+	_, child, _, cl := inst.Start(ctx, "child")
+	cl.Info("child operation")
+	child.End()
+	span.End()
+
+	// Without an ID in the context, there's no correlation ID.
+	_, other, _, ol := inst.Start(context.TODO(), "other")
+	ol.Info("other operation")
+	other.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+	assert.Len(t, records, 3)
+
+	for i, name := range []string{"child", "Reconcile"} {
+		assert.Equal(t, name, spans[i].Name)
+		assert.Contains(t, spans[i].Attributes, attribute.String(CorrelationIDKey, id))
+		assert.Contains(t, spans[i].Attributes, ControllerKey.String("game-controller"))
+	}
+	assert.Equal(t, id, records[0][CorrelationIDKey])
+	assert.Equal(t, id, records[1][CorrelationIDKey])
+
+	assert.Equal(t, "other", spans[2].Name)
+	assert.NotContains(t, spans[2].Attributes, attribute.String(CorrelationIDKey, id))
+	assert.NotContains(t, records[2], CorrelationIDKey)
+
+	// The bound attributes of the instrumentation are not modified.
+	assert.Equal(t, []attribute.KeyValue{ControllerKey.String("game-controller")}, inst.attrs)
+}
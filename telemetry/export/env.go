@@ -1,6 +1,7 @@
 package export
 
 import (
+	"log"
 	"os"
 	"strconv"
 )
@@ -8,6 +9,9 @@ import (
 const (
 	// Whether the exporter is disabled or not.
 	envDisableTracing = "DISABLE_TRACING"
+
+	// Ratio of the traces to sample, in the range [0, 1].
+	envTracingSampleRatio = "TRACING_SAMPLE_RATIO"
 )
 
 // getEnv returns environment variable value for a given key. If the variable
@@ -30,3 +34,20 @@ func getEnvAsBool(name string, defaultVal bool) bool {
 
 	return defaultVal
 }
+
+// getEnvAsFloat returns float value of an environment variable for a given
+// key, with a default value if not set. A warning is logged and the default
+// value is returned if the value isn't a valid float.
+func getEnvAsFloat(name string, defaultVal float64) float64 {
+	valStr, exists := os.LookupEnv(name)
+	if !exists {
+		return defaultVal
+	}
+	val, err := strconv.ParseFloat(valStr, 64)
+	if err != nil {
+		log.Printf("invalid %s value %q, using the default %v: %v", name, valStr, defaultVal, err)
+		return defaultVal
+	}
+
+	return val
+}
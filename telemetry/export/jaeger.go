@@ -19,10 +19,14 @@ type TracerShutdown func()
 
 // InstallJaegerExporter installs opentelemetry exporter for Jaeger with the
 // given service name. The returned TracerShutdown can be called to perform a
-// flush of the exporter.
+// flush of the exporter. The given TracerProviderOptions are applied after the
+// defaults and can override them, for example the sampler with
+// WithSampleRatio.
 // This sets up a no-op provider by default. Set DISABLE_TRACING=false
 // and OTEL_EXPORTER_JAEGER_ENDPOINT=http://<service-address>:14268/api/traces
-// environment variables to enable a functional tracer provider.
+// environment variables to enable a functional tracer provider. Set
+// TRACING_SAMPLE_RATIO to a value in the range [0, 1] to sample a ratio of the
+// traces, all the traces are sampled by default.
 // For details about configuring jaeger using otel environment variables, refer
 // https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/sdk-environment-variables.md#jaeger-exporter
 func InstallJaegerExporter(serviceName string, tpOpts ...sdktrace.TracerProviderOption) (TracerShutdown, error) {
@@ -36,13 +40,15 @@ func InstallJaegerExporter(serviceName string, tpOpts ...sdktrace.TracerProvider
 		return nil, err
 	}
 
-	tp := sdktrace.NewTracerProvider(
+	opts := []sdktrace.TracerProviderOption{
 		sdktrace.WithBatcher(exp),
 		sdktrace.WithResource(resource.NewWithAttributes(
 			semconv.ServiceNameKey.String(serviceName),
 			attribute.String("exporter", "jaeger"),
 		)),
-	)
+		sampleRatioFromEnv(),
+	}
+	tp := sdktrace.NewTracerProvider(append(opts, tpOpts...)...)
 
 	// Register the TraceProvider as the global.
 	otel.SetTracerProvider(tp)
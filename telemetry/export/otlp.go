@@ -19,7 +19,8 @@ import (
 
 // InstallOTLPExporter installs opentelemetry exporter for OTLP collector with
 // the given service name. The returned TracerShutdown can be called to perform
-// a flush of the exporter.
+// a flush of the exporter. The traces are sampled based on the
+// TRACING_SAMPLE_RATIO environment variable, all the traces by default.
 // TODO: Make it more configurable and document usage.
 func InstallOTLPExporter(serviceName string, driverOpts ...otlpgrpc.Option) (TracerShutdown, error) {
 	// If tracing is not enabled, skip setting up a Tracer Provider.
@@ -47,7 +48,7 @@ func InstallOTLPExporter(serviceName string, driverOpts ...otlpgrpc.Option) (Tra
 
 	bsp := sdktrace.NewBatchSpanProcessor(exp)
 	tracerProvider := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+		sampleRatioFromEnv(),
 		sdktrace.WithResource(res),
 		sdktrace.WithSpanProcessor(bsp),
 	)
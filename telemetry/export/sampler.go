@@ -0,0 +1,34 @@
+package export
+
+import (
+	"log"
+	"math"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// defaultSampleRatio is the ratio of the traces sampled when
+// TRACING_SAMPLE_RATIO is not set.
+const defaultSampleRatio = 1.0
+
+// WithSampleRatio returns a TracerProviderOption that sets a parent based
+// sampler, sampling the given ratio of the root traces. The child spans follow
+// the sampling decision of their parent. A ratio of 0 samples no trace and a
+// ratio of 1 or more samples all the traces.
+func WithSampleRatio(ratio float64) sdktrace.TracerProviderOption {
+	return sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio)))
+}
+
+// sampleRatioFromEnv returns a TracerProviderOption with the sample ratio set
+// in the TRACING_SAMPLE_RATIO environment variable. All the traces are
+// sampled by default. A ratio out of the range [0, 1] is clamped to the range
+// with a warning.
+func sampleRatioFromEnv() sdktrace.TracerProviderOption {
+	ratio := getEnvAsFloat(envTracingSampleRatio, defaultSampleRatio)
+	if ratio < 0 || ratio > 1 {
+		clamped := math.Min(math.Max(ratio, 0), 1)
+		log.Printf("%s value %v is out of the range [0, 1], using %v", envTracingSampleRatio, ratio, clamped)
+		ratio = clamped
+	}
+	return WithSampleRatio(ratio)
+}
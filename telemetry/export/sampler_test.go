@@ -0,0 +1,84 @@
+package export
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/darkowlzz/operator-toolkit/telemetry"
+)
+
+func TestSampleRatio(t *testing.T) {
+	cases := []struct {
+		name      string
+		envRatio  string
+		sampler   func() sdktrace.TracerProviderOption
+		wantSpans int
+	}{
+		{
+			name:      "sample none",
+			sampler:   func() sdktrace.TracerProviderOption { return WithSampleRatio(0.0) },
+			wantSpans: 0,
+		},
+		{
+			name:      "sample all",
+			sampler:   func() sdktrace.TracerProviderOption { return WithSampleRatio(1.0) },
+			wantSpans: 10,
+		},
+		{
+			name:      "sample none from env",
+			envRatio:  "0",
+			sampler:   sampleRatioFromEnv,
+			wantSpans: 0,
+		},
+		{
+			name:      "negative ratio from env clamped",
+			envRatio:  "-3",
+			sampler:   sampleRatioFromEnv,
+			wantSpans: 0,
+		},
+		{
+			name:      "large ratio from env clamped",
+			envRatio:  "7",
+			sampler:   sampleRatioFromEnv,
+			wantSpans: 10,
+		},
+		{
+			name:      "invalid ratio from env",
+			envRatio:  "abc",
+			sampler:   sampleRatioFromEnv,
+			wantSpans: 10,
+		},
+		{
+			name:      "sample all by default",
+			sampler:   sampleRatioFromEnv,
+			wantSpans: 10,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.envRatio != "" {
+				assert.Nil(t, os.Setenv(envTracingSampleRatio, tc.envRatio))
+				defer os.Unsetenv(envTracingSampleRatio)
+			}
+
+			exporter := tracetest.NewInMemoryExporter()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter), tc.sampler())
+
+			// The instrumentation spans follow the sampler of the provider.
+			inst := telemetry.NewInstrumentationWithProviders("test", tp, nil, nil)
+			for i := 0; i < 10; i++ {
+				_, span, _, _ := inst.Start(context.TODO(), "span")
+				span.End()
+			}
+
+			assert.Len(t, exporter.GetSpans(), tc.wantSpans)
+		})
+	}
+}
@@ -5,9 +5,12 @@ import (
 
 	"github.com/go-logr/logr"
 	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/metric/global"
 	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/darkowlzz/operator-toolkit/telemetry/tracing"
@@ -16,12 +19,21 @@ import (
 // Name of the logger library key.
 const logLibraryKey = "library"
 
+// Keys of the common attributes.
+const (
+	ControllerKey = attribute.Key("controller")
+	GVKKey        = attribute.Key("gvk")
+	NamespaceKey  = attribute.Key("namespace")
+	NameKey       = attribute.Key("name")
+)
+
 // Instrumentation provides instrumentation builder consisting of tracer, meter
 // and logger.
 type Instrumentation struct {
 	trace  trace.Tracer
 	metric metric.Meter
 	log    logr.Logger
+	attrs  []attribute.KeyValue
 }
 
 // NewInstrumentation constructs and returns a new Instrumentation based on the
@@ -53,10 +65,59 @@ func NewInstrumentation(name string) *Instrumentation {
 	}
 }
 
-// Start creates and returns a span, a meter and a tracing logger.
+// WithAttributes returns a copy of the Instrumentation with the given
+// attributes bound to it. All the spans and loggers created by the returned
+// Instrumentation carry the bound attributes, along with the attributes
+// already bound to the Instrumentation.
+func (i *Instrumentation) WithAttributes(attrs ...attribute.KeyValue) *Instrumentation {
+	keysAndValues := []interface{}{}
+	for _, kv := range attrs {
+		keysAndValues = append(keysAndValues, string(kv.Key), kv.Value.AsInterface())
+	}
+
+	boundAttrs := make([]attribute.KeyValue, 0, len(i.attrs)+len(attrs))
+	boundAttrs = append(boundAttrs, i.attrs...)
+	boundAttrs = append(boundAttrs, attrs...)
+
+	return &Instrumentation{
+		trace:  i.trace,
+		metric: i.metric,
+		log:    i.log.WithValues(keysAndValues...),
+		attrs:  boundAttrs,
+	}
+}
+
+// ObjectAttributes returns the common attributes of a controller reconciling
+// an object of the given GVK and key.
+func ObjectAttributes(controller string, gvk schema.GroupVersionKind, key types.NamespacedName) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		ControllerKey.String(controller),
+		GVKKey.String(gvk.String()),
+		NamespaceKey.String(key.Namespace),
+		NameKey.String(key.Name),
+	}
+}
+
+// Meter returns the meter of the Instrumentation.
+func (i *Instrumentation) Meter() metric.Meter {
+	return i.metric
+}
+
+// Start creates and returns a span, a meter and a tracing logger. The span and
+// the logger carry the attributes bound to the Instrumentation, and the
+// correlation ID in the context, if any. See WithCorrelationID.
 func (i *Instrumentation) Start(ctx context.Context, name string, opts ...trace.SpanOption) (context.Context, trace.Span, metric.Meter, logr.Logger) {
+	attrs := i.attrs
+	log := i.log
+	if id, ok := CorrelationIDFromContext(ctx); ok {
+		attrs = append(attrs[:len(attrs):len(attrs)], attribute.String(CorrelationIDKey, id))
+		log = log.WithValues(CorrelationIDKey, id)
+	}
+	if len(attrs) > 0 {
+		opts = append([]trace.SpanOption{trace.WithAttributes(attrs...)}, opts...)
+	}
 	ctx, span := i.trace.Start(ctx, name, opts...)
 	// Use the created span to create a tracing logger with the span name.
-	tl := tracing.NewLogger(i.log.WithValues("spanName", name), span)
+	tl := tracing.NewLogger(log.WithValues("spanName", name), span)
 	return ctx, span, i.metric, tl
 }
@@ -0,0 +1,53 @@
+package telemetry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestInstrumentationAttributes(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	gvk := schema.GroupVersionKind{Group: "app.example.com", Version: "v1alpha1", Kind: "Game"}
+	key := types.NamespacedName{Namespace: "default", Name: "zelda"}
+
+	base := NewInstrumentationWithProviders("test", tp, nil, nil)
+	inst := base.WithAttributes(ObjectAttributes("game-controller", gvk, key)...)
+
+	_, span, _, _ := inst.Start(context.TODO(), "bound")
+	span.End()
+	_, span, _, _ = base.Start(context.TODO(), "unbound")
+	span.End()
+	_, span, _, _ = inst.WithAttributes(attribute.String("operand", "config")).Start(context.TODO(), "chained")
+	span.End()
+
+	spans := exporter.GetSpans()
+	assert.Len(t, spans, 3)
+
+	// The span created via the bound instrumentation carries the attributes.
+	assert.Equal(t, "bound", spans[0].Name)
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		ControllerKey.String("game-controller"),
+		GVKKey.String("app.example.com/v1alpha1, Kind=Game"),
+		NamespaceKey.String("default"),
+		NameKey.String("zelda"),
+	}, spans[0].Attributes)
+
+	// The original instrumentation is not modified.
+	assert.Equal(t, "unbound", spans[1].Name)
+	assert.Empty(t, spans[1].Attributes)
+
+	// Chained attributes are added to the already bound attributes.
+	assert.Equal(t, "chained", spans[2].Name)
+	assert.Len(t, spans[2].Attributes, 5)
+	assert.Contains(t, spans[2].Attributes, attribute.String("operand", "config"))
+	assert.Contains(t, spans[2].Attributes, NameKey.String("zelda"))
+}
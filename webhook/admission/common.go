@@ -1,9 +1,22 @@
 package admission
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	"github.com/darkowlzz/operator-toolkit/constant"
 )
@@ -26,3 +39,102 @@ func addRequestInfoIntoSpan(s trace.Span, req admissionv1.AdmissionRequest) {
 	s.SetAttributes(attribute.Any("uid", req.UID))
 	s.SetAttributes(attribute.Any("userInfo", req.UserInfo))
 }
+
+// newUnstructured returns an empty unstructured object with the kind of the
+// object in the given admission request.
+func newUnstructured(req admissionv1.AdmissionRequest) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetAPIVersion(metav1.GroupVersion{Group: req.Kind.Group, Version: req.Kind.Version}.String())
+	u.SetKind(req.Kind.Kind)
+	return u
+}
+
+// decodeRaw decodes the given raw object of an admission request into the
+// given object. Decoding into an unstructured object replaces its content,
+// the namespace of the request is set on the unstructured object again if the
+// raw object doesn't have one.
+func decodeRaw(decoder *admission.Decoder, req admission.Request, rawObj runtime.RawExtension, obj client.Object) error {
+	if err := decoder.DecodeRaw(rawObj, obj); err != nil {
+		return err
+	}
+	if u, ok := obj.(*unstructured.Unstructured); ok && u.GetNamespace() == "" {
+		u.SetNamespace(req.Namespace)
+	}
+	return nil
+}
+
+// matchesObjectSelector checks if the object or the old object in the given
+// admission request match the given label selector, decoding only the
+// metadata of the objects. A nil selector matches all the objects. Like the
+// webhook configuration objectSelector, an update request matches if either
+// the object or the old object matches.
+func matchesObjectSelector(selector labels.Selector, req admissionv1.AdmissionRequest) (bool, error) {
+	if selector == nil {
+		return true, nil
+	}
+	for _, raw := range []runtime.RawExtension{req.Object, req.OldObject} {
+		if len(raw.Raw) == 0 {
+			continue
+		}
+		meta := metav1.PartialObjectMetadata{}
+		if err := json.Unmarshal(raw.Raw, &meta); err != nil {
+			return false, err
+		}
+		if selector.Matches(labels.Set(meta.Labels)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchesNamespaces checks if the namespace of the given admission request is
+// one of the given namespaces. Empty namespaces match all the requests. The
+// requests for cluster scoped objects always match, like the webhook
+// configuration namespaceSelector.
+func matchesNamespaces(namespaces []string, req admissionv1.AdmissionRequest) bool {
+	if len(namespaces) == 0 || req.Namespace == "" {
+		return true
+	}
+	for _, ns := range namespaces {
+		if ns == req.Namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// errTimeout is returned when the admission functions don't complete within
+// the handler timeout.
+var errTimeout = errors.New("admission functions timed out")
+
+// runWithTimeout runs the given function with a context that's cancelled
+// after the given timeout. If the function doesn't return within the timeout,
+// an error matching errTimeout is returned without waiting for the function,
+// which keeps running in the background. A zero timeout runs the function
+// without a deadline.
+func runWithTimeout(ctx context.Context, timeout time.Duration, f func(context.Context) error) error {
+	if timeout <= 0 {
+		return f(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- f(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("%w after %s", errTimeout, timeout)
+	}
+}
+
+// timeoutResponse returns an errored response for an admission that timed
+// out.
+func timeoutResponse(err error) admission.Response {
+	return admission.Errored(http.StatusGatewayTimeout, err)
+}
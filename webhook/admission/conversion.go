@@ -0,0 +1,103 @@
+package admission
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// decodeRawConverted decodes the given raw object of an admission request
+// into the given object, like decodeRaw. When the request object is of
+// another version of the kind of the given object, like for a webhook serving
+// multiple versions of a CRD, the raw object is decoded in the requested
+// version and converted into the version of the given object. Without a
+// scheme, the raw object is decoded as is.
+func decodeRawConverted(decoder *admission.Decoder, scheme *runtime.Scheme, req admission.Request, rawObj runtime.RawExtension, obj client.Object) error {
+	if _, ok := obj.(*unstructured.Unstructured); ok || scheme == nil {
+		return decodeRaw(decoder, req, rawObj, obj)
+	}
+
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return err
+	}
+	reqGVK := schema.GroupVersionKind{Group: req.Kind.Group, Version: req.Kind.Version, Kind: req.Kind.Kind}
+	if reqGVK.GroupKind() != gvk.GroupKind() || reqGVK.Version == gvk.Version {
+		return decodeRaw(decoder, req, rawObj, obj)
+	}
+
+	in, err := scheme.New(reqGVK)
+	if err != nil {
+		return fmt.Errorf("failed to create the requested version %s: %w", reqGVK, err)
+	}
+	// Like the target object, set the namespace of the request in case the
+	// raw object doesn't have one.
+	if inObj, ok := in.(client.Object); ok {
+		inObj.SetNamespace(req.Namespace)
+	}
+	if err := decoder.DecodeRaw(rawObj, in); err != nil {
+		return err
+	}
+	if err := convertObject(scheme, in, obj); err != nil {
+		return fmt.Errorf("failed to convert %s to %s: %w", reqGVK, gvk, err)
+	}
+	return nil
+}
+
+// convertObject converts the src object into the dst object of another
+// version of the same kind. The versions implementing the controller-runtime
+// conversion interfaces are converted through the hub version. Other versions
+// are converted with the conversion functions of the scheme.
+func convertObject(scheme *runtime.Scheme, src, dst runtime.Object) error {
+	srcConvertible, srcIsConvertible := src.(conversion.Convertible)
+	dstConvertible, dstIsConvertible := dst.(conversion.Convertible)
+
+	switch {
+	case srcIsConvertible && isHub(dst):
+		return srcConvertible.ConvertTo(dst.(conversion.Hub))
+	case dstIsConvertible && isHub(src):
+		return dstConvertible.ConvertFrom(src.(conversion.Hub))
+	case srcIsConvertible && dstIsConvertible:
+		hub, err := hubFor(scheme, src)
+		if err != nil {
+			return err
+		}
+		if err := srcConvertible.ConvertTo(hub); err != nil {
+			return err
+		}
+		return dstConvertible.ConvertFrom(hub)
+	default:
+		return scheme.Convert(src, dst, nil)
+	}
+}
+
+// isHub returns true if the given object is a conversion hub.
+func isHub(obj runtime.Object) bool {
+	_, ok := obj.(conversion.Hub)
+	return ok
+}
+
+// hubFor returns a new object of the hub version of the kind of the given
+// object.
+func hubFor(scheme *runtime.Scheme, obj runtime.Object) (conversion.Hub, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return nil, err
+	}
+	for _, gv := range scheme.PrioritizedVersionsForGroup(gvk.Group) {
+		hubObj, err := scheme.New(gv.WithKind(gvk.Kind))
+		if err != nil {
+			continue
+		}
+		if hub, ok := hubObj.(conversion.Hub); ok {
+			return hub, nil
+		}
+	}
+	return nil, fmt.Errorf("no hub version found for %s", gvk.GroupKind())
+}
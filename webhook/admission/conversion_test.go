@@ -0,0 +1,233 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const widgetGroup = "test.example.com"
+
+// widgetV1 is the hub version of the Widget test kind.
+type widgetV1 struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Size              int `json:"size"`
+}
+
+func (w *widgetV1) Hub() {}
+
+func (w *widgetV1) DeepCopyObject() runtime.Object {
+	out := *w
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+// widgetV1beta1 is a spoke version of the Widget test kind with a renamed
+// field.
+type widgetV1beta1 struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Replicas          int `json:"replicas"`
+}
+
+func (w *widgetV1beta1) ConvertTo(dst conversion.Hub) error {
+	hub := dst.(*widgetV1)
+	hub.ObjectMeta = w.ObjectMeta
+	hub.Size = w.Replicas
+	return nil
+}
+
+func (w *widgetV1beta1) ConvertFrom(src conversion.Hub) error {
+	hub := src.(*widgetV1)
+	w.ObjectMeta = hub.ObjectMeta
+	w.Replicas = hub.Size
+	return nil
+}
+
+func (w *widgetV1beta1) DeepCopyObject() runtime.Object {
+	out := *w
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+// widgetV1alpha1 is another spoke version of the Widget test kind.
+type widgetV1alpha1 struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Count             int `json:"count"`
+}
+
+func (w *widgetV1alpha1) ConvertTo(dst conversion.Hub) error {
+	hub := dst.(*widgetV1)
+	hub.ObjectMeta = w.ObjectMeta
+	hub.Size = w.Count
+	return nil
+}
+
+func (w *widgetV1alpha1) ConvertFrom(src conversion.Hub) error {
+	hub := src.(*widgetV1)
+	w.ObjectMeta = hub.ObjectMeta
+	w.Count = hub.Size
+	return nil
+}
+
+func (w *widgetV1alpha1) DeepCopyObject() runtime.Object {
+	out := *w
+	w.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	return &out
+}
+
+// widgetV1beta1Validator is a validator of the v1beta1 Widgets that records
+// the validated objects.
+type widgetV1beta1Validator struct {
+	created []*widgetV1beta1
+	updated [][2]*widgetV1beta1
+}
+
+func (v *widgetV1beta1Validator) ValidateCreate() []ValidateCreateFunc {
+	return []ValidateCreateFunc{func(ctx context.Context, obj client.Object) error {
+		v.created = append(v.created, obj.(*widgetV1beta1))
+		return nil
+	}}
+}
+
+func (v *widgetV1beta1Validator) ValidateUpdate() []ValidateUpdateFunc {
+	return []ValidateUpdateFunc{func(ctx context.Context, obj client.Object, oldObj client.Object) error {
+		v.updated = append(v.updated, [2]*widgetV1beta1{obj.(*widgetV1beta1), oldObj.(*widgetV1beta1)})
+		return nil
+	}}
+}
+
+func (v *widgetV1beta1Validator) ValidateDelete() []ValidateDeleteFunc { return nil }
+
+func (v *widgetV1beta1Validator) RequireValidating(obj client.Object) bool { return true }
+
+func (v *widgetV1beta1Validator) GetNewObject() client.Object { return &widgetV1beta1{} }
+
+func newWidgetScheme(t *testing.T) *runtime.Scheme {
+	s := runtime.NewScheme()
+	for version, obj := range map[string]runtime.Object{
+		"v1":       &widgetV1{},
+		"v1beta1":  &widgetV1beta1{},
+		"v1alpha1": &widgetV1alpha1{},
+	} {
+		gv := schema.GroupVersion{Group: widgetGroup, Version: version}
+		s.AddKnownTypeWithName(gv.WithKind("Widget"), obj)
+	}
+	assert.Nil(t, s.SetVersionPriority(
+		schema.GroupVersion{Group: widgetGroup, Version: "v1"},
+		schema.GroupVersion{Group: widgetGroup, Version: "v1beta1"},
+		schema.GroupVersion{Group: widgetGroup, Version: "v1alpha1"},
+	))
+	return s
+}
+
+func TestValidatingConversion(t *testing.T) {
+	s := newWidgetScheme(t)
+	decoder, err := admission.NewDecoder(s)
+	assert.Nil(t, err)
+
+	testcases := []struct {
+		name         string
+		version      string
+		operation    admissionv1.Operation
+		raw          string
+		oldRaw       string
+		wantReplicas []int
+	}{
+		{
+			name:         "create in the hub version",
+			version:      "v1",
+			operation:    admissionv1.Create,
+			raw:          `{"apiVersion":"test.example.com/v1","kind":"Widget","metadata":{"name":"a"},"size":3}`,
+			wantReplicas: []int{3},
+		},
+		{
+			name:         "create in the target version",
+			version:      "v1beta1",
+			operation:    admissionv1.Create,
+			raw:          `{"apiVersion":"test.example.com/v1beta1","kind":"Widget","metadata":{"name":"a"},"replicas":4}`,
+			wantReplicas: []int{4},
+		},
+		{
+			name:         "create in another spoke version",
+			version:      "v1alpha1",
+			operation:    admissionv1.Create,
+			raw:          `{"apiVersion":"test.example.com/v1alpha1","kind":"Widget","metadata":{"name":"a"},"count":5}`,
+			wantReplicas: []int{5},
+		},
+		{
+			name:         "update in the hub version",
+			version:      "v1",
+			operation:    admissionv1.Update,
+			raw:          `{"apiVersion":"test.example.com/v1","kind":"Widget","metadata":{"name":"a"},"size":2}`,
+			oldRaw:       `{"apiVersion":"test.example.com/v1","kind":"Widget","metadata":{"name":"a"},"size":1}`,
+			wantReplicas: []int{2, 1},
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			v := &widgetV1beta1Validator{}
+			h := ValidatingWebhookFor(v).Handler.(*validatingHandler)
+			assert.Nil(t, h.InjectDecoder(decoder))
+			assert.Nil(t, h.InjectScheme(s))
+
+			req := admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Operation: tc.operation,
+					Namespace: "default",
+					Kind:      metav1.GroupVersionKind{Group: widgetGroup, Version: tc.version, Kind: "Widget"},
+					Object:    runtime.RawExtension{Raw: []byte(tc.raw)},
+					OldObject: runtime.RawExtension{Raw: []byte(tc.oldRaw)},
+				},
+			}
+			resp := h.Handle(context.TODO(), req)
+			assert.True(t, resp.Allowed, "response: %v", resp.Result)
+
+			// The validate functions receive the converted objects.
+			var got []*widgetV1beta1
+			if tc.operation == admissionv1.Create {
+				got = v.created
+			} else if assert.Len(t, v.updated, 1) {
+				got = v.updated[0][:]
+			}
+			gotReplicas := []int{}
+			for _, w := range got {
+				gotReplicas = append(gotReplicas, w.Replicas)
+				assert.Equal(t, "a", w.GetName())
+				assert.Equal(t, "default", w.GetNamespace())
+			}
+			assert.Equal(t, tc.wantReplicas, gotReplicas)
+		})
+	}
+}
+
+func TestValidatingWithoutScheme(t *testing.T) {
+	decoder, err := admission.NewDecoder(newWidgetScheme(t))
+	assert.Nil(t, err)
+
+	// Without a scheme, a request of another version can't be decoded into
+	// the target object.
+	h := ValidatingWebhookFor(&widgetV1beta1Validator{}).Handler.(*validatingHandler)
+	assert.Nil(t, h.InjectDecoder(decoder))
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Kind:      metav1.GroupVersionKind{Group: widgetGroup, Version: "v1", Kind: "Widget"},
+			Object:    runtime.RawExtension{Raw: []byte(`{"apiVersion":"test.example.com/v1","kind":"Widget","metadata":{"name":"a"},"size":3}`)},
+		},
+	}
+	resp := h.Handle(context.TODO(), req)
+	assert.False(t, resp.Allowed)
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
@@ -32,17 +34,87 @@ type Defaulter interface {
 	RequireDefaulting(obj client.Object) bool
 }
 
+// DefaultingWebhookOption is used to configure the defaulting webhook.
+type DefaultingWebhookOption func(*mutatingHandler)
+
+// WithDefaultingUnstructured enables the unstructured mode, in which the
+// request object is decoded into an unstructured object with the kind of the
+// request object, instead of the object returned by GetNewObject. This allows
+// defaulting the objects of any kind generically. The default functions
+// receive *unstructured.Unstructured objects.
+func WithDefaultingUnstructured() DefaultingWebhookOption {
+	return func(h *mutatingHandler) {
+		h.unstructured = true
+	}
+}
+
+// WithDefaultingTimeout sets the time limit of running the default functions
+// of a request. The default functions receive a context with the deadline and
+// an Errored response is returned if they don't complete in time. Set it below
+// the timeoutSeconds of the webhook configuration to get a clear response
+// instead of the API server timing out the request. Defaults to no limit.
+func WithDefaultingTimeout(timeout time.Duration) DefaultingWebhookOption {
+	return func(h *mutatingHandler) {
+		h.timeout = timeout
+	}
+}
+
+// WithDefaultingObjectSelector sets a label selector to filter the objects
+// before decoding them. The requests for objects that don't match the
+// selector are allowed without any processing. This is cheaper than
+// RequireDefaulting, which runs after decoding the objects.
+func WithDefaultingObjectSelector(selector labels.Selector) DefaultingWebhookOption {
+	return func(h *mutatingHandler) {
+		h.objectSelector = selector
+	}
+}
+
+// WithDefaultingNamespaces sets the namespaces of the objects to process. The
+// requests for objects in other namespaces are allowed without any
+// processing. The requests for cluster scoped objects are always processed.
+func WithDefaultingNamespaces(namespaces ...string) DefaultingWebhookOption {
+	return func(h *mutatingHandler) {
+		h.namespaces = namespaces
+	}
+}
+
+// WithDefaultingObjectFilter sets the filters of the decoded request objects,
+// composed with AllFilters. The objects the filters return false for are
+// allowed without running the default functions, like when RequireDefaulting
+// returns false. For example, ExcludeNamespaces(SystemNamespaces...) skips the
+// control plane objects.
+func WithDefaultingObjectFilter(filters ...ObjectFilter) DefaultingWebhookOption {
+	return func(h *mutatingHandler) {
+		h.filter = AllFilters(filters...)
+	}
+}
+
 // DefaultingWebhookFor creates a new webhook for Defaulting the provided
 // object type.
-func DefaultingWebhookFor(defaulter Defaulter) *admission.Webhook {
+func DefaultingWebhookFor(defaulter Defaulter, opts ...DefaultingWebhookOption) *admission.Webhook {
+	h := &mutatingHandler{defaulter: defaulter}
+	for _, opt := range opts {
+		opt(h)
+	}
 	return &admission.Webhook{
-		Handler: &mutatingHandler{defaulter: defaulter},
+		Handler: h,
 	}
 }
 
 type mutatingHandler struct {
 	defaulter Defaulter
 	decoder   *admission.Decoder
+	// unstructured enables decoding the request object into an unstructured
+	// object.
+	unstructured bool
+	// objectSelector filters the request objects before decoding.
+	objectSelector labels.Selector
+	// namespaces filters the requests by the object namespace.
+	namespaces []string
+	// filter filters the decoded request objects.
+	filter ObjectFilter
+	// timeout limits the time of running the default functions.
+	timeout time.Duration
 }
 
 var _ admission.DecoderInjector = &mutatingHandler{}
@@ -53,8 +125,25 @@ func (h *mutatingHandler) InjectDecoder(d *admission.Decoder) error {
 	return nil
 }
 
-// Handle handles admission requests.
+// requireDefaulting returns true if the given object passes the object filter
+// and requires defaulting.
+func (h *mutatingHandler) requireDefaulting(obj client.Object) bool {
+	if h.filter != nil && !h.filter(obj) {
+		return false
+	}
+	return h.defaulter.RequireDefaulting(obj)
+}
+
+// Handle handles admission requests and records the admission metrics.
 func (h *mutatingHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	start := time.Now()
+	resp := h.handle(ctx, req)
+	observeAdmission(mutatingWebhook, req, resp, start)
+	return resp
+}
+
+// handle handles admission requests.
+func (h *mutatingHandler) handle(ctx context.Context, req admission.Request) admission.Response {
 	tr := otel.Tracer(tracerName)
 	ctx, span := tr.Start(ctx, "mutating-handle")
 	defer span.End()
@@ -63,8 +152,30 @@ func (h *mutatingHandler) Handle(ctx context.Context, req admission.Request) adm
 		panic("defaulter should never be nil")
 	}
 
+	// Skip the objects in the namespaces that aren't processed.
+	if !matchesNamespaces(h.namespaces, req.AdmissionRequest) {
+		span.AddEvent("Object namespace doesn't match the namespaces")
+		return admission.Allowed("object namespace doesn't match the namespaces")
+	}
+
+	// Skip the objects that don't match the object selector.
+	match, err := matchesObjectSelector(h.objectSelector, req.AdmissionRequest)
+	if err != nil {
+		span.RecordError(err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !match {
+		span.AddEvent("Object doesn't match the object selector")
+		return admission.Allowed("object doesn't match the object selector")
+	}
+
 	// Obtain a new object of the target type to decode the request object.
-	obj := h.defaulter.GetNewObject()
+	var obj client.Object
+	if h.unstructured {
+		obj = newUnstructured(req.AdmissionRequest)
+	} else {
+		obj = h.defaulter.GetNewObject()
+	}
 
 	// Add namespace info into the object. The webhook payload only contains
 	// runtime.Object without any metadata info.
@@ -74,19 +185,26 @@ func (h *mutatingHandler) Handle(ctx context.Context, req admission.Request) adm
 
 	// Get the object in the request.
 	span.AddEvent("Decode request object")
-	err := h.decoder.Decode(req, obj)
+	err = decodeRaw(h.decoder, req, req.Object, obj)
 	if err != nil {
 		span.RecordError(err)
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
 	// Run the defaulters only if defaulting is required.
-	if h.defaulter.RequireDefaulting(obj) {
+	if h.requireDefaulting(obj) {
 		span.AddEvent("Run defaulting functions")
 		span.SetAttributes(attribute.Int("default-func-count", len(h.defaulter.Default())))
 		// Process the object through the defaulting pipeline.
-		for _, m := range h.defaulter.Default() {
-			m(ctx, obj)
+		err := runWithTimeout(ctx, h.timeout, func(ctx context.Context) error {
+			for _, m := range h.defaulter.Default() {
+				m(ctx, obj)
+			}
+			return nil
+		})
+		if err != nil {
+			span.RecordError(err)
+			return timeoutResponse(err)
 		}
 	}
 
@@ -7,4 +7,6 @@
 // perform checks in advance before passing the object to the processing
 // pipeline to avoid repetitive checks in each of the functions for filtering
 // the objects and ignoring if needed.
+// The admission decisions and the latency of the webhooks are recorded as
+// prometheus metrics in the controller-runtime metrics registry.
 package admission
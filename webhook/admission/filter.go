@@ -0,0 +1,45 @@
+package admission
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ObjectFilter is a predicate of the request objects to process, like
+// RequireDefaulting and RequireValidating. It returns false for the objects
+// that must be allowed without processing. The filters can be composed with
+// AllFilters and set on the webhooks with WithDefaultingObjectFilter and
+// WithValidatingObjectFilter, or called from RequireDefaulting and
+// RequireValidating.
+type ObjectFilter func(obj client.Object) bool
+
+// SystemNamespaces are the namespaces of the kubernetes control plane
+// objects.
+var SystemNamespaces = []string{"kube-system", "kube-public", "kube-node-lease"}
+
+// ExcludeNamespaces returns an ObjectFilter that returns false for the objects
+// in the given namespaces, like SystemNamespaces and the namespace of the
+// operator, to not block the objects a cluster or the operator itself depends
+// on. Cluster scoped objects are never excluded.
+func ExcludeNamespaces(namespaces ...string) ObjectFilter {
+	excluded := make(map[string]struct{}, len(namespaces))
+	for _, ns := range namespaces {
+		excluded[ns] = struct{}{}
+	}
+	return func(obj client.Object) bool {
+		_, found := excluded[obj.GetNamespace()]
+		return obj.GetNamespace() == "" || !found
+	}
+}
+
+// AllFilters returns an ObjectFilter that returns true if all the given
+// filters return true. Nil filters are ignored.
+func AllFilters(filters ...ObjectFilter) ObjectFilter {
+	return func(obj client.Object) bool {
+		for _, f := range filters {
+			if f != nil && !f(obj) {
+				return false
+			}
+		}
+		return true
+	}
+}
@@ -0,0 +1,133 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func TestExcludeNamespaces(t *testing.T) {
+	filter := ExcludeNamespaces(append(SystemNamespaces, "operator-system")...)
+
+	cases := []struct {
+		name string
+		obj  client.Object
+		want bool
+	}{
+		{
+			name: "system namespace",
+			obj:  &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "kube-system"}},
+		},
+		{
+			name: "operator namespace",
+			obj:  &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "operator-system"}},
+		},
+		{
+			name: "other namespace",
+			obj:  &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "default"}},
+			want: true,
+		},
+		{
+			name: "cluster scoped",
+			obj:  &rbacv1.ClusterRole{ObjectMeta: metav1.ObjectMeta{Name: "foo"}},
+			want: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, filter(tc.obj))
+		})
+	}
+}
+
+func TestAllFilters(t *testing.T) {
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"}}
+	named := func(name string) ObjectFilter {
+		return func(obj client.Object) bool { return obj.GetName() == name }
+	}
+
+	assert.True(t, AllFilters()(obj))
+	assert.True(t, AllFilters(nil, named("a"), ExcludeNamespaces("kube-system"))(obj))
+	assert.False(t, AllFilters(named("a"), ExcludeNamespaces("default"))(obj))
+	assert.False(t, AllFilters(named("b"), ExcludeNamespaces("kube-system"))(obj))
+}
+
+func TestObjectFilterWebhooks(t *testing.T) {
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.Nil(t, err)
+
+	newRequest := func(namespace string) admission.Request {
+		req := newConfigMapCreateRequest(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","namespace":"` + namespace + `"}}`)
+		req.Namespace = namespace
+		return req
+	}
+
+	cases := []struct {
+		name          string
+		namespace     string
+		wantProcessed bool
+	}{
+		{
+			name:      "excluded namespace",
+			namespace: "kube-system",
+		},
+		{
+			name:          "other namespace",
+			namespace:     "default",
+			wantProcessed: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			filter := ExcludeNamespaces(SystemNamespaces...)
+
+			validated := false
+			v := &configMapValidator{
+				validate: func(ctx context.Context, obj client.Object) error {
+					validated = true
+					return errors.New("denied")
+				},
+			}
+			vh := ValidatingWebhookFor(v, WithValidatingObjectFilter(filter)).Handler.(*validatingHandler)
+			assert.Nil(t, vh.InjectDecoder(decoder))
+			mh := DefaultingWebhookFor(&configMapDefaulter{}, WithDefaultingObjectFilter(filter)).Handler.(*mutatingHandler)
+			assert.Nil(t, mh.InjectDecoder(decoder))
+
+			resp := vh.Handle(context.TODO(), newRequest(tc.namespace))
+			assert.Equal(t, tc.wantProcessed, validated)
+			assert.Equal(t, !tc.wantProcessed, resp.Allowed)
+
+			resp = mh.Handle(context.TODO(), newRequest(tc.namespace))
+			assert.True(t, resp.Allowed)
+			defaulted := false
+			for _, p := range resp.Patches {
+				if p.Path == "/data" {
+					defaulted = true
+				}
+			}
+			assert.Equal(t, tc.wantProcessed, defaulted)
+		})
+	}
+
+	// The filter applies to the update requests too.
+	v := &configMapValidator{}
+	vh := ValidatingWebhookFor(v, WithValidatingObjectFilter(ExcludeNamespaces(SystemNamespaces...))).Handler.(*validatingHandler)
+	assert.Nil(t, vh.InjectDecoder(decoder))
+	req := newRequest("kube-system")
+	req.Operation = admissionv1.Update
+	req.OldObject = req.Object
+	assert.True(t, vh.Handle(context.TODO(), req).Allowed)
+}
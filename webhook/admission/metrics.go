@@ -0,0 +1,61 @@
+package admission
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+const (
+	// Names of the webhook types in the metrics.
+	mutatingWebhook   = "mutating"
+	validatingWebhook = "validating"
+
+	// Admission decisions in the metrics.
+	decisionAllowed = "allowed"
+	decisionDenied  = "denied"
+)
+
+var (
+	// admissionDecisions counts the admission decisions of the webhooks by
+	// decision, operation and resource.
+	admissionDecisions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "operator_toolkit_webhook_admission_decisions_total",
+			Help: "Total number of admission decisions per webhook type, decision, operation and resource.",
+		},
+		[]string{"webhook", "decision", "operation", "resource"},
+	)
+
+	// admissionLatency is the latency of handling the admission requests.
+	admissionLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "operator_toolkit_webhook_admission_duration_seconds",
+			Help:    "Latency of handling the admission requests per webhook type, operation and resource.",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"webhook", "operation", "resource"},
+	)
+)
+
+func init() {
+	metrics.Registry.MustRegister(admissionDecisions, admissionLatency)
+}
+
+// observeAdmission records the decision and the latency of handling an
+// admission request that started at the given time.
+func observeAdmission(webhook string, req admission.Request, resp admission.Response, start time.Time) {
+	operation := string(req.Operation)
+	resource := schema.GroupResource{Group: req.Resource.Group, Resource: req.Resource.Resource}.String()
+
+	decision := decisionDenied
+	if resp.Allowed {
+		decision = decisionAllowed
+	}
+
+	admissionDecisions.WithLabelValues(webhook, decision, operation, resource).Inc()
+	admissionLatency.WithLabelValues(webhook, operation, resource).Observe(time.Since(start).Seconds())
+}
@@ -0,0 +1,38 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestAdmissionMetrics(t *testing.T) {
+	denied := admissionDecisions.WithLabelValues(validatingWebhook, decisionDenied, "CREATE", "configmaps")
+	allowed := admissionDecisions.WithLabelValues(validatingWebhook, decisionAllowed, "CREATE", "configmaps")
+	wantDenied := testutil.ToFloat64(denied) + 1
+	wantAllowed := testutil.ToFloat64(allowed)
+
+	v := &configMapValidator{
+		validate: func(ctx context.Context, obj client.Object) error {
+			return errors.New("denied")
+		},
+	}
+	h := newPooledHandler(t, v)
+
+	req := newConfigMapCreateRequest(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}`)
+	req.Resource = metav1.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	resp := h.Handle(context.TODO(), req)
+	assert.False(t, resp.Allowed)
+
+	// The denied request increments the denied counter only.
+	assert.Equal(t, wantDenied, testutil.ToFloat64(denied))
+	assert.Equal(t, wantAllowed, testutil.ToFloat64(allowed))
+
+	// The latency is observed.
+	assert.GreaterOrEqual(t, testutil.CollectAndCount(admissionLatency), 1)
+}
@@ -0,0 +1,55 @@
+package admission
+
+import (
+	"reflect"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// objectPool is a pool of reusable request objects. It's used to reduce the
+// allocations of the request objects under high admission request rate. All
+// the objects are of the webhook's target type, created with newObject. The
+// objects are fully reset before they are reused.
+type objectPool struct {
+	pool sync.Pool
+	// newObject returns a new instance of the target object.
+	newObject func() client.Object
+}
+
+// newObjectPool returns a new objectPool that creates new objects with the
+// given function.
+func newObjectPool(newObject func() client.Object) *objectPool {
+	p := &objectPool{newObject: newObject}
+	p.pool.New = func() interface{} {
+		return p.newObject()
+	}
+	return p
+}
+
+// get returns an object from the pool. The object is reset before it's
+// returned. If the object can't be reset, a new object is returned.
+func (p *objectPool) get() client.Object {
+	obj := p.pool.Get().(client.Object)
+	if !resetObject(obj) {
+		return p.newObject()
+	}
+	return obj
+}
+
+// put returns the given object to the pool.
+func (p *objectPool) put(obj client.Object) {
+	p.pool.Put(obj)
+}
+
+// resetObject sets all the fields of an object to their zero value, releasing
+// all the references held by the object. It returns false if the object is not
+// a pointer to a struct and can't be reset.
+func resetObject(obj client.Object) bool {
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	return true
+}
@@ -0,0 +1,133 @@
+package admission
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// configMapValidator is a validator for ConfigMaps that returns a new object
+// on every GetNewObject call and runs the given validate function on create.
+type configMapValidator struct {
+	validate ValidateCreateFunc
+}
+
+func (v *configMapValidator) ValidateCreate() []ValidateCreateFunc {
+	return []ValidateCreateFunc{v.validate}
+}
+
+func (v *configMapValidator) ValidateUpdate() []ValidateUpdateFunc { return nil }
+
+func (v *configMapValidator) ValidateDelete() []ValidateDeleteFunc { return nil }
+
+func (v *configMapValidator) RequireValidating(obj client.Object) bool { return true }
+
+func (v *configMapValidator) GetNewObject() client.Object { return &corev1.ConfigMap{} }
+
+// newConfigMapCreateRequest returns a create admission request for the given
+// raw ConfigMap.
+func newConfigMapCreateRequest(raw string) admission.Request {
+	return admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+			Object:    runtime.RawExtension{Raw: []byte(raw)},
+		},
+	}
+}
+
+// newPooledHandler returns a validating handler with object pooling enabled.
+func newPooledHandler(t testing.TB, validator Validator) *validatingHandler {
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.Nil(t, err)
+
+	h := ValidatingWebhookFor(validator, WithObjectPooling()).Handler.(*validatingHandler)
+	assert.Nil(t, h.InjectDecoder(decoder))
+	return h
+}
+
+func TestObjectPoolingHandler(t *testing.T) {
+	var seen []*corev1.ConfigMap
+	v := &configMapValidator{
+		validate: func(ctx context.Context, obj client.Object) error {
+			// Record a copy of the object, the object itself may be reused.
+			seen = append(seen, obj.(*corev1.ConfigMap).DeepCopy())
+			return nil
+		},
+	}
+	h := newPooledHandler(t, v)
+
+	full := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","labels":{"foo":"bar"}},"data":{"k1":"v1"}}`
+	minimal := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b"},"data":{"k2":"v2"}}`
+
+	resp := h.Handle(context.TODO(), newConfigMapCreateRequest(full))
+	assert.True(t, resp.Allowed)
+	resp = h.Handle(context.TODO(), newConfigMapCreateRequest(minimal))
+	assert.True(t, resp.Allowed)
+
+	assert.Len(t, seen, 2)
+	assert.Equal(t, "a", seen[0].Name)
+	assert.Equal(t, "b", seen[1].Name)
+	assert.Empty(t, seen[1].Labels)
+	assert.Equal(t, map[string]string{"k2": "v2"}, seen[1].Data)
+}
+
+func TestObjectPoolGet(t *testing.T) {
+	p := newObjectPool(func() client.Object { return &corev1.ConfigMap{} })
+
+	// An object put back in the pool with data is returned reset, whether
+	// it's reused or a new object is created.
+	p.put(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"foo": "bar"}},
+		Data:       map[string]string{"k": "v"},
+	})
+	assert.Equal(t, &corev1.ConfigMap{}, p.get())
+}
+
+func TestResetObject(t *testing.T) {
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Labels: map[string]string{"foo": "bar"}},
+		Data:       map[string]string{"k": "v"},
+	}
+	assert.True(t, resetObject(cm))
+	assert.Equal(t, &corev1.ConfigMap{}, cm)
+}
+
+func BenchmarkValidatingHandler(b *testing.B) {
+	raw := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","labels":{"foo":"bar"}},"data":{"k1":"v1","k2":"v2"}}`
+	req := newConfigMapCreateRequest(raw)
+	v := &configMapValidator{
+		validate: func(ctx context.Context, obj client.Object) error { return nil },
+	}
+
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.Run("new object", func(b *testing.B) {
+		h := &validatingHandler{validator: v, decoder: decoder}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			h.Handle(context.TODO(), req)
+		}
+	})
+
+	b.Run("pooled object", func(b *testing.B) {
+		h := newPooledHandler(b, v)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			h.Handle(context.TODO(), req)
+		}
+	})
+}
@@ -0,0 +1,177 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// configMapDefaulter is a defaulter for ConfigMaps that adds a default data
+// key.
+type configMapDefaulter struct{}
+
+func (d *configMapDefaulter) GetNewObject() client.Object { return &corev1.ConfigMap{} }
+
+func (d *configMapDefaulter) RequireDefaulting(obj client.Object) bool { return true }
+
+func (d *configMapDefaulter) Default() []DefaultFunc {
+	return []DefaultFunc{
+		func(ctx context.Context, obj client.Object) {
+			obj.(*corev1.ConfigMap).Data = map[string]string{"defaulted": "true"}
+		},
+	}
+}
+
+func TestObjectSelector(t *testing.T) {
+	selector := labels.SelectorFromSet(labels.Set{"app": "web"})
+
+	matching := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","labels":{"app":"web"}}}`
+	nonMatching := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"b","labels":{"app":"db"}}}`
+
+	newUpdateRequest := func(raw, oldRaw string) admission.Request {
+		req := newConfigMapCreateRequest(raw)
+		req.Operation = admissionv1.Update
+		req.OldObject = runtime.RawExtension{Raw: []byte(oldRaw)}
+		return req
+	}
+
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.Nil(t, err)
+
+	cases := []struct {
+		name        string
+		req         admission.Request
+		wantMatch   bool
+		wantAllowed bool
+	}{
+		{
+			name:        "non-matching object",
+			req:         newConfigMapCreateRequest(nonMatching),
+			wantAllowed: true,
+		},
+		{
+			name:        "object without labels",
+			req:         newConfigMapCreateRequest(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"c"}}`),
+			wantAllowed: true,
+		},
+		{
+			name:      "matching object",
+			req:       newConfigMapCreateRequest(matching),
+			wantMatch: true,
+		},
+		{
+			name:      "update with matching old object",
+			req:       newUpdateRequest(nonMatching, matching),
+			wantMatch: true,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			v := &configMapValidator{
+				validate: func(ctx context.Context, obj client.Object) error {
+					return errors.New("denied")
+				},
+			}
+			vh := ValidatingWebhookFor(v, WithValidatingObjectSelector(selector)).Handler.(*validatingHandler)
+			mh := DefaultingWebhookFor(&configMapDefaulter{}, WithDefaultingObjectSelector(selector)).Handler.(*mutatingHandler)
+
+			if !tc.wantMatch {
+				// The handlers have no decoder, decoding the objects would
+				// panic.
+				resp := vh.Handle(context.TODO(), tc.req)
+				assert.Equal(t, tc.wantAllowed, resp.Allowed)
+				resp = mh.Handle(context.TODO(), tc.req)
+				assert.Equal(t, tc.wantAllowed, resp.Allowed)
+				assert.Empty(t, resp.Patches)
+				return
+			}
+
+			assert.Nil(t, vh.InjectDecoder(decoder))
+			assert.Nil(t, mh.InjectDecoder(decoder))
+
+			// The matching objects are processed.
+			if tc.req.Operation == admissionv1.Create {
+				resp := vh.Handle(context.TODO(), tc.req)
+				assert.False(t, resp.Allowed)
+			}
+			resp := mh.Handle(context.TODO(), tc.req)
+			assert.True(t, resp.Allowed)
+			assert.NotEmpty(t, resp.Patches)
+		})
+	}
+}
+
+func TestObjectSelectorInvalidObject(t *testing.T) {
+	selector := labels.SelectorFromSet(labels.Set{"app": "web"})
+	v := &configMapValidator{}
+	h := ValidatingWebhookFor(v, WithValidatingObjectSelector(selector)).Handler.(*validatingHandler)
+
+	resp := h.Handle(context.TODO(), newConfigMapCreateRequest(`{"metadata":`))
+	assert.False(t, resp.Allowed)
+}
+
+func TestNamespaces(t *testing.T) {
+	raw := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}`
+	newRequest := func(namespace string) admission.Request {
+		req := newConfigMapCreateRequest(raw)
+		req.Namespace = namespace
+		return req
+	}
+
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.Nil(t, err)
+
+	cases := []struct {
+		name      string
+		namespace string
+		wantMatch bool
+	}{
+		{name: "other namespace", namespace: "other"},
+		{name: "matching namespace", namespace: "web", wantMatch: true},
+		{name: "cluster scoped", wantMatch: true},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			v := &configMapValidator{
+				validate: func(ctx context.Context, obj client.Object) error {
+					return errors.New("denied")
+				},
+			}
+			vh := ValidatingWebhookFor(v, WithValidatingNamespaces("web", "db")).Handler.(*validatingHandler)
+			mh := DefaultingWebhookFor(&configMapDefaulter{}, WithDefaultingNamespaces("web", "db")).Handler.(*mutatingHandler)
+
+			if !tc.wantMatch {
+				// The handlers have no decoder, decoding the objects would
+				// panic.
+				resp := vh.Handle(context.TODO(), newRequest(tc.namespace))
+				assert.True(t, resp.Allowed)
+				resp = mh.Handle(context.TODO(), newRequest(tc.namespace))
+				assert.True(t, resp.Allowed)
+				assert.Empty(t, resp.Patches)
+				return
+			}
+
+			assert.Nil(t, vh.InjectDecoder(decoder))
+			assert.Nil(t, mh.InjectDecoder(decoder))
+
+			resp := vh.Handle(context.TODO(), newRequest(tc.namespace))
+			assert.False(t, resp.Allowed)
+			resp = mh.Handle(context.TODO(), newRequest(tc.namespace))
+			assert.True(t, resp.Allowed)
+			assert.NotEmpty(t, resp.Patches)
+		})
+	}
+}
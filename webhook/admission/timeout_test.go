@@ -0,0 +1,102 @@
+package admission
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// sleepFunc returns a validate function that sleeps for the given duration or
+// until the context is done.
+func sleepFunc(d time.Duration) ValidateCreateFunc {
+	return func(ctx context.Context, obj client.Object) error {
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+		}
+		return nil
+	}
+}
+
+func TestValidatingTimeout(t *testing.T) {
+	widget := `{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"a"}}`
+
+	cases := []struct {
+		name        string
+		funcs       []ValidateCreateFunc
+		wantAllowed bool
+		wantCode    int32
+	}{
+		{
+			name:        "fast funcs",
+			funcs:       []ValidateCreateFunc{sleepFunc(0), sleepFunc(0)},
+			wantAllowed: true,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "slow func",
+			funcs:       []ValidateCreateFunc{sleepFunc(0), sleepFunc(time.Minute)},
+			wantAllowed: false,
+			wantCode:    http.StatusGatewayTimeout,
+		},
+	}
+
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.Nil(t, err)
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			v := &fakeValidator{CreateFuncs: tc.funcs, RequireValidityToReturn: true}
+			h := ValidatingWebhookFor(v,
+				WithValidatingUnstructured(),
+				WithValidatingTimeout(100*time.Millisecond),
+			).Handler.(*validatingHandler)
+			assert.Nil(t, h.InjectDecoder(decoder))
+
+			start := time.Now()
+			resp := h.Handle(context.TODO(), newWidgetRequest(admissionv1.Create, widget, ""))
+			assert.Less(t, int64(time.Since(start)), int64(10*time.Second))
+			assert.Equal(t, tc.wantAllowed, resp.Allowed)
+			assert.Equal(t, tc.wantCode, resp.Result.Code)
+			if !tc.wantAllowed {
+				assert.Contains(t, resp.Result.Message, "timed out after 100ms")
+			}
+		})
+	}
+}
+
+func TestDefaultingTimeout(t *testing.T) {
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.Nil(t, err)
+
+	// The default func ignores the context and blocks until released.
+	release := make(chan struct{})
+	defer close(release)
+	m := &fakeMutator{
+		DefaultFuncs: []DefaultFunc{
+			func(ctx context.Context, obj client.Object) {
+				<-release
+			},
+		},
+		RequireDefaultingToReturn: true,
+	}
+	h := DefaultingWebhookFor(m,
+		WithDefaultingUnstructured(),
+		WithDefaultingTimeout(100*time.Millisecond),
+	).Handler.(*mutatingHandler)
+	assert.Nil(t, h.InjectDecoder(decoder))
+
+	widget := `{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"a"}}`
+	resp := h.Handle(context.TODO(), newWidgetRequest(admissionv1.Create, widget, ""))
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, int32(http.StatusGatewayTimeout), resp.Result.Code)
+	assert.Contains(t, resp.Result.Message, "timed out after 100ms")
+}
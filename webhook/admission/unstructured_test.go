@@ -0,0 +1,152 @@
+package admission
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// widgetKind is an arbitrary kind unknown to the scheme.
+var widgetKind = metav1.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}
+
+// unstructuredController is a generic admission controller for unstructured
+// objects. It requires a spec.size field and defaults a label.
+type unstructuredController struct {
+	// seen records the kinds of the validated objects.
+	seen []string
+}
+
+func (c *unstructuredController) Name() string { return "unstructured" }
+
+// GetNewObject isn't used in the unstructured mode.
+func (c *unstructuredController) GetNewObject() client.Object { return nil }
+
+func (c *unstructuredController) RequireDefaulting(obj client.Object) bool { return true }
+
+func (c *unstructuredController) Default() []DefaultFunc {
+	return []DefaultFunc{
+		func(ctx context.Context, obj client.Object) {
+			obj.SetLabels(map[string]string{"defaulted": "true"})
+		},
+	}
+}
+
+func (c *unstructuredController) RequireValidating(obj client.Object) bool { return true }
+
+func (c *unstructuredController) validate(ctx context.Context, obj client.Object) error {
+	u := obj.(*unstructured.Unstructured)
+	c.seen = append(c.seen, u.GetObjectKind().GroupVersionKind().String()+" "+u.GetNamespace())
+	if _, found, _ := unstructured.NestedInt64(u.Object, "spec", "size"); !found {
+		return errors.New("spec.size is required")
+	}
+	return nil
+}
+
+func (c *unstructuredController) ValidateCreate() []ValidateCreateFunc {
+	return []ValidateCreateFunc{c.validate}
+}
+
+func (c *unstructuredController) ValidateUpdate() []ValidateUpdateFunc {
+	return []ValidateUpdateFunc{
+		func(ctx context.Context, obj client.Object, oldObj client.Object) error {
+			if oldObj.(*unstructured.Unstructured).GetName() != obj.GetName() {
+				return errors.New("name changed")
+			}
+			return c.validate(ctx, obj)
+		},
+	}
+}
+
+func (c *unstructuredController) ValidateDelete() []ValidateDeleteFunc {
+	return []ValidateDeleteFunc{c.validate}
+}
+
+func newWidgetRequest(op admissionv1.Operation, raw, oldRaw string) admission.Request {
+	req := admission.Request{
+		AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: op,
+			Kind:      widgetKind,
+			Namespace: "test-ns",
+		},
+	}
+	if raw != "" {
+		req.Object = runtime.RawExtension{Raw: []byte(raw)}
+	}
+	if oldRaw != "" {
+		req.OldObject = runtime.RawExtension{Raw: []byte(oldRaw)}
+	}
+	return req
+}
+
+func TestUnstructuredValidating(t *testing.T) {
+	valid := `{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"a"},"spec":{"size":3}}`
+	invalid := `{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"a"},"spec":{}}`
+
+	cases := []struct {
+		name        string
+		req         admission.Request
+		wantAllowed bool
+	}{
+		{
+			name:        "create valid",
+			req:         newWidgetRequest(admissionv1.Create, valid, ""),
+			wantAllowed: true,
+		},
+		{
+			name:        "create invalid",
+			req:         newWidgetRequest(admissionv1.Create, invalid, ""),
+			wantAllowed: false,
+		},
+		{
+			name:        "update valid",
+			req:         newWidgetRequest(admissionv1.Update, valid, invalid),
+			wantAllowed: true,
+		},
+		{
+			name:        "delete invalid",
+			req:         newWidgetRequest(admissionv1.Delete, "", invalid),
+			wantAllowed: false,
+		},
+	}
+
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.Nil(t, err)
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			c := &unstructuredController{}
+			h := ValidatingWebhookFor(c, WithValidatingUnstructured()).Handler.(*validatingHandler)
+			assert.Nil(t, h.InjectDecoder(decoder))
+
+			resp := h.Handle(context.TODO(), tc.req)
+			assert.Equal(t, tc.wantAllowed, resp.Allowed)
+			// The objects have the kind and the namespace of the request.
+			assert.Equal(t, []string{"example.com/v1, Kind=Widget test-ns"}, c.seen)
+		})
+	}
+}
+
+func TestUnstructuredDefaulting(t *testing.T) {
+	decoder, err := admission.NewDecoder(scheme.Scheme)
+	assert.Nil(t, err)
+
+	h := DefaultingWebhookFor(&unstructuredController{}, WithDefaultingUnstructured()).Handler.(*mutatingHandler)
+	assert.Nil(t, h.InjectDecoder(decoder))
+
+	raw := `{"apiVersion":"example.com/v1","kind":"Widget","metadata":{"name":"a","namespace":"test-ns"},"spec":{"size":3}}`
+	resp := h.Handle(context.TODO(), newWidgetRequest(admissionv1.Create, raw, ""))
+	assert.True(t, resp.Allowed)
+	assert.Len(t, resp.Patches, 1)
+	assert.Equal(t, "/metadata/labels", resp.Patches[0].Path)
+	assert.Equal(t, map[string]interface{}{"defaulted": "true"}, resp.Patches[0].Value)
+}
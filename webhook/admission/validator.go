@@ -3,19 +3,31 @@ package admission
 import (
 	"context"
 	goerrors "errors"
+	"fmt"
 	"net/http"
+	"strings"
+	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	v1 "k8s.io/api/admission/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 )
 
 // Validate-Funcs are functions in validating function chain, which forms a
-// validating pipeline for a type of operation.
+// validating pipeline for a type of operation. The returned error determines
+// the response. An API status error can be returned to control the status
+// code and reason, and field errors to get an Invalid response with the field
+// details. Any other error results in a Forbidden response.
 type ValidateCreateFunc func(ctx context.Context, obj client.Object) error
 type ValidateUpdateFunc func(ctx context.Context, obj client.Object, oldObj client.Object) error
 type ValidateDeleteFunc func(ctx context.Context, oldObj client.Object) error
@@ -40,20 +52,132 @@ type Validator interface {
 	RequireValidating(obj client.Object) bool
 }
 
+// ValidatingWebhookOption is used to configure the validating webhook.
+type ValidatingWebhookOption func(*validatingHandler)
+
+// WithObjectPooling enables reuse of the request objects across the requests
+// to reduce the allocations under high admission request rate. The objects are
+// fully reset to their zero value before reuse, so the objects returned by
+// GetNewObject must not rely on any pre-populated fields. The validate
+// functions must not retain the objects after returning.
+func WithObjectPooling() ValidatingWebhookOption {
+	return func(h *validatingHandler) {
+		h.pool = newObjectPool(h.validator.GetNewObject)
+	}
+}
+
+// WithValidatingUnstructured enables the unstructured mode, in which the
+// request objects are decoded into unstructured objects with the kind of the
+// request object, instead of the objects returned by GetNewObject. This allows
+// validating the objects of any kind generically. The validate functions
+// receive *unstructured.Unstructured objects. Object pooling doesn't apply to
+// the unstructured objects.
+func WithValidatingUnstructured() ValidatingWebhookOption {
+	return func(h *validatingHandler) {
+		h.unstructured = true
+	}
+}
+
+// WithValidatingTimeout sets the time limit of running the validate functions
+// of a request. The validate functions receive a context with the deadline and
+// an Errored response is returned if they don't complete in time. Set it below
+// the timeoutSeconds of the webhook configuration to get a clear response
+// instead of the API server timing out the request. Defaults to no limit.
+func WithValidatingTimeout(timeout time.Duration) ValidatingWebhookOption {
+	return func(h *validatingHandler) {
+		h.timeout = timeout
+	}
+}
+
+// WithValidatingObjectSelector sets a label selector to filter the objects
+// before decoding them. The requests for objects that don't match the
+// selector are allowed without any processing. This is cheaper than
+// RequireValidating, which runs after decoding the objects.
+func WithValidatingObjectSelector(selector labels.Selector) ValidatingWebhookOption {
+	return func(h *validatingHandler) {
+		h.objectSelector = selector
+	}
+}
+
+// WithValidatingNamespaces sets the namespaces of the objects to process. The
+// requests for objects in other namespaces are allowed without any
+// processing. The requests for cluster scoped objects are always processed.
+func WithValidatingNamespaces(namespaces ...string) ValidatingWebhookOption {
+	return func(h *validatingHandler) {
+		h.namespaces = namespaces
+	}
+}
+
+// WithValidatingObjectFilter sets the filters of the decoded request objects,
+// composed with AllFilters. The objects the filters return false for are
+// allowed without running the validate functions, like when
+// RequireValidating returns false. For example,
+// ExcludeNamespaces(SystemNamespaces...) skips the control plane objects.
+func WithValidatingObjectFilter(filters ...ObjectFilter) ValidatingWebhookOption {
+	return func(h *validatingHandler) {
+		h.filter = AllFilters(filters...)
+	}
+}
+
+// WithValidatingDenyUnhandledOperations denies the requests for the operations
+// with no validate funcs, like CONNECT, instead of allowing them. This is
+// useful when the webhook configuration isn't expected to send such requests.
+func WithValidatingDenyUnhandledOperations() ValidatingWebhookOption {
+	return func(h *validatingHandler) {
+		h.denyUnhandled = true
+	}
+}
+
 // ValidatingWebhookFor creates a new Webhook for validating the provided
 // object type.
-func ValidatingWebhookFor(validator Validator) *admission.Webhook {
+func ValidatingWebhookFor(validator Validator, opts ...ValidatingWebhookOption) *admission.Webhook {
+	h := &validatingHandler{validator: validator}
+	for _, opt := range opts {
+		opt(h)
+	}
 	return &admission.Webhook{
-		Handler: &validatingHandler{validator: validator},
+		Handler: h,
 	}
 }
 
 type validatingHandler struct {
 	validator Validator
 	decoder   *admission.Decoder
+	pool      *objectPool
+	// unstructured enables decoding the request objects into unstructured
+	// objects.
+	unstructured bool
+	// objectSelector filters the request objects before decoding.
+	objectSelector labels.Selector
+	// namespaces filters the requests by the object namespace.
+	namespaces []string
+	// filter filters the decoded request objects.
+	filter ObjectFilter
+	// timeout limits the time of running the validate functions.
+	timeout time.Duration
+	// scheme is used to convert the request objects of other versions into
+	// the version of the target object.
+	scheme *runtime.Scheme
+	// denyUnhandled denies the requests for the operations with no validate
+	// funcs.
+	denyUnhandled bool
+}
+
+// getObject returns an object of the target type for a request and a
+// function to release the object once it's no longer used.
+func (h *validatingHandler) getObject(req admission.Request) (client.Object, func()) {
+	if h.unstructured {
+		return newUnstructured(req.AdmissionRequest), func() {}
+	}
+	if h.pool == nil {
+		return h.validator.GetNewObject(), func() {}
+	}
+	obj := h.pool.get()
+	return obj, func() { h.pool.put(obj) }
 }
 
 var _ admission.DecoderInjector = &validatingHandler{}
+var _ inject.Scheme = &validatingHandler{}
 
 // InjectDecoder injects the decoder into a validatingHandler.
 func (h *validatingHandler) InjectDecoder(d *admission.Decoder) error {
@@ -61,8 +185,33 @@ func (h *validatingHandler) InjectDecoder(d *admission.Decoder) error {
 	return nil
 }
 
-// Handle handles admission requests.
+// InjectScheme injects the scheme into a validatingHandler. The scheme is
+// used to convert the request objects of another version of the target
+// object kind into the version of the target object.
+func (h *validatingHandler) InjectScheme(s *runtime.Scheme) error {
+	h.scheme = s
+	return nil
+}
+
+// requireValidating returns true if the given object passes the object filter
+// and requires validation.
+func (h *validatingHandler) requireValidating(obj client.Object) bool {
+	if h.filter != nil && !h.filter(obj) {
+		return false
+	}
+	return h.validator.RequireValidating(obj)
+}
+
+// Handle handles admission requests and records the admission metrics.
 func (h *validatingHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	start := time.Now()
+	resp := h.handle(ctx, req)
+	observeAdmission(validatingWebhook, req, resp, start)
+	return resp
+}
+
+// handle handles admission requests.
+func (h *validatingHandler) handle(ctx context.Context, req admission.Request) admission.Response {
 	tr := otel.Tracer(tracerName)
 	ctx, span := tr.Start(ctx, "validating-handle")
 	defer span.End()
@@ -71,8 +220,50 @@ func (h *validatingHandler) Handle(ctx context.Context, req admission.Request) a
 		panic("validator should never be nil")
 	}
 
+	// Skip the objects in the namespaces that aren't processed.
+	if !matchesNamespaces(h.namespaces, req.AdmissionRequest) {
+		span.AddEvent("Object namespace doesn't match the namespaces")
+		return admission.Allowed("object namespace doesn't match the namespaces")
+	}
+
+	// Skip the objects that don't match the object selector.
+	match, err := matchesObjectSelector(h.objectSelector, req.AdmissionRequest)
+	if err != nil {
+		span.RecordError(err)
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if !match {
+		span.AddEvent("Object doesn't match the object selector")
+		return admission.Allowed("object doesn't match the object selector")
+	}
+
+	// Only create, update and delete operations have validate funcs. Allow
+	// or deny the other operations, like CONNECT, without decoding the
+	// request object.
+	switch req.Operation {
+	case v1.Create, v1.Update, v1.Delete:
+	default:
+		addRequestInfoIntoSpan(span, req.AdmissionRequest)
+		span.SetAttributes(attribute.String("operation", strings.ToLower(string(req.Operation))))
+		span.AddEvent("No validating functions for the operation")
+		if h.denyUnhandled {
+			span.SetAttributes(attribute.Bool("allowed", false))
+			return admission.Denied(fmt.Sprintf("operation %q is not supported", req.Operation))
+		}
+		span.SetAttributes(attribute.Bool("allowed", true))
+		return admission.Allowed("")
+	}
+
 	// Obtain a new object of the target type to decode the request object.
-	obj := h.validator.GetNewObject()
+	obj, release := h.getObject(req)
+	// The objects are still in use by the validate functions running in the
+	// background after a timeout, don't release them for reuse.
+	timedOut := false
+	defer func() {
+		if !timedOut {
+			release()
+		}
+	}()
 
 	// Add namespace info into the object. The webhook payload only contains
 	// runtime.Object without any metadata info.
@@ -85,25 +276,31 @@ func (h *validatingHandler) Handle(ctx context.Context, req admission.Request) a
 
 		// Get the object in the request.
 		span.AddEvent("Decode request object")
-		err := h.decoder.Decode(req, obj)
+		err := decodeRawConverted(h.decoder, h.scheme, req, req.Object, obj)
 		if err != nil {
 			span.RecordError(err)
 			return admission.Errored(http.StatusBadRequest, err)
 		}
 
 		// Run the validations only if validation is required.
-		if h.validator.RequireValidating(obj) {
+		if h.requireValidating(obj) {
 			span.AddEvent("Run validating functions")
 			span.SetAttributes(attribute.Int("validatecreate-func-count", len(h.validator.ValidateCreate())))
-			for _, m := range h.validator.ValidateCreate() {
-				if err := m(ctx, obj); err != nil {
-					span.RecordError(err)
-					var apiStatus errors.APIStatus
-					if goerrors.As(err, &apiStatus) {
-						return validationResponseFromStatus(false, apiStatus.Status())
+			err := runWithTimeout(ctx, h.timeout, func(ctx context.Context) error {
+				for _, m := range h.validator.ValidateCreate() {
+					if err := m(ctx, obj); err != nil {
+						return err
 					}
-					return admission.Denied(err.Error())
 				}
+				return nil
+			})
+			if err != nil {
+				span.RecordError(err)
+				if goerrors.Is(err, errTimeout) {
+					timedOut = true
+					return timeoutResponse(err)
+				}
+				return validationResponseFromError(req, obj, err)
 			}
 		}
 	}
@@ -111,33 +308,44 @@ func (h *validatingHandler) Handle(ctx context.Context, req admission.Request) a
 	if req.Operation == v1.Update {
 		span.SetAttributes(attribute.String("operation", "update"))
 
-		oldObj := h.validator.GetNewObject()
+		oldObj, releaseOld := h.getObject(req)
+		defer func() {
+			if !timedOut {
+				releaseOld()
+			}
+		}()
 
 		span.AddEvent("Decode request objects")
-		err := h.decoder.DecodeRaw(req.Object, obj)
+		err := decodeRawConverted(h.decoder, h.scheme, req, req.Object, obj)
 		if err != nil {
 			span.RecordError(err)
 			return admission.Errored(http.StatusBadRequest, err)
 		}
-		err = h.decoder.DecodeRaw(req.OldObject, oldObj)
+		err = decodeRawConverted(h.decoder, h.scheme, req, req.OldObject, oldObj)
 		if err != nil {
 			span.RecordError(err)
 			return admission.Errored(http.StatusBadRequest, err)
 		}
 
 		// Run the validations only if validation is required.
-		if h.validator.RequireValidating(obj) {
+		if h.requireValidating(obj) {
 			span.AddEvent("Run validating")
 			span.SetAttributes(attribute.Int("validateupdate-func-count", len(h.validator.ValidateUpdate())))
-			for _, m := range h.validator.ValidateUpdate() {
-				if err := m(ctx, obj, oldObj); err != nil {
-					span.RecordError(err)
-					var apiStatus errors.APIStatus
-					if goerrors.As(err, &apiStatus) {
-						return validationResponseFromStatus(false, apiStatus.Status())
+			err := runWithTimeout(ctx, h.timeout, func(ctx context.Context) error {
+				for _, m := range h.validator.ValidateUpdate() {
+					if err := m(ctx, obj, oldObj); err != nil {
+						return err
 					}
-					return admission.Denied(err.Error())
 				}
+				return nil
+			})
+			if err != nil {
+				span.RecordError(err)
+				if goerrors.Is(err, errTimeout) {
+					timedOut = true
+					return timeoutResponse(err)
+				}
+				return validationResponseFromError(req, obj, err)
 			}
 		}
 	}
@@ -148,25 +356,31 @@ func (h *validatingHandler) Handle(ctx context.Context, req admission.Request) a
 		// In reference to PR: https://github.com/kubernetes/kubernetes/pull/76346
 		// OldObject contains the object being deleted
 		span.AddEvent("Decode request object")
-		err := h.decoder.DecodeRaw(req.OldObject, obj)
+		err := decodeRawConverted(h.decoder, h.scheme, req, req.OldObject, obj)
 		if err != nil {
 			span.RecordError(err)
 			return admission.Errored(http.StatusBadRequest, err)
 		}
 
 		// Run the validations only if validation is required.
-		if h.validator.RequireValidating(obj) {
+		if h.requireValidating(obj) {
 			span.AddEvent("Run validating")
 			span.SetAttributes(attribute.Int("validatedelete-func-count", len(h.validator.ValidateDelete())))
-			for _, m := range h.validator.ValidateDelete() {
-				if err := m(ctx, obj); err != nil {
-					span.RecordError(err)
-					var apiStatus errors.APIStatus
-					if goerrors.As(err, &apiStatus) {
-						return validationResponseFromStatus(false, apiStatus.Status())
+			err := runWithTimeout(ctx, h.timeout, func(ctx context.Context) error {
+				for _, m := range h.validator.ValidateDelete() {
+					if err := m(ctx, obj); err != nil {
+						return err
 					}
-					return admission.Denied(err.Error())
 				}
+				return nil
+			})
+			if err != nil {
+				span.RecordError(err)
+				if goerrors.Is(err, errTimeout) {
+					timedOut = true
+					return timeoutResponse(err)
+				}
+				return validationResponseFromError(req, obj, err)
 			}
 		}
 	}
@@ -176,6 +390,64 @@ func (h *validatingHandler) Handle(ctx context.Context, req admission.Request) a
 	return admission.Allowed("")
 }
 
+// validationResponseFromError returns a denied response for the error
+// returned by a validate function. Errors that carry an API status, like the
+// ones created with the k8s api errors package, are used as is, allowing the
+// validate functions to control the HTTP status code, reason and details of
+// the response. Field errors, a single field.Error or an aggregate of
+// field.ErrorList, are translated into an Invalid status with the field level
+// details as the status causes. Any other error results in a Forbidden
+// response with the error message.
+func validationResponseFromError(req admission.Request, obj client.Object, err error) admission.Response {
+	var apiStatus errors.APIStatus
+	if goerrors.As(err, &apiStatus) {
+		return validationResponseFromStatus(false, apiStatus.Status())
+	}
+
+	if fieldErrs, ok := fieldErrorsFromError(err); ok {
+		// Use the name in the request when available. The name of the
+		// object may not be populated for create requests with generateName.
+		name := req.Name
+		if name == "" {
+			name = obj.GetName()
+		}
+		gk := schema.GroupKind{Group: req.Kind.Group, Kind: req.Kind.Kind}
+		statusErr := errors.NewInvalid(gk, name, fieldErrs)
+		return validationResponseFromStatus(false, statusErr.Status())
+	}
+
+	return admission.Denied(err.Error())
+}
+
+// fieldErrorsFromError extracts a field.ErrorList from the given error. It
+// returns false if the error isn't a field.Error or an aggregate of only
+// field.Errors.
+func fieldErrorsFromError(err error) (field.ErrorList, bool) {
+	var agg utilerrors.Aggregate
+	if goerrors.As(err, &agg) {
+		errs := agg.Errors()
+		if len(errs) == 0 {
+			return nil, false
+		}
+		list := field.ErrorList{}
+		for _, e := range errs {
+			var fe *field.Error
+			if !goerrors.As(e, &fe) {
+				return nil, false
+			}
+			list = append(list, fe)
+		}
+		return list, true
+	}
+
+	var fe *field.Error
+	if goerrors.As(err, &fe) {
+		return field.ErrorList{fe}, true
+	}
+
+	return nil, false
+}
+
 // validationResponseFromStatus returns a response for admitting a request with provided Status object.
 func validationResponseFromStatus(allowed bool, status metav1.Status) admission.Response {
 	resp := admission.Response{
@@ -10,7 +10,12 @@ import (
 
 	admissionv1 "k8s.io/api/admission/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/client-go/kubernetes/scheme"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
@@ -317,6 +322,84 @@ var _ = Describe("Validating Webhooks", func() {
 		})
 	})
 
+	Context("when validating functions return structured errors", func() {
+		gr := schema.GroupResource{Resource: "configmaps"}
+
+		newHandler := func(err error) validatingHandler {
+			validateFunc := fakeValidateFunc{ErrorToReturn: err}
+			f := &fakeValidator{
+				RequireValidityToReturn: true,
+				NewObject:               &corev1.ConfigMap{},
+				CreateFuncs:             []ValidateCreateFunc{validateFunc.CreateFunc()},
+				UpdateFuncs:             []ValidateUpdateFunc{validateFunc.UpdateFunc()},
+				DeleteFuncs:             []ValidateDeleteFunc{validateFunc.DeleteFunc()},
+			}
+			return validatingHandler{validator: f, decoder: decoder}
+		}
+
+		createRequest := func(h validatingHandler) admission.Request {
+			return admission.Request{
+				AdmissionRequest: admissionv1.AdmissionRequest{
+					Name:      "foo",
+					Operation: admissionv1.Create,
+					Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+					Object: runtime.RawExtension{
+						Raw:    []byte("{}"),
+						Object: h.validator.GetNewObject(),
+					},
+				},
+			}
+		}
+
+		It("should use the status code of an API status error", func() {
+			h := newHandler(apierrors.NewConflict(gr, "foo", fmt.Errorf("fake conflict")))
+			response := h.Handle(context.TODO(), createRequest(h))
+			Expect(response.Allowed).Should(BeFalse())
+			Expect(response.Result.Code).Should(Equal(int32(http.StatusConflict)))
+			Expect(response.Result.Reason).Should(Equal(metav1.StatusReasonConflict))
+		})
+
+		It("should return an invalid status with field details for field error list", func() {
+			errs := field.ErrorList{
+				field.Required(field.NewPath("data", "foo"), "foo is required"),
+				field.Invalid(field.NewPath("data", "bar"), "baz", "bar must not be baz"),
+			}
+			h := newHandler(errs.ToAggregate())
+			response := h.Handle(context.TODO(), createRequest(h))
+			Expect(response.Allowed).Should(BeFalse())
+			Expect(response.Result.Code).Should(Equal(int32(http.StatusUnprocessableEntity)))
+			Expect(response.Result.Reason).Should(Equal(metav1.StatusReasonInvalid))
+			Expect(response.Result.Details).ShouldNot(BeNil())
+			Expect(response.Result.Details.Name).Should(Equal("foo"))
+			Expect(response.Result.Details.Kind).Should(Equal("ConfigMap"))
+			Expect(response.Result.Details.Causes).Should(HaveLen(2))
+			Expect(response.Result.Details.Causes[0].Field).Should(Equal("data.foo"))
+			Expect(response.Result.Details.Causes[0].Type).Should(Equal(metav1.CauseTypeFieldValueRequired))
+			Expect(response.Result.Details.Causes[1].Field).Should(Equal("data.bar"))
+			Expect(response.Result.Details.Causes[1].Type).Should(Equal(metav1.CauseTypeFieldValueInvalid))
+		})
+
+		It("should return an invalid status for a single field error", func() {
+			h := newHandler(field.Forbidden(field.NewPath("immutable"), "field is immutable"))
+			response := h.Handle(context.TODO(), createRequest(h))
+			Expect(response.Allowed).Should(BeFalse())
+			Expect(response.Result.Code).Should(Equal(int32(http.StatusUnprocessableEntity)))
+			Expect(response.Result.Details.Causes).Should(HaveLen(1))
+			Expect(response.Result.Details.Causes[0].Field).Should(Equal("immutable"))
+		})
+
+		It("should return forbidden for an aggregate with non-field errors", func() {
+			err := utilerrors.NewAggregate([]error{
+				field.Required(field.NewPath("data"), "data is required"),
+				fmt.Errorf("fake error"),
+			})
+			h := newHandler(err)
+			response := h.Handle(context.TODO(), createRequest(h))
+			Expect(response.Allowed).Should(BeFalse())
+			Expect(response.Result.Code).Should(Equal(int32(http.StatusForbidden)))
+		})
+	})
+
 	Context("when require validating returns false", func() {
 		// Create validate functions to chain together.
 		validateFunc1 := fakeValidateFunc{ErrorToReturn: nil}
@@ -407,6 +490,52 @@ var _ = Describe("Validating Webhooks", func() {
 			Expect(callCount).Should(Equal(0))
 		})
 	})
+
+	Context("when the operation has no validating functions", func() {
+		validateFunc := fakeValidateFunc{ErrorToReturn: fmt.Errorf("invalid")}
+
+		f := &fakeValidator{
+			RequireValidityToReturn: true,
+			NewObject:               &corev1.Pod{},
+			CreateFuncs:             []ValidateCreateFunc{validateFunc.CreateFunc()},
+			UpdateFuncs:             []ValidateUpdateFunc{validateFunc.UpdateFunc()},
+			DeleteFuncs:             []ValidateDeleteFunc{validateFunc.DeleteFunc()},
+		}
+
+		connectRequest := admission.Request{
+			AdmissionRequest: admissionv1.AdmissionRequest{
+				Operation: admissionv1.Connect,
+				Object: runtime.RawExtension{
+					Raw: []byte(`{"kind":"PodExecOptions","apiVersion":"v1","command":["ls"]}`),
+				},
+			},
+		}
+
+		BeforeEach(func() {
+			validateFunc.Reset()
+		})
+
+		It("should allow a connect request by default", func() {
+			handler := ValidatingWebhookFor(f).Handler.(*validatingHandler)
+			Expect(handler.InjectDecoder(decoder)).To(Succeed())
+
+			response := handler.Handle(context.TODO(), connectRequest)
+			Expect(response.Allowed).Should(BeTrue())
+			Expect(response.Result.Code).Should(Equal(int32(http.StatusOK)))
+			Expect(validateFunc.Count()).Should(Equal(0))
+		})
+
+		It("should deny a connect request when configured", func() {
+			handler := ValidatingWebhookFor(f, WithValidatingDenyUnhandledOperations()).Handler.(*validatingHandler)
+			Expect(handler.InjectDecoder(decoder)).To(Succeed())
+
+			response := handler.Handle(context.TODO(), connectRequest)
+			Expect(response.Allowed).Should(BeFalse())
+			Expect(response.Result.Code).Should(Equal(int32(http.StatusForbidden)))
+			Expect(string(response.Result.Reason)).Should(ContainSubstring("CONNECT"))
+			Expect(validateFunc.Count()).Should(Equal(0))
+		})
+	})
 })
 
 type fakeValidator struct {
@@ -0,0 +1,270 @@
+package builder
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// admissionReviewVersions are the AdmissionReview versions supported by the
+// webhooks.
+var admissionReviewVersions = []string{"v1", "v1beta1"}
+
+// FailurePolicy sets the failure policy of the generated webhook
+// configurations. Defaults to Fail.
+func (blder *Builder) FailurePolicy(policy admissionregistrationv1.FailurePolicyType) *Builder {
+	blder.failurePolicy = &policy
+	return blder
+}
+
+// SideEffects sets the side effects class of the generated webhook
+// configurations. Defaults to None.
+func (blder *Builder) SideEffects(sideEffects admissionregistrationv1.SideEffectClass) *Builder {
+	blder.sideEffects = &sideEffects
+	return blder
+}
+
+// MutatingWebhookConfiguration returns a MutatingWebhookConfiguration with the
+// given name for the registered mutating webhook. The rules are based on the
+// target object of the admission controller and the webhook is called at the
+// builder's mutate path of the given client config service or URL. The
+// object selector and the namespaces of the builder are set as the webhook
+// object and namespace selectors. The CA
+// bundle of the client config can be left empty to be injected by the
+// webhook cert manager. This must be called after Complete.
+func (blder *Builder) MutatingWebhookConfiguration(name string, clientConfig admissionregistrationv1.WebhookClientConfig) (*admissionregistrationv1.MutatingWebhookConfiguration, error) {
+	if blder.mutatePath == "" {
+		return nil, errors.New("mutating webhook not registered, mutate path not set")
+	}
+
+	gvk, rule, err := blder.ruleWithOperations(admissionregistrationv1.Create, admissionregistrationv1.Update)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := clientConfigWithPath(clientConfig, blder.mutatePath)
+	if err != nil {
+		return nil, err
+	}
+	objectSelector, err := labelSelector(blder.objectSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &admissionregistrationv1.MutatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+			Kind:       "MutatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Webhooks: []admissionregistrationv1.MutatingWebhook{
+			{
+				Name:                    webhookName("m", gvk),
+				ClientConfig:            cc,
+				Rules:                   []admissionregistrationv1.RuleWithOperations{rule},
+				ObjectSelector:          objectSelector,
+				NamespaceSelector:       namespaceSelector(blder.namespaces),
+				FailurePolicy:           blder.getFailurePolicy(),
+				SideEffects:             blder.getSideEffects(),
+				AdmissionReviewVersions: admissionReviewVersions,
+			},
+		},
+	}, nil
+}
+
+// ValidatingWebhookConfiguration returns a ValidatingWebhookConfiguration with
+// the given name for the registered validating webhook. The rules are based
+// on the target object of the admission controller and the webhook is called
+// at the builder's validate path of the given client config service or URL.
+// The delete operation is included only when the admission controller has
+// delete validators. The object selector and the namespaces of the builder
+// are set as the webhook object and namespace selectors. The CA bundle of the client config can be left empty to
+// be injected by the webhook cert manager. This must be called after
+// Complete.
+func (blder *Builder) ValidatingWebhookConfiguration(name string, clientConfig admissionregistrationv1.WebhookClientConfig) (*admissionregistrationv1.ValidatingWebhookConfiguration, error) {
+	if blder.validatePath == "" {
+		return nil, errors.New("validating webhook not registered, validate path not set")
+	}
+	if blder.c == nil {
+		return nil, errors.New("admission controller not set, call Complete first")
+	}
+
+	ops := []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update}
+	if len(blder.c.ValidateDelete()) > 0 {
+		ops = append(ops, admissionregistrationv1.Delete)
+	}
+	gvk, rule, err := blder.ruleWithOperations(ops...)
+	if err != nil {
+		return nil, err
+	}
+	cc, err := clientConfigWithPath(clientConfig, blder.validatePath)
+	if err != nil {
+		return nil, err
+	}
+	objectSelector, err := labelSelector(blder.objectSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	return &admissionregistrationv1.ValidatingWebhookConfiguration{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: admissionregistrationv1.SchemeGroupVersion.String(),
+			Kind:       "ValidatingWebhookConfiguration",
+		},
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Webhooks: []admissionregistrationv1.ValidatingWebhook{
+			{
+				Name:                    webhookName("v", gvk),
+				ClientConfig:            cc,
+				Rules:                   []admissionregistrationv1.RuleWithOperations{rule},
+				ObjectSelector:          objectSelector,
+				NamespaceSelector:       namespaceSelector(blder.namespaces),
+				FailurePolicy:           blder.getFailurePolicy(),
+				SideEffects:             blder.getSideEffects(),
+				AdmissionReviewVersions: admissionReviewVersions,
+			},
+		},
+	}, nil
+}
+
+// ruleWithOperations returns the GVK of the admission controller's target
+// object and a webhook rule for the object with the given operations.
+func (blder *Builder) ruleWithOperations(ops ...admissionregistrationv1.OperationType) (schema.GroupVersionKind, admissionregistrationv1.RuleWithOperations, error) {
+	rule := admissionregistrationv1.RuleWithOperations{Operations: ops}
+
+	if blder.c == nil {
+		return schema.GroupVersionKind{}, rule, errors.New("admission controller not set, call Complete first")
+	}
+
+	gvk, err := apiutil.GVKForObject(blder.c.GetNewObject(), blder.mgr.GetScheme())
+	if err != nil {
+		return gvk, rule, fmt.Errorf("failed to get the GVK of the target object: %w", err)
+	}
+	mapping, err := blder.mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return gvk, rule, fmt.Errorf("failed to get the resource of %s: %w", gvk, err)
+	}
+
+	scope := admissionregistrationv1.NamespacedScope
+	if mapping.Scope.Name() == apimeta.RESTScopeNameRoot {
+		scope = admissionregistrationv1.ClusterScope
+	}
+
+	rule.Rule = admissionregistrationv1.Rule{
+		APIGroups:   []string{gvk.Group},
+		APIVersions: []string{gvk.Version},
+		Resources:   []string{mapping.Resource.Resource},
+		Scope:       &scope,
+	}
+	return gvk, rule, nil
+}
+
+// getFailurePolicy returns the configured failure policy or the default.
+func (blder *Builder) getFailurePolicy() *admissionregistrationv1.FailurePolicyType {
+	policy := admissionregistrationv1.Fail
+	if blder.failurePolicy != nil {
+		policy = *blder.failurePolicy
+	}
+	return &policy
+}
+
+// getSideEffects returns the configured side effects class or the default.
+func (blder *Builder) getSideEffects() *admissionregistrationv1.SideEffectClass {
+	sideEffects := admissionregistrationv1.SideEffectClassNone
+	if blder.sideEffects != nil {
+		sideEffects = *blder.sideEffects
+	}
+	return &sideEffects
+}
+
+// clientConfigWithPath returns a copy of the given client config with the
+// webhook path set in the service or the URL.
+func clientConfigWithPath(cc admissionregistrationv1.WebhookClientConfig, path string) (admissionregistrationv1.WebhookClientConfig, error) {
+	cc = *cc.DeepCopy()
+	switch {
+	case cc.Service != nil && cc.URL != nil:
+		return cc, errors.New("URL and Service can't be set at the same time")
+	case cc.Service != nil:
+		cc.Service.Path = &path
+	case cc.URL != nil:
+		u := strings.TrimSuffix(*cc.URL, "/") + path
+		cc.URL = &u
+	default:
+		return cc, errors.New("one of URL and Service must be set")
+	}
+	return cc, nil
+}
+
+// webhookName returns a fully qualified webhook name for the given GVK, with
+// the given prefix, like "mgame.app.example.com".
+func webhookName(prefix string, gvk schema.GroupVersionKind) string {
+	group := gvk.Group
+	if group == "" {
+		group = "core.k8s.io"
+	}
+	return fmt.Sprintf("%s%s.%s", prefix, strings.ToLower(gvk.Kind), group)
+}
+
+// namespaceNameLabel is the label set by the API server on all the namespaces
+// with the namespace name.
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// labelSelector converts a label selector to its API representation. A nil
+// selector results in a nil API selector, matching all the objects.
+func labelSelector(selector labels.Selector) (*metav1.LabelSelector, error) {
+	if selector == nil {
+		return nil, nil
+	}
+	reqs, selectable := selector.Requirements()
+	if !selectable {
+		return nil, fmt.Errorf("object selector %q doesn't select any object", selector)
+	}
+
+	ls := &metav1.LabelSelector{}
+	for _, r := range reqs {
+		var op metav1.LabelSelectorOperator
+		switch r.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			op = metav1.LabelSelectorOpIn
+		case selection.NotEquals, selection.NotIn:
+			op = metav1.LabelSelectorOpNotIn
+		case selection.Exists:
+			op = metav1.LabelSelectorOpExists
+		case selection.DoesNotExist:
+			op = metav1.LabelSelectorOpDoesNotExist
+		default:
+			return nil, fmt.Errorf("unsupported operator %q in object selector %q", r.Operator(), selector)
+		}
+		lsr := metav1.LabelSelectorRequirement{Key: r.Key(), Operator: op}
+		if r.Values().Len() > 0 {
+			lsr.Values = r.Values().List()
+		}
+		ls.MatchExpressions = append(ls.MatchExpressions, lsr)
+	}
+	return ls, nil
+}
+
+// namespaceSelector returns a namespace selector matching the given
+// namespaces by their name label. No namespaces result in a nil selector,
+// matching all the namespaces.
+func namespaceSelector(namespaces []string) *metav1.LabelSelector {
+	if len(namespaces) == 0 {
+		return nil
+	}
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{
+				Key:      namespaceNameLabel,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   namespaces,
+			},
+		},
+	}
+}
@@ -0,0 +1,220 @@
+package builder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	tkadmission "github.com/darkowlzz/operator-toolkit/webhook/admission"
+)
+
+// configMapController is an admission controller for ConfigMaps with no
+// defaulting or validation functions.
+type configMapController struct {
+	deleteValidators []tkadmission.ValidateDeleteFunc
+}
+
+var _ tkadmission.Controller = &configMapController{}
+
+func (c *configMapController) Name() string { return "configmap" }
+
+func (c *configMapController) GetNewObject() client.Object { return &corev1.ConfigMap{} }
+
+func (c *configMapController) RequireDefaulting(obj client.Object) bool { return true }
+
+func (c *configMapController) Default() []tkadmission.DefaultFunc { return nil }
+
+func (c *configMapController) RequireValidating(obj client.Object) bool { return true }
+
+func (c *configMapController) ValidateCreate() []tkadmission.ValidateCreateFunc { return nil }
+
+func (c *configMapController) ValidateUpdate() []tkadmission.ValidateUpdateFunc { return nil }
+
+func (c *configMapController) ValidateDelete() []tkadmission.ValidateDeleteFunc {
+	return c.deleteValidators
+}
+
+// newTestManager returns a manager with a static REST mapper that doesn't
+// require an API server.
+func newTestManager(t *testing.T) manager.Manager {
+	mapper := apimeta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), apimeta.RESTScopeNamespace)
+
+	mgr, err := manager.New(&rest.Config{Host: "https://localhost:6443"}, manager.Options{
+		Scheme:             scheme.Scheme,
+		MetricsBindAddress: "0",
+		MapperProvider: func(c *rest.Config) (apimeta.RESTMapper, error) {
+			return mapper, nil
+		},
+	})
+	assert.Nil(t, err)
+	return mgr
+}
+
+func TestWebhookConfigurations(t *testing.T) {
+	wantRule := admissionregistrationv1.Rule{
+		APIGroups:   []string{""},
+		APIVersions: []string{"v1"},
+		Resources:   []string{"configmaps"},
+	}
+	namespacedScope := admissionregistrationv1.NamespacedScope
+	wantRule.Scope = &namespacedScope
+
+	cases := []struct {
+		name            string
+		controller      *configMapController
+		failurePolicy   *admissionregistrationv1.FailurePolicyType
+		clientConfig    admissionregistrationv1.WebhookClientConfig
+		wantFailure     admissionregistrationv1.FailurePolicyType
+		wantValidateOps []admissionregistrationv1.OperationType
+		wantMutatePath  string
+		wantMutateURL   string
+	}{
+		{
+			name:       "service with defaults",
+			controller: &configMapController{},
+			clientConfig: admissionregistrationv1.WebhookClientConfig{
+				Service: &admissionregistrationv1.ServiceReference{Name: "webhook", Namespace: "system"},
+			},
+			wantFailure:     admissionregistrationv1.Fail,
+			wantValidateOps: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+			wantMutatePath:  "/mutate-configmap",
+		},
+		{
+			name: "url with delete validation",
+			controller: &configMapController{
+				deleteValidators: []tkadmission.ValidateDeleteFunc{nil},
+			},
+			failurePolicy: func() *admissionregistrationv1.FailurePolicyType {
+				p := admissionregistrationv1.Ignore
+				return &p
+			}(),
+			clientConfig: func() admissionregistrationv1.WebhookClientConfig {
+				u := "https://webhook.example.com:9443/"
+				return admissionregistrationv1.WebhookClientConfig{URL: &u}
+			}(),
+			wantFailure:     admissionregistrationv1.Ignore,
+			wantValidateOps: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update, admissionregistrationv1.Delete},
+			wantMutateURL:   "https://webhook.example.com:9443/mutate-configmap",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			blder := WebhookManagedBy(newTestManager(t)).
+				MutatePath("/mutate-configmap").
+				ValidatePath("/validate-configmap")
+			if tc.failurePolicy != nil {
+				blder = blder.FailurePolicy(*tc.failurePolicy)
+			}
+			assert.Nil(t, blder.Complete(tc.controller))
+
+			mwc, err := blder.MutatingWebhookConfiguration("configmap-mutating", tc.clientConfig)
+			assert.Nil(t, err)
+			assert.Equal(t, "configmap-mutating", mwc.Name)
+			assert.Len(t, mwc.Webhooks, 1)
+			mwh := mwc.Webhooks[0]
+			assert.Equal(t, "mconfigmap.core.k8s.io", mwh.Name)
+			assert.Equal(t, []admissionregistrationv1.RuleWithOperations{
+				{
+					Operations: []admissionregistrationv1.OperationType{admissionregistrationv1.Create, admissionregistrationv1.Update},
+					Rule:       wantRule,
+				},
+			}, mwh.Rules)
+			assert.Equal(t, tc.wantFailure, *mwh.FailurePolicy)
+			assert.Equal(t, admissionregistrationv1.SideEffectClassNone, *mwh.SideEffects)
+			if tc.wantMutatePath != "" {
+				assert.Equal(t, tc.wantMutatePath, *mwh.ClientConfig.Service.Path)
+				assert.Equal(t, "webhook", mwh.ClientConfig.Service.Name)
+			}
+			if tc.wantMutateURL != "" {
+				assert.Equal(t, tc.wantMutateURL, *mwh.ClientConfig.URL)
+			}
+
+			vwc, err := blder.ValidatingWebhookConfiguration("configmap-validating", tc.clientConfig)
+			assert.Nil(t, err)
+			assert.Len(t, vwc.Webhooks, 1)
+			vwh := vwc.Webhooks[0]
+			assert.Equal(t, "vconfigmap.core.k8s.io", vwh.Name)
+			assert.Equal(t, []admissionregistrationv1.RuleWithOperations{
+				{Operations: tc.wantValidateOps, Rule: wantRule},
+			}, vwh.Rules)
+			if tc.wantMutatePath != "" {
+				assert.Equal(t, "/validate-configmap", *vwh.ClientConfig.Service.Path)
+			}
+
+			// The given client config must not be modified.
+			if tc.clientConfig.Service != nil {
+				assert.Nil(t, tc.clientConfig.Service.Path)
+			}
+		})
+	}
+}
+
+func TestWebhookConfigurationsWithoutPath(t *testing.T) {
+	blder := WebhookManagedBy(newTestManager(t)).ValidatePath("/validate-configmap")
+	assert.Nil(t, blder.Complete(&configMapController{}))
+
+	cc := admissionregistrationv1.WebhookClientConfig{
+		Service: &admissionregistrationv1.ServiceReference{Name: "webhook", Namespace: "system"},
+	}
+	_, err := blder.MutatingWebhookConfiguration("configmap-mutating", cc)
+	assert.Error(t, err)
+	_, err = blder.ValidatingWebhookConfiguration("configmap-validating", cc)
+	assert.Nil(t, err)
+}
+
+func TestWebhookConfigurationsSelectors(t *testing.T) {
+	selector, err := labels.Parse("app=web,tier!=db,canary")
+	assert.Nil(t, err)
+
+	blder := WebhookManagedBy(newTestManager(t)).
+		MutatePath("/mutate-configmap").
+		ValidatePath("/validate-configmap").
+		WithObjectSelector(selector).
+		WithNamespaces("web", "frontend")
+	assert.Nil(t, blder.Complete(&configMapController{}))
+
+	wantObjectSelector := &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"web"}},
+			{Key: "canary", Operator: metav1.LabelSelectorOpExists},
+			{Key: "tier", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"db"}},
+		},
+	}
+	wantNamespaceSelector := &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "kubernetes.io/metadata.name", Operator: metav1.LabelSelectorOpIn, Values: []string{"web", "frontend"}},
+		},
+	}
+
+	cc := admissionregistrationv1.WebhookClientConfig{
+		Service: &admissionregistrationv1.ServiceReference{Name: "webhook", Namespace: "system"},
+	}
+	mwc, err := blder.MutatingWebhookConfiguration("configmap-mutating", cc)
+	assert.Nil(t, err)
+	assert.Equal(t, wantObjectSelector, mwc.Webhooks[0].ObjectSelector)
+	assert.Equal(t, wantNamespaceSelector, mwc.Webhooks[0].NamespaceSelector)
+
+	vwc, err := blder.ValidatingWebhookConfiguration("configmap-validating", cc)
+	assert.Nil(t, err)
+	assert.Equal(t, wantObjectSelector, vwc.Webhooks[0].ObjectSelector)
+	assert.Equal(t, wantNamespaceSelector, vwc.Webhooks[0].NamespaceSelector)
+
+	// Unsupported selector operators can't be set in the configurations.
+	gtSelector, err := labels.Parse("replicas>1")
+	assert.Nil(t, err)
+	blder.WithObjectSelector(gtSelector)
+	_, err = blder.ValidatingWebhookConfiguration("configmap-validating", cc)
+	assert.Error(t, err)
+}
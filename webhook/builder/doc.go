@@ -1,6 +1,8 @@
 // Package builder is based on the controller-runtime webhook builder. It's
 // modified to support building webhooks for the unified admission controller
-// that supports creating webhooks for both native and custom resources.
+// that supports creating webhooks for both native and custom resources. The
+// builder can also generate the webhook configurations of the registered
+// webhooks.
 package builder
 
 import ctrl "sigs.k8s.io/controller-runtime"
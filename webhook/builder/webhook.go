@@ -3,8 +3,12 @@ package builder
 import (
 	"net/http"
 	"net/url"
+	"time"
 
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	tkAdmission "github.com/darkowlzz/operator-toolkit/webhook/admission"
 )
@@ -15,6 +19,37 @@ type Builder struct {
 	mgr          manager.Manager
 	mutatePath   string
 	validatePath string
+
+	// objectSelector filters the request objects before decoding.
+	objectSelector labels.Selector
+	// namespaces filters the requests by the object namespace.
+	namespaces []string
+
+	// objectPooling enables reuse of the request objects of the validating
+	// webhook.
+	objectPooling bool
+
+	// unstructured enables decoding the request objects into unstructured
+	// objects.
+	unstructured bool
+
+	// timeout limits the time of running the admission functions of a
+	// request.
+	timeout time.Duration
+
+	// failurePolicy and sideEffects are used in the generated webhook
+	// configurations.
+	failurePolicy *admissionregistrationv1.FailurePolicyType
+	sideEffects   *admissionregistrationv1.SideEffectClass
+
+	// handlers are the custom admission handlers to register.
+	handlers []pathHandler
+}
+
+// pathHandler is an admission handler with its webhook endpoint path.
+type pathHandler struct {
+	path    string
+	handler admission.Handler
 }
 
 // WebhookManagedBy adds the manager to the builder.
@@ -36,7 +71,59 @@ func (blder *Builder) ValidatePath(path string) *Builder {
 	return blder
 }
 
-// Complete builds the webhook.
+// WithObjectSelector sets a label selector to filter the request objects of
+// the webhooks before decoding them. The requests for objects that don't
+// match the selector are allowed without any processing.
+func (blder *Builder) WithObjectSelector(selector labels.Selector) *Builder {
+	blder.objectSelector = selector
+	return blder
+}
+
+// WithNamespaces sets the namespaces of the request objects of the webhooks
+// to process. The requests for objects in other namespaces are allowed
+// without any processing.
+func (blder *Builder) WithNamespaces(namespaces ...string) *Builder {
+	blder.namespaces = namespaces
+	return blder
+}
+
+// WithObjectPooling enables reuse of the request objects of the validating
+// webhook across the requests. See admission.WithObjectPooling for the
+// requirements on the admission controller.
+func (blder *Builder) WithObjectPooling() *Builder {
+	blder.objectPooling = true
+	return blder
+}
+
+// WithUnstructured enables decoding the request objects of the webhooks into
+// unstructured objects with the kind of the request object, instead of the
+// objects returned by the admission controller's GetNewObject. This allows
+// admission of the objects of any kind generically.
+func (blder *Builder) WithUnstructured() *Builder {
+	blder.unstructured = true
+	return blder
+}
+
+// WithTimeout sets the time limit of running the admission functions of a
+// request in the webhooks. An Errored response is returned if they don't
+// complete in time. Set it below the webhook configuration timeoutSeconds.
+func (blder *Builder) WithTimeout(timeout time.Duration) *Builder {
+	blder.timeout = timeout
+	return blder
+}
+
+// WithHandler adds a custom admission handler to register at the given
+// webhook endpoint path, besides the defaulting and validating webhooks of
+// the admission controller. A decoder is injected into the handler if it
+// implements admission.DecoderInjector. The handler isn't included in the
+// generated webhook configurations.
+func (blder *Builder) WithHandler(path string, handler admission.Handler) *Builder {
+	blder.handlers = append(blder.handlers, pathHandler{path: path, handler: handler})
+	return blder
+}
+
+// Complete builds the webhook. The admission controller can be nil when only
+// the custom handlers are registered, without mutate and validate paths.
 func (blder *Builder) Complete(c tkAdmission.Controller) error {
 	blder.c = c
 	return blder.registerWebhooks()
@@ -53,12 +140,47 @@ func (blder *Builder) registerWebhooks() error {
 		blder.registerValidatingWebhook()
 	}
 
+	for _, h := range blder.handlers {
+		if err := blder.registerHandler(h.path, h.handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// registerHandler registers a custom admission handler at the given path.
+func (blder *Builder) registerHandler(path string, handler admission.Handler) error {
+	// Checking if the path is already registered.
+	// If so, just skip it.
+	if blder.isAlreadyHandled(path) {
+		log.Info("Webhook path already registered, skipping registration",
+			"path", path)
+		return nil
+	}
+
+	// Inject the decoder now, the webhook server injects it only once it's
+	// started.
+	wh := &admission.Webhook{Handler: handler}
+	if err := wh.InjectScheme(blder.mgr.GetScheme()); err != nil {
+		return err
+	}
+	log.Info("Registering an admission webhook", "path", path)
+	blder.mgr.GetWebhookServer().Register(path, wh)
 	return nil
 }
 
 // registerDefaultingWebhook builds and registers the defaulting webhook.
 func (blder *Builder) registerDefaultingWebhook() {
-	mwh := tkAdmission.DefaultingWebhookFor(blder.c)
+	opts := []tkAdmission.DefaultingWebhookOption{
+		tkAdmission.WithDefaultingObjectSelector(blder.objectSelector),
+		tkAdmission.WithDefaultingNamespaces(blder.namespaces...),
+		tkAdmission.WithDefaultingTimeout(blder.timeout),
+	}
+	if blder.unstructured {
+		opts = append(opts, tkAdmission.WithDefaultingUnstructured())
+	}
+	mwh := tkAdmission.DefaultingWebhookFor(blder.c, opts...)
 	if mwh != nil {
 		path := blder.mutatePath
 
@@ -79,7 +201,18 @@ func (blder *Builder) registerDefaultingWebhook() {
 
 // registerValidatingWebhook builds and registers the validating webhook.
 func (blder *Builder) registerValidatingWebhook() {
-	vwh := tkAdmission.ValidatingWebhookFor(blder.c)
+	opts := []tkAdmission.ValidatingWebhookOption{
+		tkAdmission.WithValidatingObjectSelector(blder.objectSelector),
+		tkAdmission.WithValidatingNamespaces(blder.namespaces...),
+		tkAdmission.WithValidatingTimeout(blder.timeout),
+	}
+	if blder.objectPooling {
+		opts = append(opts, tkAdmission.WithObjectPooling())
+	}
+	if blder.unstructured {
+		opts = append(opts, tkAdmission.WithValidatingUnstructured())
+	}
+	vwh := tkAdmission.ValidatingWebhookFor(blder.c, opts...)
 	if vwh != nil {
 		path := blder.validatePath
 
@@ -0,0 +1,104 @@
+package builder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// configMapDataHandler is an admission handler that denies the ConfigMaps
+// without data.
+type configMapDataHandler struct {
+	decoder *admission.Decoder
+}
+
+func (h *configMapDataHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	cm := &corev1.ConfigMap{}
+	if err := h.decoder.Decode(req, cm); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if len(cm.Data) == 0 {
+		return admission.Denied("no data")
+	}
+	return admission.Allowed("")
+}
+
+func (h *configMapDataHandler) InjectDecoder(d *admission.Decoder) error {
+	h.decoder = d
+	return nil
+}
+
+// reviewConfigMap sends an admission review request for the given ConfigMap
+// to the given path of the webhook server mux and returns the response.
+func reviewConfigMap(t *testing.T, mux http.Handler, path string, cm *corev1.ConfigMap) *admissionv1.AdmissionResponse {
+	cm.APIVersion = "v1"
+	cm.Kind = "ConfigMap"
+	raw, err := json.Marshal(cm)
+	assert.Nil(t, err)
+
+	review := admissionv1.AdmissionReview{
+		TypeMeta: metav1.TypeMeta{APIVersion: "admission.k8s.io/v1", Kind: "AdmissionReview"},
+		Request: &admissionv1.AdmissionRequest{
+			UID:       "test-uid",
+			Kind:      metav1.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		},
+	}
+	body, err := json.Marshal(review)
+	assert.Nil(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	resp := admissionv1.AdmissionReview{}
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	if !assert.NotNil(t, resp.Response) {
+		return &admissionv1.AdmissionResponse{}
+	}
+	return resp.Response
+}
+
+func TestWithHandler(t *testing.T) {
+	mgr := newTestManager(t)
+	handler := &configMapDataHandler{}
+	assert.Nil(t, WebhookManagedBy(mgr).
+		WithHandler("/check-configmap-data", handler).
+		Complete(nil))
+
+	// The decoder is injected into the handler.
+	assert.NotNil(t, handler.decoder)
+
+	mux := mgr.GetWebhookServer().WebhookMux
+	resp := reviewConfigMap(t, mux, "/check-configmap-data", &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cm", Namespace: "default"},
+	})
+	assert.False(t, resp.Allowed)
+	assert.Equal(t, "test-uid", string(resp.UID))
+
+	resp = reviewConfigMap(t, mux, "/check-configmap-data", &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-cm", Namespace: "default"},
+		Data:       map[string]string{"foo": "bar"},
+	})
+	assert.True(t, resp.Allowed)
+
+	// Registering a handler at an already registered path is skipped.
+	other := &configMapDataHandler{}
+	assert.Nil(t, WebhookManagedBy(mgr).
+		WithHandler("/check-configmap-data", other).
+		Complete(nil))
+	assert.Nil(t, other.decoder)
+}
@@ -3,10 +3,13 @@ package cert
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -16,15 +19,16 @@ import (
 	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
 	corev1 "k8s.io/api/core/v1"
 	apix "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/darkowlzz/operator-toolkit/internal/pkiutil"
 	webhookcert "github.com/darkowlzz/operator-toolkit/internal/webhook/cert"
 	"github.com/darkowlzz/operator-toolkit/internal/webhook/cert/generator"
 	"github.com/darkowlzz/operator-toolkit/internal/webhook/cert/writer"
@@ -36,6 +40,10 @@ var log = ctrl.Log.WithName("webhook").WithName("cert").WithName("manager")
 // instances of the certificate manager.
 var defaultCertRefreshInterval = 30 * time.Minute
 
+// defaultCertValidity is the validity of the generated certificate when
+// CertValidity is not set.
+var defaultCertValidity = 365 * 24 * time.Hour
+
 const (
 	defaultCertName = "tls.crt"
 	defaultKeyName  = "tls.key"
@@ -45,7 +53,9 @@ const (
 // about the webhook configuration and service or host of the webhook in order
 // to provision self signed certificate and inject the cert into the webhook
 // configurations. The generated certificate is stored in a k8s secret object
-// and is reused if it already exists.
+// and is reused if it already exists. A valid certificate provided externally
+// in the secret, like by cert-manager, with tls.crt, tls.key and ca.crt, is
+// used as is, without generating a certificate.
 type Manager struct {
 	// Option is the certificate provisioner options.
 	Options
@@ -61,14 +71,24 @@ type Options struct {
 
 	// Service is a reference to the k8s service fronting the webhook server
 	// pod(s). This field is optional. But one and only one of Service and
-	// Host need to be set.
+	// Host need to be set, unless ClientConfigTypes is set.
 	// This maps to field .webhooks.getClientConfig.service
 	Service *admissionregistrationv1.ServiceReference
 
 	// Host is the host name of .webhooks.clientConfig.url
-	// This field is optional. But one and only one of Service and Host need to be set.
+	// This field is optional. But one and only one of Service and Host need
+	// to be set, unless ClientConfigTypes is set.
 	Host *string
 
+	// ClientConfigTypes selects the client config of the webhook
+	// configurations and CRDs, by name, for a webhook server exposed both
+	// in-cluster with the Service and externally with the Host. Both Service
+	// and Host must be set. The selected client config is set in all the
+	// webhooks of a configuration, keeping their paths. The configurations
+	// not in the map get the Service client config. The certificate is valid
+	// for both the Service and the Host.
+	ClientConfigTypes map[types.NamespacedName]ClientConfigType
+
 	// Port is the port number that the server will serve.
 	// It will be defaulted to controller-runtime's default webhook server port
 	// if unspecified.
@@ -111,8 +131,51 @@ type Options struct {
 	// the client-go cert utils package.
 	// If not set, this defaults to a year.
 	CertValidity time.Duration
+
+	// CARotationGracePeriod enables the CA rotation mode of the default
+	// CertWriter. When the CA is rotated, both the old and the new CA are
+	// set in the CABundle of the webhook configurations for this period,
+	// before trimming the CABundle to the new CA. This avoids request
+	// failures from the webhook configurations trusting only one of the CAs
+	// mid-rotation.
+	CARotationGracePeriod time.Duration
+
+	// RenewBeforeFraction is the fraction of the certificate validity
+	// remaining at which the certificate is renewed. For example, 0.33
+	// renews the certificate when a third of its validity remains. This
+	// rotates short-lived certificates proportionally to their validity. The
+	// CertRefreshInterval should be shorter than the renewal period for the
+	// renewal to be timely. If not set, the certificate is renewed when it
+	// expires within six months. Must be in the range [0, 1).
+	RenewBeforeFraction float64
+
+	// SecretType is the type of the generated secret, like
+	// corev1.SecretTypeTLS for tools that expect TLS typed secrets. A TLS
+	// secret also contains the serving certificate and key in tls.crt and
+	// tls.key. If not set, the secret is created with the default type.
+	SecretType corev1.SecretType
+
+	// SecretLabels are the labels of the generated secret, like ownership
+	// labels.
+	SecretLabels map[string]string
+
+	// SecretAnnotations are the annotations of the generated secret.
+	SecretAnnotations map[string]string
 }
 
+// ClientConfigType is the type of the client config of a webhook
+// configuration.
+type ClientConfigType int
+
+const (
+	// ServiceClientConfig is the client config with the Service of the
+	// webhook server.
+	ServiceClientConfig ClientConfigType = iota
+	// URLClientConfig is the client config with the URL of the Host of the
+	// webhook server.
+	URLClientConfig
+)
+
 // setDefault sets the default options.
 func (o *Options) setDefault() {
 	if o.Port <= 0 {
@@ -158,14 +221,29 @@ func NewManager(mgr manager.Manager, ops Options) error {
 func newManager(ops Options) (*Manager, error) {
 	ops.setDefault()
 
+	if ops.RenewBeforeFraction < 0 || ops.RenewBeforeFraction >= 1 {
+		return nil, fmt.Errorf("invalid RenewBeforeFraction %v, must be in the range [0, 1)", ops.RenewBeforeFraction)
+	}
+
+	if len(ops.ClientConfigTypes) > 0 && (ops.Service == nil || ops.Host == nil) {
+		return nil, errors.New("both Service and Host must be set with ClientConfigTypes")
+	}
+
 	// If CertWriter is not set, create a default CertWriter.
 	if ops.CertWriter == nil {
 		secretCWOpts := writer.SecretCertWriterOptions{
 			Client: ops.Client,
 			CertGenerator: &generator.SelfSignedCertGenerator{
 				Validity: ops.CertValidity,
+				DNSNames: ops.extraDNSNames(),
 			},
-			Secret: ops.SecretRef,
+			Secret:                ops.SecretRef,
+			CARotationGracePeriod: ops.CARotationGracePeriod,
+			RenewBefore:           ops.renewBefore(),
+			SecretType:            ops.SecretType,
+			SecretLabels:          ops.SecretLabels,
+			SecretAnnotations:     ops.SecretAnnotations,
+			DNSNames:              ops.extraDNSNames(),
 		}
 		cw, err := writer.NewSecretCertWriter(secretCWOpts)
 		if err != nil {
@@ -175,13 +253,38 @@ func newManager(ops Options) (*Manager, error) {
 	}
 
 	certManager := &Manager{
-		certProvisioner: webhookcert.Provisioner{CertWriter: ops.CertWriter},
-		Options:         ops,
+		certProvisioner: webhookcert.Provisioner{
+			CertWriter:    ops.CertWriter,
+			ExactCABundle: ops.CARotationGracePeriod > 0,
+		},
+		Options: ops,
 	}
 
 	return certManager, nil
 }
 
+// renewBefore returns the period before the certificate expiry at which the
+// certificate is renewed, based on the RenewBeforeFraction of the certificate
+// validity.
+func (o *Options) renewBefore() time.Duration {
+	validity := o.CertValidity
+	if validity == 0 {
+		validity = defaultCertValidity
+	}
+	return time.Duration(o.RenewBeforeFraction * float64(validity))
+}
+
+// extraDNSNames returns the DNS names the certificate must be valid for,
+// other than the DNS name of the primary client config. With
+// ClientConfigTypes, the primary client config is the Service and the Host is
+// an extra DNS name.
+func (o *Options) extraDNSNames() []string {
+	if len(o.ClientConfigTypes) > 0 && o.Host != nil {
+		return []string{*o.Host}
+	}
+	return nil
+}
+
 // NeedLeaderElection implements the LeaderElectionRunnable interface.
 func (m *Manager) NeedLeaderElection() bool {
 	return false
@@ -209,6 +312,33 @@ func (m *Manager) certExists() bool {
 	return true
 }
 
+// ReadyCheck returns a readiness check that reports ready only once a valid
+// server certificate and key exist in the CertDir of the given options. Add it
+// to the controller manager with AddReadyzCheck to not receive the webhook
+// traffic until the certificate is provisioned by the certificate manager,
+// which may start along with the webhook server.
+func ReadyCheck(ops Options) healthz.Checker {
+	ops.setDefault()
+	m := &Manager{Options: ops}
+	return func(_ *http.Request) error {
+		return m.checkCert()
+	}
+}
+
+// checkCert checks that the server certificate and key on the host form a
+// valid key pair and the certificate is within its validity period.
+func (m *Manager) checkCert() error {
+	pair, err := tls.LoadX509KeyPair(filepath.Join(m.CertDir, m.CertName), filepath.Join(m.CertDir, m.KeyName))
+	if err != nil {
+		return fmt.Errorf("failed to load the server cert: %w", err)
+	}
+	cert, err := x509.ParseCertificate(pair.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse the server cert: %w", err)
+	}
+	return pkiutil.ValidateCertPeriod(cert, 0)
+}
+
 // provision implements the Runnable interface. It starts the certificate
 // manager.
 func (m *Manager) Start(ctx context.Context) error {
@@ -269,6 +399,15 @@ func (m *Manager) run() error {
 		log.Info("generated new cert")
 	}
 
+	// Check if the cert on the host chains to the current CA, in case the
+	// cert in the secret was changed by another instance of the manager.
+	if !changed && !needHostCertUpdate {
+		if err := m.verifyHostCert(ctx); err != nil {
+			log.Info("cert on host doesn't match the current CA", "error", err)
+			needHostCertUpdate = true
+		}
+	}
+
 	// Update the cert on host.
 	if changed || needHostCertUpdate {
 		log.Info(fmt.Sprintf("updating the cert in %s", m.CertDir))
@@ -280,18 +419,15 @@ func (m *Manager) run() error {
 
 func (m *Manager) writeCertOnDisk(ctx context.Context) error {
 	// Get the cert and write on disk.
-	secret := &corev1.Secret{
-		TypeMeta: metav1.TypeMeta{
-			APIVersion: "v1",
-			Kind:       "Secret",
-		},
-	}
-	err := m.Client.Get(ctx, *m.SecretRef, secret)
-	if apierrors.IsNotFound(err) {
+	cert, key, caCert, err := m.getSecretCert(ctx)
+	if err != nil {
 		return err
 	}
-	cert := secret.Data[writer.ServerCertName]
-	key := secret.Data[writer.ServerKeyName]
+
+	// Ensure the cert chains to the CA before trusting it.
+	if err := m.verifyCert(cert, caCert); err != nil {
+		return fmt.Errorf("cert in secret %s doesn't match the CA: %w", m.SecretRef, err)
+	}
 
 	if err := os.MkdirAll(m.CertDir, 0700); err != nil {
 		return err
@@ -307,6 +443,62 @@ func (m *Manager) writeCertOnDisk(ctx context.Context) error {
 	return nil
 }
 
+// getSecretCert returns the cert, key and CA cert stored in the secret.
+func (m *Manager) getSecretCert(ctx context.Context) (cert, key, caCert []byte, err error) {
+	secret := &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+	}
+	if err := m.Client.Get(ctx, *m.SecretRef, secret); err != nil {
+		return nil, nil, nil, err
+	}
+	cert = secret.Data[writer.ServerCertName]
+	key = secret.Data[writer.ServerKeyName]
+	caCert = secret.Data[writer.CACertName]
+	// Use the externally provided cert, if any.
+	if len(cert) == 0 {
+		cert = secret.Data[corev1.TLSCertKey]
+		key = secret.Data[corev1.TLSPrivateKeyKey]
+		caCert = secret.Data[writer.ExternalCACertName]
+	}
+	return cert, key, caCert, nil
+}
+
+// verifyCert verifies that the given cert chains to the given CA cert and is
+// valid for the DNS name of the webhook server.
+func (m *Manager) verifyCert(cert, caCert []byte) error {
+	cc, err := m.getClientConfig()
+	if err != nil {
+		return err
+	}
+	dnsName, err := webhookcert.DNSNameFromClientConfig(cc)
+	if err != nil {
+		return err
+	}
+	for _, name := range append([]string{dnsName}, m.extraDNSNames()...) {
+		if err := pkiutil.VerifyCertAgainstCA(cert, caCert, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// verifyHostCert verifies that the cert on the host chains to the current CA
+// cert in the secret.
+func (m *Manager) verifyHostCert(ctx context.Context) error {
+	_, _, caCert, err := m.getSecretCert(ctx)
+	if err != nil {
+		return err
+	}
+	cert, err := ioutil.ReadFile(filepath.Join(m.CertDir, m.CertName))
+	if err != nil {
+		return err
+	}
+	return m.verifyCert(cert, caCert)
+}
+
 // refreshCert refreshes the certificate using cert provisioner if the
 // certificate is expiring. It also updates the webhook configurations with the
 // current certificate. The caller can decide to reload the webhook server
@@ -394,6 +586,9 @@ func (m *Manager) refreshCert(ctx context.Context) (bool, error) {
 		return false, err
 	}
 
+	// Set the selected client configs.
+	m.setClientConfigs(whConfigs)
+
 	// Update the webhook configurations.
 	return changed, batchUpdate(ctx, m.Client, whConfigs...)
 }
@@ -427,29 +622,128 @@ func batchUpdate(ctx context.Context, c client.Client, objs ...client.Object) er
 }
 
 // getClientConfig returns a WebhookClientConfig with the provided host or
-// service of the webhook server.
+// service of the webhook server. With ClientConfigTypes, it's the client
+// config with the service.
 func (m *Manager) getClientConfig() (*admissionregistrationv1.WebhookClientConfig, error) {
-	if m.Host != nil && m.Service != nil {
-		return nil, errors.New("URL and Service can't be set at the same time")
+	if m.Host != nil && m.Service != nil && len(m.ClientConfigTypes) == 0 {
+		return nil, errors.New("URL and Service can't be set at the same time without ClientConfigTypes")
 	}
 	// Create a webhook client config with empty CA bundle.
 	cc := &admissionregistrationv1.WebhookClientConfig{
 		CABundle: []byte{},
 	}
-	// Set the host or service of the server.
-	if m.Host != nil {
-		u := url.URL{
-			Scheme: "https",
-			Host:   net.JoinHostPort(*m.Host, strconv.Itoa(int(m.Port))),
-		}
-		urlString := u.String()
-		cc.URL = &urlString
-	}
+	// Set the service or host of the server.
 	if m.Service != nil {
 		cc.Service = &admissionregistrationv1.ServiceReference{
 			Name:      m.Service.Name,
 			Namespace: m.Service.Namespace,
 		}
+	} else if m.Host != nil {
+		urlString := m.hostURL("")
+		cc.URL = &urlString
 	}
 	return cc, nil
 }
+
+// hostURL returns the URL of the given path on the host of the webhook
+// server.
+func (m *Manager) hostURL(path string) string {
+	u := url.URL{
+		Scheme: "https",
+		Host:   net.JoinHostPort(*m.Host, strconv.Itoa(int(m.Port))),
+		Path:   path,
+	}
+	return u.String()
+}
+
+// setClientConfigs sets the client configs selected by ClientConfigTypes in
+// the webhooks of the given webhook configurations and CRDs. The paths of
+// the webhooks are kept.
+func (m *Manager) setClientConfigs(objs []client.Object) {
+	if len(m.ClientConfigTypes) == 0 {
+		return
+	}
+	for _, obj := range objs {
+		ccType := m.ClientConfigTypes[client.ObjectKeyFromObject(obj)]
+		switch typed := obj.(type) {
+		case *admissionregistrationv1.MutatingWebhookConfiguration:
+			for i := range typed.Webhooks {
+				m.setClientConfig(&typed.Webhooks[i].ClientConfig, ccType)
+			}
+		case *admissionregistrationv1.ValidatingWebhookConfiguration:
+			for i := range typed.Webhooks {
+				m.setClientConfig(&typed.Webhooks[i].ClientConfig, ccType)
+			}
+		case *apix.CustomResourceDefinition:
+			m.setCRDClientConfig(typed.Spec.Conversion.Webhook.ClientConfig, ccType)
+		}
+	}
+}
+
+// setClientConfig sets the service or the URL of the given client config,
+// based on the given client config type.
+func (m *Manager) setClientConfig(cc *admissionregistrationv1.WebhookClientConfig, ccType ClientConfigType) {
+	var path *string
+	if cc.Service != nil {
+		path = cc.Service.Path
+	} else if cc.URL != nil {
+		path = urlPath(*cc.URL)
+	}
+
+	if ccType == URLClientConfig {
+		urlString := m.hostURL(stringValue(path))
+		cc.URL = &urlString
+		cc.Service = nil
+		return
+	}
+	cc.URL = nil
+	cc.Service = &admissionregistrationv1.ServiceReference{
+		Name:      m.Service.Name,
+		Namespace: m.Service.Namespace,
+		Port:      m.Service.Port,
+		Path:      path,
+	}
+}
+
+// setCRDClientConfig is setClientConfig for the conversion webhook client
+// config of a CRD.
+func (m *Manager) setCRDClientConfig(cc *apix.WebhookClientConfig, ccType ClientConfigType) {
+	var path *string
+	if cc.Service != nil {
+		path = cc.Service.Path
+	} else if cc.URL != nil {
+		path = urlPath(*cc.URL)
+	}
+
+	if ccType == URLClientConfig {
+		urlString := m.hostURL(stringValue(path))
+		cc.URL = &urlString
+		cc.Service = nil
+		return
+	}
+	cc.URL = nil
+	cc.Service = &apix.ServiceReference{
+		Name:      m.Service.Name,
+		Namespace: m.Service.Namespace,
+		Port:      m.Service.Port,
+		Path:      path,
+	}
+}
+
+// urlPath returns the path of the given URL, or nil if it has no path.
+func urlPath(rawURL string) *string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Path == "" {
+		return nil
+	}
+	return &u.Path
+}
+
+// stringValue returns the value of the given string pointer, or an empty
+// string if it's nil.
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
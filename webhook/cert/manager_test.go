@@ -19,6 +19,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	"github.com/darkowlzz/operator-toolkit/internal/pkiutil"
+	"github.com/darkowlzz/operator-toolkit/internal/webhook/cert/generator"
+	"github.com/darkowlzz/operator-toolkit/internal/webhook/cert/writer"
 )
 
 // getTestResources returns the basic objects required in cert manager tests.
@@ -145,6 +147,290 @@ func TestManager(t *testing.T) {
 	assert.NotEmpty(t, mutatingWebhookConfig.Webhooks[0].ClientConfig.CABundle)
 }
 
+func TestCARotation(t *testing.T) {
+	secret, mutatingWebhookConfig, validatingWebhookConfig, crd := getTestResources()
+
+	tscheme := scheme.Scheme
+	assert.Nil(t, apix.AddToScheme(tscheme))
+
+	cli := fake.NewClientBuilder().WithScheme(tscheme).WithObjects(mutatingWebhookConfig, validatingWebhookConfig, crd).Build()
+
+	certDir, err := ioutil.TempDir("", "cert-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(certDir)
+
+	certOpts := Options{
+		CertDir: certDir,
+		Service: &admissionregistrationv1.ServiceReference{
+			Name:      "webhook-service",
+			Namespace: "default",
+		},
+		Client:                      cli,
+		SecretRef:                   &types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace},
+		MutatingWebhookConfigRefs:   []types.NamespacedName{{Name: mutatingWebhookConfig.Name}},
+		ValidatingWebhookConfigRefs: []types.NamespacedName{{Name: validatingWebhookConfig.Name}},
+		CRDRefs:                     []types.NamespacedName{{Name: crd.Name}},
+		CARotationGracePeriod:       time.Hour,
+	}
+
+	certMgr, err := newManager(certOpts)
+	assert.Nil(t, err)
+	assert.Nil(t, certMgr.Start(context.TODO()))
+
+	secretKey := types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}
+	getCABundle := func() []byte {
+		assert.Nil(t, cli.Get(context.TODO(), types.NamespacedName{Name: validatingWebhookConfig.Name}, validatingWebhookConfig))
+		return validatingWebhookConfig.Webhooks[0].ClientConfig.CABundle
+	}
+
+	assert.Nil(t, cli.Get(context.TODO(), secretKey, secret))
+	oldCA := secret.Data[writer.CACertName]
+	assert.Equal(t, oldCA, getCABundle())
+
+	// Invalidate the CA key and the serving cert to rotate the CA.
+	secret.Data[writer.CAKeyName] = []byte("invalid")
+	secret.Data[writer.ServerCertName] = []byte{}
+	assert.Nil(t, cli.Update(context.TODO(), secret))
+	assert.Nil(t, certMgr.run())
+
+	assert.Nil(t, cli.Get(context.TODO(), secretKey, secret))
+	newCA := secret.Data[writer.CACertName]
+	assert.NotEqual(t, oldCA, newCA)
+
+	// During the grace period, the CABundle contains both the CAs.
+	caBundle := getCABundle()
+	assert.Contains(t, string(caBundle), string(newCA))
+	assert.Contains(t, string(caBundle), string(oldCA))
+
+	// A refresh within the grace period keeps both the CAs.
+	assert.Nil(t, certMgr.run())
+	assert.Contains(t, string(getCABundle()), string(oldCA))
+
+	// End the grace period. The CABundle is trimmed to the new CA.
+	secret.Data[writer.CARotationTimeName] = []byte(time.Now().Add(-2 * time.Hour).Format(time.RFC3339))
+	assert.Nil(t, cli.Update(context.TODO(), secret))
+	assert.Nil(t, certMgr.run())
+	assert.Equal(t, newCA, getCABundle())
+}
+
+func TestRenewBeforeFraction(t *testing.T) {
+	cases := []struct {
+		name        string
+		fraction    float64
+		wantRenewed bool
+	}{
+		{
+			// 10 minutes remaining, renew within 30 minutes.
+			name:        "within renewal period",
+			fraction:    0.5,
+			wantRenewed: true,
+		},
+		{
+			// 10 minutes remaining, renew within 6 minutes.
+			name:     "before renewal period",
+			fraction: 0.1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			secret, mutatingWebhookConfig, validatingWebhookConfig, crd := getTestResources()
+
+			tscheme := scheme.Scheme
+			assert.Nil(t, apix.AddToScheme(tscheme))
+
+			// Create a short-lived cert in the secret.
+			cp := generator.SelfSignedCertGenerator{Validity: 10 * time.Minute}
+			certs, err := cp.Generate(generator.ServiceToCommonName("default", "webhook-service"))
+			assert.Nil(t, err)
+			secret.Data = map[string][]byte{
+				writer.CAKeyName:      certs.CAKey,
+				writer.CACertName:     certs.CACert,
+				writer.ServerKeyName:  certs.Key,
+				writer.ServerCertName: certs.Cert,
+			}
+
+			cli := fake.NewClientBuilder().WithScheme(tscheme).WithObjects(secret, mutatingWebhookConfig, validatingWebhookConfig, crd).Build()
+
+			certDir, err := ioutil.TempDir("", "cert-test")
+			assert.Nil(t, err)
+			defer os.RemoveAll(certDir)
+
+			certOpts := Options{
+				CertDir: certDir,
+				Service: &admissionregistrationv1.ServiceReference{
+					Name:      "webhook-service",
+					Namespace: "default",
+				},
+				Client:                      cli,
+				SecretRef:                   &types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace},
+				MutatingWebhookConfigRefs:   []types.NamespacedName{{Name: mutatingWebhookConfig.Name}},
+				ValidatingWebhookConfigRefs: []types.NamespacedName{{Name: validatingWebhookConfig.Name}},
+				CRDRefs:                     []types.NamespacedName{{Name: crd.Name}},
+				CertValidity:                time.Hour,
+				RenewBeforeFraction:         tc.fraction,
+			}
+
+			certMgr, err := newManager(certOpts)
+			assert.Nil(t, err)
+			assert.Nil(t, certMgr.run())
+
+			assert.Nil(t, cli.Get(context.TODO(), types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, secret))
+			assert.Equal(t, tc.wantRenewed, string(secret.Data[writer.ServerCertName]) != string(certs.Cert))
+		})
+	}
+}
+
+func TestExternalCert(t *testing.T) {
+	secret, mutatingWebhookConfig, validatingWebhookConfig, crd := getTestResources()
+
+	tscheme := scheme.Scheme
+	assert.Nil(t, apix.AddToScheme(tscheme))
+
+	// Create a short-lived cert in the secret, as provided by an external
+	// issuer. A generated cert this short-lived would be renewed.
+	dnsName := generator.ServiceToCommonName("default", "webhook-service")
+	cp := generator.SelfSignedCertGenerator{Validity: 10 * time.Minute}
+	certs, err := cp.Generate(dnsName)
+	assert.Nil(t, err)
+	externalData := map[string][]byte{
+		corev1.TLSCertKey:         certs.Cert,
+		corev1.TLSPrivateKeyKey:   certs.Key,
+		writer.ExternalCACertName: certs.CACert,
+	}
+	secret.Data = externalData
+
+	cli := fake.NewClientBuilder().WithScheme(tscheme).WithObjects(secret, mutatingWebhookConfig, validatingWebhookConfig, crd).Build()
+
+	certDir, err := ioutil.TempDir("", "cert-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(certDir)
+
+	certOpts := Options{
+		CertDir: certDir,
+		Service: &admissionregistrationv1.ServiceReference{
+			Name:      "webhook-service",
+			Namespace: "default",
+		},
+		Client:                      cli,
+		SecretRef:                   &types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace},
+		MutatingWebhookConfigRefs:   []types.NamespacedName{{Name: mutatingWebhookConfig.Name}},
+		ValidatingWebhookConfigRefs: []types.NamespacedName{{Name: validatingWebhookConfig.Name}},
+		CRDRefs:                     []types.NamespacedName{{Name: crd.Name}},
+	}
+
+	certMgr, err := newManager(certOpts)
+	assert.Nil(t, err)
+	assert.Nil(t, certMgr.Start(context.TODO()))
+
+	secretKey := types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}
+	getHostCert := func() []byte {
+		cert, err := ioutil.ReadFile(filepath.Join(certDir, defaultCertName))
+		assert.Nil(t, err)
+		return cert
+	}
+
+	// The secret is not regenerated.
+	assert.Nil(t, cli.Get(context.TODO(), secretKey, secret))
+	assert.Equal(t, externalData, secret.Data)
+
+	// The external cert is written on host and its CA is injected.
+	assert.Equal(t, certs.Cert, getHostCert())
+	assert.Nil(t, cli.Get(context.TODO(), types.NamespacedName{Name: validatingWebhookConfig.Name}, validatingWebhookConfig))
+	assert.Equal(t, certs.CACert, validatingWebhookConfig.Webhooks[0].ClientConfig.CABundle)
+
+	// A cert renewed by the external issuer is written on host.
+	cp.SetCA(certs.CAKey, certs.CACert)
+	renewed, err := cp.Generate(dnsName)
+	assert.Nil(t, err)
+	secret.Data[corev1.TLSCertKey] = renewed.Cert
+	secret.Data[corev1.TLSPrivateKeyKey] = renewed.Key
+	assert.Nil(t, cli.Update(context.TODO(), secret))
+	assert.Nil(t, certMgr.run())
+	assert.Equal(t, renewed.Cert, getHostCert())
+
+	// An invalid external cert is replaced by a generated cert.
+	assert.Nil(t, cli.Get(context.TODO(), secretKey, secret))
+	secret.Data[corev1.TLSCertKey] = []byte("invalid")
+	assert.Nil(t, cli.Update(context.TODO(), secret))
+	assert.Nil(t, certMgr.run())
+	assert.Nil(t, cli.Get(context.TODO(), secretKey, secret))
+	assert.NotEmpty(t, secret.Data[writer.ServerCertName])
+	assert.Equal(t, secret.Data[writer.ServerCertName], getHostCert())
+
+	// A cert on host that doesn't chain to the current CA is replaced.
+	other, err := (&generator.SelfSignedCertGenerator{}).Generate(dnsName)
+	assert.Nil(t, err)
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(certDir, defaultCertName), other.Cert, 0666))
+	assert.Nil(t, certMgr.run())
+	assert.Equal(t, secret.Data[writer.ServerCertName], getHostCert())
+}
+
+func TestSecretTypeAndMeta(t *testing.T) {
+	secret, mutatingWebhookConfig, validatingWebhookConfig, crd := getTestResources()
+
+	tscheme := scheme.Scheme
+	assert.Nil(t, apix.AddToScheme(tscheme))
+
+	cli := fake.NewClientBuilder().WithScheme(tscheme).WithObjects(mutatingWebhookConfig, validatingWebhookConfig, crd).Build()
+
+	certDir, err := ioutil.TempDir("", "cert-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(certDir)
+
+	labels := map[string]string{"app.kubernetes.io/managed-by": "test-operator"}
+	annotations := map[string]string{"example.com/owner": "webhook"}
+
+	certOpts := Options{
+		CertDir: certDir,
+		Service: &admissionregistrationv1.ServiceReference{
+			Name:      "webhook-service",
+			Namespace: "default",
+		},
+		Client:                      cli,
+		SecretRef:                   &types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace},
+		MutatingWebhookConfigRefs:   []types.NamespacedName{{Name: mutatingWebhookConfig.Name}},
+		ValidatingWebhookConfigRefs: []types.NamespacedName{{Name: validatingWebhookConfig.Name}},
+		CRDRefs:                     []types.NamespacedName{{Name: crd.Name}},
+		SecretType:                  corev1.SecretTypeTLS,
+		SecretLabels:                labels,
+		SecretAnnotations:           annotations,
+	}
+
+	certMgr, err := newManager(certOpts)
+	assert.Nil(t, err)
+	assert.Nil(t, certMgr.Start(context.TODO()))
+
+	secretKey := types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}
+	checkSecret := func() {
+		assert.Nil(t, cli.Get(context.TODO(), secretKey, secret))
+		assert.Equal(t, corev1.SecretTypeTLS, secret.Type)
+		assert.Equal(t, labels, secret.Labels)
+		assert.Equal(t, annotations, secret.Annotations)
+		assert.Equal(t, secret.Data[writer.ServerCertName], secret.Data[corev1.TLSCertKey])
+		assert.Equal(t, secret.Data[writer.ServerKeyName], secret.Data[corev1.TLSPrivateKeyKey])
+	}
+
+	// The created secret has the configured type and metadata.
+	checkSecret()
+	oldCert := secret.Data[writer.ServerCertName]
+
+	// The regenerated secret keeps the configured type and metadata.
+	secret.Data[writer.ServerCertName] = []byte{}
+	assert.Nil(t, cli.Update(context.TODO(), secret))
+	assert.Nil(t, certMgr.run())
+	checkSecret()
+	assert.NotEqual(t, oldCert, secret.Data[writer.ServerCertName])
+}
+
+func TestInvalidRenewBeforeFraction(t *testing.T) {
+	for _, fraction := range []float64{-0.1, 1, 1.5} {
+		_, err := newManager(Options{RenewBeforeFraction: fraction})
+		assert.Error(t, err, "fraction %v", fraction)
+	}
+}
+
 func TestMultipleManagers(t *testing.T) {
 	// Get the basic resources needed to run the cert manager.
 	secret, mutatingWebhookConfig, validatingWebhookConfig, crd := getTestResources()
@@ -204,6 +490,100 @@ func TestMultipleManagers(t *testing.T) {
 	contentCheck(filepath.Join(certDir1, defaultKeyName), filepath.Join(certDir2, defaultKeyName))
 }
 
+func TestClientConfigTypes(t *testing.T) {
+	secret, mutatingWebhookConfig, validatingWebhookConfig, crd := getTestResources()
+	// The paths of the webhooks are kept.
+	path := "/validate-foo"
+	validatingWebhookConfig.Webhooks[0].ClientConfig.Service = &admissionregistrationv1.ServiceReference{Path: &path}
+
+	tscheme := scheme.Scheme
+	assert.Nil(t, apix.AddToScheme(tscheme))
+
+	cli := fake.NewClientBuilder().WithScheme(tscheme).WithObjects(mutatingWebhookConfig, validatingWebhookConfig, crd).Build()
+
+	certDir, err := ioutil.TempDir("", "cert-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(certDir)
+
+	host := "webhook.example.com"
+	port := int32(8443)
+	certOpts := Options{
+		CertDir: certDir,
+		Port:    port,
+		Service: &admissionregistrationv1.ServiceReference{
+			Name:      "webhook-service",
+			Namespace: "default",
+			Port:      &port,
+		},
+		Host:                        &host,
+		Client:                      cli,
+		SecretRef:                   &types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace},
+		MutatingWebhookConfigRefs:   []types.NamespacedName{{Name: mutatingWebhookConfig.Name}},
+		ValidatingWebhookConfigRefs: []types.NamespacedName{{Name: validatingWebhookConfig.Name}},
+		CRDRefs:                     []types.NamespacedName{{Name: crd.Name}},
+		ClientConfigTypes: map[types.NamespacedName]ClientConfigType{
+			{Name: validatingWebhookConfig.Name}: URLClientConfig,
+			{Name: crd.Name}:                     ServiceClientConfig,
+		},
+	}
+
+	certMgr, err := newManager(certOpts)
+	assert.Nil(t, err)
+	assert.Nil(t, certMgr.Start(context.TODO()))
+
+	// The unmapped mutating webhook configuration gets the service.
+	assert.Nil(t, cli.Get(context.TODO(), types.NamespacedName{Name: mutatingWebhookConfig.Name}, mutatingWebhookConfig))
+	mcc := mutatingWebhookConfig.Webhooks[0].ClientConfig
+	assert.Nil(t, mcc.URL)
+	if assert.NotNil(t, mcc.Service) {
+		assert.Equal(t, "webhook-service", mcc.Service.Name)
+		assert.Equal(t, "default", mcc.Service.Namespace)
+		assert.Equal(t, &port, mcc.Service.Port)
+	}
+	assert.NotEmpty(t, mcc.CABundle)
+
+	// The validating webhook configuration gets the URL with its path.
+	vwc := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+	assert.Nil(t, cli.Get(context.TODO(), types.NamespacedName{Name: validatingWebhookConfig.Name}, vwc))
+	vcc := vwc.Webhooks[0].ClientConfig
+	assert.Nil(t, vcc.Service)
+	if assert.NotNil(t, vcc.URL) {
+		assert.Equal(t, "https://webhook.example.com:8443/validate-foo", *vcc.URL)
+	}
+	assert.Equal(t, mcc.CABundle, vcc.CABundle)
+
+	// The CRD gets the service.
+	assert.Nil(t, cli.Get(context.TODO(), types.NamespacedName{Name: crd.Name}, crd))
+	ccc := crd.Spec.Conversion.Webhook.ClientConfig
+	assert.Nil(t, ccc.URL)
+	if assert.NotNil(t, ccc.Service) {
+		assert.Equal(t, "webhook-service", ccc.Service.Name)
+		assert.Equal(t, "default", ccc.Service.Namespace)
+	}
+
+	// The cert is valid for both the service and the host.
+	cert, err := pkiutil.TryLoadCertFromDisk(certDir, "tls")
+	assert.Nil(t, err)
+	assert.Nil(t, cert.VerifyHostname(generator.ServiceToCommonName("default", "webhook-service")))
+	assert.Nil(t, cert.VerifyHostname(host))
+
+	// A refresh keeps the client configs and the cert.
+	assert.Nil(t, certMgr.run())
+	cert2, err := pkiutil.TryLoadCertFromDisk(certDir, "tls")
+	assert.Nil(t, err)
+	assert.Equal(t, cert.Raw, cert2.Raw)
+	assert.Nil(t, cli.Get(context.TODO(), types.NamespacedName{Name: validatingWebhookConfig.Name}, vwc))
+	assert.Equal(t, vcc.URL, vwc.Webhooks[0].ClientConfig.URL)
+}
+
+func TestClientConfigTypesWithoutHost(t *testing.T) {
+	_, err := newManager(Options{
+		Service:           &admissionregistrationv1.ServiceReference{Name: "webhook-service", Namespace: "default"},
+		ClientConfigTypes: map[types.NamespacedName]ClientConfigType{{Name: "foo"}: URLClientConfig},
+	})
+	assert.Error(t, err)
+}
+
 func TestOptionsSetDefault(t *testing.T) {
 	testcases := map[string]struct {
 		name      string
@@ -257,3 +637,43 @@ func TestOptionsSetDefault(t *testing.T) {
 		})
 	}
 }
+
+func TestReadyCheck(t *testing.T) {
+	secret, mutatingWebhookConfig, validatingWebhookConfig, _ := getTestResources()
+
+	cli := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(mutatingWebhookConfig, validatingWebhookConfig).Build()
+
+	certDir, err := ioutil.TempDir("", "cert-test")
+	assert.Nil(t, err)
+	defer os.RemoveAll(certDir)
+
+	certOpts := Options{
+		CertDir: certDir,
+		Service: &admissionregistrationv1.ServiceReference{
+			Name:      "webhook-service",
+			Namespace: "default",
+		},
+		Client:                      cli,
+		SecretRef:                   &types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace},
+		MutatingWebhookConfigRefs:   []types.NamespacedName{{Name: mutatingWebhookConfig.Name}},
+		ValidatingWebhookConfigRefs: []types.NamespacedName{{Name: validatingWebhookConfig.Name}},
+	}
+
+	check := ReadyCheck(certOpts)
+
+	// Not ready before the cert is provisioned.
+	assert.NotNil(t, check(nil))
+
+	// Not ready with an invalid cert on the host.
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(certDir, defaultCertName), []byte("invalid"), 0666))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(certDir, defaultKeyName), []byte("invalid"), 0666))
+	assert.NotNil(t, check(nil))
+	assert.Nil(t, os.RemoveAll(certDir))
+
+	certMgr, err := newManager(certOpts)
+	assert.Nil(t, err)
+	assert.Nil(t, certMgr.Start(context.TODO()))
+
+	// Ready once the cert is provisioned.
+	assert.Nil(t, check(nil))
+}